@@ -3,21 +3,22 @@ package main
 import (
 	"context"
 	"crypto/rand"
-	"database/sql"
 	"encoding/hex"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Schedule timezones resolve in images without zoneinfo
 
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
 	"github.com/aryan0dhankhar/containerlease/internal/handler"
 	"github.com/aryan0dhankhar/containerlease/internal/infrastructure/docker"
 	"github.com/aryan0dhankhar/containerlease/internal/infrastructure/logger"
+	"github.com/aryan0dhankhar/containerlease/internal/infrastructure/notify"
 	"github.com/aryan0dhankhar/containerlease/internal/infrastructure/redis"
 	obsmetrics "github.com/aryan0dhankhar/containerlease/internal/observability/metrics"
 	"github.com/aryan0dhankhar/containerlease/internal/observability/tracing"
@@ -37,6 +38,11 @@ import (
 )
 
 func main() {
+	// Schema management subcommand: server migrate status|up|down [n]
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrateCommand(os.Args[2:]))
+	}
+
 	// 0. Validate required environment variables
 	if jwtSecret := os.Getenv("JWT_SECRET"); jwtSecret == "" {
 		fmt.Fprintf(os.Stderr, "FATAL: JWT_SECRET environment variable is required\n")
@@ -83,41 +89,40 @@ func main() {
 		defer redisClient.Close()
 	}
 
-	// 4. Initialize Docker client
-	dockerClient, err := docker.NewClient(cfg.DockerHost, log)
-	if err != nil {
-		log.Error("failed to initialize Docker client", slog.String("error", err.Error()))
-		os.Exit(1)
-	}
-
-	// 5. Initialize repositories (Redis-backed for containers/leases)
-	leaseRepo := repository.NewLeaseRepository(redisClient, log)
-	containerRepo := repository.NewContainerRepository(redisClient, log)
-
-	// 5a. Initialize PostgreSQL connection (for users/tenants/auth)
-	dbCfg := database.DefaultConfig()
-	// Override with env vars if set
-	if h := os.Getenv("DB_HOST"); h != "" {
-		dbCfg.Host = h
-	}
-	if port := os.Getenv("DB_PORT"); port != "" {
-		fmt.Sscanf(port, "%d", &dbCfg.Port)
-	}
-	if u := os.Getenv("DB_USER"); u != "" {
-		dbCfg.User = u
-	}
-	if p := os.Getenv("DB_PASSWORD"); p != "" {
-		dbCfg.Password = p
+	// 4. Initialize the Docker node pool (DOCKER_NODES_FILE, or just DOCKER_HOST)
+	nodes := cfg.DockerNodes
+	if len(nodes) == 0 {
+		nodes = []config.DockerNode{{ID: "local", Host: cfg.DockerHost}}
 	}
-	if d := os.Getenv("DB_NAME"); d != "" {
-		dbCfg.Database = d
-	}
-	// Use SSL by default for production, disable for local dev
-	if ssl := os.Getenv("DB_SSLMODE"); ssl != "" {
-		dbCfg.SSLMode = ssl
-	} else if cfg.Environment == "production" {
-		dbCfg.SSLMode = "require"
+	dockerClient := docker.NewNodePool(log)
+	for i, node := range nodes {
+		nodeClient, err := docker.NewClient(node.Host, log.With(slog.String("node", node.ID)))
+		if err != nil {
+			log.Error("failed to initialize Docker client", slog.String("node", node.ID), slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		nodeClient.WithRegistryCredentials(cfg.RegistryCredentials).
+			WithSecurityProfiles(cfg.SecurityProfiles, cfg.DefaultSecurityProfile)
+		// Each tenant's containers share a network isolated from other tenants. The proxy
+		// container runs next to the server, on the default node.
+		proxyContainer := ""
+		if i == 0 {
+			proxyContainer = cfg.ProxyContainer
+		}
+		nodeClient.WithTenantNetworks(cfg.ProxyNetwork, proxyContainer)
+		dockerClient.Add(domain.Node{
+			ID:       node.ID,
+			Host:     node.Host,
+			Labels:   node.Labels,
+			CPUMilli: node.CPUMilli,
+			MemoryMB: node.MemoryMB,
+		}, nodeClient)
 	}
+	dockerClient.CheckHealth(context.Background())
+	log.Info("docker node pool", slog.Int("nodes", len(nodes)), slog.String("placement", cfg.PlacementStrategy))
+
+	// 5. Initialize PostgreSQL connection (for users/tenants/auth, and optionally containers/leases)
+	dbCfg := databaseConfig(cfg)
 	dbCtx, dbCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer dbCancel()
 	dbPool, err := database.NewConnectionPool(dbCtx, dbCfg, log)
@@ -128,36 +133,167 @@ func main() {
 	defer dbPool.Close()
 
 	// 5b. Run database migrations
-	if err := runMigrations(dbCtx, dbPool.GetDB(), log); err != nil {
+	if err := runMigrations(context.Background(), dbPool.GetDB(), log); err != nil {
 		log.Error("failed to run migrations", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 
-	// 5c. SQL-backed repositories
+	// 5c. Container/lease repositories (Redis or Postgres, see STORAGE_BACKEND)
+	var leaseRepo domain.LeaseRepository
+	var containerRepo domain.ContainerRepository
+	switch {
+	case cfg.StorageBackend == "postgres" && cfg.StorageRedisCache && redisClient != nil:
+		leaseRepo = repository.NewCachedLeaseRepository(
+			repository.NewPostgresLeaseRepository(dbPool.GetDB(), log),
+			repository.NewLeaseRepository(redisClient, log),
+			log,
+		)
+		containerRepo = repository.NewCachedContainerRepository(
+			repository.NewPostgresContainerRepository(dbPool.GetDB(), log),
+			repository.NewContainerRepository(redisClient, log),
+			log,
+		)
+	case cfg.StorageBackend == "postgres":
+		leaseRepo = repository.NewPostgresLeaseRepository(dbPool.GetDB(), log)
+		containerRepo = repository.NewPostgresContainerRepository(dbPool.GetDB(), log)
+	default:
+		leaseRepo = repository.NewLeaseRepository(redisClient, log)
+		containerRepo = repository.NewContainerRepository(redisClient, log)
+	}
+	log.Info("container storage configured",
+		slog.String("backend", cfg.StorageBackend),
+		slog.Bool("redis_cache", cfg.StorageBackend == "postgres" && cfg.StorageRedisCache && redisClient != nil),
+	)
+
+	// 5d. SQL-backed repositories
 	userRepo := repository.NewPostgresUserRepository(dbPool.GetDB(), log)
 	_ = userRepo // used by auth service
 
+	quotaRepo := repository.NewPostgresQuotaRepository(dbPool.GetDB(), log)
+	billingRepo := repository.NewPostgresBillingRepository(dbPool.GetDB(), log)
+	budgetRepo := repository.NewPostgresBudgetRepository(dbPool.GetDB(), log)
+	recordingRepo := repository.NewPostgresRecordingRepository(dbPool.GetDB(), log)
+	reservationRepo := repository.NewPostgresReservationRepository(dbPool.GetDB(), log)
+	scheduleRepo := repository.NewPostgresScheduleRepository(dbPool.GetDB(), log)
+	var snapshotRepo domain.SnapshotRepository
+	if redisClient != nil {
+		snapshotRepo = repository.NewSnapshotRepository(redisClient.Raw())
+	}
+
+	// 5e. Durable job queue for provisioning, deletion and snapshots (Redis Streams or Postgres, see JOB_BACKEND)
+	var jobQueue domain.JobQueue
+	if cfg.JobBackend == "redis" && redisClient != nil {
+		jobQueue = repository.NewRedisJobQueue(redisClient.Raw(), log)
+	} else {
+		if cfg.JobBackend == "redis" {
+			log.Warn("Redis not available - queueing jobs in Postgres")
+		}
+		jobQueue = repository.NewPostgresJobQueue(dbPool.GetDB(), log)
+	}
+
+	// 5f. Idempotency-Key records, shared by every server so a retry can land on any of them
+	var idempotencyStore domain.IdempotencyStore
+	if redisClient != nil {
+		idempotencyStore = repository.NewRedisIdempotencyStore(redisClient.Raw())
+	} else {
+		idempotencyStore = repository.NewPostgresIdempotencyStore(dbPool.GetDB())
+	}
+
+	// Calls for containers provisioned before this start go to the node recorded on them
+	if containers, err := containerRepo.List(); err == nil {
+		for _, c := range containers {
+			dockerClient.Track(c)
+		}
+	} else {
+		log.Warn("failed to load container nodes", slog.String("error", err.Error()))
+	}
+
 	// 6. Initialize services
-	containerService := service.NewContainerService(dockerClient, leaseRepo, containerRepo, log, cfg)
+	quotaService := service.NewQuotaService(quotaRepo, containerRepo, snapshotRepo, log, cfg).
+		WithReservations(reservationRepo).
+		WithTenantLocks(quotaRepo)
+	billingService := service.NewBillingService(billingRepo, log, cfg)
+	budgetService := service.NewBudgetService(budgetRepo, billingRepo, containerRepo, leaseRepo, billingService, log, cfg)
+	recordingService := service.NewRecordingService(recordingRepo, log, cfg)
+	fileService := service.NewFileService(dockerClient, containerRepo, log, cfg).WithQuotas(quotaService)
+	if cfg.BudgetWebhookURL != "" {
+		budgetService.WithNotifier(notify.NewWebhookNotifier(cfg.BudgetWebhookURL, log))
+	}
+	containerService := service.NewContainerService(dockerClient, leaseRepo, containerRepo, log, cfg).
+		WithQuotas(quotaService).
+		WithBilling(billingService).
+		WithBudgets(budgetService).
+		WithJobs(jobQueue)
+	// Each lease is placed on a node of the pool by PLACEMENT_STRATEGY
+	placementService := service.NewPlacementService(dockerClient, containerRepo, log, cfg)
+	containerService.WithPlacement(placementService)
+	// Leases are admitted against the host capacity and need a node with room; those that
+	// do not fit wait in the queue
+	hostCapacity := service.ResolveHostCapacity(context.Background(), dockerClient, cfg, log)
+	var provisionQueue *service.ProvisionQueue
+	if hostCapacity.Limited() || placementService.Limited() {
+		provisionQueue = service.NewProvisionQueue(containerRepo, hostCapacity, log, cfg).
+			WithReservations(reservationRepo).
+			WithPlacement(placementService)
+		containerService.WithQueue(provisionQueue)
+	}
+	reservationService := service.NewReservationService(reservationRepo, containerRepo, containerService, dockerClient, log, cfg).
+		WithQuotas(quotaService).
+		WithCapacity(hostCapacity)
+	// Restoring a schedule's latest snapshot needs the snapshot store
+	scheduleService := service.NewScheduleService(scheduleRepo, containerService, log, cfg)
+	if snapshotRepo != nil {
+		scheduleService.WithSnapshots(snapshotRepo)
+	}
 	authService := service.NewAuthService(userRepo, os.Getenv("JWT_SECRET"), log)
+	// Idle leases are handled per tenant policy; snapshot-and-terminate needs the snapshot store
+	idleService := service.NewIdleService(containerService, log, cfg)
+	var snapshotService *service.SnapshotService
+	if snapshotRepo != nil {
+		snapshotService = service.NewSnapshotService(dockerClient, containerRepo, snapshotRepo, log, cfg).
+			WithQuotas(quotaService).
+			WithLeases(containerService).
+			WithJobs(jobQueue)
+		idleService.WithSnapshots(snapshotService)
+	}
+	if cfg.IdleWebhookURL != "" {
+		idleService.WithNotifier(notify.NewWebhookNotifier(cfg.IdleWebhookURL, log))
+	}
 
 	// 7. Initialize security components
 	tokenManager := auth.NewTokenManager(os.Getenv("JWT_SECRET"), "containerlease")
 	userStore := auth.NewUserStore()
 	rateLimiter := ratelimit.NewLimiter(100, time.Minute) // 100 requests per minute per tenant
 	auditLogger := audit.NewLogger(log)
-	authz := security.NewAuthorizationService(log)
+	authz := security.NewAuthorizationService(log).WithAdminTenants(cfg.AdminTenantIDs)
 
 	// 7a. Initialize handlers
 	loginHandler := handler.NewLoginHandler(tokenManager, userStore, log)
 	// New auth endpoints backed by Postgres users
 	authHandler := handler.NewAuthHandler(authService, log)
-	provisionHandler := handler.NewProvisionHandler(containerService, log, cfg, authz)
-	provisionStatusHandler := handler.NewProvisionStatusHandler(containerRepo, log)
+	imagePolicy := service.NewImagePolicy(cfg)
+	provisionHandler := handler.NewProvisionHandler(containerService, log, cfg, authz, imagePolicy)
+	reservationsHandler := handler.NewReservationsHandler(reservationService, billingService, imagePolicy, log, cfg, authz)
+	schedulesHandler := handler.NewSchedulesHandler(scheduleService, billingService, imagePolicy, log, cfg, authz)
+	provisionStatusHandler := handler.NewProvisionStatusHandler(containerRepo, log, billingService)
+	if provisionQueue != nil {
+		provisionStatusHandler.WithQueue(provisionQueue)
+	}
 	presetsHandler := handler.NewPresetsHandler(cfg, log)
-	logsHandler := handler.NewLogsHandler(dockerClient, log, cfg.CORSAllowedOrigins, containerRepo)
-	statusHandler := handler.NewContainersHandler(containerRepo, log, authz)
+	logsHandler := handler.NewLogsHandler(dockerClient, log, cfg.CORSAllowedOrigins, containerRepo).WithIdle(idleService)
+	execHandler := handler.NewExecHandler(dockerClient, containerRepo, log, cfg.CORSAllowedOrigins, authz, recordingService).WithIdle(idleService)
+	statusHandler := handler.NewContainersHandler(containerRepo, log, authz, billingService)
 	deleteHandler := handler.NewDeleteHandler(containerService, log, authz)
+	extendHandler := handler.NewExtendHandler(containerService, log, authz)
+	pauseHandler := handler.NewPauseHandler(containerService, log, authz, time.Duration(cfg.MaxPauseMinutes)*time.Minute)
+	quotaHandler := handler.NewQuotaHandler(quotaService, log, authz)
+	budgetHandler := handler.NewBudgetHandler(budgetService, log, authz)
+	recordingsHandler := handler.NewRecordingsHandler(recordingService, log, authz)
+	filesHandler := handler.NewFilesHandler(fileService, containerRepo, log, authz)
+	proxyHandler := handler.NewProxyHandler(dockerClient, containerRepo, tokenManager, log, cfg, authz)
+	statsHandler := handler.NewStatsHandler(dockerClient, containerRepo, log, cfg.CORSAllowedOrigins, authz)
+	jobsHandler := handler.NewJobsHandler(jobQueue, log, authz)
+	nodesHandler := handler.NewNodesHandler(placementService, log, authz)
 
 	// 8. Setup HTTP routes
 	mux := http.NewServeMux()
@@ -171,11 +307,45 @@ func main() {
 	mux.Handle("GET /api/containers", statusHandler)
 	mux.Handle("GET /api/containers/{id}/status", provisionStatusHandler)
 	mux.Handle("DELETE /api/containers/{id}", deleteHandler)
+	mux.Handle("POST /api/containers/{id}/extend", extendHandler)
+	mux.HandleFunc("POST /api/containers/{id}/pause", pauseHandler.Pause)
+	mux.HandleFunc("POST /api/containers/{id}/resume", pauseHandler.Resume)
+	mux.HandleFunc("GET /api/containers/{id}/files", filesHandler.Download)
+	mux.HandleFunc("PUT /api/containers/{id}/files", filesHandler.Upload)
+	mux.HandleFunc("POST /api/containers/{id}/share", proxyHandler.CreateShare)
+	mux.HandleFunc("GET /api/containers/{id}/stats", statsHandler.Get)
+	mux.HandleFunc("GET /api/containers/{id}/recordings", recordingsHandler.List)
+	mux.HandleFunc("GET /api/containers/{id}/recordings/{recordingId}", recordingsHandler.Download)
+	mux.HandleFunc("POST /api/reservations", reservationsHandler.Create)
+	mux.HandleFunc("GET /api/reservations", reservationsHandler.List)
+	mux.HandleFunc("GET /api/reservations/{id}", reservationsHandler.Get)
+	mux.HandleFunc("DELETE /api/reservations/{id}", reservationsHandler.Cancel)
+	mux.HandleFunc("POST /api/schedules", schedulesHandler.Create)
+	mux.HandleFunc("GET /api/schedules", schedulesHandler.List)
+	mux.HandleFunc("GET /api/schedules/{id}", schedulesHandler.Get)
+	mux.HandleFunc("DELETE /api/schedules/{id}", schedulesHandler.Delete)
+	mux.HandleFunc("GET /api/schedules/{id}/runs", schedulesHandler.Runs)
+	mux.HandleFunc("POST /api/schedules/{id}/pause", schedulesHandler.Pause)
+	mux.HandleFunc("POST /api/schedules/{id}/resume", schedulesHandler.Resume)
+	mux.HandleFunc("POST /api/schedules/{id}/skip", schedulesHandler.Skip)
+	mux.HandleFunc("GET /api/quota", quotaHandler.GetUsage)
+	mux.HandleFunc("GET /api/admin/tenants/{tenantId}/quota", quotaHandler.GetTenantQuota)
+	mux.HandleFunc("PUT /api/admin/tenants/{tenantId}/quota", quotaHandler.UpdateTenantQuota)
+	mux.HandleFunc("GET /api/admin/jobs/dead", jobsHandler.ListDead)
+	mux.HandleFunc("GET /api/admin/nodes", nodesHandler.List)
+	mux.HandleFunc("GET /api/budget", budgetHandler.GetBudget)
+	mux.HandleFunc("PUT /api/budget", budgetHandler.UpdateBudget)
+	mux.HandleFunc("DELETE /api/budget", budgetHandler.DeleteBudget)
 	mux.Handle("GET /api/logs", http.HandlerFunc(logsHandler.GetLogs))
 	// WebSocket logs endpoint - handled separately without OpenTelemetry wrapping
 	mux.Handle("GET /ws/logs/{id}", logsHandler)
+	mux.Handle("GET /ws/exec/{id}", execHandler)
+	mux.Handle("GET /ws/stats/{id}", statsHandler)
 	mux.Handle("/metrics", promhttp.Handler())
 
+	// Retried POST and DELETE requests with the same Idempotency-Key get the first response
+	idempotent := middleware.IdempotencyMiddleware(idempotencyStore, time.Duration(cfg.IdempotencyWindowMins)*time.Minute, log)(mux)
+
 	// CORS middleware honoring configured origins
 	handlerWithCORS := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		origin := r.Header.Get("Origin")
@@ -185,15 +355,15 @@ func main() {
 			w.Header().Set("Access-Control-Allow-Origin", cfg.CORSAllowedOrigins[0])
 		}
 		w.Header().Set("Vary", "Origin")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, OPTIONS, DELETE")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, Authorization, Idempotency-Key")
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
 
-		mux.ServeHTTP(w, r)
+		idempotent.ServeHTTP(w, r)
 	})
 
 	// Health and readiness endpoints (no auth required)
@@ -236,27 +406,90 @@ func main() {
 	// Wrap with OpenTelemetry HTTP handler for tracing
 	rootHandler := otelhttp.NewHandler(withMetrics, "http.server")
 
-	// 9. Start cleanup worker in background (only if Redis is available)
+	// 9. Start cleanup worker in background (only if container storage is available)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	if redisClient != nil {
+	// Unhealthy nodes get no new leases until they answer again
+	go dockerClient.StartHealthChecks(ctx, time.Duration(cfg.NodeHealthSeconds)*time.Second)
+
+	if redisClient != nil || cfg.StorageBackend == "postgres" {
 		cleanupWorker := worker.NewCleanupWorker(
 			leaseRepo,
 			containerRepo,
 			dockerClient,
 			log,
 			time.Duration(cfg.CleanupIntervalMinutes)*time.Minute,
-		)
+		).WithBilling(billingService).WithBudgets(budgetService).WithRecordings(recordingService).
+			WithPauses(containerService, time.Duration(cfg.MaxPauseMinutes)*time.Minute)
 		go cleanupWorker.Start(ctx)
+
+		// Keep container status in sync with Docker (exits, OOM kills, external removals)
+		eventWatcher := worker.NewEventWatcher(containerRepo, dockerClient, log)
+		go eventWatcher.Start(ctx)
+
+		if cfg.StatsIntervalSeconds > 0 {
+			statsCollector := worker.NewStatsCollector(containerRepo, dockerClient, log, time.Duration(cfg.StatsIntervalSeconds)*time.Second)
+			go statsCollector.Start(ctx)
+		}
+
+		if cfg.IdleCheckSeconds > 0 {
+			idleWorker := worker.NewIdleWorker(containerRepo, dockerClient, idleService, log, cfg)
+			go idleWorker.Start(ctx)
+		}
+
+		// Jobs claimed when the server stopped are run again; pending leases whose job was lost get a new one
+		if err := containerService.ReconcilePending(ctx); err != nil {
+			log.Error("failed to reconcile pending leases", slog.String("error", err.Error()))
+		}
+		jobWorker := worker.NewJobWorker(jobQueue, log, cfg).
+			Handle(domain.JobProvision, containerService.RunProvisionJob, containerService.FailProvisionJob).
+			Handle(domain.JobDelete, containerService.RunDeleteJob, nil)
+		if snapshotService != nil {
+			jobWorker.Handle(domain.JobSnapshot, snapshotService.RunSnapshotJob, snapshotService.FailSnapshotJob)
+		}
+		go jobWorker.Start(ctx)
+
+		// Leases left queued by a restart keep their place; queued leases start as capacity frees up
+		if provisionQueue != nil {
+			if err := containerService.RestoreQueue(ctx); err != nil {
+				log.Error("failed to restore provisioning queue", slog.String("error", err.Error()))
+			}
+			queueWorker := worker.NewQueueWorker(provisionQueue, log, time.Duration(cfg.QueueCheckSeconds)*time.Second)
+			go queueWorker.Start(ctx)
+		}
+
+		// Reserved leases are provisioned RESERVATION_LEAD_MINUTES before they start
+		if cfg.ReservationCheckSeconds > 0 {
+			reservationScheduler := worker.NewReservationScheduler(reservationService, log, time.Duration(cfg.ReservationCheckSeconds)*time.Second)
+			go reservationScheduler.Start(ctx)
+		}
+
+		if cfg.ScheduleCheckSeconds > 0 {
+			scheduleWorker := worker.NewScheduleWorker(scheduleService, log, time.Duration(cfg.ScheduleCheckSeconds)*time.Second)
+			go scheduleWorker.Start(ctx)
+		}
 	} else {
-		log.Warn("Redis not available - cleanup worker disabled")
+		log.Warn("Redis not available - cleanup worker and event watcher disabled")
 	}
 
 	// Combined handler: WebSocket routes bypass middleware wrapping, other routes go through full middleware stack
 	finalHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Route WebSocket connections directly to the logs handler without heavy middleware
-		if r.Method == http.MethodGet && len(r.URL.Path) > 8 && r.URL.Path[:8] == "/ws/logs" {
+		// Route WebSocket connections directly to their handlers without heavy middleware
+		var wsHandler http.Handler
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/ws/logs/"):
+			wsHandler = logsHandler
+		case strings.HasPrefix(r.URL.Path, "/ws/exec/"):
+			wsHandler = execHandler
+		case strings.HasPrefix(r.URL.Path, "/ws/stats/"):
+			wsHandler = statsHandler
+		case strings.HasPrefix(r.URL.Path, "/proxy/"):
+			// Proxied apps authenticate with owner or share tokens and may use any method
+			proxyHandler.ServeHTTP(w, r)
+			return
+		}
+		if r.Method == http.MethodGet && wsHandler != nil {
 			log.Debug("websocket handler intercepted", slog.String("path", r.URL.Path))
 			// Apply CORS headers manually
 			origin := r.Header.Get("Origin")
@@ -266,7 +499,7 @@ func main() {
 				w.Header().Set("Access-Control-Allow-Origin", cfg.CORSAllowedOrigins[0])
 			}
 			w.Header().Set("Vary", "Origin")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, DELETE")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, OPTIONS, DELETE")
 			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept, Authorization")
 
 			// Apply JWT middleware for WebSocket
@@ -296,7 +529,7 @@ func main() {
 			ctx := context.WithValue(r.Context(), middleware.ClaimsContextKey{}, claims)
 			ctx = context.WithValue(ctx, middleware.TenantContextKey{}, claims.TenantID)
 
-			// Extract container ID from path manually (path format: /ws/logs/{id})
+			// Extract container ID from path manually (path format: /ws/{logs,exec,stats}/{id})
 			parts := strings.Split(r.URL.Path, "/")
 			if len(parts) >= 4 {
 				containerID := parts[3]
@@ -304,7 +537,7 @@ func main() {
 				r = r.WithContext(context.WithValue(ctx, "container_id", containerID))
 			}
 
-			logsHandler.ServeHTTP(w, r.WithContext(ctx))
+			wsHandler.ServeHTTP(w, r.WithContext(ctx))
 			return
 		}
 
@@ -398,42 +631,6 @@ func generateRequestID() string {
 	return fmt.Sprintf("req-%d", time.Now().UnixNano())
 }
 
-func runMigrations(ctx context.Context, db *sql.DB, log *slog.Logger) error {
-	// Simple migration runner: read SQL files from migrations dir and execute
-	migrationsDir := "migrations"
-	entries, err := os.ReadDir(migrationsDir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			log.Info("no migrations directory found")
-			return nil
-		}
-		return err
-	}
-
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
-			continue
-		}
-
-		path := filepath.Join(migrationsDir, entry.Name())
-		sqlBytes, err := os.ReadFile(path)
-		if err != nil {
-			return fmt.Errorf("failed to read migration %s: %w", path, err)
-		}
-
-		// Execute migration (idempotent: ignore "already exists" errors)
-		_, err = db.ExecContext(ctx, string(sqlBytes))
-		if err != nil {
-			if !strings.Contains(err.Error(), "already exists") && !strings.Contains(err.Error(), "duplicate") {
-				log.Warn("migration execution warning", slog.String("file", entry.Name()), slog.String("error", err.Error()))
-			}
-		} else {
-			log.Info("migration applied", slog.String("file", entry.Name()))
-		}
-	}
-	return nil
-}
-
 func originAllowed(allowed []string, origin string) bool {
 	if origin == "" {
 		return false
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/infrastructure/logger"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+	"github.com/aryan0dhankhar/containerlease/pkg/database"
+)
+
+const migrationsDir = "migrations"
+
+// databaseConfig builds the Postgres config from DB_* environment variables
+func databaseConfig(cfg *config.Config) *database.Config {
+	dbCfg := database.DefaultConfig()
+	// Override with env vars if set
+	if h := os.Getenv("DB_HOST"); h != "" {
+		dbCfg.Host = h
+	}
+	if port := os.Getenv("DB_PORT"); port != "" {
+		fmt.Sscanf(port, "%d", &dbCfg.Port)
+	}
+	if u := os.Getenv("DB_USER"); u != "" {
+		dbCfg.User = u
+	}
+	if p := os.Getenv("DB_PASSWORD"); p != "" {
+		dbCfg.Password = p
+	}
+	if d := os.Getenv("DB_NAME"); d != "" {
+		dbCfg.Database = d
+	}
+	// Use SSL by default for production, disable for local dev
+	if ssl := os.Getenv("DB_SSLMODE"); ssl != "" {
+		dbCfg.SSLMode = ssl
+	} else if cfg.Environment == "production" {
+		dbCfg.SSLMode = "require"
+	}
+	return dbCfg
+}
+
+func newMigrator(db *sql.DB, log *slog.Logger) *database.Migrator {
+	// Databases created by the old runner already have the initial schema but no schema_migrations rows
+	return database.NewMigrator(db, os.DirFS(migrationsDir), log).WithLegacyBaseline("users", 1)
+}
+
+// runMigrations applies pending migrations on server start
+func runMigrations(ctx context.Context, db *sql.DB, log *slog.Logger) error {
+	if _, err := os.Stat(migrationsDir); os.IsNotExist(err) {
+		log.Info("no migrations directory found")
+		return nil
+	}
+
+	applied, err := newMigrator(db, log).Up(ctx)
+	if err != nil {
+		return err
+	}
+	log.Info("database migrations up to date", slog.Int("applied", applied))
+	return nil
+}
+
+// runMigrateCommand implements `server migrate status|up|down [n]` and returns the exit code
+func runMigrateCommand(args []string) int {
+	usage := func() int {
+		fmt.Fprintln(os.Stderr, "usage: server migrate status|up|down [n]")
+		return 2
+	}
+	if len(args) == 0 {
+		return usage()
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		return 1
+	}
+	log := logger.NewLogger(cfg.LogLevel)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	dbPool, err := database.NewConnectionPool(ctx, databaseConfig(cfg), log)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to Postgres: %v\n", err)
+		return 1
+	}
+	defer dbPool.Close()
+
+	migrator := newMigrator(dbPool.GetDB(), log)
+	ctx = context.Background()
+
+	switch args[0] {
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate status failed: %v\n", err)
+			return 1
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range statuses {
+			state, appliedAt := "pending", ""
+			if s.Applied {
+				state, appliedAt = "applied", s.AppliedAt.Format(time.RFC3339)
+				if s.ChecksumMismatch {
+					state = "applied (modified)"
+				}
+			}
+			fmt.Fprintf(tw, "%03d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+		}
+		tw.Flush()
+
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate up failed after %d migration(s): %v\n", applied, err)
+			return 1
+		}
+		fmt.Printf("applied %d migration(s)\n", applied)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return usage()
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate down failed after %d migration(s): %v\n", reverted, err)
+			return 1
+		}
+		fmt.Printf("reverted %d migration(s)\n", reverted)
+
+	default:
+		return usage()
+	}
+	return 0
+}
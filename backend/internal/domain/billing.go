@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// BillingRecord is the finalized charge for one container lease
+type BillingRecord struct {
+	ID              string
+	ContainerID     string
+	TenantID        string
+	Preset          string
+	Amount          float64 // Dollars
+	CPUMilliHours   float64
+	MemoryMBHours   float64
+	VolumeMBHours   float64
+	DurationMinutes int // Billable minutes
+	PeriodStart     time.Time
+	PeriodEnd       time.Time
+	CreatedAt       time.Time
+}
+
+// BillingRepository defines data access for billing records
+type BillingRepository interface {
+	// Create stores a record; a second record for the same container is ignored
+	Create(record *BillingRecord) error
+	// SumByTenant totals the tenant's records that ended at or after since
+	SumByTenant(tenantID string, since time.Time) (float64, error)
+}
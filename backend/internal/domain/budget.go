@@ -0,0 +1,42 @@
+package domain
+
+import "time"
+
+// Budget event kinds
+const (
+	BudgetAlert = "alert" // Spend crossed the soft-alert threshold
+	BudgetStop  = "stop"  // Spend reached the monthly budget
+)
+
+// TenantBudget caps a tenant's spend per calendar month (UTC)
+type TenantBudget struct {
+	TenantID       string
+	MonthlyLimit   float64 // Dollars per month
+	AlertThreshold float64 // Fraction of MonthlyLimit that triggers an alert, e.g. 0.8
+	HardStop       bool    // Refuse provisions and extensions once the budget is spent
+	ShortenLeases  bool    // On hard stop, also cut running leases down to GraceMinutes
+	GraceMinutes   int
+	UpdatedAt      time.Time
+}
+
+// BudgetEvent is raised once per tenant, kind and month
+type BudgetEvent struct {
+	TenantID  string
+	Kind      string    // BudgetAlert or BudgetStop
+	Period    time.Time // First instant of the budget month
+	Spend     float64
+	Limit     float64
+	CreatedAt time.Time
+}
+
+// BudgetRepository defines data access for tenant budgets and their events
+type BudgetRepository interface {
+	// GetBudget returns the tenant's budget, or nil if none has been set
+	GetBudget(tenantID string) (*TenantBudget, error)
+	SaveBudget(budget *TenantBudget) error
+	DeleteBudget(tenantID string) error
+	ListBudgets() ([]*TenantBudget, error)
+	// RecordEvent stores an event and reports whether it is new for its tenant, kind and period
+	RecordEvent(event *BudgetEvent) (bool, error)
+	ListEvents(tenantID string, period time.Time) ([]*BudgetEvent, error)
+}
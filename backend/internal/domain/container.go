@@ -2,29 +2,124 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"io"
+	"os"
 	"time"
 )
 
+// ErrPathNotFound is returned by DockerClient.CopyFrom and CopyTo when a path does not exist in the container
+var ErrPathNotFound = errors.New("path not found in container")
+
+// ErrLeaseChanged is returned when a lease was changed between reading and extending it
+var ErrLeaseChanged = errors.New("lease changed concurrently")
+
+// ErrNodeEventsInterrupted wraps errors a DockerClient reports while its event stream carries
+// on, e.g. one node's stream of a node pool that is being reconnected on its own
+var ErrNodeEventsInterrupted = errors.New("node event stream interrupted")
+
 // Container represents a Docker container entity
 type Container struct {
 	ID              string // Our unique ID (not the Docker ID)
 	DockerID        string // The actual Docker container ID
 	TenantID        string // Tenant/User who owns this container
-	ImageType       string
-	Status          string // pending, running, exited, stopped, error
+	ImageType       string // Image as requested: an alias or a reference
+	Image           string // Fully qualified reference that was pulled, e.g. docker.io/library/ubuntu:22.04
+	ImageDigest     string // Digest reference the container runs, e.g. docker.io/library/ubuntu@sha256:...
+	Status          string // pending, running, paused, exited, stopped, error
 	CPUMilli        int    // Requested CPU in millicores
 	MemoryMB        int    // Requested memory in MB
 	CreatedAt       time.Time
 	ExpiryAt        time.Time
-	Cost            float64   // Cost in dollars
-	Error           string    // Error message if status is error
-	VolumeID        string    // Docker volume ID if volumes are attached
-	VolumeSize      int       // Volume size in MB (0 if no volume)
-	RestartCount    int       // Phase 2: Self-healing - number of restart attempts
-	LastFailureTime time.Time // Phase 2: Self-healing - time of last failure
-	FailureReason   string    // Phase 2: Self-healing - reason for last failure
-	MaxRestarts     int       // Phase 2: Self-healing - maximum restart attempts (default: 3)
+	Cost            float64           // Cost in dollars accrued up to CostAccruedAt
+	CostAccruedAt   time.Time         // When Cost was last brought up to date (zero = billing not started)
+	BilledDuration  time.Duration     // Total billable time included in Cost
+	Preset          string            // Provisioning preset, if one was used (for preset pricing)
+	Ports           []int             // Container ports reachable through the proxy
+	Error           string            // Error message if status is error
+	VolumeID        string            // Docker volume ID if volumes are attached
+	VolumeSize      int               // Volume size in MB (0 if no volume)
+	LogDemo         bool              // Provisioning spec: run the demo log loop instead of sleeping
+	Entrypoint      []string          // Provisioning spec: entrypoint override (nil = image default)
+	Command         []string          // Provisioning spec: command override (nil = sleep infinity)
+	Env             []EnvVar          // Provisioning spec: environment variables
+	WorkingDir      string            // Provisioning spec: working directory (empty = image default)
+	InitScript      string            // Shell script run once after the container first starts
+	SecurityProfile string            // Name of the hardening profile the container runs under
+	Egress          string            // Network egress of the tenant network: none, internal or full
+	PausedAt        time.Time         // When the container was paused (zero unless status is paused)
+	TerminatingAt   time.Time         // When its teardown started (zero unless it is being removed)
+	NodeID          string            // Docker node the container runs on (empty = the pool's default node)
+	NodeLabels      map[string]string // Node labels the lease asked for, e.g. ssd=true
+	LocalImage      bool              // Image only exists on NodeID (e.g. a snapshot) and is never pulled
+	UploadedBytes   int64             // Bytes uploaded into a container without a volume; counted against the volume quota
+	InitStatus      string            // Init script progress: pending, running, succeeded, failed (empty = no script)
+	InitExitCode    int               // Init script exit code once it has finished
+	InitOutput      string            // Init script output, truncated, with secret values redacted
+	RestartCount    int               // Phase 2: Self-healing - number of restart attempts
+	LastFailureTime time.Time         // Phase 2: Self-healing - time of last failure
+	FailureReason   string            // Phase 2: Self-healing - reason for last failure
+	MaxRestarts     int               // Phase 2: Self-healing - maximum restart attempts (default: 3)
+}
+
+// Init script states
+const (
+	InitPending   = "pending"
+	InitRunning   = "running"
+	InitSucceeded = "succeeded"
+	InitFailed    = "failed"
+)
+
+// EnvVar is an environment variable set in a container. Secret values are
+// never returned by the API or written to logs.
+type EnvVar struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Secret bool   `json:"secret,omitempty"`
+}
+
+// ContainerSpec describes a Docker container to create
+type ContainerSpec struct {
+	Image      string
+	CPUMilli   int
+	MemoryMB   int
+	VolumeID   string // Mounted at /data when set
+	Entrypoint []string
+	Cmd        []string // Empty = sleep infinity, or the demo log loop when LogDemo is set
+	Env        []EnvVar
+	WorkingDir string
+	LogDemo    bool
+	// SecurityProfile names the hardening profile to apply (empty = the configured default)
+	SecurityProfile string
+	// TenantID and Egress select the tenant network the container joins (empty egress = full)
+	TenantID string
+	Egress   string
+}
+
+// Spec returns the spec to recreate the container from, preferring the recorded image digest
+func (c *Container) Spec() ContainerSpec {
+	image := c.ImageDigest
+	if image == "" {
+		image = c.Image
+	}
+	if image == "" {
+		image = c.ImageType
+	}
+	return ContainerSpec{
+		Image:      image,
+		CPUMilli:   c.CPUMilli,
+		MemoryMB:   c.MemoryMB,
+		VolumeID:   c.VolumeID,
+		Entrypoint: c.Entrypoint,
+		Cmd:        c.Command,
+		Env:        c.Env,
+		WorkingDir: c.WorkingDir,
+		LogDemo:    c.LogDemo,
+
+		SecurityProfile: c.SecurityProfile,
+		TenantID:        c.TenantID,
+		Egress:          c.Egress,
+	}
 }
 
 // Lease represents a temporary lease/reservation for a container
@@ -34,8 +129,16 @@ type Lease struct {
 	ExpiryTime      time.Time
 	DurationMinutes int
 	CreatedAt       time.Time
+	ExtensionCount  int // Number of times the lease has been extended
 }
 
+// Snapshot states; snapshots stored before states existed have none and are ready
+const (
+	SnapshotPending = "pending" // Waiting for the container to be committed, by its snapshot job or the request itself
+	SnapshotReady   = "ready"   // The image exists and can be restored
+	SnapshotFailed  = "failed"  // The commit failed on every attempt; Error says why
+)
+
 // Snapshot represents a saved image of a container (Phase 2: Disaster Recovery)
 type Snapshot struct {
 	ID          string    // Unique snapshot ID
@@ -45,6 +148,93 @@ type Snapshot struct {
 	Size        int64     // Snapshot size in bytes
 	Description string    // Optional description/notes
 	TenantID    string    // Tenant who owns this snapshot
+	Status      string    // One of the Snapshot* states
+	Error       string    // Why the snapshot failed
+	NodeID      string    // Docker node holding the snapshot's image
+}
+
+// Ready reports whether the snapshot's image has been committed
+func (s *Snapshot) Ready() bool {
+	return s.Status == "" || s.Status == SnapshotReady
+}
+
+// ContainerEvent is a Docker lifecycle event for a container
+type ContainerEvent struct {
+	DockerID string
+	Action   string // start, die, oom, destroy
+	ExitCode int    // Process exit code (die events only)
+	Time     time.Time
+}
+
+// ContainerState is the observed Docker state of a container
+type ContainerState struct {
+	Running   bool
+	Status    string // Docker status: created, running, exited, dead, ...
+	ExitCode  int
+	OOMKilled bool
+	IPAddress string // Address on the network the proxy reaches containers through
+}
+
+// ContainerStats is one resource usage sample of a container
+type ContainerStats struct {
+	Time             time.Time
+	CPUMilli         float64 // CPU in use, in millicores
+	MemoryBytes      uint64  // Memory in use, excluding reclaimable page cache
+	MemoryLimitBytes uint64
+	NetRxBytes       uint64 // Totals since the container started
+	NetTxBytes       uint64
+	BlockReadBytes   uint64
+	BlockWriteBytes  uint64
+	PIDs             uint64
+	PIDsLimit        uint64 // 0 = unlimited
+}
+
+// CPUPercent returns CPU use as a percentage of requestedMilli (0 when nothing was requested)
+func (s ContainerStats) CPUPercent(requestedMilli int) float64 {
+	if requestedMilli <= 0 {
+		return 0
+	}
+	return s.CPUMilli / float64(requestedMilli) * 100
+}
+
+// MemoryPercent returns memory use as a percentage of requestedMB (0 when nothing was requested)
+func (s ContainerStats) MemoryPercent(requestedMB int) float64 {
+	if requestedMB <= 0 {
+		return 0
+	}
+	return float64(s.MemoryBytes) / float64(requestedMB<<20) * 100
+}
+
+// IdleEvent records an idle policy applied to a lease
+type IdleEvent struct {
+	ContainerID string
+	TenantID    string
+	Action      string        // The tenant's idle policy
+	IdleFor     time.Duration // How long the lease had been idle
+	CreatedAt   time.Time
+}
+
+// ExecOptions configures an interactive process started inside a container
+type ExecOptions struct {
+	Cmd  []string
+	Cols uint // Initial terminal size; 0 leaves the Docker default
+	Rows uint
+}
+
+// ExecSession is a running exec process attached to a TTY.
+// Reads return terminal output and writes go to the process's stdin.
+type ExecSession interface {
+	io.ReadWriteCloser
+	Resize(ctx context.Context, cols, rows uint) error
+}
+
+// FileStat describes a path inside a container
+type FileStat struct {
+	Name       string
+	Size       int64
+	Mode       os.FileMode
+	ModTime    time.Time
+	LinkTarget string // Set when the path is a symlink
 }
 
 // ContainerRepository defines data access for containers
@@ -56,20 +246,61 @@ type ContainerRepository interface {
 	ListByTenant(tenantID string) ([]*Container, error)
 }
 
+// ContainerHistoryRepository is implemented by repositories that keep terminated
+// containers beyond their retention window
+type ContainerHistoryRepository interface {
+	ListHistoryByTenant(tenantID string) ([]*Container, error)
+}
+
+// ContainerDockerIDRepository is implemented by repositories that can find a container by
+// its Docker ID without listing every container
+type ContainerDockerIDRepository interface {
+	// GetByDockerID returns the container with a Docker ID, or nil if none is tracked
+	GetByDockerID(dockerID string) (*Container, error)
+}
+
+// FindByDockerID returns the container with a Docker ID, or nil if none is tracked. It uses
+// the repository's own lookup when it has one and lists every container otherwise.
+func FindByDockerID(repo ContainerRepository, dockerID string) (*Container, error) {
+	if lookup, ok := repo.(ContainerDockerIDRepository); ok {
+		return lookup.GetByDockerID(dockerID)
+	}
+	containers, err := repo.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range containers {
+		if c.DockerID == dockerID {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
 // LeaseRepository defines data access for leases
 type LeaseRepository interface {
 	CreateLease(lease *Lease) error
 	GetLease(leaseKey string) (*Lease, error)
 	DeleteLease(leaseKey string) error
 	GetExpiredLeases() ([]string, error) // Returns container IDs
+	// CompareAndExtendLease persists a changed lease together with the container's ExpiryAt in
+	// one atomic write, leaving the rest of the stored container as it is, provided the stored lease still expires at expiryTime, the expiry it was read
+	// with. It returns ErrLeaseChanged otherwise, so concurrent lease writes cannot overwrite
+	// each other or both pass the extension limit
+	CompareAndExtendLease(lease *Lease, container *Container, expiryTime time.Time) error
 }
 
 // DockerClient defines Docker operations
 type DockerClient interface {
-	CreateContainer(ctx context.Context, imageType string, cpuMilli int, memoryMB int, logDemo bool, volumeID string) (string, error)
+	// PullImage pulls an image and returns the digest reference it resolved to ("" for images with no registry digest)
+	PullImage(ctx context.Context, image string) (string, error)
+	CreateContainer(ctx context.Context, spec ContainerSpec) (string, error)
 	StopContainer(ctx context.Context, containerID string) error
 	RemoveContainer(ctx context.Context, containerID string) error
 	StartContainer(ctx context.Context, containerID string) error
+	// PauseContainer freezes a container's processes (docker pause); UnpauseContainer resumes them
+	PauseContainer(ctx context.Context, containerID string) error
+	UnpauseContainer(ctx context.Context, containerID string) error
 	StreamLogs(ctx context.Context, containerID string) (io.ReadCloser, error)
 	CreateVolume(ctx context.Context, volumeID string, sizeMB int) (string, error)
 	RemoveVolume(ctx context.Context, volumeID string) error
@@ -78,6 +309,23 @@ type DockerClient interface {
 	SaveImage(ctx context.Context, imageName string, filePath string) error
 	LoadImage(ctx context.Context, filePath string) (string, error)
 	RemoveImage(ctx context.Context, imageName string) error
+	// State tracking: lifecycle events and on-demand inspection
+	WatchEvents(ctx context.Context, since time.Time) (<-chan ContainerEvent, <-chan error)
+	InspectContainer(ctx context.Context, containerID string) (*ContainerState, error)
+	// Exec starts an interactive TTY process in a running container
+	Exec(ctx context.Context, containerID string, opts ExecOptions) (ExecSession, error)
+	// RunCommand runs a non-interactive process to completion, writing its combined output, and returns its exit code
+	RunCommand(ctx context.Context, containerID string, cmd []string, output io.Writer) (int, error)
+	// CopyTo extracts a tar archive into dstDir inside the container
+	CopyTo(ctx context.Context, containerID string, dstDir string, archive io.Reader) error
+	// CopyFrom returns a tar archive of srcPath inside the container; the caller closes it
+	CopyFrom(ctx context.Context, containerID string, srcPath string) (io.ReadCloser, *FileStat, error)
+	// Stats returns one resource usage sample of a running container
+	Stats(ctx context.Context, containerID string) (*ContainerStats, error)
+	// StreamStats sends a sample about once a second until ctx ends or the container stops
+	StreamStats(ctx context.Context, containerID string) (<-chan ContainerStats, <-chan error)
+	// HostResources returns the CPUs, in millicores, and memory, in MB, of the Docker host
+	HostResources(ctx context.Context) (cpuMilli int, memoryMB int, err error)
 }
 
 // SnapshotRepository defines data access for snapshots
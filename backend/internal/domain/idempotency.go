@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// IdempotencyRecord is what is kept for a request made with an Idempotency-Key
+type IdempotencyRecord struct {
+	RequestHash string      `json:"requestHash"` // SHA-256 of the method, path and body
+	Completed   bool        `json:"completed"`   // False while the first request is still being handled
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"` // Headers the handler set on the response
+	Body        []byte      `json:"body,omitempty"`
+	CreatedAt   time.Time   `json:"createdAt"`
+}
+
+// IdempotencyStore keeps idempotency records per tenant and key until they expire
+type IdempotencyStore interface {
+	// Reserve stores rec under the key unless a record is already there, which is
+	// returned instead; it returns nil when the key was free and is now reserved
+	Reserve(ctx context.Context, tenantID, key string, rec *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error)
+	// Save replaces the record under the key, e.g. once the response is known
+	Save(ctx context.Context, tenantID, key string, rec *IdempotencyRecord, ttl time.Duration) error
+	// Release deletes the record so the key can be used again
+	Release(ctx context.Context, tenantID, key string) error
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Job kinds
+const (
+	JobProvision = "provision" // Create the Docker container of a pending lease
+	JobDelete    = "delete"    // Remove a terminated lease's container and volume
+	JobSnapshot  = "snapshot"  // Commit a container to a snapshot image
+)
+
+// Job is a unit of background work. Jobs are delivered at least once, so running one
+// twice must be harmless.
+type Job struct {
+	ID        string          // Unique per piece of work; enqueueing an ID that is still outstanding is a no-op
+	Kind      string          // One of the Job* kinds
+	Payload   json.RawMessage // Kind-specific arguments
+	Attempts  int             // Failed or abandoned deliveries so far
+	LastError string
+	CreatedAt time.Time
+	Receipt   string `json:"-"` // Backend handle of the current delivery, used to acknowledge it
+}
+
+// JobQueue is a durable queue of jobs shared by every server instance
+type JobQueue interface {
+	Enqueue(ctx context.Context, job *Job) error
+	// Dequeue claims the next job that is due for the named consumer, waiting up to
+	// block for one. It returns nil when there is none.
+	Dequeue(ctx context.Context, consumer string, block time.Duration) (*Job, error)
+	// Ack removes a finished job
+	Ack(ctx context.Context, job *Job) error
+	// Retry hands a failed job back to be delivered again at the given time
+	Retry(ctx context.Context, job *Job, at time.Time) error
+	// DeadLetter moves a job that has used up its attempts to the dead-letter list
+	DeadLetter(ctx context.Context, job *Job) error
+	// Reclaim hands jobs claimed at least minIdle ago back to be delivered again, counting
+	// an attempt for each; with a consumer only that consumer's jobs are reclaimed
+	Reclaim(ctx context.Context, consumer string, minIdle time.Duration) (int, error)
+	// ListDead returns the most recently dead-lettered jobs, newest first
+	ListDead(ctx context.Context, limit int) ([]*Job, error)
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Placement strategies choosing the node a lease runs on
+const (
+	PlacementBinpack = "binpack" // Fill the most loaded node that still has room
+	PlacementSpread  = "spread"  // Use the least loaded node
+)
+
+// Node is a Docker host that leases can be placed on
+type Node struct {
+	ID        string            `json:"id"`
+	Host      string            `json:"host"`             // Docker endpoint, e.g. tcp://10.0.0.5:2376
+	Labels    map[string]string `json:"labels,omitempty"` // Matched against the node labels a lease asks for, e.g. ssd=true
+	CPUMilli  int               `json:"cpuMilli"`         // CPU leases may use on the node at once (0 = unlimited)
+	MemoryMB  int               `json:"memoryMB"`         // Memory leases may use on the node at once (0 = unlimited)
+	Healthy   bool              `json:"healthy"`
+	Error     string            `json:"error,omitempty"` // Why the last health check failed
+	CheckedAt time.Time         `json:"checkedAt"`
+}
+
+// HasLabels reports whether the node has every label with the given value
+func (n *Node) HasLabels(labels map[string]string) bool {
+	for k, v := range labels {
+		if n.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// NodeRegistry lists the Docker nodes of the pool with their last known health.
+// The first node is the default one, where containers without a NodeID run.
+type NodeRegistry interface {
+	Nodes() []Node
+}
+
+type nodeContextKey struct{}
+
+// WithNode sends the Docker calls made with ctx to the given node
+func WithNode(ctx context.Context, nodeID string) context.Context {
+	if nodeID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, nodeContextKey{}, nodeID)
+}
+
+// NodeFromContext returns the node set with WithNode, or ""
+func NodeFromContext(ctx context.Context) string {
+	nodeID, _ := ctx.Value(nodeContextKey{}).(string)
+	return nodeID
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Unlimited disables a quota limit
+const Unlimited = -1
+
+// TenantQuota caps the resources a tenant may hold at once (Unlimited = no cap)
+type TenantQuota struct {
+	TenantID      string
+	MaxContainers int // Concurrent containers (anything not yet terminated)
+	MaxCPUMilli   int // Total CPU millicores across containers
+	MaxMemoryMB   int // Total memory across containers
+	MaxVolumeMB   int // Total volume storage across containers
+	MaxSnapshots  int // Snapshots kept at once
+	UpdatedAt     time.Time
+}
+
+// TenantUsage is what a tenant currently holds against its quota
+type TenantUsage struct {
+	Containers int
+	CPUMilli   int
+	MemoryMB   int
+	VolumeMB   int
+	Snapshots  int
+}
+
+// QuotaRepository defines data access for per-tenant quota overrides
+type QuotaRepository interface {
+	// GetQuota returns the tenant's quota, or nil if none has been set
+	GetQuota(tenantID string) (*TenantQuota, error)
+	SaveQuota(quota *TenantQuota) error
+}
+
+// TenantLocker serialises a tenant's quota check-and-reserve sections across servers
+type TenantLocker interface {
+	// LockTenant blocks until the tenant's lock is held and returns its release function
+	LockTenant(ctx context.Context, tenantID string) (func(), error)
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// Recording is an asciicast v2 capture of one terminal session.
+// Recordings belong to the tenant's audit trail and are kept after the container is gone.
+type Recording struct {
+	ID          string
+	ContainerID string
+	TenantID    string
+	Command     []string
+	Cols        uint
+	Rows        uint
+	Path        string // Location of the .cast file on disk
+	SizeBytes   int64
+	StartedAt   time.Time
+	EndedAt     *time.Time // nil while the session is open
+}
+
+// RecordingRepository defines data access for session recording metadata
+type RecordingRepository interface {
+	Create(recording *Recording) error
+	// Finish stores the end time and final size of a recording
+	Finish(recording *Recording) error
+	GetByID(id string) (*Recording, error)
+	ListByContainer(containerID string) ([]*Recording, error)
+	// DeleteEndedBefore removes recordings that ended before cutoff and returns them
+	DeleteEndedBefore(cutoff time.Time) ([]*Recording, error)
+}
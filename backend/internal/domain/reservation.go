@@ -0,0 +1,79 @@
+package domain
+
+import "time"
+
+// Reservation states
+const (
+	ReservationPending      = "pending"      // Booked; its resources are held against quota and capacity
+	ReservationProvisioning = "provisioning" // The image is being pulled and the leases created
+	ReservationActive       = "active"       // Every lease has been created
+	ReservationCompleted    = "completed"    // The reserved window is over
+	ReservationCancelled    = "cancelled"    // Cancelled by the tenant before provisioning
+	ReservationFailed       = "failed"       // Provisioning failed; Error says why
+)
+
+// Reservation books one or more identical leases for a future time window
+type Reservation struct {
+	ID              string
+	TenantID        string
+	Status          string
+	StartAt         time.Time // When the leases must be ready
+	DurationMinutes int       // Length of the window; the leases expire at StartAt + DurationMinutes
+	Count           int       // Number of leases
+	Spec            LeaseSpec
+	ContainerIDs    []string // Leases created for the reservation so far
+	Error           string
+	ClaimedBy       string    // Server provisioning the reservation
+	ClaimExpiresAt  time.Time // When another server may take over the provisioning
+	CreatedAt       time.Time
+}
+
+// EndAt is when the reserved window, and every lease in it, ends
+func (r *Reservation) EndAt() time.Time {
+	return r.StartAt.Add(time.Duration(r.DurationMinutes) * time.Minute)
+}
+
+// Overlaps reports whether the reservation holds resources at any time in [start, end)
+func (r *Reservation) Overlaps(start, end time.Time) bool {
+	return r.StartAt.Before(end) && start.Before(r.EndAt())
+}
+
+// LeaseSpec is the validated provisioning request reserved and scheduled leases are created from
+type LeaseSpec struct {
+	ImageType    string            `json:"imageType"`
+	Image        string            `json:"image"`
+	CPUMilli     int               `json:"cpuMilli"`
+	MemoryMB     int               `json:"memoryMB"`
+	VolumeSizeMB int               `json:"volumeSizeMB,omitempty"`
+	Preset       string            `json:"preset,omitempty"`
+	Ports        []int             `json:"ports,omitempty"`
+	LogDemo      bool              `json:"logDemo,omitempty"`
+	Entrypoint   []string          `json:"entrypoint,omitempty"`
+	Command      []string          `json:"command,omitempty"`
+	Env          []EnvVar          `json:"env,omitempty"`
+	WorkingDir   string            `json:"workingDir,omitempty"`
+	InitScript   string            `json:"initScript,omitempty"`
+	NodeLabels   map[string]string `json:"nodeLabels,omitempty"`
+}
+
+// ReservationRepository defines data access for reservations
+type ReservationRepository interface {
+	Save(reservation *Reservation) error
+	GetByID(id string) (*Reservation, error)
+	// ListByTenant returns a tenant's reservations, latest start first
+	ListByTenant(tenantID string) ([]*Reservation, error)
+	// ListByStatus returns reservations in any of the given states, earliest start first
+	ListByStatus(statuses ...string) ([]*Reservation, error)
+	// UpdateStatus moves a reservation from one status to another, reporting false if it
+	// was no longer in the first one
+	UpdateStatus(id, from, to string) (bool, error)
+	// Claim moves a pending reservation, or a provisioning one whose claim expired by now,
+	// to provisioning for owner until the given time, reporting false if it could not
+	Claim(id, owner string, now, until time.Time) (bool, error)
+	// AddContainer records a lease created for a reservation owner still holds and
+	// extends the claim, reporting false if the claim was lost
+	AddContainer(id, owner, containerID string, until time.Time) (bool, error)
+	// Release frees a reservation owner has claimed for the next run, moving it to status
+	// and recording reason, reporting false if the claim was lost
+	Release(id, owner, status, reason string) (bool, error)
+}
@@ -0,0 +1,61 @@
+package domain
+
+import "time"
+
+// Schedule run outcomes
+const (
+	ScheduleRunProvisioned = "provisioned" // A lease was created for the occurrence
+	ScheduleRunSkipped     = "skipped"     // The tenant skipped the occurrence
+	ScheduleRunMissed      = "missed"      // The occurrence's window was over before it could run
+	ScheduleRunFailed      = "failed"      // Provisioning failed; Error says why
+)
+
+// Schedule creates a lease from the same spec at every occurrence of a cron expression
+type Schedule struct {
+	ID              string
+	TenantID        string
+	Name            string
+	Cron            string // Five-field cron expression, evaluated in Timezone
+	Timezone        string // IANA name, e.g. "Europe/Berlin"
+	DurationMinutes int    // Each lease expires this long after its occurrence
+	Spec            LeaseSpec
+	RestoreSnapshot bool      // Start from the latest snapshot of an earlier run instead of a fresh image
+	Paused          bool      // No leases are created while paused
+	SkipAt          time.Time // An occurrence to skip once (zero = none)
+	NextRunAt       time.Time // The next occurrence
+	CreatedAt       time.Time
+}
+
+// ScheduleRun records what happened at one occurrence of a schedule
+type ScheduleRun struct {
+	ID          string
+	ScheduleID  string
+	ScheduledAt time.Time // The occurrence
+	Status      string
+	ContainerID string // Lease created for the occurrence
+	SnapshotID  string // Snapshot the lease was restored from
+	Error       string
+	CreatedAt   time.Time
+}
+
+// ScheduleRepository defines data access for schedules and their run history
+type ScheduleRepository interface {
+	Save(schedule *Schedule) error
+	GetByID(id string) (*Schedule, error)
+	// ListByTenant returns a tenant's schedules, oldest first
+	ListByTenant(tenantID string) ([]*Schedule, error)
+	// ListDue returns unpaused schedules whose next occurrence is at or before now
+	ListDue(now time.Time) ([]*Schedule, error)
+	// ClaimOccurrence moves a schedule on from its due occurrence, saving the NextRunAt,
+	// SkipAt and Paused set on it, unless another server has done so first. It reports
+	// whether this call claimed the occurrence.
+	ClaimOccurrence(schedule *Schedule, due time.Time) (bool, error)
+	// Update saves the Paused, SkipAt and NextRunAt set on a schedule, unless its stored
+	// next occurrence is no longer nextRunAt. It reports whether the schedule was saved.
+	Update(schedule *Schedule, nextRunAt time.Time) (bool, error)
+	// Delete removes a schedule and its run history
+	Delete(id string) error
+	AddRun(run *ScheduleRun) error
+	// ListRuns returns a schedule's most recent runs, latest first
+	ListRuns(scheduleID string, limit int) ([]*ScheduleRun, error)
+}
@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/security"
+	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+	"github.com/aryan0dhankhar/containerlease/internal/service"
+)
+
+// BudgetSettings is the JSON form of a tenant budget
+type BudgetSettings struct {
+	MonthlyLimit   float64 `json:"monthlyLimit"`
+	AlertThreshold float64 `json:"alertThreshold"`
+	HardStop       bool    `json:"hardStop"`
+	ShortenLeases  bool    `json:"shortenLeases"`
+	GraceMinutes   int     `json:"graceMinutes"`
+}
+
+// BudgetEventResponse is a budget alert or stop raised this month
+type BudgetEventResponse struct {
+	Kind      string    `json:"kind"`
+	Spend     float64   `json:"spend"`
+	Limit     float64   `json:"limit"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BudgetResponse reports a tenant's budget and spend for the current month
+type BudgetResponse struct {
+	TenantID  string                `json:"tenantId"`
+	Budget    *BudgetSettings       `json:"budget"` // null when no budget is set
+	Period    string                `json:"period"` // YYYY-MM (UTC)
+	Spend     float64               `json:"spend"`
+	Stopped   bool                  `json:"stopped"` // Hard stop in effect: provisions and extensions are refused
+	Events    []BudgetEventResponse `json:"events"`
+	UpdatedAt *time.Time            `json:"updatedAt,omitempty"`
+}
+
+// UpdateBudgetRequest changes the caller's budget; omitted fields keep their current value
+type UpdateBudgetRequest struct {
+	MonthlyLimit   *float64 `json:"monthlyLimit,omitempty"`
+	AlertThreshold *float64 `json:"alertThreshold,omitempty"`
+	HardStop       *bool    `json:"hardStop,omitempty"`
+	ShortenLeases  *bool    `json:"shortenLeases,omitempty"`
+	GraceMinutes   *int     `json:"graceMinutes,omitempty"`
+}
+
+// BudgetErrorResponse is returned when a hard-stopped tenant asks for more resources
+type BudgetErrorResponse struct {
+	Error   string  `json:"error"`
+	Limit   float64 `json:"limit"`
+	Spend   float64 `json:"spend"`
+	Message string  `json:"message"`
+}
+
+// BudgetHandler serves the tenant budget endpoints
+type BudgetHandler struct {
+	budgetService *service.BudgetService
+	logger        *slog.Logger
+	authz         *security.AuthorizationService
+}
+
+// NewBudgetHandler creates a new budget handler
+func NewBudgetHandler(budgetService *service.BudgetService, logger *slog.Logger, authz *security.AuthorizationService) *BudgetHandler {
+	return &BudgetHandler{
+		budgetService: budgetService,
+		logger:        logger,
+		authz:         authz,
+	}
+}
+
+// GetBudget handles GET /api/budget
+func (h *BudgetHandler) GetBudget(w http.ResponseWriter, r *http.Request) {
+	tenantID := middleware.GetTenantFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	h.writeStatus(w, tenantID)
+}
+
+// UpdateBudget handles PUT /api/budget
+func (h *BudgetHandler) UpdateBudget(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateBudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	budget, err := h.budgetService.GetBudget(tenantID)
+	if err != nil {
+		h.logger.Error("failed to get budget", slog.String("tenant_id", tenantID), slog.String("error", err.Error()))
+		http.Error(w, "failed to get budget", http.StatusInternalServerError)
+		return
+	}
+	if budget == nil {
+		budget = h.budgetService.DefaultBudget(tenantID)
+	}
+	if req.MonthlyLimit != nil {
+		budget.MonthlyLimit = *req.MonthlyLimit
+	}
+	if req.AlertThreshold != nil {
+		budget.AlertThreshold = *req.AlertThreshold
+	}
+	if req.HardStop != nil {
+		budget.HardStop = *req.HardStop
+	}
+	if req.ShortenLeases != nil {
+		budget.ShortenLeases = *req.ShortenLeases
+	}
+	if req.GraceMinutes != nil {
+		budget.GraceMinutes = *req.GraceMinutes
+	}
+
+	if err := h.budgetService.UpdateBudget(budget); err != nil {
+		if errors.Is(err, service.ErrInvalidBudget) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.logger.Error("failed to update budget", slog.String("tenant_id", tenantID), slog.String("error", err.Error()))
+		http.Error(w, "failed to update budget", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("tenant budget changed",
+		slog.String("tenant_id", tenantID),
+		slog.Float64("monthly_limit", budget.MonthlyLimit),
+		slog.Bool("hard_stop", budget.HardStop),
+	)
+	h.writeStatus(w, tenantID)
+}
+
+// DeleteBudget handles DELETE /api/budget
+func (h *BudgetHandler) DeleteBudget(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.budgetService.DeleteBudget(tenantID); err != nil {
+		h.logger.Error("failed to delete budget", slog.String("tenant_id", tenantID), slog.String("error", err.Error()))
+		http.Error(w, "failed to delete budget", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *BudgetHandler) authorize(w http.ResponseWriter, r *http.Request) (string, bool) {
+	tenantID := middleware.GetTenantFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return "", false
+	}
+	if err := h.authz.ValidatePermission(h.authz.RoleForTenant(tenantID), security.PermManageBudget); err != nil {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return "", false
+	}
+	return tenantID, true
+}
+
+func (h *BudgetHandler) writeStatus(w http.ResponseWriter, tenantID string) {
+	status, err := h.budgetService.Status(tenantID, time.Now())
+	if err != nil {
+		h.logger.Error("failed to get budget status", slog.String("tenant_id", tenantID), slog.String("error", err.Error()))
+		http.Error(w, "failed to get budget", http.StatusInternalServerError)
+		return
+	}
+
+	resp := BudgetResponse{
+		TenantID: tenantID,
+		Period:   status.Period.Format("2006-01"),
+		Spend:    status.Spend,
+		Events:   make([]BudgetEventResponse, 0, len(status.Events)),
+	}
+	if b := status.Budget; b != nil {
+		resp.Budget = &BudgetSettings{
+			MonthlyLimit:   b.MonthlyLimit,
+			AlertThreshold: b.AlertThreshold,
+			HardStop:       b.HardStop,
+			ShortenLeases:  b.ShortenLeases,
+			GraceMinutes:   b.GraceMinutes,
+		}
+		resp.Stopped = b.HardStop && status.Spend >= b.MonthlyLimit
+		resp.UpdatedAt = &b.UpdatedAt
+	}
+	for _, e := range status.Events {
+		resp.Events = append(resp.Events, BudgetEventResponse{Kind: e.Kind, Spend: e.Spend, Limit: e.Limit, CreatedAt: e.CreatedAt})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// writeBudgetError writes a 402 response if err is a budget hard stop and reports whether it did
+func writeBudgetError(w http.ResponseWriter, err error) bool {
+	var be *service.BudgetExceededError
+	if !errors.As(err, &be) {
+		return false
+	}
+	writeJSON(w, http.StatusPaymentRequired, BudgetErrorResponse{
+		Error:   "budget_exceeded",
+		Limit:   be.Limit,
+		Spend:   be.Spend,
+		Message: be.Error(),
+	})
+	return true
+}
@@ -0,0 +1,327 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/security"
+	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+	"github.com/aryan0dhankhar/containerlease/internal/service"
+	"github.com/gorilla/websocket"
+)
+
+// defaultExecCmd is run when the client does not ask for a specific command
+var defaultExecCmd = []string{"/bin/sh"}
+
+// leaseCheckInterval bounds how long a terminal or proxied connection can outlive a lease that was shortened or deleted
+const leaseCheckInterval = 5 * time.Second
+
+// ExecMessage is a control message sent by the client as a text frame.
+// Binary frames are written to the process's stdin as-is.
+type ExecMessage struct {
+	Type string `json:"type"`           // input or resize
+	Data string `json:"data,omitempty"` // input: keystrokes
+	Cols uint   `json:"cols,omitempty"` // resize: terminal width
+	Rows uint   `json:"rows,omitempty"` // resize: terminal height
+}
+
+// ExecHandler serves interactive terminal sessions over WebSocket
+type ExecHandler struct {
+	dockerClient   domain.DockerClient
+	containerRepo  domain.ContainerRepository
+	logger         *slog.Logger
+	allowedOrigins []string
+	authz          *security.AuthorizationService
+	recordings     *service.RecordingService
+	idle           *service.IdleService
+}
+
+// NewExecHandler creates a new exec handler. Sessions are not recorded when recordings is nil.
+func NewExecHandler(dockerClient domain.DockerClient, containerRepo domain.ContainerRepository, logger *slog.Logger, allowedOrigins []string, authz *security.AuthorizationService, recordings *service.RecordingService) *ExecHandler {
+	return &ExecHandler{
+		dockerClient:   dockerClient,
+		containerRepo:  containerRepo,
+		logger:         logger,
+		allowedOrigins: allowedOrigins,
+		authz:          authz,
+		recordings:     recordings,
+	}
+}
+
+// WithIdle counts terminal input as lease activity and resumes paused leases when a terminal is opened
+func (h *ExecHandler) WithIdle(idle *service.IdleService) *ExecHandler {
+	h.idle = idle
+	return h
+}
+
+// ServeHTTP handles GET /ws/exec/{id}?cols=&rows=&cmd=&record= requests.
+// Terminal output is sent to the client as binary frames.
+func (h *ExecHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	containerID := r.PathValue("id")
+	if containerID == "" {
+		// Path format: /ws/exec/{id}
+		parts := strings.Split(r.URL.Path, "/")
+		if len(parts) >= 4 {
+			containerID = parts[3]
+		}
+	}
+	if containerID == "" {
+		http.Error(w, "missing container id", http.StatusBadRequest)
+		return
+	}
+
+	tenantID := middleware.GetTenantFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := h.authz.ValidatePermission(security.RoleUser, security.PermExecContainer); err != nil {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	container, err := h.containerRepo.GetByID(containerID)
+	if err != nil {
+		http.Error(w, "container not found", http.StatusNotFound)
+		return
+	}
+	if container.TenantID != tenantID {
+		h.logger.Warn("tenant attempted to exec into another tenant's container",
+			slog.String("tenant_id", tenantID),
+			slog.String("container_tenant", container.TenantID),
+			slog.String("container_id", containerID),
+		)
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	if h.idle != nil && time.Now().Before(container.ExpiryAt) {
+		if container, err = h.idle.Wake(r.Context(), container); err != nil {
+			h.logger.Error("failed to resume paused container", slog.String("container_id", containerID), slog.String("error", err.Error()))
+			http.Error(w, "failed to resume container", http.StatusBadGateway)
+			return
+		}
+	}
+	if container.Status != "running" || container.DockerID == "" || !time.Now().Before(container.ExpiryAt) {
+		http.Error(w, "container is not running", http.StatusConflict)
+		return
+	}
+
+	opts := domain.ExecOptions{Cmd: defaultExecCmd}
+	query := r.URL.Query()
+	if cmd := query["cmd"]; len(cmd) > 0 {
+		opts.Cmd = cmd
+	}
+	if cols, err := strconv.ParseUint(query.Get("cols"), 10, 16); err == nil {
+		opts.Cols = uint(cols)
+	}
+	if rows, err := strconv.ParseUint(query.Get("rows"), 10, 16); err == nil {
+		opts.Rows = uint(rows)
+	}
+
+	// Recording starts before the upgrade so a mandatory recording that fails can still be refused with a status code
+	var recorder *service.Recorder
+	var respHeader http.Header
+	if h.recordings != nil && h.recordings.ShouldRecord(tenantID, query.Get("record") == "true") {
+		recorder, err = h.recordings.Start(container, opts)
+		if err != nil {
+			h.logger.Error("failed to start session recording", slog.String("container_id", containerID), slog.String("error", err.Error()))
+			if h.recordings.Required(tenantID) {
+				http.Error(w, "session recording unavailable", http.StatusServiceUnavailable)
+				return
+			}
+		} else {
+			defer recorder.Close()
+			respHeader = http.Header{"X-Session-Recording": {recorder.Recording().ID}}
+		}
+	}
+
+	upgrader := newWebSocketUpgrader(h.allowedOrigins, h.logger)
+	ws, err := upgrader.Upgrade(w, r, respHeader)
+	if err != nil {
+		h.logger.Error("websocket upgrade failed", slog.String("error", err.Error()))
+		return
+	}
+	defer ws.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	session, err := h.dockerClient.Exec(ctx, container.DockerID, opts)
+	if err != nil {
+		h.logger.Error("failed to start exec session", slog.String("container_id", containerID), slog.String("error", err.Error()))
+		closeWebSocket(ws, websocket.CloseInternalServerErr, "failed to start session")
+		return
+	}
+	defer session.Close()
+	if recorder != nil {
+		session = &recordedSession{ExecSession: session, recorder: recorder}
+	}
+	if h.idle != nil {
+		session = &activeSession{ExecSession: session, touch: func() { h.idle.Touch(containerID) }}
+	}
+
+	logger := h.logger.With(slog.String("container_id", containerID), slog.String("tenant_id", tenantID))
+	logger.Info("exec session opened", slog.Any("cmd", opts.Cmd))
+
+	// The first goroutine to finish decides why the session ended
+	var once sync.Once
+	reason := "session ended"
+	end := func(why string) {
+		once.Do(func() {
+			reason = why
+			cancel()
+		})
+	}
+
+	go h.pumpOutput(ws, session, end)
+	go h.pumpInput(ctx, ws, session, end, logger)
+	go func() {
+		if why := watchLease(ctx, h.containerRepo, containerID); why != "" {
+			end(why)
+		}
+	}()
+	go keepAlive(ctx, ws)
+
+	<-ctx.Done()
+	closeWebSocket(ws, websocket.CloseNormalClosure, reason)
+	logger.Info("exec session closed", slog.String("reason", reason))
+}
+
+// pumpOutput copies terminal output to the client until the process exits
+func (h *ExecHandler) pumpOutput(ws *websocket.Conn, session domain.ExecSession, end func(string)) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := session.Read(buf)
+		if n > 0 {
+			if werr := ws.WriteMessage(websocket.BinaryMessage, buf[:n]); werr != nil {
+				end("client disconnected")
+				return
+			}
+		}
+		if err != nil {
+			end("process exited")
+			return
+		}
+	}
+}
+
+// pumpInput forwards client keystrokes to the process and applies resize requests
+func (h *ExecHandler) pumpInput(ctx context.Context, ws *websocket.Conn, session domain.ExecSession, end func(string), logger *slog.Logger) {
+	for {
+		msgType, data, err := ws.ReadMessage()
+		if err != nil {
+			end("client disconnected")
+			return
+		}
+
+		if msgType == websocket.BinaryMessage {
+			if _, err := session.Write(data); err != nil {
+				end("process exited")
+				return
+			}
+			continue
+		}
+
+		var msg ExecMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			logger.Debug("ignoring malformed exec message", slog.String("error", err.Error()))
+			continue
+		}
+		switch msg.Type {
+		case "input":
+			if _, err := session.Write([]byte(msg.Data)); err != nil {
+				end("process exited")
+				return
+			}
+		case "resize":
+			if msg.Cols == 0 || msg.Rows == 0 {
+				continue
+			}
+			if err := session.Resize(ctx, msg.Cols, msg.Rows); err != nil {
+				logger.Warn("failed to resize terminal", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// watchLease returns a reason once the container's lease is over, or "" if ctx ends first.
+// The lease is re-read on every check so extensions and early terminations are honoured.
+func watchLease(ctx context.Context, containerRepo domain.ContainerRepository, containerID string) string {
+	for {
+		container, err := containerRepo.GetByID(containerID)
+		if err != nil || container.Status == "terminated" {
+			return "container terminated"
+		}
+		remaining := time.Until(container.ExpiryAt)
+		if remaining <= 0 {
+			return "lease expired"
+		}
+		if remaining > leaseCheckInterval {
+			remaining = leaseCheckInterval
+		}
+
+		select {
+		case <-ctx.Done():
+			return ""
+		case <-time.After(remaining):
+		}
+	}
+}
+
+// recordedSession copies a session's output and resizes to its recording
+type recordedSession struct {
+	domain.ExecSession
+	recorder *service.Recorder
+}
+
+func (s *recordedSession) Read(p []byte) (int, error) {
+	n, err := s.ExecSession.Read(p)
+	if n > 0 {
+		_ = s.recorder.Output(p[:n])
+	}
+	return n, err
+}
+
+func (s *recordedSession) Resize(ctx context.Context, cols, rows uint) error {
+	if err := s.ExecSession.Resize(ctx, cols, rows); err != nil {
+		return err
+	}
+	_ = s.recorder.Resize(cols, rows)
+	return nil
+}
+
+// activeSession marks its lease as in use whenever the user types
+type activeSession struct {
+	domain.ExecSession
+	touch func()
+}
+
+func (s *activeSession) Write(p []byte) (int, error) {
+	s.touch()
+	return s.ExecSession.Write(p)
+}
+
+// keepAlive pings the client so idle terminals are not dropped by proxies
+func keepAlive(ctx context.Context, ws *websocket.Conn) {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = ws.WriteControl(websocket.PingMessage, []byte("ping"), time.Now().Add(5*time.Second))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func closeWebSocket(ws *websocket.Conn, code int, reason string) {
+	_ = ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+}
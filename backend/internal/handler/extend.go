@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/security"
+	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+	"github.com/aryan0dhankhar/containerlease/internal/service"
+)
+
+// ExtendRequest represents a request to add time to a running lease
+type ExtendRequest struct {
+	Minutes int `json:"minutes"`
+}
+
+// ExtendResponse represents the lease after an extension
+type ExtendResponse struct {
+	ID             string    `json:"id"`
+	ExpiryTime     time.Time `json:"expiryTime"`
+	TimeLeft       int       `json:"timeLeftSeconds"`
+	ExtensionCount int       `json:"extensionCount"`
+}
+
+// ExtendHandler handles lease extension requests
+type ExtendHandler struct {
+	containerService *service.ContainerService
+	logger           *slog.Logger
+	authz            *security.AuthorizationService
+}
+
+// NewExtendHandler creates a new extend handler
+func NewExtendHandler(containerService *service.ContainerService, logger *slog.Logger, authz *security.AuthorizationService) *ExtendHandler {
+	return &ExtendHandler{
+		containerService: containerService,
+		logger:           logger,
+		authz:            authz,
+	}
+}
+
+// ServeHTTP handles POST /api/containers/{id}/extend requests
+func (h *ExtendHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	containerID := r.PathValue("id")
+	if containerID == "" {
+		http.Error(w, "container id required", http.StatusBadRequest)
+		return
+	}
+
+	tenantID := middleware.GetTenantFromContext(r.Context())
+	if tenantID == "" {
+		h.logger.Error("tenant ID not found in context")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// RBAC: require permission to extend containers
+	if err := h.authz.ValidatePermission(security.RoleUser, security.PermExtendContainer); err != nil {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	var req ExtendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("failed to decode extend request", slog.String("error", err.Error()))
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	// Verify tenant owns this container before extending
+	container, err := h.containerService.GetContainer(r.Context(), containerID)
+	if err != nil {
+		http.Error(w, "container not found", http.StatusNotFound)
+		return
+	}
+	if container.TenantID != tenantID {
+		h.logger.Warn("tenant attempted to extend another tenant's container",
+			slog.String("tenant_id", tenantID),
+			slog.String("container_tenant", container.TenantID),
+			slog.String("container_id", containerID),
+		)
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	container, lease, err := h.containerService.ExtendLease(r.Context(), containerID, req.Minutes)
+	if err != nil {
+		if writeBudgetError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, service.ErrInvalidExtension), errors.Is(err, service.ErrMaxDurationExceeded):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrLeaseNotActive), errors.Is(err, service.ErrExtensionLimitReached), errors.Is(err, domain.ErrLeaseChanged):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Error("failed to extend lease", slog.String("container_id", containerID), slog.String("error", err.Error()))
+			http.Error(w, "failed to extend lease", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	timeLeft := int(time.Until(container.ExpiryAt).Seconds())
+	if timeLeft < 0 {
+		timeLeft = 0
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(ExtendResponse{
+		ID:             container.ID,
+		ExpiryTime:     container.ExpiryAt,
+		TimeLeft:       timeLeft,
+		ExtensionCount: lease.ExtensionCount,
+	}); err != nil {
+		h.logger.Error("failed to encode response", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/security"
+	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+	"github.com/aryan0dhankhar/containerlease/internal/service"
+)
+
+// tarContentType marks request and response bodies that are tar archives
+const tarContentType = "application/x-tar"
+
+// FilesHandler copies files into and out of leased containers
+type FilesHandler struct {
+	files         *service.FileService
+	containerRepo domain.ContainerRepository
+	logger        *slog.Logger
+	authz         *security.AuthorizationService
+}
+
+// NewFilesHandler creates a new files handler
+func NewFilesHandler(files *service.FileService, containerRepo domain.ContainerRepository, logger *slog.Logger, authz *security.AuthorizationService) *FilesHandler {
+	return &FilesHandler{
+		files:         files,
+		containerRepo: containerRepo,
+		logger:        logger,
+		authz:         authz,
+	}
+}
+
+// Upload handles PUT /api/containers/{id}/files?path=.
+// A body sent as application/x-tar is extracted into the directory path;
+// any other body is written to the file path and needs a Content-Length.
+func (h *FilesHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	container, ok := h.loadContainer(w, r)
+	if !ok {
+		return
+	}
+
+	// Large uploads must not be cut by the server's timeouts
+	h.clearDeadlines(w, container.ID)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	archive := mediaType == tarContentType
+	if !archive && r.ContentLength < 0 {
+		http.Error(w, "Content-Length required", http.StatusLengthRequired)
+		return
+	}
+
+	dst := r.URL.Query().Get("path")
+	if err := h.files.Upload(r.Context(), container, dst, r.Body, r.ContentLength, archive); err != nil {
+		h.writeFileError(w, container.ID, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Download handles GET /api/containers/{id}/files?path=.
+// Files are returned as-is; directories are returned as a tar archive.
+func (h *FilesHandler) Download(w http.ResponseWriter, r *http.Request) {
+	container, ok := h.loadContainer(w, r)
+	if !ok {
+		return
+	}
+
+	// Large downloads must not be cut by the server's timeouts
+	h.clearDeadlines(w, container.ID)
+
+	download, err := h.files.Download(r.Context(), container, r.URL.Query().Get("path"))
+	if err != nil {
+		h.writeFileError(w, container.ID, err)
+		return
+	}
+	defer download.Close()
+
+	name := download.Stat.Name
+	if download.Archive {
+		w.Header().Set("Content-Type", tarContentType)
+		name += ".tar"
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.FormatInt(download.Stat.Size, 10))
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(name)))
+	w.Header().Set("Last-Modified", download.Stat.ModTime.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, download); err != nil {
+		h.logger.Warn("file download interrupted", slog.String("container_id", container.ID), slog.String("error", err.Error()))
+	}
+}
+
+// loadContainer authorizes the request and returns the caller's running container
+func (h *FilesHandler) loadContainer(w http.ResponseWriter, r *http.Request) (*domain.Container, bool) {
+	tenantID := middleware.GetTenantFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	if err := h.authz.ValidatePermission(security.RoleUser, security.PermTransferFiles); err != nil {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return nil, false
+	}
+
+	containerID := r.PathValue("id")
+	container, err := h.containerRepo.GetByID(containerID)
+	if err != nil {
+		http.Error(w, "container not found", http.StatusNotFound)
+		return nil, false
+	}
+	if container.TenantID != tenantID {
+		h.logger.Warn("tenant attempted to access another tenant's files",
+			slog.String("tenant_id", tenantID),
+			slog.String("container_tenant", container.TenantID),
+			slog.String("container_id", containerID),
+		)
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return nil, false
+	}
+	if container.Status != "running" || container.DockerID == "" || !time.Now().Before(container.ExpiryAt) {
+		http.Error(w, "container is not running", http.StatusConflict)
+		return nil, false
+	}
+	return container, true
+}
+
+// clearDeadlines lifts the server's read and write timeouts for this request.
+// Every middleware wrapping the writer must unwrap to the connection's writer for this to work.
+func (h *FilesHandler) clearDeadlines(w http.ResponseWriter, containerID string) {
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		h.logger.Warn("failed to lift read deadline for file transfer", slog.String("container_id", containerID), slog.String("error", err.Error()))
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("failed to lift write deadline for file transfer", slog.String("container_id", containerID), slog.String("error", err.Error()))
+	}
+}
+
+func (h *FilesHandler) writeFileError(w http.ResponseWriter, containerID string, err error) {
+	switch {
+	case errors.Is(err, service.ErrPathNotAllowed):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrInvalidArchive):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrUploadTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, domain.ErrPathNotFound):
+		http.Error(w, "path not found", http.StatusNotFound)
+	default:
+		h.logger.Error("file transfer failed", slog.String("container_id", containerID), slog.String("error", err.Error()))
+		http.Error(w, "file transfer failed", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/security"
+	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+)
+
+// Dead-lettered jobs listed by default, and at most
+const (
+	deadJobsDefault = 50
+	deadJobsMax     = 1000
+)
+
+// JobResponse describes a background job. The payload is left out: jobs queued by
+// earlier versions carried the full provisioning request, secret values included.
+type JobResponse struct {
+	ID        string    `json:"id"`   // <kind>:<lease or snapshot ID>
+	Kind      string    `json:"kind"` // provision, delete or snapshot
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"lastError,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// JobsHandler lets administrators inspect the durable job queue
+type JobsHandler struct {
+	queue  domain.JobQueue
+	logger *slog.Logger
+	authz  *security.AuthorizationService
+}
+
+// NewJobsHandler creates a new jobs handler
+func NewJobsHandler(queue domain.JobQueue, logger *slog.Logger, authz *security.AuthorizationService) *JobsHandler {
+	return &JobsHandler{
+		queue:  queue,
+		logger: logger,
+		authz:  authz,
+	}
+}
+
+// ListDead handles GET /api/admin/jobs/dead?limit=N
+func (h *JobsHandler) ListDead(w http.ResponseWriter, r *http.Request) {
+	tenantID := middleware.GetTenantFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := h.authz.ValidatePermission(h.authz.RoleForTenant(tenantID), security.PermManageTenant); err != nil {
+		http.Error(w, "forbidden - admin access required", http.StatusForbidden)
+		return
+	}
+
+	limit := deadJobsDefault
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > deadJobsMax {
+			http.Error(w, "limit must be between 1 and 1000", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	jobs, err := h.queue.ListDead(r.Context(), limit)
+	if err != nil {
+		h.logger.Error("failed to list dead jobs", slog.String("error", err.Error()))
+		http.Error(w, "failed to list dead jobs", http.StatusInternalServerError)
+		return
+	}
+	resp := make([]JobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		resp = append(resp, JobResponse{
+			ID:        job.ID,
+			Kind:      job.Kind,
+			Attempts:  job.Attempts,
+			LastError: job.LastError,
+			CreatedAt: job.CreatedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -12,6 +12,8 @@ import (
 
 	"github.com/gorilla/websocket"
 	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+	"github.com/aryan0dhankhar/containerlease/internal/service"
 )
 
 // LogsHandler handles WebSocket connections for container logs
@@ -20,6 +22,7 @@ type LogsHandler struct {
 	logger         *slog.Logger
 	allowedOrigins []string
 	containerRepo  domain.ContainerRepository
+	idle           *service.IdleService
 }
 
 // NewLogsHandler creates a new logs handler
@@ -32,26 +35,15 @@ func NewLogsHandler(dockerClient domain.DockerClient, logger *slog.Logger, allow
 	}
 }
 
+// WithIdle counts streamed log output as lease activity and resumes paused leases when logs are opened
+func (h *LogsHandler) WithIdle(idle *service.IdleService) *LogsHandler {
+	h.idle = idle
+	return h
+}
+
 // upgrader is initialized per-request to use instance's allowed origins
 func (h *LogsHandler) getUpgrader() websocket.Upgrader {
-	return websocket.Upgrader{
-		ReadBufferSize:  1024,
-		WriteBufferSize: 1024,
-		CheckOrigin: func(r *http.Request) bool {
-			origin := r.Header.Get("Origin")
-			if origin == "" {
-				// Allow requests with no origin (e.g., non-browser clients)
-				return true
-			}
-			for _, allowed := range h.allowedOrigins {
-				if origin == allowed {
-					return true
-				}
-			}
-			h.logger.Warn("websocket origin rejected", slog.String("origin", origin))
-			return false
-		},
-	}
+	return newWebSocketUpgrader(h.allowedOrigins, h.logger)
 }
 
 // ServeHTTP handles WebSocket requests for container logs
@@ -74,6 +66,28 @@ func (h *LogsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Only the owning tenant may read the logs; opening them also resumes a paused lease
+	tenantID := middleware.GetTenantFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	container, err := h.containerRepo.GetByID(containerID)
+	if err != nil {
+		h.logger.Error("container not found for logs", slog.String("container_id", containerID), slog.String("error", err.Error()))
+		http.Error(w, "container not found", http.StatusNotFound)
+		return
+	}
+	if container.TenantID != tenantID {
+		h.logger.Warn("tenant attempted to read another tenant's logs",
+			slog.String("tenant_id", tenantID),
+			slog.String("container_tenant", container.TenantID),
+			slog.String("container_id", containerID),
+		)
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
 	// Upgrade HTTP connection to WebSocket with origin checking
 	upgrader := h.getUpgrader()
 	ws, err := upgrader.Upgrade(w, r, nil)
@@ -86,18 +100,18 @@ func (h *LogsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Use request context to avoid premature timeout; allows long-lived streams
 	ctx := r.Context()
 
-	// Resolve Docker ID from repository
-	container, err := h.containerRepo.GetByID(containerID)
-	if err != nil {
-		h.logger.Error("container not found for logs", slog.String("container_id", containerID), slog.String("error", err.Error()))
-		ws.WriteMessage(websocket.TextMessage, []byte("Error: container not found"))
-		return
-	}
 	if container.DockerID == "" {
 		h.logger.Error("container has no docker id", slog.String("container_id", containerID))
 		ws.WriteMessage(websocket.TextMessage, []byte("Error: container not yet running"))
 		return
 	}
+	if h.idle != nil && time.Now().Before(container.ExpiryAt) {
+		if container, err = h.idle.Wake(ctx, container); err != nil {
+			h.logger.Error("failed to resume paused container", slog.String("container_id", containerID), slog.String("error", err.Error()))
+			ws.WriteMessage(websocket.TextMessage, []byte("Error: failed to resume container"))
+			return
+		}
+	}
 
 	// Get logs from Docker using Docker ID
 	logStream, err := h.dockerClient.StreamLogs(ctx, container.DockerID)
@@ -143,6 +157,9 @@ func (h *LogsHandler) streamLogsToWebSocket(ws *websocket.Conn, logStream io.Rea
 	}()
 	for scanner.Scan() {
 		line := scanner.Bytes()
+		if h.idle != nil {
+			h.idle.Touch(containerID)
+		}
 		if err := ws.WriteMessage(websocket.TextMessage, line); err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				h.logger.Debug("websocket closed", slog.String("container_id", containerID))
@@ -189,6 +206,13 @@ func (h *LogsHandler) GetLogs(w http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+	if container.TenantID != middleware.GetTenantFromContext(r.Context()) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "forbidden",
+		})
+		return
+	}
 	if container.DockerID == "" {
 		h.logger.Error("container has no docker id", slog.String("container_id", containerID))
 		w.WriteHeader(http.StatusBadRequest)
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/aryan0dhankhar/containerlease/internal/security"
+	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+	"github.com/aryan0dhankhar/containerlease/internal/service"
+)
+
+// NodesHandler lets administrators inspect the Docker node pool
+type NodesHandler struct {
+	placement *service.PlacementService
+	logger    *slog.Logger
+	authz     *security.AuthorizationService
+}
+
+// NewNodesHandler creates a new nodes handler
+func NewNodesHandler(placement *service.PlacementService, logger *slog.Logger, authz *security.AuthorizationService) *NodesHandler {
+	return &NodesHandler{
+		placement: placement,
+		logger:    logger,
+		authz:     authz,
+	}
+}
+
+// List handles GET /api/admin/nodes
+func (h *NodesHandler) List(w http.ResponseWriter, r *http.Request) {
+	tenantID := middleware.GetTenantFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := h.authz.ValidatePermission(h.authz.RoleForTenant(tenantID), security.PermManageTenant); err != nil {
+		http.Error(w, "forbidden - admin access required", http.StatusForbidden)
+		return
+	}
+
+	usage, err := h.placement.Usage()
+	if err != nil {
+		h.logger.Error("failed to list nodes", slog.String("error", err.Error()))
+		http.Error(w, "failed to list nodes", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, usage)
+}
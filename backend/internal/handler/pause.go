@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/security"
+	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+	"github.com/aryan0dhankhar/containerlease/internal/service"
+)
+
+// PauseResponse represents a lease after it was paused or resumed
+type PauseResponse struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"`
+	ExpiryTime time.Time  `json:"expiryTime"`
+	TimeLeft   int        `json:"timeLeftSeconds"`
+	PausedAt   *time.Time `json:"pausedAt,omitempty"`
+	ResumeBy   *time.Time `json:"resumeBy,omitempty"` // Resumed automatically at this time
+}
+
+// PauseHandler handles pausing and resuming leases
+type PauseHandler struct {
+	containerService *service.ContainerService
+	logger           *slog.Logger
+	authz            *security.AuthorizationService
+	maxPause         time.Duration
+}
+
+// NewPauseHandler creates a new pause handler
+func NewPauseHandler(containerService *service.ContainerService, logger *slog.Logger, authz *security.AuthorizationService, maxPause time.Duration) *PauseHandler {
+	return &PauseHandler{
+		containerService: containerService,
+		logger:           logger,
+		authz:            authz,
+		maxPause:         maxPause,
+	}
+}
+
+// Pause handles POST /api/containers/{id}/pause
+func (h *PauseHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	h.apply(w, r, "pause", h.containerService.PauseContainer)
+}
+
+// Resume handles POST /api/containers/{id}/resume
+func (h *PauseHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	h.apply(w, r, "resume", h.containerService.ResumeContainer)
+}
+
+// apply checks the caller owns the container, then pauses or resumes it
+func (h *PauseHandler) apply(w http.ResponseWriter, r *http.Request, action string, fn func(context.Context, string) (*domain.Container, error)) {
+	containerID := r.PathValue("id")
+	if containerID == "" {
+		http.Error(w, "container id required", http.StatusBadRequest)
+		return
+	}
+
+	tenantID := middleware.GetTenantFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := h.authz.ValidatePermission(security.RoleUser, security.PermPauseContainer); err != nil {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	container, err := h.containerService.GetContainer(r.Context(), containerID)
+	if err != nil {
+		http.Error(w, "container not found", http.StatusNotFound)
+		return
+	}
+	if container.TenantID != tenantID {
+		h.logger.Warn("tenant attempted to "+action+" another tenant's container",
+			slog.String("tenant_id", tenantID),
+			slog.String("container_tenant", container.TenantID),
+			slog.String("container_id", containerID),
+		)
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	container, err = fn(r.Context(), containerID)
+	if err != nil {
+		if errors.Is(err, service.ErrLeaseNotActive) || errors.Is(err, service.ErrNotPaused) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		h.logger.Error("failed to "+action+" container", slog.String("container_id", containerID), slog.String("error", err.Error()))
+		http.Error(w, "failed to "+action+" container", http.StatusInternalServerError)
+		return
+	}
+
+	timeLeft := int(time.Until(container.ExpiryAt).Seconds())
+	if timeLeft < 0 {
+		timeLeft = 0
+	}
+
+	resp := PauseResponse{
+		ID:         container.ID,
+		Status:     container.Status,
+		ExpiryTime: container.ExpiryAt,
+		TimeLeft:   timeLeft,
+	}
+	if !container.PausedAt.IsZero() {
+		resumeBy := container.PausedAt.Add(h.maxPause)
+		resp.PausedAt = &container.PausedAt
+		resp.ResumeBy = &resumeBy
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -27,21 +27,23 @@ func (h *PresetsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	type PresetResponse struct {
-		ID          string `json:"id"`
-		Name        string `json:"name"`
-		CPUMilli    int    `json:"cpuMilli"`
-		MemoryMB    int    `json:"memoryMB"`
-		DurationMin int    `json:"durationMin"`
+		ID           string  `json:"id"`
+		Name         string  `json:"name"`
+		CPUMilli     int     `json:"cpuMilli"`
+		MemoryMB     int     `json:"memoryMB"`
+		DurationMin  int     `json:"durationMin"`
+		PricePerHour float64 `json:"pricePerHour,omitempty"`
 	}
 
 	presets := make([]PresetResponse, 0)
 	for id, preset := range h.config.Presets {
 		presets = append(presets, PresetResponse{
-			ID:          id,
-			Name:        preset.Name,
-			CPUMilli:    preset.CPUMilli,
-			MemoryMB:    preset.MemoryMB,
-			DurationMin: preset.DurationMin,
+			ID:           id,
+			Name:         preset.Name,
+			CPUMilli:     preset.CPUMilli,
+			MemoryMB:     preset.MemoryMB,
+			DurationMin:  preset.DurationMin,
+			PricePerHour: preset.PricePerHour,
 		})
 	}
 
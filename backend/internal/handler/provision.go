@@ -2,10 +2,17 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
 	"github.com/aryan0dhankhar/containerlease/internal/security"
 	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
 	"github.com/aryan0dhankhar/containerlease/internal/service"
@@ -14,12 +21,20 @@ import (
 
 // ProvisionRequest represents the request to provision a container
 type ProvisionRequest struct {
-	ImageType       string `json:"imageType"`
-	DurationMinutes int    `json:"durationMinutes"`
-	CPUMilli        int    `json:"cpuMilli,omitempty"`
-	MemoryMB        int    `json:"memoryMB,omitempty"`
-	LogDemo         bool   `json:"logDemo,omitempty"`
-	VolumeSizeMB    int    `json:"volumeSizeMB,omitempty"`
+	ImageType       string            `json:"imageType"` // Image alias or full reference, e.g. ghcr.io/acme/tool:1.2 or name@sha256:...
+	DurationMinutes int               `json:"durationMinutes"`
+	CPUMilli        int               `json:"cpuMilli,omitempty"`
+	MemoryMB        int               `json:"memoryMB,omitempty"`
+	LogDemo         bool              `json:"logDemo,omitempty"`
+	VolumeSizeMB    int               `json:"volumeSizeMB,omitempty"`
+	Preset          string            `json:"preset,omitempty"`     // Supplies CPU, memory and duration defaults; billed at the preset price
+	Ports           []int             `json:"ports,omitempty"`      // Container ports to reach through /proxy/{id}/{port}/
+	Entrypoint      []string          `json:"entrypoint,omitempty"` // Replaces the image entrypoint
+	Command         []string          `json:"command,omitempty"`    // Replaces the default sleep infinity
+	Env             []domain.EnvVar   `json:"env,omitempty"`        // Secret values are never returned or logged
+	WorkingDir      string            `json:"workingDir,omitempty"`
+	InitScript      string            `json:"initScript,omitempty"` // Run with sh -c after the container starts
+	NodeLabels      map[string]string `json:"nodeLabels,omitempty"` // Labels the Docker node must have, e.g. {"ssd": "true"}
 }
 
 // ProvisionResponse represents the response after provisioning
@@ -29,24 +44,43 @@ type ProvisionResponse struct {
 	ExpiryTime time.Time `json:"expiryTime"`
 	CreatedAt  time.Time `json:"createdAt"`
 	ImageType  string    `json:"imageType"`
-	Cost       float64   `json:"cost"`
+	Image      string    `json:"image"` // Fully qualified reference that will be pulled
+	Cost       float64   `json:"cost"`  // Estimated cost for the full lease duration
+	Ports      []int     `json:"ports,omitempty"`
+	// Place in the provisioning queue while status is queued, waiting for host capacity
+	QueuePosition int `json:"queuePosition,omitempty"`
 }
 
+// maxContainerPorts caps how many ports one container may expose through the proxy
+const maxContainerPorts = 10
+
+// Limits on the runtime spec a client may send
+const (
+	maxEnvVars       = 100
+	maxInitScriptLen = 64 << 10
+	maxNodeLabels    = 20
+)
+
+// envNamePattern matches portable environment variable names
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 // ProvisionHandler handles container provisioning requests
 type ProvisionHandler struct {
 	containerService *service.ContainerService
 	logger           *slog.Logger
 	config           *config.Config
 	authz            *security.AuthorizationService
+	images           *service.ImagePolicy
 }
 
 // NewProvisionHandler creates a new provision handler
-func NewProvisionHandler(containerService *service.ContainerService, logger *slog.Logger, cfg *config.Config, authz *security.AuthorizationService) *ProvisionHandler {
+func NewProvisionHandler(containerService *service.ContainerService, logger *slog.Logger, cfg *config.Config, authz *security.AuthorizationService, images *service.ImagePolicy) *ProvisionHandler {
 	return &ProvisionHandler{
 		containerService: containerService,
 		logger:           logger,
 		config:           cfg,
 		authz:            authz,
+		images:           images,
 	}
 }
 
@@ -71,47 +105,18 @@ func (h *ProvisionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !h.isImageAllowed(req.ImageType) {
-		h.logger.Warn("image type not allowed",
+	image, err := h.images.Resolve(req.ImageType)
+	if err != nil {
+		h.logger.Warn("image not allowed",
 			slog.String("requested_image", req.ImageType),
-			slog.Any("allowed_images", h.config.AllowedImages),
+			slog.String("error", err.Error()),
 		)
-		http.Error(w, "imageType not allowed", http.StatusBadRequest)
-		return
-	}
-
-	if req.DurationMinutes < h.config.ContainerMinDuration || req.DurationMinutes > h.config.ContainerMaxDuration {
-		http.Error(w, "durationMinutes out of bounds", http.StatusBadRequest)
-		return
-	}
-
-	// Apply defaults and caps for CPU and memory
-	cpuMilli := req.CPUMilli
-	if cpuMilli <= 0 {
-		cpuMilli = h.config.DefaultCPUMilli
-	}
-	if cpuMilli > h.config.MaxCPUMilli {
-		http.Error(w, "cpuMilli exceeds allowed maximum", http.StatusBadRequest)
-		return
-	}
-
-	memoryMB := req.MemoryMB
-	if memoryMB <= 0 {
-		memoryMB = h.config.DefaultMemoryMB
-	}
-	if memoryMB > h.config.MaxMemoryMB {
-		http.Error(w, "memoryMB exceeds allowed maximum", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Validate volume size (optional)
-	volumeSizeMB := req.VolumeSizeMB
-	maxVolumeMB := h.config.MaxVolumeMB
-	if maxVolumeMB == 0 {
-		maxVolumeMB = 5120 // default 5GB if not configured
-	}
-	if volumeSizeMB > maxVolumeMB {
-		http.Error(w, "volumeSizeMB exceeds allowed maximum", http.StatusBadRequest)
+	if err := validateProvisionRequest(h.config, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -131,16 +136,33 @@ func (h *ProvisionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Call service layer
-	container, err := h.containerService.ProvisionContainer(r.Context(), service.ProvisionOptions{
+	opts := service.ProvisionOptions{
 		TenantID:        tenantID,
 		ImageType:       req.ImageType,
+		Image:           image,
 		DurationMinutes: req.DurationMinutes,
-		CPUMilli:        cpuMilli,
-		MemoryMB:        memoryMB,
+		CPUMilli:        req.CPUMilli,
+		MemoryMB:        req.MemoryMB,
 		LogDemo:         req.LogDemo,
-		VolumeSizeMB:    volumeSizeMB,
-	})
+		VolumeSizeMB:    req.VolumeSizeMB,
+		Preset:          req.Preset,
+		Ports:           req.Ports,
+		Entrypoint:      req.Entrypoint,
+		Command:         req.Command,
+		Env:             req.Env,
+		WorkingDir:      req.WorkingDir,
+		InitScript:      req.InitScript,
+		NodeLabels:      req.NodeLabels,
+	}
+	container, err := h.containerService.ProvisionContainer(r.Context(), opts)
 	if err != nil {
+		if writeQuotaError(w, err) || writeBudgetError(w, err) || writeCapacityError(w, err) {
+			return
+		}
+		if errors.Is(err, service.ErrNoMatchingNode) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		h.logger.Error("failed to provision container", slog.String("error", err.Error()))
 		http.Error(w, "failed to provision container", http.StatusInternalServerError)
 		return
@@ -153,7 +175,12 @@ func (h *ProvisionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		ExpiryTime: container.ExpiryAt,
 		CreatedAt:  container.CreatedAt,
 		ImageType:  container.ImageType,
-		Cost:       container.Cost,
+		Image:      container.Image,
+		Cost:       h.containerService.EstimateCost(opts),
+		Ports:      container.Ports,
+	}
+	if container.Status == "queued" {
+		response.QueuePosition = h.containerService.QueuePosition(container.ID)
 	}
 
 	// Send response
@@ -164,11 +191,98 @@ func (h *ProvisionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *ProvisionHandler) isImageAllowed(image string) bool {
-	for _, allowed := range h.config.AllowedImages {
-		if allowed == image {
-			return true
+// validateProvisionRequest checks the sizing, duration, ports and runtime spec of a request,
+// filling in the preset's values and the default CPU and memory
+func validateProvisionRequest(cfg *config.Config, req *ProvisionRequest) error {
+	// A preset fills in any omitted sizing; explicit values must agree with it
+	if req.Preset != "" {
+		preset, ok := cfg.Presets[req.Preset]
+		if !ok {
+			return errors.New("unknown preset")
+		}
+		if (req.CPUMilli != 0 && req.CPUMilli != preset.CPUMilli) || (req.MemoryMB != 0 && req.MemoryMB != preset.MemoryMB) {
+			return errors.New("cpuMilli and memoryMB must match the preset")
+		}
+		req.CPUMilli = preset.CPUMilli
+		req.MemoryMB = preset.MemoryMB
+		if req.DurationMinutes == 0 {
+			req.DurationMinutes = preset.DurationMin
+		}
+	}
+
+	if req.DurationMinutes < cfg.ContainerMinDuration || req.DurationMinutes > cfg.ContainerMaxDuration {
+		return errors.New("durationMinutes out of bounds")
+	}
+
+	// Apply defaults and caps for CPU and memory
+	if req.CPUMilli <= 0 {
+		req.CPUMilli = cfg.DefaultCPUMilli
+	}
+	if req.CPUMilli > cfg.MaxCPUMilli {
+		return errors.New("cpuMilli exceeds allowed maximum")
+	}
+	if req.MemoryMB <= 0 {
+		req.MemoryMB = cfg.DefaultMemoryMB
+	}
+	if req.MemoryMB > cfg.MaxMemoryMB {
+		return errors.New("memoryMB exceeds allowed maximum")
+	}
+
+	// Validate volume size (optional)
+	maxVolumeMB := cfg.MaxVolumeMB
+	if maxVolumeMB == 0 {
+		maxVolumeMB = 5120 // default 5GB if not configured
+	}
+	if req.VolumeSizeMB > maxVolumeMB {
+		return errors.New("volumeSizeMB exceeds allowed maximum")
+	}
+
+	if len(req.Ports) > maxContainerPorts {
+		return fmt.Errorf("at most %d ports may be declared", maxContainerPorts)
+	}
+	for i, port := range req.Ports {
+		if port < 1 || port > 65535 || slices.Contains(req.Ports[:i], port) {
+			return errors.New("ports must be unique numbers between 1 and 65535")
+		}
+	}
+
+	return validateRuntimeSpec(req)
+}
+
+// validateRuntimeSpec checks the command, environment, working directory and init script of a request
+func validateRuntimeSpec(req *ProvisionRequest) error {
+	if req.LogDemo && (len(req.Command) > 0 || len(req.Entrypoint) > 0) {
+		return errors.New("logDemo cannot be combined with command or entrypoint")
+	}
+	if len(req.Env) > maxEnvVars {
+		return fmt.Errorf("at most %d env variables may be set", maxEnvVars)
+	}
+	seen := map[string]bool{}
+	for _, e := range req.Env {
+		if !envNamePattern.MatchString(e.Name) {
+			return fmt.Errorf("invalid env variable name %q", e.Name)
+		}
+		if seen[e.Name] {
+			return fmt.Errorf("env variable %q is set more than once", e.Name)
+		}
+		seen[e.Name] = true
+		if strings.ContainsRune(e.Value, 0) {
+			return fmt.Errorf("env variable %q contains a NUL byte", e.Name)
+		}
+	}
+	if req.WorkingDir != "" && !path.IsAbs(req.WorkingDir) {
+		return errors.New("workingDir must be an absolute path")
+	}
+	if len(req.InitScript) > maxInitScriptLen {
+		return fmt.Errorf("initScript exceeds %d bytes", maxInitScriptLen)
+	}
+	if len(req.NodeLabels) > maxNodeLabels {
+		return fmt.Errorf("at most %d node labels may be requested", maxNodeLabels)
+	}
+	for k := range req.NodeLabels {
+		if k == "" {
+			return errors.New("node label names must not be empty")
 		}
 	}
-	return false
+	return nil
 }
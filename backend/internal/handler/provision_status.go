@@ -7,34 +7,61 @@ import (
 	"time"
 
 	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/service"
 )
 
 // ProvisionStatusResponse represents the current status of a provisioning container
 type ProvisionStatusResponse struct {
-	ID         string    `json:"id"`
-	Status     string    `json:"status"` // pending, running, error
-	ImageType  string    `json:"imageType"`
-	CreatedAt  time.Time `json:"createdAt"`
-	ExpiryTime time.Time `json:"expiryTime"`
-	Cost       float64   `json:"cost"`
-	Error      string    `json:"error,omitempty"`
-	TimeLeft   int       `json:"timeLeftSeconds"` // Seconds remaining
+	ID          string            `json:"id"`
+	Status      string            `json:"status"` // queued, pending, running, paused, error
+	ImageType   string            `json:"imageType"`
+	Image       string            `json:"image,omitempty"`
+	ImageDigest string            `json:"imageDigest,omitempty"` // Set once the image has been pulled
+	CreatedAt   time.Time         `json:"createdAt"`
+	ExpiryTime  time.Time         `json:"expiryTime"`
+	Cost        float64           `json:"cost"` // Cost accrued so far
+	Error       string            `json:"error,omitempty"`
+	TimeLeft    int               `json:"timeLeftSeconds"` // Seconds remaining
+	Init        *InitScriptStatus `json:"init,omitempty"`  // Present when an init script was requested
+	Security    string            `json:"securityProfile,omitempty"`
+	Egress      string            `json:"egress,omitempty"` // Network egress: none, internal or full
+	PausedAt    *time.Time        `json:"pausedAt,omitempty"`
+	Node        string            `json:"node,omitempty"` // Docker node the container runs on
+	NodeLabels  map[string]string `json:"nodeLabels,omitempty"`
+	// Place in the provisioning queue while status is queued, waiting for host capacity
+	QueuePosition int `json:"queuePosition,omitempty"`
+}
+
+// InitScriptStatus reports the progress of a container's init script
+type InitScriptStatus struct {
+	Status   string `json:"status"` // pending, running, succeeded, failed
+	ExitCode int    `json:"exitCode"`
+	Output   string `json:"output,omitempty"` // Truncated, with secret env values redacted
 }
 
 // ProvisionStatusHandler handles GET /api/containers/{id} requests for real-time status
 type ProvisionStatusHandler struct {
 	containerRepo domain.ContainerRepository
 	logger        *slog.Logger
+	billing       *service.BillingService
+	queue         *service.ProvisionQueue
 }
 
 // NewProvisionStatusHandler creates a new provision status handler
-func NewProvisionStatusHandler(containerRepo domain.ContainerRepository, logger *slog.Logger) *ProvisionStatusHandler {
+func NewProvisionStatusHandler(containerRepo domain.ContainerRepository, logger *slog.Logger, billing *service.BillingService) *ProvisionStatusHandler {
 	return &ProvisionStatusHandler{
 		containerRepo: containerRepo,
 		logger:        logger,
+		billing:       billing,
 	}
 }
 
+// WithQueue reports the position of queued containers in the provisioning queue
+func (h *ProvisionStatusHandler) WithQueue(queue *service.ProvisionQueue) *ProvisionStatusHandler {
+	h.queue = queue
+	return h
+}
+
 // ServeHTTP handles GET /api/containers/{id}/status requests
 func (h *ProvisionStatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -63,14 +90,33 @@ func (h *ProvisionStatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Reques
 	}
 
 	response := ProvisionStatusResponse{
-		ID:         container.ID,
-		Status:     container.Status,
-		ImageType:  container.ImageType,
-		CreatedAt:  container.CreatedAt,
-		ExpiryTime: container.ExpiryAt,
-		Cost:       container.Cost,
-		Error:      container.Error,
-		TimeLeft:   timeLeft,
+		ID:          container.ID,
+		Status:      container.Status,
+		ImageType:   container.ImageType,
+		Image:       container.Image,
+		ImageDigest: container.ImageDigest,
+		CreatedAt:   container.CreatedAt,
+		ExpiryTime:  container.ExpiryAt,
+		Cost:        liveCost(h.billing, container, now),
+		Error:       container.Error,
+		TimeLeft:    timeLeft,
+		Security:    container.SecurityProfile,
+		Egress:      container.Egress,
+		Node:        container.NodeID,
+		NodeLabels:  container.NodeLabels,
+	}
+	if !container.PausedAt.IsZero() {
+		response.PausedAt = &container.PausedAt
+	}
+	if container.Status == "queued" && h.queue != nil {
+		response.QueuePosition = h.queue.Position(container.ID)
+	}
+	if container.InitStatus != "" {
+		response.Init = &InitScriptStatus{
+			Status:   container.InitStatus,
+			ExitCode: container.InitExitCode,
+			Output:   container.InitOutput,
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,340 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/security"
+	"github.com/aryan0dhankhar/containerlease/internal/security/auth"
+	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// proxyTokenCookie carries the proxy token after the first request so pages can load their assets
+const proxyTokenCookie = "cl_proxy_token"
+
+// proxyAddrTTL is how long a container's network address is cached between requests
+const proxyAddrTTL = 10 * time.Second
+
+// ShareRequest asks for a token that opens one container port to anyone holding it
+type ShareRequest struct {
+	Port             int `json:"port"`
+	ExpiresInMinutes int `json:"expiresInMinutes,omitempty"` // Default and maximum: SHARE_TOKEN_MAX_MINUTES
+}
+
+// ShareResponse is a share token and the proxy URL that uses it
+type ShareResponse struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type cachedAddr struct {
+	ip      string
+	fetched time.Time
+}
+
+// ProxyHandler forwards /proxy/{containerID}/{port}/... to a declared port of a running container
+type ProxyHandler struct {
+	dockerClient  domain.DockerClient
+	containerRepo domain.ContainerRepository
+	tokenManager  *auth.TokenManager
+	logger        *slog.Logger
+	config        *config.Config
+	authz         *security.AuthorizationService
+
+	mu    sync.Mutex
+	addrs map[string]cachedAddr // Docker ID -> address
+}
+
+// NewProxyHandler creates a new proxy handler
+func NewProxyHandler(dockerClient domain.DockerClient, containerRepo domain.ContainerRepository, tokenManager *auth.TokenManager, logger *slog.Logger, cfg *config.Config, authz *security.AuthorizationService) *ProxyHandler {
+	return &ProxyHandler{
+		dockerClient:  dockerClient,
+		containerRepo: containerRepo,
+		tokenManager:  tokenManager,
+		logger:        logger,
+		config:        cfg,
+		authz:         authz,
+		addrs:         map[string]cachedAddr{},
+	}
+}
+
+// ServeHTTP handles /proxy/{containerID}/{port}/... requests, including WebSocket upgrades.
+// It authenticates the request itself: a ?token= query parameter (owner JWT or share token),
+// the cookie set from it, or an Authorization header.
+func (h *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/proxy/"), "/", 3)
+	if len(parts) < 2 || parts[0] == "" {
+		http.NotFound(w, r)
+		return
+	}
+	containerID := parts[0]
+	port, err := strconv.Atoi(parts[1])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	prefix := fmt.Sprintf("/proxy/%s/%d/", containerID, port)
+	if len(parts) == 2 {
+		// Relative links in the app only resolve under a trailing slash
+		target := prefix
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, target, http.StatusFound)
+		return
+	}
+
+	// Unknown, expired and undeclared routes all look the same
+	container, err := h.containerRepo.GetByID(containerID)
+	if err != nil || !slices.Contains(container.Ports, port) {
+		http.NotFound(w, r)
+		return
+	}
+	if container.Status != "running" || container.DockerID == "" || !time.Now().Before(container.ExpiryAt) {
+		http.NotFound(w, r)
+		return
+	}
+
+	token, fromQuery, fromHeader := proxyToken(r)
+	if token == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	expiresAt, status := h.authorize(token, container, port)
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	if fromQuery {
+		http.SetCookie(w, &http.Cookie{
+			Name:     proxyTokenCookie,
+			Value:    token,
+			Path:     prefix,
+			Expires:  expiresAt,
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	ip, err := h.address(r.Context(), container.DockerID)
+	if err != nil {
+		h.logger.Error("failed to resolve container address", slog.String("container_id", containerID), slog.String("error", err.Error()))
+		http.Error(w, "container unreachable", http.StatusBadGateway)
+		return
+	}
+	target := &url.URL{Scheme: "http", Host: net.JoinHostPort(ip, strconv.Itoa(port))}
+
+	// Routes disappear with the lease: upgraded connections re-check it, plain requests get a deadline
+	ctx, cancel := context.WithDeadline(r.Context(), container.ExpiryAt)
+	if isUpgrade(r) {
+		cancel()
+		ctx, cancel = context.WithCancel(r.Context())
+		go func() {
+			if why := watchLease(ctx, h.containerRepo, containerID); why != "" {
+				h.logger.Info("closing proxied connection", slog.String("container_id", containerID), slog.String("reason", why))
+				cancel()
+			}
+		}()
+	}
+	defer cancel()
+
+	// Long downloads and WebSockets must not be cut by the server's timeouts
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.Out.URL.Path = "/" + parts[2]
+			pr.Out.URL.RawPath = ""
+			pr.SetXForwarded()
+			pr.Out.Header.Set("X-Forwarded-Prefix", strings.TrimSuffix(prefix, "/"))
+			stripProxyCredentials(pr.Out, fromHeader)
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if ctx.Err() == nil {
+				h.logger.Warn("proxy request failed", slog.String("container_id", containerID), slog.Int("port", port), slog.String("error", err.Error()))
+			}
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	proxy.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// CreateShare handles POST /api/containers/{id}/share
+func (h *ProxyHandler) CreateShare(w http.ResponseWriter, r *http.Request) {
+	tenantID := middleware.GetTenantFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if err := h.authz.ValidatePermission(security.RoleUser, security.PermShareContainer); err != nil {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	var req ShareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	container, err := h.containerRepo.GetByID(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "container not found", http.StatusNotFound)
+		return
+	}
+	if container.TenantID != tenantID {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	if !slices.Contains(container.Ports, req.Port) {
+		http.Error(w, "port is not declared for this container", http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	if container.Status == "terminated" || !now.Before(container.ExpiryAt) {
+		http.Error(w, "lease is not active", http.StatusConflict)
+		return
+	}
+
+	maxMinutes := h.config.ShareTokenMaxMinutes
+	minutes := req.ExpiresInMinutes
+	if minutes <= 0 || minutes > maxMinutes {
+		minutes = maxMinutes
+	}
+	expiresAt := now.Add(time.Duration(minutes) * time.Minute)
+	if expiresAt.After(container.ExpiryAt) {
+		expiresAt = container.ExpiryAt
+	}
+
+	token, err := h.tokenManager.GenerateShareToken(container.ID, req.Port, expiresAt)
+	if err != nil {
+		h.logger.Error("failed to issue share token", slog.String("container_id", container.ID), slog.String("error", err.Error()))
+		http.Error(w, "failed to create share token", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("proxy share token issued",
+		slog.String("tenant_id", tenantID),
+		slog.String("container_id", container.ID),
+		slog.Int("port", req.Port),
+		slog.Time("expires_at", expiresAt),
+	)
+	writeJSON(w, http.StatusCreated, ShareResponse{
+		Token:     token,
+		URL:       fmt.Sprintf("/proxy/%s/%d/?token=%s", container.ID, req.Port, url.QueryEscape(token)),
+		ExpiresAt: expiresAt,
+	})
+}
+
+// authorize accepts a share token for this container and port, or the owner's login token.
+// It returns when the token expires and an HTTP status.
+func (h *ProxyHandler) authorize(token string, container *domain.Container, port int) (time.Time, int) {
+	if share, err := h.tokenManager.ValidateShareToken(token); err == nil {
+		if share.ContainerID != container.ID || share.Port != port {
+			return time.Time{}, http.StatusForbidden
+		}
+		return share.ExpiresAt.Time, http.StatusOK
+	}
+
+	claims, err := h.tokenManager.ValidateToken(token)
+	if err != nil {
+		return time.Time{}, http.StatusUnauthorized
+	}
+	if claims.TenantID != container.TenantID {
+		return time.Time{}, http.StatusForbidden
+	}
+	if err := h.authz.ValidatePermission(security.RoleUser, security.PermReadContainer); err != nil {
+		return time.Time{}, http.StatusForbidden
+	}
+	var expiresAt time.Time
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	return expiresAt, http.StatusOK
+}
+
+// address returns the container's IP on the proxy network
+func (h *ProxyHandler) address(ctx context.Context, dockerID string) (string, error) {
+	h.mu.Lock()
+	cached, ok := h.addrs[dockerID]
+	h.mu.Unlock()
+	if ok && time.Since(cached.fetched) < proxyAddrTTL {
+		return cached.ip, nil
+	}
+
+	state, err := h.dockerClient.InspectContainer(ctx, dockerID)
+	if err != nil {
+		return "", err
+	}
+	if state.IPAddress == "" {
+		return "", fmt.Errorf("container has no network address")
+	}
+
+	h.mu.Lock()
+	for id, a := range h.addrs {
+		if time.Since(a.fetched) >= proxyAddrTTL {
+			delete(h.addrs, id)
+		}
+	}
+	h.addrs[dockerID] = cachedAddr{ip: state.IPAddress, fetched: time.Now()}
+	h.mu.Unlock()
+	return state.IPAddress, nil
+}
+
+// proxyToken finds the request's proxy credential and reports where it came from
+func proxyToken(r *http.Request) (token string, fromQuery, fromHeader bool) {
+	if token = r.URL.Query().Get("token"); token != "" {
+		return token, true, false
+	}
+	if c, err := r.Cookie(proxyTokenCookie); err == nil && c.Value != "" {
+		return c.Value, false, false
+	}
+	if header := r.Header.Get("Authorization"); header != "" {
+		if token, err := auth.ExtractToken(header); err == nil {
+			return token, false, true
+		}
+	}
+	return "", false, false
+}
+
+// stripProxyCredentials keeps ContainerLease tokens from reaching the container
+func stripProxyCredentials(out *http.Request, fromHeader bool) {
+	query := out.URL.Query()
+	if query.Has("token") {
+		query.Del("token")
+		out.URL.RawQuery = query.Encode()
+	}
+	if fromHeader {
+		out.Header.Del("Authorization")
+	}
+
+	cookies := out.Cookies()
+	out.Header.Del("Cookie")
+	for _, c := range cookies {
+		if c.Name != proxyTokenCookie {
+			out.AddCookie(c)
+		}
+	}
+}
+
+func isUpgrade(r *http.Request) bool {
+	return r.Header.Get("Upgrade") != "" && strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
+}
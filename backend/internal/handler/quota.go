@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/security"
+	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+	"github.com/aryan0dhankhar/containerlease/internal/service"
+)
+
+// QuotaLimits is the JSON form of a tenant quota (-1 = unlimited)
+type QuotaLimits struct {
+	MaxContainers int `json:"maxContainers"`
+	MaxCPUMilli   int `json:"maxCpuMilli"`
+	MaxMemoryMB   int `json:"maxMemoryMB"`
+	MaxVolumeMB   int `json:"maxVolumeMB"`
+	MaxSnapshots  int `json:"maxSnapshots"`
+}
+
+// QuotaUsage is the JSON form of a tenant's current usage
+type QuotaUsage struct {
+	Containers int `json:"containers"`
+	CPUMilli   int `json:"cpuMilli"`
+	MemoryMB   int `json:"memoryMB"`
+	VolumeMB   int `json:"volumeMB"`
+	Snapshots  int `json:"snapshots"`
+}
+
+// QuotaResponse reports a tenant's quota, and usage where requested
+type QuotaResponse struct {
+	TenantID  string      `json:"tenantId"`
+	Quota     QuotaLimits `json:"quota"`
+	Usage     *QuotaUsage `json:"usage,omitempty"`
+	UpdatedAt *time.Time  `json:"updatedAt,omitempty"` // Unset while the tenant uses the defaults
+}
+
+// UpdateQuotaRequest changes a tenant's quota; omitted fields keep their current value
+type UpdateQuotaRequest struct {
+	MaxContainers *int `json:"maxContainers,omitempty"`
+	MaxCPUMilli   *int `json:"maxCpuMilli,omitempty"`
+	MaxMemoryMB   *int `json:"maxMemoryMB,omitempty"`
+	MaxVolumeMB   *int `json:"maxVolumeMB,omitempty"`
+	MaxSnapshots  *int `json:"maxSnapshots,omitempty"`
+}
+
+// QuotaErrorResponse is returned when a request would exceed a tenant quota
+type QuotaErrorResponse struct {
+	Error     string `json:"error"`
+	Limit     string `json:"limit"`
+	Max       int    `json:"max"`
+	Used      int    `json:"used"`
+	Requested int    `json:"requested"`
+	Message   string `json:"message"`
+}
+
+// QuotaHandler serves tenant quota and usage endpoints
+type QuotaHandler struct {
+	quotaService *service.QuotaService
+	logger       *slog.Logger
+	authz        *security.AuthorizationService
+}
+
+// NewQuotaHandler creates a new quota handler
+func NewQuotaHandler(quotaService *service.QuotaService, logger *slog.Logger, authz *security.AuthorizationService) *QuotaHandler {
+	return &QuotaHandler{
+		quotaService: quotaService,
+		logger:       logger,
+		authz:        authz,
+	}
+}
+
+// GetUsage handles GET /api/quota: the caller's usage against its quota
+func (h *QuotaHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	tenantID := middleware.GetTenantFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	quota, err := h.quotaService.GetQuota(tenantID)
+	if err != nil {
+		h.logger.Error("failed to get quota", slog.String("tenant_id", tenantID), slog.String("error", err.Error()))
+		http.Error(w, "failed to get quota", http.StatusInternalServerError)
+		return
+	}
+	usage, err := h.quotaService.GetUsage(tenantID)
+	if err != nil {
+		h.logger.Error("failed to get usage", slog.String("tenant_id", tenantID), slog.String("error", err.Error()))
+		http.Error(w, "failed to get usage", http.StatusInternalServerError)
+		return
+	}
+
+	resp := newQuotaResponse(quota)
+	resp.Usage = &QuotaUsage{
+		Containers: usage.Containers,
+		CPUMilli:   usage.CPUMilli,
+		MemoryMB:   usage.MemoryMB,
+		VolumeMB:   usage.VolumeMB,
+		Snapshots:  usage.Snapshots,
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetTenantQuota handles GET /api/admin/tenants/{tenantId}/quota
+func (h *QuotaHandler) GetTenantQuota(w http.ResponseWriter, r *http.Request) {
+	targetTenant, ok := h.authorizeAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	quota, err := h.quotaService.GetQuota(targetTenant)
+	if err != nil {
+		h.logger.Error("failed to get quota", slog.String("tenant_id", targetTenant), slog.String("error", err.Error()))
+		http.Error(w, "failed to get quota", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, newQuotaResponse(quota))
+}
+
+// UpdateTenantQuota handles PUT /api/admin/tenants/{tenantId}/quota
+func (h *QuotaHandler) UpdateTenantQuota(w http.ResponseWriter, r *http.Request) {
+	targetTenant, ok := h.authorizeAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateQuotaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	quota, err := h.quotaService.GetQuota(targetTenant)
+	if err != nil {
+		h.logger.Error("failed to get quota", slog.String("tenant_id", targetTenant), slog.String("error", err.Error()))
+		http.Error(w, "failed to get quota", http.StatusInternalServerError)
+		return
+	}
+	if req.MaxContainers != nil {
+		quota.MaxContainers = *req.MaxContainers
+	}
+	if req.MaxCPUMilli != nil {
+		quota.MaxCPUMilli = *req.MaxCPUMilli
+	}
+	if req.MaxMemoryMB != nil {
+		quota.MaxMemoryMB = *req.MaxMemoryMB
+	}
+	if req.MaxVolumeMB != nil {
+		quota.MaxVolumeMB = *req.MaxVolumeMB
+	}
+	if req.MaxSnapshots != nil {
+		quota.MaxSnapshots = *req.MaxSnapshots
+	}
+
+	if err := h.quotaService.UpdateQuota(quota); err != nil {
+		if errors.Is(err, service.ErrInvalidQuota) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.logger.Error("failed to update quota", slog.String("tenant_id", targetTenant), slog.String("error", err.Error()))
+		http.Error(w, "failed to update quota", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("tenant quota changed by admin",
+		slog.String("tenant_id", targetTenant),
+		slog.String("admin_tenant", middleware.GetTenantFromContext(r.Context())),
+	)
+	writeJSON(w, http.StatusOK, newQuotaResponse(quota))
+}
+
+// authorizeAdmin checks the caller may manage quotas and returns the target tenant ID
+func (h *QuotaHandler) authorizeAdmin(w http.ResponseWriter, r *http.Request) (string, bool) {
+	tenantID := middleware.GetTenantFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return "", false
+	}
+	if err := h.authz.ValidatePermission(h.authz.RoleForTenant(tenantID), security.PermManageQuotas); err != nil {
+		http.Error(w, "forbidden - admin access required", http.StatusForbidden)
+		return "", false
+	}
+
+	targetTenant := r.PathValue("tenantId")
+	if targetTenant == "" {
+		http.Error(w, "tenant id required", http.StatusBadRequest)
+		return "", false
+	}
+	return targetTenant, true
+}
+
+func newQuotaResponse(quota *domain.TenantQuota) *QuotaResponse {
+	resp := &QuotaResponse{
+		TenantID: quota.TenantID,
+		Quota: QuotaLimits{
+			MaxContainers: quota.MaxContainers,
+			MaxCPUMilli:   quota.MaxCPUMilli,
+			MaxMemoryMB:   quota.MaxMemoryMB,
+			MaxVolumeMB:   quota.MaxVolumeMB,
+			MaxSnapshots:  quota.MaxSnapshots,
+		},
+	}
+	if !quota.UpdatedAt.IsZero() {
+		resp.UpdatedAt = &quota.UpdatedAt
+	}
+	return resp
+}
+
+// writeQuotaError writes a structured response if err is a quota violation and reports whether it did.
+// Requests larger than the quota itself get 403; requests that would fit once usage drops get 409.
+func writeQuotaError(w http.ResponseWriter, err error) bool {
+	var qe *service.QuotaExceededError
+	if !errors.As(err, &qe) {
+		return false
+	}
+
+	status := http.StatusConflict
+	if qe.ExceedsLimit() {
+		status = http.StatusForbidden
+	}
+	writeJSON(w, status, QuotaErrorResponse{
+		Error:     "quota_exceeded",
+		Limit:     qe.Limit,
+		Max:       qe.Max,
+		Used:      qe.Used,
+		Requested: qe.Requested,
+		Message:   qe.Error(),
+	})
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/security"
+	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+	"github.com/aryan0dhankhar/containerlease/internal/service"
+)
+
+// RecordingResponse describes one recorded terminal session
+type RecordingResponse struct {
+	ID          string     `json:"id"`
+	ContainerID string     `json:"containerId"`
+	Command     []string   `json:"command"`
+	Cols        uint       `json:"cols"`
+	Rows        uint       `json:"rows"`
+	SizeBytes   int64      `json:"sizeBytes"`
+	StartedAt   time.Time  `json:"startedAt"`
+	EndedAt     *time.Time `json:"endedAt"` // null while the session is open
+}
+
+// RecordingsHandler lists and downloads terminal session recordings.
+// Recordings are looked up by their own tenant so they stay reachable after the container is gone.
+type RecordingsHandler struct {
+	recordings *service.RecordingService
+	logger     *slog.Logger
+	authz      *security.AuthorizationService
+}
+
+// NewRecordingsHandler creates a new recordings handler
+func NewRecordingsHandler(recordings *service.RecordingService, logger *slog.Logger, authz *security.AuthorizationService) *RecordingsHandler {
+	return &RecordingsHandler{
+		recordings: recordings,
+		logger:     logger,
+		authz:      authz,
+	}
+}
+
+// List handles GET /api/containers/{id}/recordings
+func (h *RecordingsHandler) List(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.authorize(w, r)
+	if !ok {
+		return
+	}
+	containerID := r.PathValue("id")
+
+	recs, err := h.recordings.List(containerID)
+	if err != nil {
+		h.logger.Error("failed to list recordings", slog.String("container_id", containerID), slog.String("error", err.Error()))
+		http.Error(w, "failed to list recordings", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]RecordingResponse, 0, len(recs))
+	for _, rec := range recs {
+		if rec.TenantID != tenantID {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		resp = append(resp, recordingResponse(rec))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Download handles GET /api/containers/{id}/recordings/{recordingId}
+func (h *RecordingsHandler) Download(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	rec, err := h.recordings.Get(r.PathValue("recordingId"))
+	if err != nil || rec.ContainerID != r.PathValue("id") {
+		http.Error(w, "recording not found", http.StatusNotFound)
+		return
+	}
+	if rec.TenantID != tenantID {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	file, err := h.recordings.Open(rec)
+	if err != nil {
+		h.logger.Error("failed to open recording", slog.String("recording_id", rec.ID), slog.String("error", err.Error()))
+		http.Error(w, "recording not found", http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/x-asciicast")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", rec.ID+".cast"))
+	http.ServeContent(w, r, "", rec.StartedAt, file)
+}
+
+func (h *RecordingsHandler) authorize(w http.ResponseWriter, r *http.Request) (string, bool) {
+	tenantID := middleware.GetTenantFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return "", false
+	}
+	if err := h.authz.ValidatePermission(security.RoleUser, security.PermReadContainer); err != nil {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return "", false
+	}
+	return tenantID, true
+}
+
+func recordingResponse(rec *domain.Recording) RecordingResponse {
+	return RecordingResponse{
+		ID:          rec.ID,
+		ContainerID: rec.ContainerID,
+		Command:     rec.Command,
+		Cols:        rec.Cols,
+		Rows:        rec.Rows,
+		SizeBytes:   rec.SizeBytes,
+		StartedAt:   rec.StartedAt,
+		EndedAt:     rec.EndedAt,
+	}
+}
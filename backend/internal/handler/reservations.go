@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/security"
+	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+	"github.com/aryan0dhankhar/containerlease/internal/service"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// ReservationRequest books leases for a future window. The provisioning fields are
+// the same as for POST /api/provision and apply to every lease.
+type ReservationRequest struct {
+	StartTime time.Time `json:"startTime"`
+	Count     int       `json:"count,omitempty"` // Number of identical leases (default 1)
+	ProvisionRequest
+}
+
+// ReservationResponse describes a reservation and the leases created for it
+type ReservationResponse struct {
+	ID              string    `json:"id"`
+	Status          string    `json:"status"` // pending, provisioning, active, completed, cancelled or failed
+	StartTime       time.Time `json:"startTime"`
+	EndTime         time.Time `json:"endTime"`
+	DurationMinutes int       `json:"durationMinutes"`
+	Count           int       `json:"count"`
+	ImageType       string    `json:"imageType"`
+	Image           string    `json:"image"`
+	CPUMilli        int       `json:"cpuMilli"`
+	MemoryMB        int       `json:"memoryMB"`
+	Preset          string    `json:"preset,omitempty"`
+	ContainerIDs    []string  `json:"containerIds"`
+	Error           string    `json:"error,omitempty"`
+	Cost            float64   `json:"cost"` // Estimated cost of every lease for the full window
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// CapacityErrorResponse is returned when a booking would overcommit the host
+type CapacityErrorResponse struct {
+	Error     string `json:"error"`
+	Resource  string `json:"resource"`
+	Capacity  int    `json:"capacity"`
+	Booked    int    `json:"booked"`
+	Requested int    `json:"requested"`
+	Message   string `json:"message"`
+}
+
+// ReservationsHandler books, lists and cancels reservations
+type ReservationsHandler struct {
+	reservations *service.ReservationService
+	billing      *service.BillingService
+	images       *service.ImagePolicy
+	logger       *slog.Logger
+	config       *config.Config
+	authz        *security.AuthorizationService
+}
+
+// NewReservationsHandler creates a new reservations handler; billing may be nil
+func NewReservationsHandler(reservations *service.ReservationService, billing *service.BillingService, images *service.ImagePolicy, logger *slog.Logger, cfg *config.Config, authz *security.AuthorizationService) *ReservationsHandler {
+	return &ReservationsHandler{
+		reservations: reservations,
+		billing:      billing,
+		images:       images,
+		logger:       logger,
+		config:       cfg,
+		authz:        authz,
+	}
+}
+
+// Create handles POST /api/reservations
+func (h *ReservationsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.authorize(w, r, security.PermCreateContainer)
+	if !ok {
+		return
+	}
+
+	var req ReservationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.StartTime.IsZero() {
+		http.Error(w, "startTime is required", http.StatusBadRequest)
+		return
+	}
+	if req.Count == 0 {
+		req.Count = 1
+	}
+	if req.ImageType == "" {
+		http.Error(w, "imageType is required", http.StatusBadRequest)
+		return
+	}
+	image, err := h.images.Resolve(req.ImageType)
+	if err != nil {
+		h.logger.Warn("image not allowed",
+			slog.String("requested_image", req.ImageType),
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateProvisionRequest(h.config, &req.ProvisionRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.reservations.Book(r.Context(), &domain.Reservation{
+		TenantID:        tenantID,
+		StartAt:         req.StartTime,
+		DurationMinutes: req.DurationMinutes,
+		Count:           req.Count,
+		Spec: domain.LeaseSpec{
+			ImageType:    req.ImageType,
+			Image:        image,
+			CPUMilli:     req.CPUMilli,
+			MemoryMB:     req.MemoryMB,
+			VolumeSizeMB: req.VolumeSizeMB,
+			Preset:       req.Preset,
+			Ports:        req.Ports,
+			LogDemo:      req.LogDemo,
+			Entrypoint:   req.Entrypoint,
+			Command:      req.Command,
+			Env:          req.Env,
+			WorkingDir:   req.WorkingDir,
+			InitScript:   req.InitScript,
+			NodeLabels:   req.NodeLabels,
+		},
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidReservation) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if writeQuotaError(w, err) || writeCapacityError(w, err) {
+			return
+		}
+		h.logger.Error("failed to book reservation", slog.String("tenant_id", tenantID), slog.String("error", err.Error()))
+		http.Error(w, "failed to book reservation", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, h.response(res))
+}
+
+// List handles GET /api/reservations
+func (h *ReservationsHandler) List(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.authorize(w, r, security.PermListContainers)
+	if !ok {
+		return
+	}
+
+	reservations, err := h.reservations.ListReservations(r.Context(), tenantID)
+	if err != nil {
+		h.logger.Error("failed to list reservations", slog.String("tenant_id", tenantID), slog.String("error", err.Error()))
+		http.Error(w, "failed to list reservations", http.StatusInternalServerError)
+		return
+	}
+	resp := make([]ReservationResponse, 0, len(reservations))
+	for _, res := range reservations {
+		resp = append(resp, h.response(res))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Get handles GET /api/reservations/{id}
+func (h *ReservationsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.authorize(w, r, security.PermReadContainer)
+	if !ok {
+		return
+	}
+	res, ok := h.owned(w, r, tenantID)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, h.response(res))
+}
+
+// Cancel handles DELETE /api/reservations/{id}
+func (h *ReservationsHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.authorize(w, r, security.PermDeleteContainer)
+	if !ok {
+		return
+	}
+	if _, ok := h.owned(w, r, tenantID); !ok {
+		return
+	}
+
+	res, err := h.reservations.Cancel(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, service.ErrReservationStarted) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		h.logger.Error("failed to cancel reservation", slog.String("reservation_id", r.PathValue("id")), slog.String("error", err.Error()))
+		http.Error(w, "failed to cancel reservation", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.response(res))
+}
+
+func (h *ReservationsHandler) authorize(w http.ResponseWriter, r *http.Request, perm security.Permission) (string, bool) {
+	tenantID := middleware.GetTenantFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return "", false
+	}
+	if err := h.authz.ValidatePermission(security.RoleUser, perm); err != nil {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return "", false
+	}
+	return tenantID, true
+}
+
+// owned loads the reservation in the path and checks it belongs to the tenant
+func (h *ReservationsHandler) owned(w http.ResponseWriter, r *http.Request, tenantID string) (*domain.Reservation, bool) {
+	res, err := h.reservations.GetReservation(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "reservation not found", http.StatusNotFound)
+		return nil, false
+	}
+	if res.TenantID != tenantID {
+		h.logger.Warn("tenant attempted to access another tenant's reservation",
+			slog.String("tenant_id", tenantID),
+			slog.String("reservation_tenant", res.TenantID),
+			slog.String("reservation_id", res.ID),
+		)
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return nil, false
+	}
+	return res, true
+}
+
+func (h *ReservationsHandler) response(res *domain.Reservation) ReservationResponse {
+	resp := ReservationResponse{
+		ID:              res.ID,
+		Status:          res.Status,
+		StartTime:       res.StartAt,
+		EndTime:         res.EndAt(),
+		DurationMinutes: res.DurationMinutes,
+		Count:           res.Count,
+		ImageType:       res.Spec.ImageType,
+		Image:           res.Spec.Image,
+		CPUMilli:        res.Spec.CPUMilli,
+		MemoryMB:        res.Spec.MemoryMB,
+		Preset:          res.Spec.Preset,
+		ContainerIDs:    res.ContainerIDs,
+		Error:           res.Error,
+		CreatedAt:       res.CreatedAt,
+	}
+	if resp.ContainerIDs == nil {
+		resp.ContainerIDs = []string{}
+	}
+	if h.billing != nil {
+		resp.Cost = float64(res.Count) * h.billing.Estimate(service.ProvisionOptions{
+			Preset:          res.Spec.Preset,
+			CPUMilli:        res.Spec.CPUMilli,
+			MemoryMB:        res.Spec.MemoryMB,
+			VolumeSizeMB:    res.Spec.VolumeSizeMB,
+			DurationMinutes: res.DurationMinutes,
+		})
+	}
+	return resp
+}
+
+// writeCapacityError writes a 409 if err is a host capacity error and reports whether it did
+func writeCapacityError(w http.ResponseWriter, err error) bool {
+	var ce *service.CapacityExceededError
+	if !errors.As(err, &ce) {
+		return false
+	}
+	writeJSON(w, http.StatusConflict, CapacityErrorResponse{
+		Error:     "capacity_exceeded",
+		Resource:  ce.Resource,
+		Capacity:  ce.Capacity,
+		Booked:    ce.Booked,
+		Requested: ce.Requested,
+		Message:   ce.Error(),
+	})
+	return true
+}
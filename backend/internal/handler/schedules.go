@@ -0,0 +1,353 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/security"
+	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+	"github.com/aryan0dhankhar/containerlease/internal/service"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// Run history page sizes for GET /api/schedules/{id}/runs
+const (
+	defaultScheduleRuns = 20
+	maxScheduleRuns     = 100
+)
+
+// ScheduleRequest defines a recurring lease. The provisioning fields are the same as
+// for POST /api/provision; durationMinutes is how long each occurrence's lease runs.
+type ScheduleRequest struct {
+	Name            string `json:"name"`
+	Cron            string `json:"cron"`                      // e.g. "0 1 * * 1-5" for 01:00 every weekday
+	Timezone        string `json:"timezone,omitempty"`        // IANA name the expression is evaluated in (default UTC)
+	RestoreSnapshot bool   `json:"restoreSnapshot,omitempty"` // Start from the latest snapshot of an earlier run
+	ProvisionRequest
+}
+
+// ScheduleResponse describes a recurring lease schedule
+type ScheduleResponse struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	Cron            string     `json:"cron"`
+	Timezone        string     `json:"timezone"`
+	DurationMinutes int        `json:"durationMinutes"`
+	ImageType       string     `json:"imageType"`
+	Image           string     `json:"image"`
+	CPUMilli        int        `json:"cpuMilli"`
+	MemoryMB        int        `json:"memoryMB"`
+	Preset          string     `json:"preset,omitempty"`
+	RestoreSnapshot bool       `json:"restoreSnapshot"`
+	Paused          bool       `json:"paused"`
+	NextRunAt       *time.Time `json:"nextRunAt,omitempty"` // Omitted while paused
+	SkipNext        bool       `json:"skipNext"`            // The next occurrence will be skipped
+	Cost            float64    `json:"cost"`                // Estimated cost of each occurrence's lease
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
+// ScheduleRunResponse describes what happened at one occurrence of a schedule
+type ScheduleRunResponse struct {
+	ScheduledAt time.Time `json:"scheduledAt"`
+	Status      string    `json:"status"` // provisioned, skipped, missed or failed
+	ContainerID string    `json:"containerId,omitempty"`
+	SnapshotID  string    `json:"snapshotId,omitempty"` // Snapshot the lease was restored from
+	Error       string    `json:"error,omitempty"`
+}
+
+// SchedulesHandler manages recurring lease schedules
+type SchedulesHandler struct {
+	schedules *service.ScheduleService
+	billing   *service.BillingService
+	images    *service.ImagePolicy
+	logger    *slog.Logger
+	config    *config.Config
+	authz     *security.AuthorizationService
+}
+
+// NewSchedulesHandler creates a new schedules handler; billing may be nil
+func NewSchedulesHandler(schedules *service.ScheduleService, billing *service.BillingService, images *service.ImagePolicy, logger *slog.Logger, cfg *config.Config, authz *security.AuthorizationService) *SchedulesHandler {
+	return &SchedulesHandler{
+		schedules: schedules,
+		billing:   billing,
+		images:    images,
+		logger:    logger,
+		config:    cfg,
+		authz:     authz,
+	}
+}
+
+// Create handles POST /api/schedules
+func (h *SchedulesHandler) Create(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.authorize(w, r, security.PermCreateContainer)
+	if !ok {
+		return
+	}
+
+	var req ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.Cron == "" {
+		http.Error(w, "cron is required", http.StatusBadRequest)
+		return
+	}
+	if req.ImageType == "" {
+		http.Error(w, "imageType is required", http.StatusBadRequest)
+		return
+	}
+	image, err := h.images.Resolve(req.ImageType)
+	if err != nil {
+		h.logger.Warn("image not allowed",
+			slog.String("requested_image", req.ImageType),
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateProvisionRequest(h.config, &req.ProvisionRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sch, err := h.schedules.Create(r.Context(), &domain.Schedule{
+		TenantID:        tenantID,
+		Name:            req.Name,
+		Cron:            req.Cron,
+		Timezone:        req.Timezone,
+		DurationMinutes: req.DurationMinutes,
+		RestoreSnapshot: req.RestoreSnapshot,
+		Spec: domain.LeaseSpec{
+			ImageType:    req.ImageType,
+			Image:        image,
+			CPUMilli:     req.CPUMilli,
+			MemoryMB:     req.MemoryMB,
+			VolumeSizeMB: req.VolumeSizeMB,
+			Preset:       req.Preset,
+			Ports:        req.Ports,
+			LogDemo:      req.LogDemo,
+			Entrypoint:   req.Entrypoint,
+			Command:      req.Command,
+			Env:          req.Env,
+			WorkingDir:   req.WorkingDir,
+			InitScript:   req.InitScript,
+			NodeLabels:   req.NodeLabels,
+		},
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSchedule) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.logger.Error("failed to create schedule", slog.String("tenant_id", tenantID), slog.String("error", err.Error()))
+		http.Error(w, "failed to create schedule", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, h.response(sch))
+}
+
+// List handles GET /api/schedules
+func (h *SchedulesHandler) List(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.authorize(w, r, security.PermListContainers)
+	if !ok {
+		return
+	}
+
+	schedules, err := h.schedules.ListSchedules(r.Context(), tenantID)
+	if err != nil {
+		h.logger.Error("failed to list schedules", slog.String("tenant_id", tenantID), slog.String("error", err.Error()))
+		http.Error(w, "failed to list schedules", http.StatusInternalServerError)
+		return
+	}
+	resp := make([]ScheduleResponse, 0, len(schedules))
+	for _, sch := range schedules {
+		resp = append(resp, h.response(sch))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Get handles GET /api/schedules/{id}
+func (h *SchedulesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.authorize(w, r, security.PermReadContainer)
+	if !ok {
+		return
+	}
+	sch, ok := h.owned(w, r, tenantID)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, h.response(sch))
+}
+
+// Runs handles GET /api/schedules/{id}/runs?limit=N, latest occurrence first
+func (h *SchedulesHandler) Runs(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.authorize(w, r, security.PermReadContainer)
+	if !ok {
+		return
+	}
+	sch, ok := h.owned(w, r, tenantID)
+	if !ok {
+		return
+	}
+
+	limit := defaultScheduleRuns
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxScheduleRuns {
+			http.Error(w, "limit must be between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	runs, err := h.schedules.ListRuns(r.Context(), sch.ID, limit)
+	if err != nil {
+		h.logger.Error("failed to list schedule runs", slog.String("schedule_id", sch.ID), slog.String("error", err.Error()))
+		http.Error(w, "failed to list schedule runs", http.StatusInternalServerError)
+		return
+	}
+	resp := make([]ScheduleRunResponse, 0, len(runs))
+	for _, run := range runs {
+		resp = append(resp, ScheduleRunResponse{
+			ScheduledAt: run.ScheduledAt,
+			Status:      run.Status,
+			ContainerID: run.ContainerID,
+			SnapshotID:  run.SnapshotID,
+			Error:       run.Error,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Delete handles DELETE /api/schedules/{id}; leases already created keep running
+func (h *SchedulesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	tenantID, ok := h.authorize(w, r, security.PermDeleteContainer)
+	if !ok {
+		return
+	}
+	sch, ok := h.owned(w, r, tenantID)
+	if !ok {
+		return
+	}
+
+	if err := h.schedules.Delete(r.Context(), sch.ID); err != nil {
+		h.logger.Error("failed to delete schedule", slog.String("schedule_id", sch.ID), slog.String("error", err.Error()))
+		http.Error(w, "failed to delete schedule", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Pause handles POST /api/schedules/{id}/pause
+func (h *SchedulesHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	h.change(w, r, "pause", h.schedules.Pause)
+}
+
+// Resume handles POST /api/schedules/{id}/resume
+func (h *SchedulesHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	h.change(w, r, "resume", h.schedules.Resume)
+}
+
+// Skip handles POST /api/schedules/{id}/skip, skipping the next occurrence only
+func (h *SchedulesHandler) Skip(w http.ResponseWriter, r *http.Request) {
+	h.change(w, r, "skip", h.schedules.Skip)
+}
+
+func (h *SchedulesHandler) change(w http.ResponseWriter, r *http.Request, action string, apply func(ctx context.Context, id string) (*domain.Schedule, error)) {
+	tenantID, ok := h.authorize(w, r, security.PermCreateContainer)
+	if !ok {
+		return
+	}
+	sch, ok := h.owned(w, r, tenantID)
+	if !ok {
+		return
+	}
+
+	sch, err := apply(r.Context(), sch.ID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSchedule) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, service.ErrSchedulePaused) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		h.logger.Error("failed to "+action+" schedule", slog.String("schedule_id", r.PathValue("id")), slog.String("error", err.Error()))
+		http.Error(w, "failed to "+action+" schedule", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.response(sch))
+}
+
+func (h *SchedulesHandler) authorize(w http.ResponseWriter, r *http.Request, perm security.Permission) (string, bool) {
+	tenantID := middleware.GetTenantFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return "", false
+	}
+	if err := h.authz.ValidatePermission(security.RoleUser, perm); err != nil {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return "", false
+	}
+	return tenantID, true
+}
+
+// owned loads the schedule in the path and checks it belongs to the tenant
+func (h *SchedulesHandler) owned(w http.ResponseWriter, r *http.Request, tenantID string) (*domain.Schedule, bool) {
+	sch, err := h.schedules.GetSchedule(r.Context(), r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "schedule not found", http.StatusNotFound)
+		return nil, false
+	}
+	if sch.TenantID != tenantID {
+		h.logger.Warn("tenant attempted to access another tenant's schedule",
+			slog.String("tenant_id", tenantID),
+			slog.String("schedule_tenant", sch.TenantID),
+			slog.String("schedule_id", sch.ID),
+		)
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return nil, false
+	}
+	return sch, true
+}
+
+func (h *SchedulesHandler) response(sch *domain.Schedule) ScheduleResponse {
+	resp := ScheduleResponse{
+		ID:              sch.ID,
+		Name:            sch.Name,
+		Cron:            sch.Cron,
+		Timezone:        sch.Timezone,
+		DurationMinutes: sch.DurationMinutes,
+		ImageType:       sch.Spec.ImageType,
+		Image:           sch.Spec.Image,
+		CPUMilli:        sch.Spec.CPUMilli,
+		MemoryMB:        sch.Spec.MemoryMB,
+		Preset:          sch.Spec.Preset,
+		RestoreSnapshot: sch.RestoreSnapshot,
+		Paused:          sch.Paused,
+		SkipNext:        !sch.SkipAt.IsZero() && sch.SkipAt.Equal(sch.NextRunAt),
+		CreatedAt:       sch.CreatedAt,
+	}
+	if !sch.Paused {
+		next := sch.NextRunAt
+		resp.NextRunAt = &next
+	}
+	if h.billing != nil {
+		resp.Cost = h.billing.Estimate(service.ProvisionOptions{
+			Preset:          sch.Spec.Preset,
+			CPUMilli:        sch.Spec.CPUMilli,
+			MemoryMB:        sch.Spec.MemoryMB,
+			VolumeSizeMB:    sch.Spec.VolumeSizeMB,
+			DurationMinutes: sch.DurationMinutes,
+		})
+	}
+	return resp
+}
@@ -49,6 +49,8 @@ type SnapshotResponse struct {
 	Size        int64     `json:"size"`
 	Description string    `json:"description"`
 	TenantID    string    `json:"tenantId"`
+	Status      string    `json:"status"` // pending until its snapshot job has committed it, then ready or failed
+	Error       string    `json:"error,omitempty"`
 }
 
 // CreateSnapshot handles POST /api/containers/{id}/snapshot
@@ -121,6 +123,9 @@ func (h *SnapshotHandler) CreateSnapshot(w http.ResponseWriter, r *http.Request)
 	// Create snapshot
 	snapshot, err := h.snapshotService.CreateSnapshot(r.Context(), containerID, req.Description)
 	if err != nil {
+		if writeQuotaError(w, err) {
+			return
+		}
 		h.logger.Error("failed to create snapshot",
 			slog.String("container_id", containerID),
 			slog.String("snapshot_name", req.SnapshotName),
@@ -130,9 +135,13 @@ func (h *SnapshotHandler) CreateSnapshot(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Return snapshot response
+	// Return snapshot response; a pending snapshot is committed by a background job
+	status := http.StatusCreated
+	if snapshot.Status == domain.SnapshotPending {
+		status = http.StatusAccepted
+	}
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(snapshotToResponse(snapshot))
 }
 
@@ -349,6 +358,10 @@ func (h *SnapshotHandler) RestoreSnapshot(w http.ResponseWriter, r *http.Request
 
 // Helper function to convert domain.Snapshot to SnapshotResponse
 func snapshotToResponse(snap *domain.Snapshot) SnapshotResponse {
+	status := snap.Status
+	if snap.Ready() {
+		status = domain.SnapshotReady
+	}
 	return SnapshotResponse{
 		ID:          snap.ID,
 		ContainerID: snap.ContainerID,
@@ -357,5 +370,7 @@ func snapshotToResponse(snap *domain.Snapshot) SnapshotResponse {
 		Size:        snap.Size,
 		Description: snap.Description,
 		TenantID:    snap.TenantID,
+		Status:      status,
+		Error:       snap.Error,
 	}
 }
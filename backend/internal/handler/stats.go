@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/security"
+	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+	"github.com/gorilla/websocket"
+)
+
+// StatsSample is one resource usage measurement of a lease, relative to what it requested
+type StatsSample struct {
+	Time     time.Time   `json:"time"`
+	CPU      CPUStats    `json:"cpu"`
+	Memory   MemoryStats `json:"memory"`
+	Network  IOStats     `json:"network"` // Totals since the container started
+	BlockIO  IOStats     `json:"blockIO"`
+	PIDs     uint64      `json:"pids"`
+	PIDLimit uint64      `json:"pidsLimit,omitempty"`
+}
+
+// CPUStats reports CPU use in millicores
+type CPUStats struct {
+	UsedMilli      float64 `json:"usedMilli"`
+	RequestedMilli int     `json:"requestedMilli"`
+	Percent        float64 `json:"percent"` // Of requestedMilli; above 100 when bursting
+}
+
+// MemoryStats reports memory use, excluding reclaimable page cache
+type MemoryStats struct {
+	UsedBytes   uint64  `json:"usedBytes"`
+	LimitBytes  uint64  `json:"limitBytes"` // Enforced by Docker
+	RequestedMB int     `json:"requestedMB"`
+	Percent     float64 `json:"percent"` // Of requestedMB
+}
+
+// IOStats reports bytes moved in each direction
+type IOStats struct {
+	ReadBytes  uint64 `json:"readBytes"` // Received, for network
+	WriteBytes uint64 `json:"writeBytes"`
+}
+
+// StatsHandler serves container resource usage, one-shot and streamed
+type StatsHandler struct {
+	dockerClient   domain.DockerClient
+	containerRepo  domain.ContainerRepository
+	logger         *slog.Logger
+	allowedOrigins []string
+	authz          *security.AuthorizationService
+}
+
+// NewStatsHandler creates a new stats handler
+func NewStatsHandler(dockerClient domain.DockerClient, containerRepo domain.ContainerRepository, logger *slog.Logger, allowedOrigins []string, authz *security.AuthorizationService) *StatsHandler {
+	return &StatsHandler{
+		dockerClient:   dockerClient,
+		containerRepo:  containerRepo,
+		logger:         logger,
+		allowedOrigins: allowedOrigins,
+		authz:          authz,
+	}
+}
+
+// Get handles GET /api/containers/{id}/stats
+func (h *StatsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	container, ok := h.runningContainer(w, r, r.PathValue("id"))
+	if !ok {
+		return
+	}
+
+	stats, err := h.dockerClient.Stats(r.Context(), container.DockerID)
+	if err != nil {
+		h.logger.Error("failed to get container stats", slog.String("container_id", container.ID), slog.String("error", err.Error()))
+		http.Error(w, "failed to get stats", http.StatusBadGateway)
+		return
+	}
+	writeJSON(w, http.StatusOK, newStatsSample(container, *stats))
+}
+
+// ServeHTTP handles GET /ws/stats/{id}: a JSON sample about once a second until the lease ends
+func (h *StatsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	containerID := r.PathValue("id")
+	if containerID == "" {
+		// Path format: /ws/stats/{id}
+		parts := strings.Split(r.URL.Path, "/")
+		if len(parts) >= 4 {
+			containerID = parts[3]
+		}
+	}
+	container, ok := h.runningContainer(w, r, containerID)
+	if !ok {
+		return
+	}
+
+	upgrader := newWebSocketUpgrader(h.allowedOrigins, h.logger)
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.logger.Error("websocket upgrade failed", slog.String("error", err.Error()))
+		return
+	}
+	defer ws.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// The first goroutine to finish decides why the stream ended
+	var once sync.Once
+	reason := "container stopped"
+	end := func(why string) {
+		once.Do(func() {
+			reason = why
+			cancel()
+		})
+	}
+
+	// Reads only notice the client going away; stats flow one way
+	go func() {
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				end("client disconnected")
+				return
+			}
+		}
+	}()
+	go func() {
+		if why := watchLease(ctx, h.containerRepo, container.ID); why != "" {
+			end(why)
+		}
+	}()
+
+	samples, errs := h.dockerClient.StreamStats(ctx, container.DockerID)
+	for {
+		select {
+		case stats, ok := <-samples:
+			if !ok {
+				end("container stopped")
+				closeWebSocket(ws, websocket.CloseNormalClosure, reason)
+				return
+			}
+			if err := ws.WriteJSON(newStatsSample(container, stats)); err != nil {
+				return
+			}
+		case err := <-errs:
+			h.logger.Warn("container stats stream failed", slog.String("container_id", container.ID), slog.String("error", err.Error()))
+			closeWebSocket(ws, websocket.CloseInternalServerErr, "stats unavailable")
+			return
+		case <-ctx.Done():
+			closeWebSocket(ws, websocket.CloseNormalClosure, reason)
+			return
+		}
+	}
+}
+
+// runningContainer loads a container the caller owns and checks its lease is active,
+// writing the error response if not
+func (h *StatsHandler) runningContainer(w http.ResponseWriter, r *http.Request, containerID string) (*domain.Container, bool) {
+	if containerID == "" {
+		http.Error(w, "missing container id", http.StatusBadRequest)
+		return nil, false
+	}
+	tenantID := middleware.GetTenantFromContext(r.Context())
+	if tenantID == "" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	if err := h.authz.ValidatePermission(security.RoleUser, security.PermReadContainer); err != nil {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return nil, false
+	}
+
+	container, err := h.containerRepo.GetByID(containerID)
+	if err != nil {
+		http.Error(w, "container not found", http.StatusNotFound)
+		return nil, false
+	}
+	if container.TenantID != tenantID {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return nil, false
+	}
+	if container.Status != "running" || container.DockerID == "" || !time.Now().Before(container.ExpiryAt) {
+		http.Error(w, "container is not running", http.StatusConflict)
+		return nil, false
+	}
+	return container, true
+}
+
+func newStatsSample(container *domain.Container, stats domain.ContainerStats) StatsSample {
+	return StatsSample{
+		Time: stats.Time,
+		CPU: CPUStats{
+			UsedMilli:      stats.CPUMilli,
+			RequestedMilli: container.CPUMilli,
+			Percent:        stats.CPUPercent(container.CPUMilli),
+		},
+		Memory: MemoryStats{
+			UsedBytes:   stats.MemoryBytes,
+			LimitBytes:  stats.MemoryLimitBytes,
+			RequestedMB: container.MemoryMB,
+			Percent:     stats.MemoryPercent(container.MemoryMB),
+		},
+		Network:  IOStats{ReadBytes: stats.NetRxBytes, WriteBytes: stats.NetTxBytes},
+		BlockIO:  IOStats{ReadBytes: stats.BlockReadBytes, WriteBytes: stats.BlockWriteBytes},
+		PIDs:     stats.PIDs,
+		PIDLimit: stats.PIDsLimit,
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/aryan0dhankhar/containerlease/internal/domain"
 	"github.com/aryan0dhankhar/containerlease/internal/security"
 	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+	"github.com/aryan0dhankhar/containerlease/internal/service"
 )
 
 // ContainersHandler handles listing active containers
@@ -16,14 +17,16 @@ type ContainersHandler struct {
 	containerRepo domain.ContainerRepository
 	logger        *slog.Logger
 	authz         *security.AuthorizationService
+	billing       *service.BillingService
 }
 
 // NewContainersHandler creates a new containers handler
-func NewContainersHandler(containerRepo domain.ContainerRepository, logger *slog.Logger, authz *security.AuthorizationService) *ContainersHandler {
+func NewContainersHandler(containerRepo domain.ContainerRepository, logger *slog.Logger, authz *security.AuthorizationService, billing *service.BillingService) *ContainersHandler {
 	return &ContainersHandler{
 		containerRepo: containerRepo,
 		logger:        logger,
 		authz:         authz,
+		billing:       billing,
 	}
 }
 
@@ -49,7 +52,15 @@ func (h *ContainersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	containers, err := h.containerRepo.ListByTenant(tenantID)
+	// history=true includes terminated containers past their retention window,
+	// when the storage backend keeps them
+	var containers []*domain.Container
+	var err error
+	if history, ok := h.containerRepo.(domain.ContainerHistoryRepository); ok && r.URL.Query().Get("history") == "true" {
+		containers, err = history.ListHistoryByTenant(tenantID)
+	} else {
+		containers, err = h.containerRepo.ListByTenant(tenantID)
+	}
 
 	if err != nil {
 		h.logger.Error("failed to list containers", slog.String("error", err.Error()))
@@ -58,15 +69,28 @@ func (h *ContainersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	type ContainerResponse struct {
-		ID        string  `json:"id"`
-		ImageType string  `json:"imageType"`
-		Status    string  `json:"status"`
-		Cost      float64 `json:"cost"`
-		CreatedAt string  `json:"createdAt"`
-		ExpiryAt  string  `json:"expiryAt"`
-		ExpiresIn int     `json:"expiresIn"`
+		ID          string          `json:"id"`
+		ImageType   string          `json:"imageType"`
+		Image       string          `json:"image,omitempty"`
+		ImageDigest string          `json:"imageDigest,omitempty"`
+		Status      string          `json:"status"`
+		Cost        float64         `json:"cost"`
+		CreatedAt   string          `json:"createdAt"`
+		ExpiryAt    string          `json:"expiryAt"`
+		ExpiresIn   int             `json:"expiresIn"`
+		Ports       []int           `json:"ports,omitempty"`
+		Entrypoint  []string        `json:"entrypoint,omitempty"`
+		Command     []string        `json:"command,omitempty"`
+		Env         []domain.EnvVar `json:"env,omitempty"` // Secret values redacted
+		WorkingDir  string          `json:"workingDir,omitempty"`
+		InitStatus  string          `json:"initStatus,omitempty"`
+		Security    string          `json:"securityProfile,omitempty"`
+		Egress      string          `json:"egress,omitempty"`
+		PausedAt    string          `json:"pausedAt,omitempty"`
+		Node        string          `json:"node,omitempty"`
 	}
 
+	now := time.Now()
 	respItems := make([]ContainerResponse, 0, len(containers))
 	for _, c := range containers {
 		remaining := int(time.Until(c.ExpiryAt).Seconds())
@@ -74,14 +98,31 @@ func (h *ContainersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			remaining = 0
 		}
 
+		pausedAt := ""
+		if !c.PausedAt.IsZero() {
+			pausedAt = c.PausedAt.Format(time.RFC3339)
+		}
+
 		respItems = append(respItems, ContainerResponse{
-			ID:        c.ID,
-			ImageType: c.ImageType,
-			Status:    c.Status,
-			Cost:      c.Cost,
-			CreatedAt: c.CreatedAt.Format(time.RFC3339),
-			ExpiryAt:  c.ExpiryAt.Format(time.RFC3339),
-			ExpiresIn: remaining,
+			ID:          c.ID,
+			ImageType:   c.ImageType,
+			Image:       c.Image,
+			ImageDigest: c.ImageDigest,
+			Status:      c.Status,
+			Cost:        liveCost(h.billing, c, now),
+			CreatedAt:   c.CreatedAt.Format(time.RFC3339),
+			ExpiryAt:    c.ExpiryAt.Format(time.RFC3339),
+			ExpiresIn:   remaining,
+			Ports:       c.Ports,
+			Entrypoint:  c.Entrypoint,
+			Command:     c.Command,
+			Env:         service.RedactEnv(c.Env),
+			WorkingDir:  c.WorkingDir,
+			InitStatus:  c.InitStatus,
+			Security:    c.SecurityProfile,
+			Egress:      c.Egress,
+			PausedAt:    pausedAt,
+			Node:        c.NodeID,
 		})
 	}
 
@@ -93,3 +134,11 @@ func (h *ContainersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
+
+// liveCost returns the cost a container has accrued so far
+func liveCost(billing *service.BillingService, c *domain.Container, now time.Time) float64 {
+	if billing == nil {
+		return c.Cost
+	}
+	return billing.LiveCost(c, now)
+}
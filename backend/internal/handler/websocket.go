@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// newWebSocketUpgrader returns an upgrader that only accepts the configured origins
+// (or no origin at all, for non-browser clients)
+func newWebSocketUpgrader(allowedOrigins []string, logger *slog.Logger) websocket.Upgrader {
+	return websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				return true
+			}
+			for _, allowed := range allowedOrigins {
+				if origin == allowed {
+					return true
+				}
+			}
+			logger.Warn("websocket origin rejected", slog.String("origin", origin))
+			return false
+		},
+	}
+}
@@ -2,24 +2,48 @@ package docker
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/reliability/circuitbreaker"
+	"github.com/aryan0dhankhar/containerlease/internal/reliability/retry"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/registry"
 	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
-	"github.com/aryan0dhankhar/containerlease/internal/reliability/circuitbreaker"
-	"github.com/aryan0dhankhar/containerlease/internal/reliability/retry"
+	"github.com/docker/docker/pkg/stdcopy"
 )
 
+// managedLabel marks containers and volumes created by ContainerLease
+const managedLabel = "containerlease"
+
+// dockerHubAuthServer is the server address Docker Hub credentials are issued for
+const dockerHubAuthServer = "https://index.docker.io/v1/"
+
 // Client wraps the Docker SDK client with retry and circuit breaker capabilities
 type Client struct {
 	cli            *client.Client
 	logger         *slog.Logger
 	retryConfig    *retry.Config
 	circuitBreaker *circuitbreaker.CircuitBreaker
+	networkPrefix  string // Tenant networks are named after it; empty = Docker's default bridge
+	proxyContainer string
+	netMu          sync.Mutex // Keeps a tenant network from being removed while a container joins it
+	credentials    map[string]config.RegistryCredential
+	profiles       map[string]config.SecurityProfile
+	defaultProfile string
 }
 
 // NewClient creates a new Docker client
@@ -68,14 +92,28 @@ func NewClient(host string, logger *slog.Logger) (*Client, error) {
 	}, nil
 }
 
-// CreateContainer creates a new Docker container with retry logic and circuit breaker protection
-func (c *Client) CreateContainer(ctx context.Context, imageType string, cpuMilli int, memoryMB int, logDemo bool, volumeID string) (string, error) {
+// WithRegistryCredentials authenticates pulls from private registries, keyed by registry host
+func (c *Client) WithRegistryCredentials(creds map[string]config.RegistryCredential) *Client {
+	c.credentials = creds
+	return c
+}
+
+// WithSecurityProfiles sets the hardening profiles containers are created with.
+// Specs without a profile name get defaultProfile.
+func (c *Client) WithSecurityProfiles(profiles map[string]config.SecurityProfile, defaultProfile string) *Client {
+	c.profiles = profiles
+	c.defaultProfile = defaultProfile
+	return c
+}
+
+// CreateContainer creates and starts a Docker container from spec with retry logic and circuit breaker protection
+func (c *Client) CreateContainer(ctx context.Context, spec domain.ContainerSpec) (string, error) {
 	if !c.circuitBreaker.AllowRequest() {
 		return "", fmt.Errorf("docker service temporarily unavailable (circuit breaker open)")
 	}
 
 	result, err := retry.Do(ctx, c.retryConfig, c.logger, "CreateContainer", func(ctx context.Context) (string, error) {
-		imageName := getImageName(imageType)
+		cpuMilli, memoryMB := spec.CPUMilli, spec.MemoryMB
 		if cpuMilli <= 0 {
 			cpuMilli = 500
 		}
@@ -83,20 +121,38 @@ func (c *Client) CreateContainer(ctx context.Context, imageType string, cpuMilli
 			memoryMB = 512
 		}
 
-		// Pull image if needed
-		if _, err := c.cli.ImagePull(ctx, imageName, image.PullOptions{}); err != nil {
-			return "", fmt.Errorf("failed to pull image: %w", err)
+		// Pull image if needed; recreated containers usually find it locally
+		if _, err := c.cli.ImageInspect(ctx, spec.Image); client.IsErrNotFound(err) {
+			if err := c.pull(ctx, spec.Image); err != nil {
+				return "", err
+			}
+		} else if err != nil {
+			return "", fmt.Errorf("failed to inspect image: %w", err)
 		}
 
-		// Create container with resource limits
-		cmd := []string{"sleep", "infinity"}
-		if logDemo {
-			cmd = []string{"sh", "-c", "while true; do echo $(date) 'container demo log'; sleep 1; done"}
+		// Without an override the container idles so it stays up for the lease
+		cmd := spec.Cmd
+		if len(cmd) == 0 && len(spec.Entrypoint) == 0 {
+			cmd = []string{"sleep", "infinity"}
+			if spec.LogDemo {
+				cmd = []string{"sh", "-c", "while true; do echo $(date) 'container demo log'; sleep 1; done"}
+			}
+		}
+
+		env := make([]string, 0, len(spec.Env))
+		for _, e := range spec.Env {
+			env = append(env, e.Name+"="+e.Value)
 		}
 
 		config := &container.Config{
-			Image: imageName,
-			Cmd:   cmd,
+			Image:      spec.Image,
+			Entrypoint: spec.Entrypoint,
+			Cmd:        cmd,
+			Env:        env,
+			WorkingDir: spec.WorkingDir,
+			Labels: map[string]string{
+				managedLabel: "true",
+			},
 		}
 
 		hostConfig := &container.HostConfig{
@@ -107,13 +163,25 @@ func (c *Client) CreateContainer(ctx context.Context, imageType string, cpuMilli
 		}
 
 		// Mount volume if provided
-		if volumeID != "" {
+		if spec.VolumeID != "" {
 			hostConfig.Binds = []string{
-				fmt.Sprintf("%s:/data", volumeID),
+				fmt.Sprintf("%s:/data", spec.VolumeID),
 			}
 		}
 
+		if err := c.applySecurityProfile(spec.SecurityProfile, config, hostConfig); err != nil {
+			return "", err
+		}
+
+		c.netMu.Lock()
+		mode, err := c.networkMode(ctx, spec.TenantID, spec.Egress)
+		if err != nil {
+			c.netMu.Unlock()
+			return "", err
+		}
+		hostConfig.NetworkMode = container.NetworkMode(mode)
 		resp, err := c.cli.ContainerCreate(ctx, config, hostConfig, nil, nil, "")
+		c.netMu.Unlock()
 		if err != nil {
 			return "", fmt.Errorf("failed to create container: %w", err)
 		}
@@ -125,7 +193,7 @@ func (c *Client) CreateContainer(ctx context.Context, imageType string, cpuMilli
 
 		c.logger.Info("container created and started",
 			slog.String("container_id", resp.ID),
-			slog.String("image_type", imageType),
+			slog.String("image", spec.Image),
 		)
 
 		return resp.ID, nil
@@ -140,6 +208,50 @@ func (c *Client) CreateContainer(ctx context.Context, imageType string, cpuMilli
 	return result, nil
 }
 
+// applySecurityProfile adds a named hardening profile's options to a container's configuration
+func (c *Client) applySecurityProfile(name string, cfg *container.Config, hostConfig *container.HostConfig) error {
+	if name == "" {
+		name = c.defaultProfile
+	}
+	if name == "" && len(c.profiles) == 0 {
+		return nil // No profiles configured
+	}
+	profile, ok := c.profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown security profile %q", name)
+	}
+
+	hostConfig.CapDrop = profile.CapDrop
+	hostConfig.CapAdd = profile.CapAdd
+	if profile.NoNewPrivileges {
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "no-new-privileges:true")
+	}
+	switch {
+	case profile.Seccomp == "unconfined":
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "seccomp=unconfined")
+	case profile.SeccompJSON != "":
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "seccomp="+profile.SeccompJSON)
+	}
+	if profile.PidsLimit > 0 {
+		limit := profile.PidsLimit
+		hostConfig.Resources.PidsLimit = &limit
+	}
+	for _, u := range profile.Ulimits {
+		hostConfig.Resources.Ulimits = append(hostConfig.Resources.Ulimits, &container.Ulimit{Name: u.Name, Soft: u.Soft, Hard: u.Hard})
+	}
+	hostConfig.ReadonlyRootfs = profile.ReadOnlyRootfs
+	if len(profile.Tmpfs) > 0 {
+		hostConfig.Tmpfs = map[string]string{}
+		for mount, sizeMB := range profile.Tmpfs {
+			hostConfig.Tmpfs[mount] = fmt.Sprintf("rw,nosuid,nodev,size=%dm,mode=1777", sizeMB)
+		}
+	}
+	if profile.User != "" {
+		cfg.User = profile.User
+	}
+	return nil
+}
+
 // StopContainer stops a running container with retry logic
 func (c *Client) StopContainer(ctx context.Context, containerID string) error {
 	if !c.circuitBreaker.AllowRequest() {
@@ -161,15 +273,56 @@ func (c *Client) StopContainer(ctx context.Context, containerID string) error {
 	return nil
 }
 
-// RemoveContainer removes a container with retry logic
+// PauseContainer freezes every process in a container; memory stays allocated
+func (c *Client) PauseContainer(ctx context.Context, containerID string) error {
+	if !c.circuitBreaker.AllowRequest() {
+		return fmt.Errorf("docker service temporarily unavailable (circuit breaker open)")
+	}
+
+	if err := c.cli.ContainerPause(ctx, containerID); err != nil {
+		c.circuitBreaker.RecordFailure()
+		return fmt.Errorf("failed to pause container %s: %w", containerID, err)
+	}
+	c.circuitBreaker.RecordSuccess()
+	c.logger.Info("container paused", slog.String("container_id", containerID))
+	return nil
+}
+
+// UnpauseContainer resumes a paused container
+func (c *Client) UnpauseContainer(ctx context.Context, containerID string) error {
+	if !c.circuitBreaker.AllowRequest() {
+		return fmt.Errorf("docker service temporarily unavailable (circuit breaker open)")
+	}
+
+	if err := c.cli.ContainerUnpause(ctx, containerID); err != nil {
+		c.circuitBreaker.RecordFailure()
+		return fmt.Errorf("failed to unpause container %s: %w", containerID, err)
+	}
+	c.circuitBreaker.RecordSuccess()
+	c.logger.Info("container unpaused", slog.String("container_id", containerID))
+	return nil
+}
+
+// RemoveContainer removes a container with retry logic; a container that no longer exists counts as removed
 func (c *Client) RemoveContainer(ctx context.Context, containerID string) error {
 	if !c.circuitBreaker.AllowRequest() {
 		return fmt.Errorf("docker service temporarily unavailable (circuit breaker open)")
 	}
 
+	// Remember the container's networks so they can go with their last container
+	var networks []string
+	if info, err := c.cli.ContainerInspect(ctx, containerID); err == nil && info.NetworkSettings != nil {
+		for name := range info.NetworkSettings.Networks {
+			networks = append(networks, name)
+		}
+	}
+
 	_, err := retry.Do(ctx, c.retryConfig, c.logger, "RemoveContainer", func(ctx context.Context) (struct{}, error) {
 		options := container.RemoveOptions{Force: true}
-		return struct{}{}, c.cli.ContainerRemove(ctx, containerID, options)
+		if err := c.cli.ContainerRemove(ctx, containerID, options); !client.IsErrNotFound(err) {
+			return struct{}{}, err
+		}
+		return struct{}{}, nil // Already gone
 	})
 
 	if err != nil {
@@ -178,6 +331,7 @@ func (c *Client) RemoveContainer(ctx context.Context, containerID string) error
 	}
 
 	c.circuitBreaker.RecordSuccess()
+	c.releaseNetworks(ctx, networks)
 	return nil
 }
 
@@ -210,29 +364,93 @@ func (c *Client) Close() error {
 	return c.cli.Close()
 }
 
-// PullImageAsync pulls a Docker image asynchronously (doesn't block)
-func (c *Client) PullImageAsync(ctx context.Context, imageType string) error {
-	imageName := getImageName(imageType)
-	readCloser, err := c.cli.ImagePull(ctx, imageName, image.PullOptions{})
+// PullImage pulls an image and returns the registry digest reference it resolved to.
+// Digest-pinned images already present locally are not pulled again.
+func (c *Client) PullImage(ctx context.Context, imageName string) (string, error) {
+	if !c.circuitBreaker.AllowRequest() {
+		return "", fmt.Errorf("docker service temporarily unavailable (circuit breaker open)")
+	}
+
+	named, err := reference.ParseNormalizedNamed(imageName)
+	if err != nil {
+		return "", fmt.Errorf("invalid image reference %q: %w", imageName, err)
+	}
+
+	result, err := retry.Do(ctx, c.retryConfig, c.logger, "PullImage", func(ctx context.Context) (string, error) {
+		if canonical, ok := named.(reference.Canonical); ok {
+			if _, err := c.cli.ImageInspect(ctx, canonical.String()); err == nil {
+				return canonical.String(), nil
+			}
+		}
+		if err := c.pull(ctx, named.String()); err != nil {
+			return "", err
+		}
+
+		info, err := c.cli.ImageInspect(ctx, named.String())
+		if err != nil {
+			return "", fmt.Errorf("failed to inspect image: %w", err)
+		}
+		for _, repoDigest := range info.RepoDigests {
+			digested, err := reference.ParseNormalizedNamed(repoDigest)
+			if err == nil && digested.Name() == named.Name() {
+				return digested.String(), nil
+			}
+		}
+		return "", nil // Built locally or loaded from a tar; there is no registry digest
+	})
+
 	if err != nil {
-		return fmt.Errorf("failed to pull image: %w", err)
+		c.circuitBreaker.RecordFailure()
+		return "", err
 	}
-	defer readCloser.Close()
 
-	// Drain the response body (required for async pull to complete)
-	_, err = io.ReadAll(readCloser)
-	return err
+	c.circuitBreaker.RecordSuccess()
+	c.logger.Info("image pulled", slog.String("image", named.String()), slog.String("digest", result))
+	return result, nil
 }
 
-// getImageName returns the full image name for a given type
-func getImageName(imageType string) string {
-	switch imageType {
-	case "ubuntu":
-		return "ubuntu:22.04"
-	case "alpine":
-		return "alpine:latest"
-	default:
-		return "ubuntu:22.04" // Default fallback
+// pull downloads an image, using the registry's credentials if configured.
+// Pull failures are reported inside the progress stream, so it is read to the end.
+func (c *Client) pull(ctx context.Context, imageName string) error {
+	opts := image.PullOptions{}
+	if named, err := reference.ParseNormalizedNamed(imageName); err == nil {
+		if cred, ok := c.credentials[reference.Domain(named)]; ok {
+			serverAddress := reference.Domain(named)
+			if serverAddress == "docker.io" {
+				serverAddress = dockerHubAuthServer
+			}
+			auth, err := registry.EncodeAuthConfig(registry.AuthConfig{
+				Username:      cred.Username,
+				Password:      cred.Password,
+				IdentityToken: cred.IdentityToken,
+				ServerAddress: serverAddress,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to encode registry credentials: %w", err)
+			}
+			opts.RegistryAuth = auth
+		}
+	}
+
+	rc, err := c.cli.ImagePull(ctx, imageName, opts)
+	if err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", imageName, err)
+	}
+	defer rc.Close()
+
+	dec := json.NewDecoder(rc)
+	for {
+		var msg struct {
+			Error string `json:"error"`
+		}
+		if err := dec.Decode(&msg); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to pull image %s: %w", imageName, err)
+		}
+		if msg.Error != "" {
+			return fmt.Errorf("failed to pull image %s: %s", imageName, msg.Error)
+		}
 	}
 }
 
@@ -246,8 +464,8 @@ func (c *Client) CreateVolume(ctx context.Context, volumeID string, sizeMB int)
 		opts := volume.CreateOptions{
 			Name: volumeID,
 			Labels: map[string]string{
-				"containerlease": "true",
-				"size_mb":        fmt.Sprintf("%d", sizeMB),
+				managedLabel: "true",
+				"size_mb":    fmt.Sprintf("%d", sizeMB),
 			},
 		}
 		vol, err := c.cli.VolumeCreate(ctx, opts)
@@ -267,14 +485,17 @@ func (c *Client) CreateVolume(ctx context.Context, volumeID string, sizeMB int)
 	return result, nil
 }
 
-// RemoveVolume removes a Docker volume
+// RemoveVolume removes a Docker volume; a volume that no longer exists counts as removed
 func (c *Client) RemoveVolume(ctx context.Context, volumeID string) error {
 	if !c.circuitBreaker.AllowRequest() {
 		return fmt.Errorf("docker service temporarily unavailable (circuit breaker open)")
 	}
 
 	_, err := retry.Do(ctx, c.retryConfig, c.logger, "RemoveVolume", func(ctx context.Context) (struct{}, error) {
-		return struct{}{}, c.cli.VolumeRemove(ctx, volumeID, false)
+		if err := c.cli.VolumeRemove(ctx, volumeID, false); !client.IsErrNotFound(err) {
+			return struct{}{}, err
+		}
+		return struct{}{}, nil // Already gone
 	})
 
 	if err != nil {
@@ -424,3 +645,240 @@ func (c *Client) StartContainer(ctx context.Context, containerID string) error {
 	c.circuitBreaker.RecordSuccess()
 	return nil
 }
+
+// WatchEvents streams start, die, oom and destroy events for ContainerLease-managed containers.
+// Events since the given time are replayed first so callers can cover reconnect gaps.
+// The error channel receives a value when the stream ends; callers should reconnect.
+func (c *Client) WatchEvents(ctx context.Context, since time.Time) (<-chan domain.ContainerEvent, <-chan error) {
+	opts := events.ListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("type", string(events.ContainerEventType)),
+			filters.Arg("label", managedLabel+"=true"),
+			filters.Arg("event", string(events.ActionStart)),
+			filters.Arg("event", string(events.ActionDie)),
+			filters.Arg("event", string(events.ActionOOM)),
+			filters.Arg("event", string(events.ActionDestroy)),
+		),
+	}
+	if !since.IsZero() {
+		opts.Since = strconv.FormatInt(since.Unix(), 10)
+	}
+
+	msgs, errs := c.cli.Events(ctx, opts)
+	out := make(chan domain.ContainerEvent)
+	outErr := make(chan error, 1)
+
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case msg := <-msgs:
+				event := domain.ContainerEvent{
+					DockerID: msg.Actor.ID,
+					Action:   string(msg.Action),
+					Time:     time.Unix(0, msg.TimeNano),
+				}
+				if code, err := strconv.Atoi(msg.Actor.Attributes["exitCode"]); err == nil {
+					event.ExitCode = code
+				}
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return
+				}
+			case err := <-errs:
+				if err == nil {
+					err = fmt.Errorf("docker event stream closed")
+				}
+				outErr <- err
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, outErr
+}
+
+// InspectContainer returns the current Docker state of a container
+func (c *Client) InspectContainer(ctx context.Context, containerID string) (*domain.ContainerState, error) {
+	if !c.circuitBreaker.AllowRequest() {
+		return nil, fmt.Errorf("docker service temporarily unavailable (circuit breaker open)")
+	}
+
+	info, err := c.cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		// A missing container is a valid answer, not a daemon failure
+		if !client.IsErrNotFound(err) {
+			c.circuitBreaker.RecordFailure()
+		}
+		return nil, fmt.Errorf("failed to inspect container %s: %w", containerID, err)
+	}
+	c.circuitBreaker.RecordSuccess()
+
+	state := &domain.ContainerState{}
+	if info.ContainerJSONBase != nil && info.State != nil {
+		state.Running = info.State.Running
+		state.Status = info.State.Status
+		state.ExitCode = info.State.ExitCode
+		state.OOMKilled = info.State.OOMKilled
+	}
+	if info.NetworkSettings != nil {
+		state.IPAddress = info.NetworkSettings.IPAddress
+		for _, ep := range info.NetworkSettings.Networks {
+			if ep != nil && ep.IPAddress != "" {
+				state.IPAddress = ep.IPAddress // Containers join a single network
+				break
+			}
+		}
+	}
+	return state, nil
+}
+
+// Exec starts an interactive process with a TTY in a running container
+func (c *Client) Exec(ctx context.Context, containerID string, opts domain.ExecOptions) (domain.ExecSession, error) {
+	if !c.circuitBreaker.AllowRequest() {
+		return nil, fmt.Errorf("docker service temporarily unavailable (circuit breaker open)")
+	}
+
+	execOpts := container.ExecOptions{
+		Cmd:          opts.Cmd,
+		Tty:          true,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	}
+	if opts.Cols > 0 && opts.Rows > 0 {
+		execOpts.ConsoleSize = &[2]uint{opts.Rows, opts.Cols}
+	}
+
+	created, err := c.cli.ContainerExecCreate(ctx, containerID, execOpts)
+	if err != nil {
+		if !client.IsErrNotFound(err) {
+			c.circuitBreaker.RecordFailure()
+		}
+		return nil, fmt.Errorf("failed to create exec in container %s: %w", containerID, err)
+	}
+
+	resp, err := c.cli.ContainerExecAttach(ctx, created.ID, container.ExecAttachOptions{Tty: true, ConsoleSize: execOpts.ConsoleSize})
+	if err != nil {
+		c.circuitBreaker.RecordFailure()
+		return nil, fmt.Errorf("failed to attach to exec %s: %w", created.ID, err)
+	}
+	c.circuitBreaker.RecordSuccess()
+
+	c.logger.Info("exec session started",
+		slog.String("container_id", containerID),
+		slog.String("exec_id", created.ID),
+	)
+	return &execSession{cli: c.cli, id: created.ID, resp: resp}, nil
+}
+
+// RunCommand runs a non-interactive process in a running container and waits for it to exit.
+// Stdout and stderr are both written to output.
+func (c *Client) RunCommand(ctx context.Context, containerID string, cmd []string, output io.Writer) (int, error) {
+	if !c.circuitBreaker.AllowRequest() {
+		return -1, fmt.Errorf("docker service temporarily unavailable (circuit breaker open)")
+	}
+
+	created, err := c.cli.ContainerExecCreate(ctx, containerID, container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		if !client.IsErrNotFound(err) {
+			c.circuitBreaker.RecordFailure()
+		}
+		return -1, fmt.Errorf("failed to create exec in container %s: %w", containerID, err)
+	}
+
+	resp, err := c.cli.ContainerExecAttach(ctx, created.ID, container.ExecAttachOptions{})
+	if err != nil {
+		c.circuitBreaker.RecordFailure()
+		return -1, fmt.Errorf("failed to attach to exec %s: %w", created.ID, err)
+	}
+	c.circuitBreaker.RecordSuccess()
+
+	// The hijacked connection ignores ctx, so close it when ctx ends
+	stop := context.AfterFunc(ctx, resp.Close)
+	_, copyErr := stdcopy.StdCopy(output, output, resp.Reader)
+	stop()
+	resp.Close()
+	if ctx.Err() != nil {
+		return -1, ctx.Err()
+	}
+	if copyErr != nil {
+		return -1, fmt.Errorf("failed to read exec output: %w", copyErr)
+	}
+
+	inspect, err := c.cli.ContainerExecInspect(ctx, created.ID)
+	if err != nil {
+		return -1, fmt.Errorf("failed to inspect exec %s: %w", created.ID, err)
+	}
+	return inspect.ExitCode, nil
+}
+
+// CopyTo extracts a tar archive into dstDir inside the container
+func (c *Client) CopyTo(ctx context.Context, containerID string, dstDir string, archive io.Reader) error {
+	if !c.circuitBreaker.AllowRequest() {
+		return fmt.Errorf("docker service temporarily unavailable (circuit breaker open)")
+	}
+
+	// The archive is a one-shot stream, so this call is not retried
+	err := c.cli.CopyToContainer(ctx, containerID, dstDir, archive, container.CopyToContainerOptions{})
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return fmt.Errorf("%w: %s", domain.ErrPathNotFound, dstDir)
+		}
+		c.circuitBreaker.RecordFailure()
+		return fmt.Errorf("failed to copy into container %s: %w", containerID, err)
+	}
+	c.circuitBreaker.RecordSuccess()
+	return nil
+}
+
+// CopyFrom returns a tar archive of srcPath inside the container
+func (c *Client) CopyFrom(ctx context.Context, containerID string, srcPath string) (io.ReadCloser, *domain.FileStat, error) {
+	if !c.circuitBreaker.AllowRequest() {
+		return nil, nil, fmt.Errorf("docker service temporarily unavailable (circuit breaker open)")
+	}
+
+	rc, stat, err := c.cli.CopyFromContainer(ctx, containerID, srcPath)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil, nil, fmt.Errorf("%w: %s", domain.ErrPathNotFound, srcPath)
+		}
+		c.circuitBreaker.RecordFailure()
+		return nil, nil, fmt.Errorf("failed to copy from container %s: %w", containerID, err)
+	}
+	c.circuitBreaker.RecordSuccess()
+
+	return rc, &domain.FileStat{
+		Name:       stat.Name,
+		Size:       stat.Size,
+		Mode:       stat.Mode,
+		ModTime:    stat.Mtime,
+		LinkTarget: stat.LinkTarget,
+	}, nil
+}
+
+// execSession adapts a hijacked Docker exec connection to domain.ExecSession
+type execSession struct {
+	cli  *client.Client
+	id   string
+	resp types.HijackedResponse
+}
+
+func (s *execSession) Read(p []byte) (int, error)  { return s.resp.Reader.Read(p) }
+func (s *execSession) Write(p []byte) (int, error) { return s.resp.Conn.Write(p) }
+
+func (s *execSession) Close() error {
+	s.resp.Close()
+	return nil
+}
+
+func (s *execSession) Resize(ctx context.Context, cols, rows uint) error {
+	return s.cli.ContainerExecResize(ctx, s.id, container.ResizeOptions{Width: cols, Height: rows})
+}
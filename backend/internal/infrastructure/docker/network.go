@@ -0,0 +1,138 @@
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+)
+
+// Labels identifying the tenant networks ContainerLease manages
+const (
+	tenantLabel = "containerlease.tenant"
+	egressLabel = "containerlease.egress"
+)
+
+// invalidNetworkChars are the characters Docker does not accept in network names
+var invalidNetworkChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// WithTenantNetworks gives every tenant its own bridge network named after prefix.
+// proxyContainer is the server's own container, if it runs in one; it joins each
+// tenant network so the reverse proxy can reach container ports.
+func (c *Client) WithTenantNetworks(prefix, proxyContainer string) *Client {
+	c.networkPrefix = prefix
+	c.proxyContainer = proxyContainer
+	return c
+}
+
+// tenantNetworkName returns the network for a tenant and egress mode. Tenant IDs that
+// are not valid network names are sanitized and suffixed with a hash to stay unique.
+func (c *Client) tenantNetworkName(tenantID, egress string) string {
+	name := invalidNetworkChars.ReplaceAllString(tenantID, "-")
+	if name != tenantID {
+		sum := sha256.Sum256([]byte(tenantID))
+		name += "-" + hex.EncodeToString(sum[:4])
+	}
+	return fmt.Sprintf("%s-%s-%s", c.networkPrefix, name, egress)
+}
+
+// networkMode returns the network a container should join, creating it if needed.
+// Callers must hold netMu until the container has been created on it.
+func (c *Client) networkMode(ctx context.Context, tenantID, egress string) (string, error) {
+	if egress == "" {
+		egress = config.EgressFull
+	}
+	if egress == config.EgressNone {
+		return "none", nil
+	}
+	if c.networkPrefix == "" || tenantID == "" {
+		return "", nil // Docker's default bridge
+	}
+
+	name := c.tenantNetworkName(tenantID, egress)
+	info, err := c.cli.NetworkInspect(ctx, name, network.InspectOptions{})
+	if client.IsErrNotFound(err) {
+		_, err = c.cli.NetworkCreate(ctx, name, network.CreateOptions{
+			Driver:   "bridge",
+			Internal: egress == config.EgressInternal, // No route out of the network
+			Labels: map[string]string{
+				managedLabel: "true",
+				tenantLabel:  tenantID,
+				egressLabel:  egress,
+			},
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to create network %s: %w", name, err)
+		}
+		c.logger.Info("tenant network created", slog.String("network", name), slog.String("tenant_id", tenantID), slog.String("egress", egress))
+	} else if err != nil {
+		return "", fmt.Errorf("failed to inspect network %s: %w", name, err)
+	}
+
+	if c.proxyContainer != "" && !c.hasProxyEndpoint(info) {
+		if err := c.cli.NetworkConnect(ctx, name, c.proxyContainer, nil); err != nil {
+			// Leases still work; only their proxied ports are unreachable
+			c.logger.Warn("failed to connect proxy to tenant network", slog.String("network", name), slog.String("error", err.Error()))
+		}
+	}
+	return name, nil
+}
+
+// releaseNetworks removes tenant networks that no container uses any more
+func (c *Client) releaseNetworks(ctx context.Context, names []string) {
+	c.netMu.Lock()
+	defer c.netMu.Unlock()
+
+	for _, name := range names {
+		info, err := c.cli.NetworkInspect(ctx, name, network.InspectOptions{})
+		if err != nil || info.Labels[tenantLabel] == "" {
+			continue // Gone already, or not a tenant network
+		}
+		inUse := false
+		for id, ep := range info.Containers {
+			if !c.isProxyEndpoint(id, ep) {
+				inUse = true
+				break
+			}
+		}
+		if inUse {
+			continue
+		}
+
+		if c.proxyContainer != "" && c.hasProxyEndpoint(info) {
+			if err := c.cli.NetworkDisconnect(ctx, name, c.proxyContainer, true); err != nil {
+				c.logger.Warn("failed to disconnect proxy from tenant network", slog.String("network", name), slog.String("error", err.Error()))
+				continue
+			}
+		}
+		if err := c.cli.NetworkRemove(ctx, name); err != nil {
+			c.logger.Warn("failed to remove tenant network", slog.String("network", name), slog.String("error", err.Error()))
+			continue
+		}
+		c.logger.Info("tenant network removed", slog.String("network", name), slog.String("tenant_id", info.Labels[tenantLabel]))
+	}
+}
+
+func (c *Client) hasProxyEndpoint(info network.Inspect) bool {
+	for id, ep := range info.Containers {
+		if c.isProxyEndpoint(id, ep) {
+			return true
+		}
+	}
+	return false
+}
+
+// isProxyEndpoint matches the proxy container by name or (short) ID
+func (c *Client) isProxyEndpoint(id string, ep network.EndpointResource) bool {
+	if c.proxyContainer == "" {
+		return false
+	}
+	return ep.Name == c.proxyContainer || strings.HasPrefix(id, c.proxyContainer)
+}
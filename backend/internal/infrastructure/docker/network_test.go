@@ -0,0 +1,182 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+	"github.com/docker/docker/api/types/network"
+)
+
+// fakeEngine serves the network endpoints of the Docker Engine API from memory
+type fakeEngine struct {
+	mu       sync.Mutex
+	networks map[string]*network.Inspect
+	created  int
+	removed  []string
+}
+
+func newFakeEngine(t *testing.T) (*fakeEngine, *Client) {
+	e := &fakeEngine{networks: map[string]*network.Inspect{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1.44/networks/{name}", e.inspect)
+	mux.HandleFunc("POST /v1.44/networks/create", e.create)
+	mux.HandleFunc("POST /v1.44/networks/{name}/connect", e.connect)
+	mux.HandleFunc("POST /v1.44/networks/{name}/disconnect", e.disconnect)
+	mux.HandleFunc("DELETE /v1.44/networks/{name}", e.remove)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	c, err := NewClient("tcp://"+strings.TrimPrefix(server.URL, "http://"), nil)
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+	return e, c
+}
+
+func (e *fakeEngine) network(w http.ResponseWriter, r *http.Request) *network.Inspect {
+	n, ok := e.networks[r.PathValue("name")]
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"network not found"}`))
+	}
+	return n
+}
+
+func (e *fakeEngine) inspect(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if n := e.network(w, r); n != nil {
+		_ = json.NewEncoder(w).Encode(n)
+	}
+}
+
+func (e *fakeEngine) create(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var req network.CreateRequest
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	e.networks[req.Name] = &network.Inspect{
+		Name:       req.Name,
+		ID:         req.Name,
+		Driver:     req.Driver,
+		Internal:   req.Internal,
+		Labels:     req.Labels,
+		Containers: map[string]network.EndpointResource{},
+	}
+	e.created++
+	_ = json.NewEncoder(w).Encode(network.CreateResponse{ID: req.Name})
+}
+
+func (e *fakeEngine) connect(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var req network.ConnectOptions
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	if n := e.network(w, r); n != nil {
+		n.Containers[req.Container+"0123456789"] = network.EndpointResource{Name: req.Container}
+	}
+}
+
+func (e *fakeEngine) disconnect(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var req network.DisconnectOptions
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	if n := e.network(w, r); n != nil {
+		for id, ep := range n.Containers {
+			if ep.Name == req.Container {
+				delete(n.Containers, id)
+			}
+		}
+	}
+}
+
+func (e *fakeEngine) remove(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if n := e.network(w, r); n != nil {
+		delete(e.networks, n.Name)
+		e.removed = append(e.removed, n.Name)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// join adds a lease container to a network, as creating it on the network would
+func (e *fakeEngine) join(name, containerID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.networks[name].Containers[containerID] = network.EndpointResource{Name: "lease-" + containerID}
+}
+
+func TestNetworkModeCreatesTenantNetworks(t *testing.T) {
+	engine, c := newFakeEngine(t)
+	c.WithTenantNetworks("cl", "proxy")
+	ctx := context.Background()
+
+	name, err := c.networkMode(ctx, "tenant-a", config.EgressInternal)
+	if err != nil || name != "cl-tenant-a-internal" {
+		t.Fatalf("expected the tenant network, got %q %v", name, err)
+	}
+	n := engine.networks[name]
+	if n == nil || !n.Internal || n.Driver != "bridge" || n.Labels[tenantLabel] != "tenant-a" || n.Labels[egressLabel] != config.EgressInternal {
+		t.Fatalf("expected an internal bridge labelled for the tenant, got %+v", n)
+	}
+	if !c.hasProxyEndpoint(*n) {
+		t.Fatal("expected the proxy connected to the tenant network")
+	}
+
+	// The second container reuses the network and the proxy endpoint
+	if again, err := c.networkMode(ctx, "tenant-a", config.EgressInternal); err != nil || again != name || engine.created != 1 {
+		t.Fatalf("expected the network reused, got %q %v after %d creations", again, err, engine.created)
+	}
+	if len(n.Containers) != 1 {
+		t.Fatalf("expected the proxy connected once, got %d endpoints", len(n.Containers))
+	}
+
+	if mode, _ := c.networkMode(ctx, "tenant-a", config.EgressNone); mode != "none" {
+		t.Fatalf("expected no network for egress none, got %q", mode)
+	}
+	if name := c.tenantNetworkName("tenant/b", config.EgressFull); !strings.HasPrefix(name, "cl-tenant-b-") || strings.Contains(name, "/") {
+		t.Fatalf("expected a sanitized network name, got %q", name)
+	}
+}
+
+func TestReleaseNetworksRemovesOnlyUnusedTenantNetworks(t *testing.T) {
+	engine, c := newFakeEngine(t)
+	c.WithTenantNetworks("cl", "proxy")
+	ctx := context.Background()
+
+	busy, _ := c.networkMode(ctx, "tenant-a", config.EgressFull)
+	idle, _ := c.networkMode(ctx, "tenant-b", config.EgressFull)
+	engine.join(busy, "c1")
+	engine.join(busy, "c2")
+	engine.networks["bridge"] = &network.Inspect{Name: "bridge", Containers: map[string]network.EndpointResource{}}
+
+	// c1 leaves tenant-a's network; c2 still uses it
+	delete(engine.networks[busy].Containers, "c1")
+	c.releaseNetworks(ctx, []string{busy, idle, "bridge"})
+
+	if _, ok := engine.networks[busy]; !ok {
+		t.Fatal("expected the network still in use kept")
+	}
+	if _, ok := engine.networks["bridge"]; !ok {
+		t.Fatal("expected networks not managed per tenant left alone")
+	}
+	if len(engine.removed) != 1 || engine.removed[0] != idle {
+		t.Fatalf("expected only the idle tenant network removed, got %v", engine.removed)
+	}
+
+	// The last container leaving takes the network and the proxy endpoint with it
+	delete(engine.networks[busy].Containers, "c2")
+	c.releaseNetworks(ctx, []string{busy})
+	if _, ok := engine.networks[busy]; ok {
+		t.Fatal("expected the network removed with its last container")
+	}
+}
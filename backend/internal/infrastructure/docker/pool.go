@@ -0,0 +1,595 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+)
+
+// healthCheckTimeout bounds the health check of a single node
+const healthCheckTimeout = 5 * time.Second
+
+// NodePool is a registry of Docker nodes that is itself a domain.DockerClient. Calls for
+// a container, volume or image go to the node it lives on: the node set on the context
+// with domain.WithNode, else the node it was created on or tracked to, else the node
+// that knows it. Calls that create something go to the context's node or the default one.
+type NodePool struct {
+	mu         sync.RWMutex
+	nodes      []*poolNode // The first node is the default one
+	byID       map[string]*poolNode
+	containers map[string]string // Docker container ID -> node ID
+	volumes    map[string]string // Volume name -> node ID
+	images     map[string]string // Committed or loaded image -> node ID
+	logger     *slog.Logger
+
+	// A node's event stream is reconnected after eventRetryMin, doubling up to eventRetryMax
+	eventRetryMin time.Duration
+	eventRetryMax time.Duration
+}
+
+type poolNode struct {
+	node   domain.Node
+	client domain.DockerClient
+}
+
+// NewNodePool creates an empty node pool
+func NewNodePool(logger *slog.Logger) *NodePool {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &NodePool{
+		byID:          map[string]*poolNode{},
+		containers:    map[string]string{},
+		volumes:       map[string]string{},
+		images:        map[string]string{},
+		logger:        logger,
+		eventRetryMin: time.Second,
+		eventRetryMax: 30 * time.Second,
+	}
+}
+
+// Add registers a node and the client that reaches it. Nodes start out healthy until
+// their first health check.
+func (p *NodePool) Add(node domain.Node, client domain.DockerClient) *NodePool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	node.Healthy = true
+	n := &poolNode{node: node, client: client}
+	p.nodes = append(p.nodes, n)
+	p.byID[node.ID] = n
+	return p
+}
+
+// Nodes returns the nodes with their last known health, the default node first
+func (p *NodePool) Nodes() []domain.Node {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	nodes := make([]domain.Node, 0, len(p.nodes))
+	for _, n := range p.nodes {
+		nodes = append(nodes, n.node)
+	}
+	return nodes
+}
+
+// Track records the node a container and its volume run on, e.g. for containers
+// provisioned before this server started
+func (p *NodePool) Track(c *domain.Container) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	nodeID := c.NodeID
+	if nodeID == "" && len(p.nodes) > 0 {
+		nodeID = p.nodes[0].node.ID
+	}
+	if _, ok := p.byID[nodeID]; !ok {
+		return
+	}
+	if c.DockerID != "" {
+		p.containers[c.DockerID] = nodeID
+	}
+	if c.VolumeID != "" {
+		p.volumes[c.VolumeID] = nodeID
+	}
+}
+
+// CheckHealth asks every node for its host resources and records whether it answered
+func (p *NodePool) CheckHealth(ctx context.Context) {
+	p.mu.RLock()
+	nodes := append([]*poolNode(nil), p.nodes...)
+	p.mu.RUnlock()
+
+	for _, n := range nodes {
+		checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		_, _, err := n.client.HostResources(checkCtx)
+		cancel()
+
+		p.mu.Lock()
+		wasHealthy := n.node.Healthy
+		n.node.Healthy = err == nil
+		n.node.Error = ""
+		if err != nil {
+			n.node.Error = err.Error()
+		}
+		n.node.CheckedAt = time.Now()
+		p.mu.Unlock()
+
+		switch {
+		case err != nil && wasHealthy:
+			p.logger.Warn("docker node unhealthy", slog.String("node", n.node.ID), slog.String("error", err.Error()))
+		case err == nil && !wasHealthy:
+			p.logger.Info("docker node healthy again", slog.String("node", n.node.ID))
+		}
+	}
+}
+
+// StartHealthChecks checks the nodes every interval until the context is cancelled
+func (p *NodePool) StartHealthChecks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.CheckHealth(ctx)
+		}
+	}
+}
+
+// node returns the node with the given ID, or the default node for ""
+func (p *NodePool) node(nodeID string) (*poolNode, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if len(p.nodes) == 0 {
+		return nil, fmt.Errorf("no docker nodes configured")
+	}
+	if nodeID == "" {
+		return p.nodes[0], nil
+	}
+	n, ok := p.byID[nodeID]
+	if !ok {
+		return nil, fmt.Errorf("unknown docker node %q", nodeID)
+	}
+	return n, nil
+}
+
+// target returns the node things are created on: the context's node or the default one
+func (p *NodePool) target(ctx context.Context) (*poolNode, error) {
+	return p.node(domain.NodeFromContext(ctx))
+}
+
+// lookup returns the node recorded for a key in index, or the context's node
+func (p *NodePool) lookup(ctx context.Context, index map[string]string, key string) (string, bool) {
+	if nodeID := domain.NodeFromContext(ctx); nodeID != "" {
+		return nodeID, true
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if len(p.nodes) == 1 {
+		return p.nodes[0].node.ID, true
+	}
+	nodeID, ok := index[key]
+	return nodeID, ok
+}
+
+func (p *NodePool) record(index map[string]string, key, nodeID string) {
+	p.mu.Lock()
+	index[key] = nodeID
+	p.mu.Unlock()
+}
+
+// forContainer returns the node running a Docker container. A container this server
+// has not seen, e.g. one another server provisioned, is looked for on every node.
+func (p *NodePool) forContainer(ctx context.Context, dockerID string) (*poolNode, error) {
+	if nodeID, ok := p.lookup(ctx, p.containers, dockerID); ok {
+		return p.node(nodeID)
+	}
+	for _, n := range p.all() {
+		if _, err := n.client.InspectContainer(ctx, dockerID); err == nil {
+			p.record(p.containers, dockerID, n.node.ID)
+			return n, nil
+		}
+	}
+	// Not found anywhere: let the default node report it
+	return p.node("")
+}
+
+func (p *NodePool) all() []*poolNode {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]*poolNode(nil), p.nodes...)
+}
+
+// isHealthy reports whether a node passed its last health check
+func (p *NodePool) isHealthy(n *poolNode) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return n.node.Healthy
+}
+
+// healthy returns the nodes that passed their last health check
+func (p *NodePool) healthy() []*poolNode {
+	var nodes []*poolNode
+	for _, n := range p.all() {
+		if p.isHealthy(n) {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// everywhere runs fn on every node that may hold something not tracked to a node. It
+// succeeds if fn succeeded on any node.
+func (p *NodePool) everywhere(fn func(n *poolNode) error) error {
+	var errs []error
+	for _, n := range p.all() {
+		err := fn(n)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("node %s: %w", n.node.ID, err))
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("no docker nodes configured")
+	}
+	return errors.Join(errs...)
+}
+
+// PullImage pulls on the context's node. Without one, e.g. to pre-pull for a
+// reservation, it pulls on every healthy node since the lease may land on any.
+func (p *NodePool) PullImage(ctx context.Context, image string) (string, error) {
+	if nodeID := domain.NodeFromContext(ctx); nodeID != "" {
+		n, err := p.node(nodeID)
+		if err != nil {
+			return "", err
+		}
+		return n.client.PullImage(ctx, image)
+	}
+	nodes := p.healthy()
+	if len(nodes) == 0 {
+		return "", fmt.Errorf("no healthy docker nodes")
+	}
+	var digest string
+	for _, n := range nodes {
+		d, err := n.client.PullImage(ctx, image)
+		if err != nil {
+			return "", fmt.Errorf("node %s: %w", n.node.ID, err)
+		}
+		if digest == "" {
+			digest = d
+		}
+	}
+	return digest, nil
+}
+
+func (p *NodePool) CreateContainer(ctx context.Context, spec domain.ContainerSpec) (string, error) {
+	n, err := p.target(ctx)
+	if err != nil {
+		return "", err
+	}
+	dockerID, err := n.client.CreateContainer(ctx, spec)
+	if err != nil {
+		return "", err
+	}
+	p.record(p.containers, dockerID, n.node.ID)
+	return dockerID, nil
+}
+
+func (p *NodePool) StopContainer(ctx context.Context, containerID string) error {
+	n, err := p.forContainer(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	return n.client.StopContainer(ctx, containerID)
+}
+
+func (p *NodePool) RemoveContainer(ctx context.Context, containerID string) error {
+	n, err := p.forContainer(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	if err := n.client.RemoveContainer(ctx, containerID); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	delete(p.containers, containerID)
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *NodePool) StartContainer(ctx context.Context, containerID string) error {
+	n, err := p.forContainer(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	return n.client.StartContainer(ctx, containerID)
+}
+
+func (p *NodePool) PauseContainer(ctx context.Context, containerID string) error {
+	n, err := p.forContainer(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	return n.client.PauseContainer(ctx, containerID)
+}
+
+func (p *NodePool) UnpauseContainer(ctx context.Context, containerID string) error {
+	n, err := p.forContainer(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	return n.client.UnpauseContainer(ctx, containerID)
+}
+
+func (p *NodePool) StreamLogs(ctx context.Context, containerID string) (io.ReadCloser, error) {
+	n, err := p.forContainer(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	return n.client.StreamLogs(ctx, containerID)
+}
+
+func (p *NodePool) CreateVolume(ctx context.Context, volumeID string, sizeMB int) (string, error) {
+	n, err := p.target(ctx)
+	if err != nil {
+		return "", err
+	}
+	name, err := n.client.CreateVolume(ctx, volumeID, sizeMB)
+	if err != nil {
+		return "", err
+	}
+	p.record(p.volumes, name, n.node.ID)
+	return name, nil
+}
+
+// RemoveVolume removes a volume from its node. An untracked volume is removed
+// wherever it is; removing a volume that does not exist succeeds.
+func (p *NodePool) RemoveVolume(ctx context.Context, volumeID string) error {
+	defer func() {
+		p.mu.Lock()
+		delete(p.volumes, volumeID)
+		p.mu.Unlock()
+	}()
+	if nodeID, ok := p.lookup(ctx, p.volumes, volumeID); ok {
+		n, err := p.node(nodeID)
+		if err != nil {
+			return err
+		}
+		return n.client.RemoveVolume(ctx, volumeID)
+	}
+	var errs []error
+	for _, n := range p.all() {
+		if err := n.client.RemoveVolume(ctx, volumeID); err != nil {
+			errs = append(errs, fmt.Errorf("node %s: %w", n.node.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CommitContainer commits a container to an image on the container's node
+func (p *NodePool) CommitContainer(ctx context.Context, containerID string, imageName string) error {
+	n, err := p.forContainer(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	if err := n.client.CommitContainer(ctx, containerID, imageName); err != nil {
+		return err
+	}
+	p.record(p.images, imageName, n.node.ID)
+	return nil
+}
+
+func (p *NodePool) SaveImage(ctx context.Context, imageName string, filePath string) error {
+	if nodeID, ok := p.lookup(ctx, p.images, imageName); ok {
+		n, err := p.node(nodeID)
+		if err != nil {
+			return err
+		}
+		return n.client.SaveImage(ctx, imageName, filePath)
+	}
+	return p.everywhere(func(n *poolNode) error { return n.client.SaveImage(ctx, imageName, filePath) })
+}
+
+func (p *NodePool) LoadImage(ctx context.Context, filePath string) (string, error) {
+	n, err := p.target(ctx)
+	if err != nil {
+		return "", err
+	}
+	imageName, err := n.client.LoadImage(ctx, filePath)
+	if err != nil {
+		return "", err
+	}
+	p.record(p.images, imageName, n.node.ID)
+	return imageName, nil
+}
+
+func (p *NodePool) RemoveImage(ctx context.Context, imageName string) error {
+	defer func() {
+		p.mu.Lock()
+		delete(p.images, imageName)
+		p.mu.Unlock()
+	}()
+	if nodeID, ok := p.lookup(ctx, p.images, imageName); ok {
+		n, err := p.node(nodeID)
+		if err != nil {
+			return err
+		}
+		return n.client.RemoveImage(ctx, imageName)
+	}
+	return p.everywhere(func(n *poolNode) error { return n.client.RemoveImage(ctx, imageName) })
+}
+
+// WatchEvents merges the event streams of the healthy nodes. Each node's stream is
+// reconnected on its own with backoff, resuming from its last event, while the other
+// streams carry on; its errors are sent on the error channel wrapped in
+// domain.ErrNodeEventsInterrupted. Both channels stay open until ctx is cancelled.
+func (p *NodePool) WatchEvents(ctx context.Context, since time.Time) (<-chan domain.ContainerEvent, <-chan error) {
+	out := make(chan domain.ContainerEvent)
+	outErr := make(chan error, 1)
+
+	var wg sync.WaitGroup
+	for _, n := range p.all() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.watchNode(ctx, n, since, out, outErr)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out, outErr
+}
+
+// watchNode forwards one node's events until ctx is cancelled. The node is only watched
+// while healthy, and its stream is reconnected after a growing delay when it fails.
+func (p *NodePool) watchNode(ctx context.Context, n *poolNode, since time.Time, out chan<- domain.ContainerEvent, outErr chan<- error) {
+	delay := p.eventRetryMin
+	for {
+		if p.isHealthy(n) {
+			lastSeen, err := p.forwardEvents(ctx, n, since, out)
+			if ctx.Err() != nil {
+				return
+			}
+			if !lastSeen.IsZero() {
+				since = lastSeen
+				delay = p.eventRetryMin // The stream worked; start the backoff over
+			}
+			p.logger.Warn("docker node event stream interrupted, reconnecting",
+				slog.String("node", n.node.ID),
+				slog.String("error", err.Error()),
+				slog.Duration("delay", delay),
+			)
+			select {
+			case outErr <- fmt.Errorf("%w: node %s: %w", domain.ErrNodeEventsInterrupted, n.node.ID, err):
+			default:
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, p.eventRetryMax)
+	}
+}
+
+// forwardEvents streams a node's events to out until its stream fails, returning the
+// time of the last event forwarded
+func (p *NodePool) forwardEvents(ctx context.Context, n *poolNode, since time.Time, out chan<- domain.ContainerEvent) (time.Time, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events, errs := n.client.WatchEvents(ctx, since)
+
+	var lastSeen time.Time
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				events = nil // The error follows on errs
+				continue
+			}
+			if event.Action != "destroy" {
+				p.record(p.containers, event.DockerID, n.node.ID)
+			}
+			select {
+			case out <- event:
+				lastSeen = event.Time
+			case <-ctx.Done():
+				return lastSeen, ctx.Err()
+			}
+		case err := <-errs:
+			if err == nil {
+				err = fmt.Errorf("docker event stream closed")
+			}
+			return lastSeen, err
+		case <-ctx.Done():
+			return lastSeen, ctx.Err()
+		}
+	}
+}
+
+func (p *NodePool) InspectContainer(ctx context.Context, containerID string) (*domain.ContainerState, error) {
+	n, err := p.forContainer(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	return n.client.InspectContainer(ctx, containerID)
+}
+
+func (p *NodePool) Exec(ctx context.Context, containerID string, opts domain.ExecOptions) (domain.ExecSession, error) {
+	n, err := p.forContainer(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	return n.client.Exec(ctx, containerID, opts)
+}
+
+func (p *NodePool) RunCommand(ctx context.Context, containerID string, cmd []string, output io.Writer) (int, error) {
+	n, err := p.forContainer(ctx, containerID)
+	if err != nil {
+		return 0, err
+	}
+	return n.client.RunCommand(ctx, containerID, cmd, output)
+}
+
+func (p *NodePool) CopyTo(ctx context.Context, containerID string, dstDir string, archive io.Reader) error {
+	n, err := p.forContainer(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	return n.client.CopyTo(ctx, containerID, dstDir, archive)
+}
+
+func (p *NodePool) CopyFrom(ctx context.Context, containerID string, srcPath string) (io.ReadCloser, *domain.FileStat, error) {
+	n, err := p.forContainer(ctx, containerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return n.client.CopyFrom(ctx, containerID, srcPath)
+}
+
+func (p *NodePool) Stats(ctx context.Context, containerID string) (*domain.ContainerStats, error) {
+	n, err := p.forContainer(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	return n.client.Stats(ctx, containerID)
+}
+
+func (p *NodePool) StreamStats(ctx context.Context, containerID string) (<-chan domain.ContainerStats, <-chan error) {
+	n, err := p.forContainer(ctx, containerID)
+	if err != nil {
+		samples := make(chan domain.ContainerStats)
+		close(samples)
+		errs := make(chan error, 1)
+		errs <- err
+		return samples, errs
+	}
+	return n.client.StreamStats(ctx, containerID)
+}
+
+// HostResources returns the CPUs and memory of all healthy nodes together
+func (p *NodePool) HostResources(ctx context.Context) (int, int, error) {
+	nodes := p.healthy()
+	if len(nodes) == 0 {
+		return 0, 0, fmt.Errorf("no healthy docker nodes")
+	}
+	cpuMilli, memoryMB := 0, 0
+	for _, n := range nodes {
+		cpu, mem, err := n.client.HostResources(ctx)
+		if err != nil {
+			return 0, 0, fmt.Errorf("node %s: %w", n.node.ID, err)
+		}
+		cpuMilli += cpu
+		memoryMB += mem
+	}
+	return cpuMilli, memoryMB, nil
+}
@@ -0,0 +1,100 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+)
+
+// eventNode is a Docker node whose event stream the test drives. A failing node's
+// stream breaks as soon as it is opened.
+type eventNode struct {
+	domain.DockerClient
+	mu      sync.Mutex
+	watches int
+	events  chan domain.ContainerEvent
+	failing bool
+	down    bool
+}
+
+func (n *eventNode) WatchEvents(ctx context.Context, since time.Time) (<-chan domain.ContainerEvent, <-chan error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.watches++
+	errs := make(chan error, 1)
+	if n.failing {
+		errs <- errors.New("connection reset by peer")
+		return make(chan domain.ContainerEvent), errs
+	}
+	return n.events, errs
+}
+
+func (n *eventNode) HostResources(ctx context.Context) (int, int, error) {
+	if n.down {
+		return 0, 0, errors.New("connection refused")
+	}
+	return 4000, 8192, nil
+}
+
+func (n *eventNode) watchCount() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.watches
+}
+
+func TestNodePoolEventsSurviveAFailingNode(t *testing.T) {
+	steady := &eventNode{events: make(chan domain.ContainerEvent)}
+	flapping := &eventNode{failing: true}
+	down := &eventNode{down: true}
+	pool := NewNodePool(slog.Default()).
+		Add(domain.Node{ID: "steady"}, steady).
+		Add(domain.Node{ID: "flapping"}, flapping).
+		Add(domain.Node{ID: "down"}, down)
+	pool.eventRetryMin = 5 * time.Millisecond
+	pool.eventRetryMax = 20 * time.Millisecond
+	pool.CheckHealth(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, errs := pool.WatchEvents(ctx, time.Time{})
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, domain.ErrNodeEventsInterrupted) {
+			t.Fatalf("expected the node's error marked as an interruption, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the failing node's error forwarded")
+	}
+	// Let the failing node reconnect a few times before the healthy node's event arrives
+	time.Sleep(50 * time.Millisecond)
+
+	go func() { steady.events <- domain.ContainerEvent{DockerID: "steady-1", Action: "die", Time: time.Now()} }()
+	select {
+	case event := <-events:
+		if event.DockerID != "steady-1" {
+			t.Fatalf("unexpected event %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the healthy node's event delivered")
+	}
+
+	if n := steady.watchCount(); n != 1 {
+		t.Fatalf("expected the healthy node's stream left open, opened %d times", n)
+	}
+	if n := flapping.watchCount(); n < 2 {
+		t.Fatalf("expected the failing node reconnected, opened %d times", n)
+	}
+	if n := down.watchCount(); n != 0 {
+		t.Fatalf("expected the unhealthy node not watched, opened %d times", n)
+	}
+
+	cancel()
+	for range events {
+	}
+}
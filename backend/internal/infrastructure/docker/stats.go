@@ -0,0 +1,141 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/docker/docker/api/types/container"
+)
+
+// Stats returns one resource usage sample. Docker measures CPU over about a second before answering.
+func (c *Client) Stats(ctx context.Context, containerID string) (*domain.ContainerStats, error) {
+	if !c.circuitBreaker.AllowRequest() {
+		return nil, fmt.Errorf("docker service temporarily unavailable (circuit breaker open)")
+	}
+
+	resp, err := c.cli.ContainerStats(ctx, containerID, false)
+	if err != nil {
+		c.circuitBreaker.RecordFailure()
+		return nil, fmt.Errorf("failed to get stats for container %s: %w", containerID, err)
+	}
+	defer resp.Body.Close()
+	c.circuitBreaker.RecordSuccess()
+
+	var raw container.StatsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode stats for container %s: %w", containerID, err)
+	}
+	stats := toContainerStats(&raw)
+	return &stats, nil
+}
+
+// StreamStats sends a sample about once a second until ctx ends or the container stops.
+// The error channel receives a value if the stream fails before ctx ends.
+func (c *Client) StreamStats(ctx context.Context, containerID string) (<-chan domain.ContainerStats, <-chan error) {
+	out := make(chan domain.ContainerStats)
+	outErr := make(chan error, 1)
+
+	if !c.circuitBreaker.AllowRequest() {
+		outErr <- fmt.Errorf("docker service temporarily unavailable (circuit breaker open)")
+		close(out)
+		return out, outErr
+	}
+	resp, err := c.cli.ContainerStats(ctx, containerID, true)
+	if err != nil {
+		c.circuitBreaker.RecordFailure()
+		outErr <- fmt.Errorf("failed to stream stats for container %s: %w", containerID, err)
+		close(out)
+		return out, outErr
+	}
+	c.circuitBreaker.RecordSuccess()
+
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var raw container.StatsResponse
+			if err := dec.Decode(&raw); err != nil {
+				if ctx.Err() == nil && err != io.EOF {
+					outErr <- fmt.Errorf("stats stream for container %s failed: %w", containerID, err)
+				}
+				return
+			}
+			// The first sample has nothing to measure CPU against
+			if raw.PreCPUStats.SystemUsage == 0 {
+				continue
+			}
+			select {
+			case out <- toContainerStats(&raw):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, outErr
+}
+
+// toContainerStats converts a Docker stats sample, computing CPU use the way `docker stats` does
+func toContainerStats(raw *container.StatsResponse) domain.ContainerStats {
+	stats := domain.ContainerStats{
+		Time:             raw.Read,
+		MemoryBytes:      raw.MemoryStats.Usage,
+		MemoryLimitBytes: raw.MemoryStats.Limit,
+		PIDs:             raw.PidsStats.Current,
+		PIDsLimit:        raw.PidsStats.Limit,
+	}
+
+	cpuDelta := float64(raw.CPUStats.CPUUsage.TotalUsage) - float64(raw.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(raw.CPUStats.SystemUsage) - float64(raw.PreCPUStats.SystemUsage)
+	cpus := float64(raw.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(raw.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		stats.CPUMilli = cpuDelta / systemDelta * cpus * 1000
+	}
+
+	// Page cache the kernel can reclaim is not counted: inactive_file on cgroup v2, total_inactive_file on v1
+	cache, ok := raw.MemoryStats.Stats["inactive_file"]
+	if !ok {
+		cache = raw.MemoryStats.Stats["total_inactive_file"]
+	}
+	if cache < stats.MemoryBytes {
+		stats.MemoryBytes -= cache
+	}
+
+	for _, n := range raw.Networks {
+		stats.NetRxBytes += n.RxBytes
+		stats.NetTxBytes += n.TxBytes
+	}
+	for _, entry := range raw.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			stats.BlockReadBytes += entry.Value
+		case "write":
+			stats.BlockWriteBytes += entry.Value
+		}
+	}
+	return stats
+}
+
+// HostResources returns the CPUs, in millicores, and memory, in MB, of the Docker host
+func (c *Client) HostResources(ctx context.Context) (int, int, error) {
+	if !c.circuitBreaker.AllowRequest() {
+		return 0, 0, fmt.Errorf("docker service temporarily unavailable (circuit breaker open)")
+	}
+
+	info, err := c.cli.Info(ctx)
+	if err != nil {
+		c.circuitBreaker.RecordFailure()
+		return 0, 0, fmt.Errorf("failed to get docker host info: %w", err)
+	}
+	c.circuitBreaker.RecordSuccess()
+	return info.NCPU * 1000, int(info.MemTotal / (1024 * 1024)), nil
+}
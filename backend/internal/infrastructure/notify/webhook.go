@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+)
+
+// WebhookNotifier POSTs budget and idle events as JSON to a configured URL
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+	logger *slog.Logger
+}
+
+// NewWebhookNotifier creates a notifier that posts to url
+func NewWebhookNotifier(url string, logger *slog.Logger) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+		logger: logger,
+	}
+}
+
+// budgetPayload is the webhook body for a budget event
+type budgetPayload struct {
+	Type     string    `json:"type"` // budget.alert or budget.stop
+	TenantID string    `json:"tenantId"`
+	Period   string    `json:"period"` // YYYY-MM
+	Spend    float64   `json:"spend"`
+	Limit    float64   `json:"limit"`
+	Time     time.Time `json:"time"`
+}
+
+// NotifyBudget delivers a budget event
+func (n *WebhookNotifier) NotifyBudget(ctx context.Context, event *domain.BudgetEvent) error {
+	body, err := json.Marshal(budgetPayload{
+		Type:     "budget." + event.Kind,
+		TenantID: event.TenantID,
+		Period:   event.Period.Format("2006-01"),
+		Spend:    event.Spend,
+		Limit:    event.Limit,
+		Time:     event.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal budget event: %w", err)
+	}
+	if err := n.post(ctx, body); err != nil {
+		return err
+	}
+
+	n.logger.Debug("budget webhook delivered", slog.String("tenant_id", event.TenantID), slog.String("kind", event.Kind))
+	return nil
+}
+
+// idlePayload is the webhook body for an idle event
+type idlePayload struct {
+	Type        string    `json:"type"` // lease.idle
+	TenantID    string    `json:"tenantId"`
+	ContainerID string    `json:"containerId"`
+	Action      string    `json:"action"` // notify, pause, snapshot-and-terminate or terminate
+	IdleMinutes int       `json:"idleMinutes"`
+	Time        time.Time `json:"time"`
+}
+
+// NotifyIdle delivers an idle event
+func (n *WebhookNotifier) NotifyIdle(ctx context.Context, event *domain.IdleEvent) error {
+	body, err := json.Marshal(idlePayload{
+		Type:        "lease.idle",
+		TenantID:    event.TenantID,
+		ContainerID: event.ContainerID,
+		Action:      event.Action,
+		IdleMinutes: int(event.IdleFor.Minutes()),
+		Time:        event.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal idle event: %w", err)
+	}
+	if err := n.post(ctx, body); err != nil {
+		return err
+	}
+
+	n.logger.Debug("idle webhook delivered", slog.String("container_id", event.ContainerID), slog.String("action", event.Action))
+	return nil
+}
+
+// post sends a JSON body to the webhook URL
+func (n *WebhookNotifier) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -33,6 +33,11 @@ func NewClient(url string) (*Client, error) {
 	return &Client{rdb: rdb}, nil
 }
 
+// Raw returns the underlying go-redis client for repositories that need commands not wrapped here
+func (c *Client) Raw() *redis.Client {
+	return c.rdb
+}
+
 // Set stores a value with optional TTL
 func (c *Client) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
 	return c.rdb.Set(ctx, key, value, ttl).Err()
@@ -67,3 +72,21 @@ func (c *Client) Ping(ctx context.Context) error {
 func (c *Client) Close() error {
 	return c.rdb.Close()
 }
+
+// SetEntry is a single key/value pair with its TTL, used by SetMany
+type SetEntry struct {
+	Key   string
+	Value interface{}
+	TTL   time.Duration
+}
+
+// SetMany stores several values in a single MULTI/EXEC transaction so either all or none are written
+func (c *Client) SetMany(ctx context.Context, entries ...SetEntry) error {
+	_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, e := range entries {
+			pipe.Set(ctx, e.Key, e.Value, e.TTL)
+		}
+		return nil
+	})
+	return err
+}
@@ -3,6 +3,8 @@ package metrics
 import (
 	"time"
 
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -43,13 +45,87 @@ var (
 
 	containerFailures = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "containerlease_container_failures_total",
-		Help: "Count of container failures before restart/termination",
+		Help: "Count of container failures by reason, counted once when detected",
 	}, []string{"reason"})
 
 	chaosMonkeyKills = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "containerlease_chaos_monkey_kills_total",
 		Help: "Count of containers killed by chaos monkey for resilience testing",
 	}, []string{"operation"})
+
+	// Resource usage of running leases, sampled by the stats collector
+	containerCPU = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "containerlease_container_cpu_millicores",
+		Help: "CPU in use by a leased container, in millicores",
+	}, []string{"tenant", "container"})
+
+	containerCPURatio = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "containerlease_container_cpu_request_ratio",
+		Help: "CPU in use by a leased container as a fraction of its requested CPU",
+	}, []string{"tenant", "container"})
+
+	containerMemory = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "containerlease_container_memory_bytes",
+		Help: "Memory in use by a leased container, excluding reclaimable page cache",
+	}, []string{"tenant", "container"})
+
+	containerMemoryRatio = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "containerlease_container_memory_request_ratio",
+		Help: "Memory in use by a leased container as a fraction of its requested memory",
+	}, []string{"tenant", "container"})
+
+	containerNetwork = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "containerlease_container_network_bytes",
+		Help: "Bytes a leased container has received (rx) and transmitted (tx) since it started",
+	}, []string{"tenant", "container", "direction"})
+
+	containerBlockIO = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "containerlease_container_block_io_bytes",
+		Help: "Bytes a leased container has read and written on block devices since it started",
+	}, []string{"tenant", "container", "op"})
+
+	containerPIDs = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "containerlease_container_pids",
+		Help: "Number of processes in a leased container",
+	}, []string{"tenant", "container"})
+
+	// Idle detection and the capacity reclaimed from idle leases
+	idleActions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "containerlease_idle_actions_total",
+		Help: "Count of idle policies applied to leases by tenant, action and result",
+	}, []string{"tenant", "action", "result"})
+
+	idleReclaimedCPU = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "containerlease_idle_reclaimed_cpu_millicores_total",
+		Help: "Requested CPU freed by pausing or terminating idle leases, in millicores",
+	}, []string{"action"})
+
+	idleReclaimedMemory = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "containerlease_idle_reclaimed_memory_bytes_total",
+		Help: "Requested memory freed by terminating idle leases (paused containers keep theirs)",
+	}, []string{"action"})
+
+	idleLeases = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "containerlease_idle_leases",
+		Help: "Number of running leases currently below the idle thresholds",
+	})
+
+	pausedCPU = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "containerlease_paused_cpu_millicores",
+		Help: "Requested CPU of paused leases, available to other leases, in millicores",
+	})
+
+	// Admission control
+	queuedLeases = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "containerlease_queued_leases",
+		Help: "Number of leases waiting in the provisioning queue for host capacity",
+	})
+
+	jobRuns = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "containerlease_job_duration_seconds",
+		Help:    "Duration of background job runs by kind and result (success, retry or dead)",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"kind", "result"})
 )
 
 // ObserveHTTPRequest records an HTTP request metric
@@ -91,7 +167,8 @@ func ObserveContainerRestart(result string) {
 	containerRestarts.WithLabelValues(result).Inc()
 }
 
-// ObserveContainerFailure records a container failure (Phase 2: Self-Healing)
+// ObserveContainerFailure records a newly detected container failure (Phase 2: Self-Healing).
+// Restarting it is counted separately by ObserveContainerRestart.
 func ObserveContainerFailure(reason string) {
 	containerFailures.WithLabelValues(reason).Inc()
 }
@@ -109,3 +186,56 @@ func ObserveChaosMoney(operation string, count int) {
 		}
 	}
 }
+
+// ObserveContainerStats records a resource usage sample of a running lease
+func ObserveContainerStats(c *domain.Container, stats domain.ContainerStats) {
+	containerCPU.WithLabelValues(c.TenantID, c.ID).Set(stats.CPUMilli)
+	containerCPURatio.WithLabelValues(c.TenantID, c.ID).Set(stats.CPUPercent(c.CPUMilli) / 100)
+	containerMemory.WithLabelValues(c.TenantID, c.ID).Set(float64(stats.MemoryBytes))
+	containerMemoryRatio.WithLabelValues(c.TenantID, c.ID).Set(stats.MemoryPercent(c.MemoryMB) / 100)
+	containerNetwork.WithLabelValues(c.TenantID, c.ID, "rx").Set(float64(stats.NetRxBytes))
+	containerNetwork.WithLabelValues(c.TenantID, c.ID, "tx").Set(float64(stats.NetTxBytes))
+	containerBlockIO.WithLabelValues(c.TenantID, c.ID, "read").Set(float64(stats.BlockReadBytes))
+	containerBlockIO.WithLabelValues(c.TenantID, c.ID, "write").Set(float64(stats.BlockWriteBytes))
+	containerPIDs.WithLabelValues(c.TenantID, c.ID).Set(float64(stats.PIDs))
+}
+
+// ForgetContainerStats removes the resource usage series of a lease that is no longer running
+func ForgetContainerStats(tenantID, containerID string) {
+	labels := prometheus.Labels{"tenant": tenantID, "container": containerID}
+	for _, vec := range []*prometheus.GaugeVec{containerCPU, containerCPURatio, containerMemory, containerMemoryRatio, containerNetwork, containerBlockIO, containerPIDs} {
+		vec.DeletePartialMatch(labels)
+	}
+}
+
+// ObserveIdleAction records an idle policy applied to a lease and, on success, the capacity it freed.
+// Pausing frees CPU only; the container keeps its memory.
+func ObserveIdleAction(c *domain.Container, action, result string) {
+	idleActions.WithLabelValues(c.TenantID, action, result).Inc()
+	if result != "success" {
+		return
+	}
+	switch action {
+	case "pause":
+		idleReclaimedCPU.WithLabelValues(action).Add(float64(c.CPUMilli))
+	case "snapshot-and-terminate", "terminate":
+		idleReclaimedCPU.WithLabelValues(action).Add(float64(c.CPUMilli))
+		idleReclaimedMemory.WithLabelValues(action).Add(float64(c.MemoryMB << 20))
+	}
+}
+
+// SetIdleLeases sets the number of idle running leases and the CPU requested by paused ones
+func SetIdleLeases(idle int, pausedCPUMilli int) {
+	idleLeases.Set(float64(idle))
+	pausedCPU.Set(float64(pausedCPUMilli))
+}
+
+// SetQueuedLeases sets the number of leases waiting for host capacity
+func SetQueuedLeases(queued int) {
+	queuedLeases.Set(float64(queued))
+}
+
+// ObserveJob records a background job run
+func ObserveJob(kind, result string, duration time.Duration) {
+	jobRuns.WithLabelValues(kind, result).Observe(duration.Seconds())
+}
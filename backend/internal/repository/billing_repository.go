@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+)
+
+// PostgresBillingRepository implements domain.BillingRepository using PostgreSQL
+type PostgresBillingRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewPostgresBillingRepository creates a new billing repository
+func NewPostgresBillingRepository(db *sql.DB, logger *slog.Logger) *PostgresBillingRepository {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &PostgresBillingRepository{db: db, logger: logger}
+}
+
+// Create stores a billing record, ignoring duplicates for the same container
+func (r *PostgresBillingRepository) Create(record *domain.BillingRecord) error {
+	query := `
+		INSERT INTO billing_records (
+			container_id, tenant_id, preset, amount, cpu_milli_hours, memory_mb_hours,
+			volume_mb_hours, duration_minutes, period_start, period_end
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (container_id) DO NOTHING
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRow(query,
+		record.ContainerID,
+		record.TenantID,
+		nullString(record.Preset),
+		record.Amount,
+		record.CPUMilliHours,
+		record.MemoryMBHours,
+		record.VolumeMBHours,
+		record.DurationMinutes,
+		record.PeriodStart,
+		record.PeriodEnd,
+	).Scan(&record.ID, &record.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		r.logger.Debug("billing record already exists", slog.String("container_id", record.ContainerID))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create billing record: %w", err)
+	}
+
+	r.logger.Debug("billing record created",
+		slog.String("container_id", record.ContainerID),
+		slog.Float64("amount", record.Amount),
+	)
+	return nil
+}
+
+// SumByTenant totals the tenant's billing records that ended at or after since
+func (r *PostgresBillingRepository) SumByTenant(tenantID string, since time.Time) (float64, error) {
+	var total float64
+	query := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM billing_records
+		WHERE tenant_id = $1 AND period_end >= $2
+	`
+	if err := r.db.QueryRow(query, tenantID, since).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to sum billing records: %w", err)
+	}
+	return total, nil
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+)
+
+// PostgresBudgetRepository implements domain.BudgetRepository using PostgreSQL
+type PostgresBudgetRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewPostgresBudgetRepository creates a new budget repository
+func NewPostgresBudgetRepository(db *sql.DB, logger *slog.Logger) *PostgresBudgetRepository {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &PostgresBudgetRepository{db: db, logger: logger}
+}
+
+const budgetColumns = `tenant_id, monthly_limit, alert_threshold, hard_stop, shorten_leases, grace_minutes, updated_at`
+
+// GetBudget retrieves a tenant's budget, returning nil if none has been set
+func (r *PostgresBudgetRepository) GetBudget(tenantID string) (*domain.TenantBudget, error) {
+	query := `SELECT ` + budgetColumns + ` FROM tenant_budgets WHERE tenant_id = $1`
+	b, err := scanBudget(r.db.QueryRow(query, tenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get budget: %w", err)
+	}
+	return b, nil
+}
+
+// SaveBudget inserts or replaces a tenant's budget
+func (r *PostgresBudgetRepository) SaveBudget(budget *domain.TenantBudget) error {
+	query := `
+		INSERT INTO tenant_budgets (tenant_id, monthly_limit, alert_threshold, hard_stop, shorten_leases, grace_minutes, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			monthly_limit = EXCLUDED.monthly_limit,
+			alert_threshold = EXCLUDED.alert_threshold,
+			hard_stop = EXCLUDED.hard_stop,
+			shorten_leases = EXCLUDED.shorten_leases,
+			grace_minutes = EXCLUDED.grace_minutes,
+			updated_at = NOW()
+		RETURNING updated_at
+	`
+	err := r.db.QueryRow(query,
+		budget.TenantID, budget.MonthlyLimit, budget.AlertThreshold, budget.HardStop, budget.ShortenLeases, budget.GraceMinutes,
+	).Scan(&budget.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save budget: %w", err)
+	}
+
+	r.logger.Info("tenant budget updated", slog.String("tenant_id", budget.TenantID))
+	return nil
+}
+
+// DeleteBudget removes a tenant's budget
+func (r *PostgresBudgetRepository) DeleteBudget(tenantID string) error {
+	if _, err := r.db.Exec(`DELETE FROM tenant_budgets WHERE tenant_id = $1`, tenantID); err != nil {
+		return fmt.Errorf("failed to delete budget: %w", err)
+	}
+	return nil
+}
+
+// ListBudgets returns every tenant budget
+func (r *PostgresBudgetRepository) ListBudgets() ([]*domain.TenantBudget, error) {
+	rows, err := r.db.Query(`SELECT ` + budgetColumns + ` FROM tenant_budgets ORDER BY tenant_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list budgets: %w", err)
+	}
+	defer rows.Close()
+
+	var budgets []*domain.TenantBudget
+	for rows.Next() {
+		b, err := scanBudget(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan budget: %w", err)
+		}
+		budgets = append(budgets, b)
+	}
+	return budgets, rows.Err()
+}
+
+// RecordEvent stores a budget event, reporting false if one already exists for its tenant, kind and period
+func (r *PostgresBudgetRepository) RecordEvent(event *domain.BudgetEvent) (bool, error) {
+	query := `
+		INSERT INTO budget_events (tenant_id, kind, period, spend, monthly_limit)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant_id, kind, period) DO NOTHING
+		RETURNING created_at
+	`
+	err := r.db.QueryRow(query, event.TenantID, event.Kind, event.Period, event.Spend, event.Limit).Scan(&event.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to record budget event: %w", err)
+	}
+	return true, nil
+}
+
+// ListEvents returns a tenant's events for one budget period
+func (r *PostgresBudgetRepository) ListEvents(tenantID string, period time.Time) ([]*domain.BudgetEvent, error) {
+	query := `
+		SELECT tenant_id, kind, period, spend, monthly_limit, created_at
+		FROM budget_events
+		WHERE tenant_id = $1 AND period = $2
+		ORDER BY created_at
+	`
+	rows, err := r.db.Query(query, tenantID, period)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list budget events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*domain.BudgetEvent
+	for rows.Next() {
+		e := &domain.BudgetEvent{}
+		if err := rows.Scan(&e.TenantID, &e.Kind, &e.Period, &e.Spend, &e.Limit, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan budget event: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func scanBudget(row rowScanner) (*domain.TenantBudget, error) {
+	b := &domain.TenantBudget{}
+	err := row.Scan(&b.TenantID, &b.MonthlyLimit, &b.AlertThreshold, &b.HardStop, &b.ShortenLeases, &b.GraceMinutes, &b.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
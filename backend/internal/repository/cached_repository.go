@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+)
+
+// CachedContainerRepository fronts a durable container repository with the Redis repository.
+// Reads by ID are served from Redis when present and populate it on a miss; writes go to
+// the primary first and then refresh the cache. List queries always hit the primary.
+type CachedContainerRepository struct {
+	primary domain.ContainerRepository
+	cache   *ContainerRepository
+	logger  *slog.Logger
+}
+
+// NewCachedContainerRepository creates a read-through cached container repository
+func NewCachedContainerRepository(primary domain.ContainerRepository, cache *ContainerRepository, logger *slog.Logger) *CachedContainerRepository {
+	return &CachedContainerRepository{primary: primary, cache: cache, logger: logger}
+}
+
+// Save stores a container in the primary and refreshes the cache
+func (r *CachedContainerRepository) Save(container *domain.Container) error {
+	if err := r.primary.Save(container); err != nil {
+		return err
+	}
+	if err := r.cache.Save(container); err != nil {
+		r.logger.Warn("failed to cache container", slog.String("container_id", container.ID), slog.String("error", err.Error()))
+		// A stale entry is worse than none
+		_ = r.cache.Delete(container.ID)
+	}
+	return nil
+}
+
+// GetByID retrieves a container, consulting the cache first
+func (r *CachedContainerRepository) GetByID(id string) (*domain.Container, error) {
+	if container, err := r.cache.GetByID(id); err == nil {
+		return container, nil
+	}
+
+	container, err := r.primary.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.cache.Save(container); err != nil {
+		r.logger.Warn("failed to cache container", slog.String("container_id", id), slog.String("error", err.Error()))
+	}
+	return container, nil
+}
+
+// Delete removes a container from the primary and the cache
+func (r *CachedContainerRepository) Delete(id string) error {
+	if err := r.primary.Delete(id); err != nil {
+		return err
+	}
+	if err := r.cache.Delete(id); err != nil {
+		r.logger.Warn("failed to evict cached container", slog.String("container_id", id), slog.String("error", err.Error()))
+	}
+	return nil
+}
+
+// List returns containers from the primary
+func (r *CachedContainerRepository) List() ([]*domain.Container, error) {
+	return r.primary.List()
+}
+
+// ListByTenant returns a tenant's containers from the primary
+func (r *CachedContainerRepository) ListByTenant(tenantID string) ([]*domain.Container, error) {
+	return r.primary.ListByTenant(tenantID)
+}
+
+// GetByDockerID looks a container up by its Docker ID in the primary
+func (r *CachedContainerRepository) GetByDockerID(dockerID string) (*domain.Container, error) {
+	return domain.FindByDockerID(r.primary, dockerID)
+}
+
+// ListHistoryByTenant returns a tenant's full container history when the primary keeps one
+func (r *CachedContainerRepository) ListHistoryByTenant(tenantID string) ([]*domain.Container, error) {
+	if history, ok := r.primary.(domain.ContainerHistoryRepository); ok {
+		return history.ListHistoryByTenant(tenantID)
+	}
+	return r.primary.ListByTenant(tenantID)
+}
+
+// CachedLeaseRepository fronts a durable lease repository with the Redis repository
+type CachedLeaseRepository struct {
+	primary domain.LeaseRepository
+	cache   *LeaseRepository
+	logger  *slog.Logger
+}
+
+// NewCachedLeaseRepository creates a read-through cached lease repository
+func NewCachedLeaseRepository(primary domain.LeaseRepository, cache *LeaseRepository, logger *slog.Logger) *CachedLeaseRepository {
+	return &CachedLeaseRepository{primary: primary, cache: cache, logger: logger}
+}
+
+// CreateLease stores a lease in the primary and the cache
+func (r *CachedLeaseRepository) CreateLease(lease *domain.Lease) error {
+	if err := r.primary.CreateLease(lease); err != nil {
+		return err
+	}
+	if err := r.cache.CreateLease(lease); err != nil {
+		r.logger.Warn("failed to cache lease", slog.String("lease_key", lease.LeaseKey), slog.String("error", err.Error()))
+		_ = r.cache.DeleteLease(lease.LeaseKey)
+	}
+	return nil
+}
+
+// GetLease retrieves a lease, consulting the cache first
+func (r *CachedLeaseRepository) GetLease(leaseKey string) (*domain.Lease, error) {
+	if lease, err := r.cache.GetLease(leaseKey); err == nil {
+		return lease, nil
+	}
+
+	lease, err := r.primary.GetLease(leaseKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.cache.CreateLease(lease); err != nil {
+		r.logger.Warn("failed to cache lease", slog.String("lease_key", leaseKey), slog.String("error", err.Error()))
+	}
+	return lease, nil
+}
+
+// DeleteLease removes a lease from the primary and the cache
+func (r *CachedLeaseRepository) DeleteLease(leaseKey string) error {
+	if err := r.primary.DeleteLease(leaseKey); err != nil {
+		return err
+	}
+	if err := r.cache.DeleteLease(leaseKey); err != nil {
+		r.logger.Warn("failed to evict cached lease", slog.String("lease_key", leaseKey), slog.String("error", err.Error()))
+	}
+	return nil
+}
+
+// CompareAndExtendLease extends the lease in the primary if it is unchanged there, and evicts
+// the cached lease and container. The primary only moved the container's expiry, so the
+// caller's copy may be stale; the next read loads both from the primary again.
+func (r *CachedLeaseRepository) CompareAndExtendLease(lease *domain.Lease, container *domain.Container, expiryTime time.Time) error {
+	if err := r.primary.CompareAndExtendLease(lease, container, expiryTime); err != nil {
+		return err
+	}
+	if err := r.cache.DeleteLease(lease.LeaseKey); err != nil {
+		r.logger.Warn("failed to evict cached lease", slog.String("lease_key", lease.LeaseKey), slog.String("error", err.Error()))
+	}
+	if err := r.cache.redis.Delete(context.Background(), fmt.Sprintf("container:%s", container.ID)); err != nil {
+		r.logger.Warn("failed to evict cached container", slog.String("container_id", container.ID), slog.String("error", err.Error()))
+	}
+	return nil
+}
+
+// GetExpiredLeases returns expired leases from the primary
+func (r *CachedLeaseRepository) GetExpiredLeases() ([]string, error) {
+	return r.primary.GetExpiredLeases()
+}
@@ -0,0 +1,574 @@
+package repository
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/infrastructure/redis"
+)
+
+// fakeRedis serves the few Redis commands the repositories use from memory, over RESP2
+type fakeRedis struct {
+	mu         sync.Mutex
+	values     map[string]string
+	expires    map[string]time.Time
+	versions   map[string]int // Bumped on every write, for WATCH
+	failWrites bool           // SET replies with an error, as a full or read-only Redis would
+}
+
+// fakeRedisConn is one client connection's transaction state
+type fakeRedisConn struct {
+	watched map[string]int
+	queued  [][]string // nil outside MULTI
+	multi   bool
+}
+
+func newFakeRedis(t *testing.T) (*fakeRedis, *redis.Client) {
+	t.Helper()
+	f := &fakeRedis{values: map[string]string{}, expires: map[string]time.Time{}, versions: map[string]int{}}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+
+	client, err := redis.NewClient("redis://" + ln.Addr().String())
+	if err != nil {
+		t.Fatalf("redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return f, client
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	state := &fakeRedisConn{watched: map[string]int{}}
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		reply := f.handle(state, args)
+		f.mu.Unlock()
+		_, _ = w.WriteString(reply)
+		if r.Buffered() == 0 {
+			_ = w.Flush()
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected %q", line)
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func (f *fakeRedis) handle(state *fakeRedisConn, args []string) string {
+	cmd := strings.ToUpper(args[0])
+	switch {
+	case cmd == "MULTI":
+		state.multi, state.queued = true, nil
+		return "+OK\r\n"
+	case cmd == "DISCARD":
+		state.multi, state.queued = false, nil
+		state.watched = map[string]int{}
+		return "+OK\r\n"
+	case cmd == "EXEC":
+		queued := state.queued
+		state.multi, state.queued = false, nil
+		for key, version := range state.watched {
+			if f.versions[key] != version {
+				state.watched = map[string]int{}
+				return "*-1\r\n"
+			}
+		}
+		state.watched = map[string]int{}
+		out := fmt.Sprintf("*%d\r\n", len(queued))
+		for _, q := range queued {
+			out += f.exec(q)
+		}
+		return out
+	case state.multi:
+		state.queued = append(state.queued, args)
+		return "+QUEUED\r\n"
+	case cmd == "WATCH":
+		for _, key := range args[1:] {
+			f.expire(key)
+			state.watched[key] = f.versions[key]
+		}
+		return "+OK\r\n"
+	case cmd == "UNWATCH":
+		state.watched = map[string]int{}
+		return "+OK\r\n"
+	}
+	return f.exec(args)
+}
+
+// exec runs a single command against the store
+func (f *fakeRedis) exec(args []string) string {
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "CLIENT", "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		f.expire(args[1])
+		v, ok := f.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(v)
+	case "SET":
+		if f.failWrites {
+			return "-READONLY You can't write against a read only replica.\r\n"
+		}
+		key := args[1]
+		f.expire(key)
+		var ttl time.Duration
+		for i := 3; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "NX":
+				if _, ok := f.values[key]; ok {
+					return "$-1\r\n"
+				}
+			case "EX", "PX":
+				n, _ := strconv.Atoi(args[i+1])
+				ttl = time.Duration(n) * time.Millisecond
+				if strings.ToUpper(args[i]) == "EX" {
+					ttl = time.Duration(n) * time.Second
+				}
+				i++
+			}
+		}
+		f.set(key, args[2], ttl)
+		return "+OK\r\n"
+	case "DEL":
+		n := 0
+		for _, key := range args[1:] {
+			f.expire(key)
+			if _, ok := f.values[key]; ok {
+				delete(f.values, key)
+				f.versions[key]++
+				n++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	case "KEYS":
+		var keys []string
+		for key := range f.values {
+			f.expire(key)
+			if ok, _ := path.Match(args[1], key); ok {
+				if _, live := f.values[key]; live {
+					keys = append(keys, key)
+				}
+			}
+		}
+		out := fmt.Sprintf("*%d\r\n", len(keys))
+		for _, key := range keys {
+			out += bulk(key)
+		}
+		return out
+	}
+	return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+}
+
+func (f *fakeRedis) set(key, value string, ttl time.Duration) {
+	f.values[key] = value
+	delete(f.expires, key)
+	if ttl > 0 {
+		f.expires[key] = time.Now().Add(ttl)
+	}
+	f.versions[key]++
+}
+
+// expire drops the key if its TTL has passed
+func (f *fakeRedis) expire(key string) {
+	if at, ok := f.expires[key]; ok && !time.Now().Before(at) {
+		delete(f.values, key)
+		delete(f.expires, key)
+		f.versions[key]++
+	}
+}
+
+func (f *fakeRedis) get(key string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.expire(key)
+	v, ok := f.values[key]
+	return v, ok
+}
+
+func bulk(s string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+}
+
+// memPrimary is a durable container and lease store that keeps terminated containers
+type memPrimary struct {
+	containers map[string]*domain.Container
+	leases     map[string]*domain.Lease
+	reads      int
+}
+
+func newMemPrimary() *memPrimary {
+	return &memPrimary{containers: map[string]*domain.Container{}, leases: map[string]*domain.Lease{}}
+}
+
+func (m *memPrimary) Save(c *domain.Container) error {
+	cp := *c
+	m.containers[c.ID] = &cp
+	return nil
+}
+func (m *memPrimary) GetByID(id string) (*domain.Container, error) {
+	m.reads++
+	c, ok := m.containers[id]
+	if !ok {
+		return nil, errors.New("container not found")
+	}
+	cp := *c
+	return &cp, nil
+}
+func (m *memPrimary) Delete(id string) error {
+	delete(m.containers, id)
+	return nil
+}
+func (m *memPrimary) List() ([]*domain.Container, error) {
+	return m.ListHistoryByTenant("")
+}
+func (m *memPrimary) ListByTenant(tenantID string) ([]*domain.Container, error) {
+	var out []*domain.Container
+	for _, c := range m.containers {
+		if c.TenantID == tenantID && c.Status != "terminated" {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+func (m *memPrimary) ListHistoryByTenant(tenantID string) ([]*domain.Container, error) {
+	var out []*domain.Container
+	for _, c := range m.containers {
+		if tenantID == "" || c.TenantID == tenantID {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+func (m *memPrimary) CreateLease(l *domain.Lease) error {
+	cp := *l
+	m.leases[l.LeaseKey] = &cp
+	return nil
+}
+func (m *memPrimary) GetLease(key string) (*domain.Lease, error) {
+	m.reads++
+	l, ok := m.leases[key]
+	if !ok {
+		return nil, errors.New("lease not found")
+	}
+	cp := *l
+	return &cp, nil
+}
+func (m *memPrimary) DeleteLease(key string) error {
+	delete(m.leases, key)
+	return nil
+}
+func (m *memPrimary) CompareAndExtendLease(l *domain.Lease, c *domain.Container, expiryTime time.Time) error {
+	if stored, ok := m.leases[l.LeaseKey]; !ok || !stored.ExpiryTime.Equal(expiryTime) {
+		return domain.ErrLeaseChanged
+	}
+	if err := m.CreateLease(l); err != nil {
+		return err
+	}
+	// Like the Postgres repository, only the container's expiry changes
+	if stored, ok := m.containers[c.ID]; ok {
+		stored.ExpiryAt = c.ExpiryAt
+	}
+	return nil
+}
+func (m *memPrimary) GetExpiredLeases() ([]string, error) {
+	return nil, nil
+}
+
+// containersOnly hides the primary's history, like a store that does not keep one
+type containersOnly struct {
+	domain.ContainerRepository
+}
+
+func TestCachedContainerRepositoryReadsThrough(t *testing.T) {
+	cache, client := newFakeRedis(t)
+	primary := newMemPrimary()
+	repo := NewCachedContainerRepository(primary, NewContainerRepository(client, slog.Default()), slog.Default())
+	_ = primary.Save(&domain.Container{ID: "c1", TenantID: "t1", Status: "running", ExpiryAt: time.Now().Add(time.Hour)})
+
+	// A miss is read from the primary and cached
+	if c, err := repo.GetByID("c1"); err != nil || c.Status != "running" {
+		t.Fatalf("get: %+v %v", c, err)
+	}
+	if _, ok := cache.get("container:c1"); !ok {
+		t.Fatal("expected the container cached after a miss")
+	}
+	// Later reads are served from the cache
+	reads := primary.reads
+	if c, err := repo.GetByID("c1"); err != nil || c.ID != "c1" || primary.reads != reads {
+		t.Fatalf("expected a cache hit, got %+v %v after %d primary reads", c, err, primary.reads-reads)
+	}
+	if _, err := repo.GetByID("missing"); err == nil {
+		t.Fatal("expected an error for a container in neither store")
+	}
+}
+
+func TestCachedContainerRepositoryWritesThrough(t *testing.T) {
+	cache, client := newFakeRedis(t)
+	primary := newMemPrimary()
+	repo := NewCachedContainerRepository(primary, NewContainerRepository(client, slog.Default()), slog.Default())
+
+	c := &domain.Container{ID: "c1", TenantID: "t1", Status: "pending", ExpiryAt: time.Now().Add(time.Hour)}
+	if err := repo.Save(c); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	c.Status = "running"
+	_ = repo.Save(c)
+	if got, _ := repo.GetByID("c1"); got.Status != "running" || primary.containers["c1"].Status != "running" {
+		t.Fatalf("expected both stores updated, got cache %q primary %q", got.Status, primary.containers["c1"].Status)
+	}
+
+	// If the cache cannot be refreshed its entry is dropped rather than left stale
+	cache.failWrites = true
+	c.Status = "terminated"
+	if err := repo.Save(c); err != nil {
+		t.Fatalf("save with a failing cache: %v", err)
+	}
+	if _, ok := cache.get("container:c1"); ok {
+		t.Fatal("expected the stale cache entry evicted")
+	}
+	if got, _ := repo.GetByID("c1"); got.Status != "terminated" {
+		t.Fatalf("expected the primary's state after eviction, got %q", got.Status)
+	}
+	cache.failWrites = false
+
+	if err := repo.Delete("c1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := repo.GetByID("c1"); err == nil {
+		t.Fatal("expected the container gone from both stores")
+	}
+}
+
+func TestContainerRepositoryGetByDockerID(t *testing.T) {
+	_, client := newFakeRedis(t)
+	repo := NewContainerRepository(client, slog.Default())
+	expiry := time.Now().Add(time.Hour)
+	_ = repo.Save(&domain.Container{ID: "c1", DockerID: "docker-1", Status: "running", ExpiryAt: expiry})
+	_ = repo.Save(&domain.Container{ID: "c2", Status: "pending", ExpiryAt: expiry})
+
+	if c, err := repo.GetByDockerID("docker-1"); err != nil || c == nil || c.ID != "c1" {
+		t.Fatalf("expected c1 by its Docker ID, got %+v %v", c, err)
+	}
+	if c, err := repo.GetByDockerID("docker-other"); err != nil || c != nil {
+		t.Fatalf("expected no container for an untracked Docker ID, got %+v %v", c, err)
+	}
+	// The index entry may outlive a deleted container
+	_ = repo.Delete("c1")
+	if c, err := repo.GetByDockerID("docker-1"); err != nil || c != nil {
+		t.Fatalf("expected no container after delete, got %+v %v", c, err)
+	}
+	if keys, _ := repo.List(); len(keys) != 1 {
+		t.Fatalf("expected the index kept out of the container list, got %d containers", len(keys))
+	}
+}
+
+func TestExtendedLeaseKeepsDockerIDIndex(t *testing.T) {
+	cache, client := newFakeRedis(t)
+	leases := NewLeaseRepository(client, slog.Default())
+	containers := NewContainerRepository(client, slog.Default())
+	expiry := time.Now().Add(time.Second)
+	_ = leases.CreateLease(&domain.Lease{ContainerID: "c1", LeaseKey: "lease:c1", ExpiryTime: expiry})
+	_ = containers.Save(&domain.Container{ID: "c1", DockerID: "docker-1", Status: "running", ExpiryAt: expiry})
+
+	extended := expiry.Add(time.Hour)
+	lease := &domain.Lease{ContainerID: "c1", LeaseKey: "lease:c1", ExpiryTime: extended, ExtensionCount: 1}
+	if err := leases.CompareAndExtendLease(lease, &domain.Container{ID: "c1", ExpiryAt: extended}, expiry); err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+
+	// Past the original expiry the index still finds the container
+	cache.mu.Lock()
+	for key, at := range cache.expires {
+		cache.expires[key] = at.Add(-2 * time.Second)
+	}
+	cache.mu.Unlock()
+	if c, err := containers.GetByDockerID("docker-1"); err != nil || c == nil || c.ID != "c1" {
+		t.Fatalf("expected the extended container found by its Docker ID, got %+v %v", c, err)
+	}
+}
+
+func TestCachedContainerRepositoryHistory(t *testing.T) {
+	_, client := newFakeRedis(t)
+	primary := newMemPrimary()
+	cache := NewContainerRepository(client, slog.Default())
+	_ = primary.Save(&domain.Container{ID: "live", TenantID: "t1", Status: "running"})
+	_ = primary.Save(&domain.Container{ID: "old", TenantID: "t1", Status: "terminated"})
+	_ = primary.Save(&domain.Container{ID: "other", TenantID: "t2", Status: "running"})
+
+	// ?history=true reads the primary's full history; the cache never holds it
+	repo := NewCachedContainerRepository(primary, cache, slog.Default())
+	if history, err := repo.ListHistoryByTenant("t1"); err != nil || len(history) != 2 {
+		t.Fatalf("expected the terminated container in the history, got %d %v", len(history), err)
+	}
+	if live, _ := repo.ListByTenant("t1"); len(live) != 1 {
+		t.Fatalf("expected only the live container listed, got %d", len(live))
+	}
+
+	// A primary without history falls back to the live list
+	repo = NewCachedContainerRepository(containersOnly{primary}, cache, slog.Default())
+	if history, err := repo.ListHistoryByTenant("t1"); err != nil || len(history) != 1 {
+		t.Fatalf("expected the live list without history, got %d %v", len(history), err)
+	}
+}
+
+func TestCachedLeaseRepository(t *testing.T) {
+	cache, client := newFakeRedis(t)
+	primary := newMemPrimary()
+	repo := NewCachedLeaseRepository(primary, NewLeaseRepository(client, slog.Default()), slog.Default())
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	lease := &domain.Lease{ContainerID: "c1", LeaseKey: "lease:c1", ExpiryTime: expiry, DurationMinutes: 60}
+	if err := repo.CreateLease(lease); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, ok := cache.get("lease:c1"); !ok || primary.leases["lease:c1"] == nil {
+		t.Fatal("expected the lease written to both stores")
+	}
+
+	// Read-through after the cache entry is lost
+	_ = client.Delete(t.Context(), "lease:c1")
+	if got, err := repo.GetLease("lease:c1"); err != nil || !got.ExpiryTime.Equal(expiry) {
+		t.Fatalf("get: %+v %v", got, err)
+	}
+	if _, ok := cache.get("lease:c1"); !ok {
+		t.Fatal("expected the lease cached after a miss")
+	}
+
+	// Extending evicts the cached lease and container rather than caching the caller's copy,
+	// which may predate a save the primary has since taken
+	containers := NewCachedContainerRepository(primary, NewContainerRepository(client, slog.Default()), slog.Default())
+	_ = containers.Save(&domain.Container{ID: "c1", Status: "pending", ExpiryAt: expiry})
+	container, _ := containers.GetByID("c1")
+	_ = primary.Save(&domain.Container{ID: "c1", DockerID: "docker-1", Status: "running", ExpiryAt: expiry})
+	container.ExpiryAt = expiry.Add(time.Hour)
+	lease.ExpiryTime, lease.ExtensionCount = expiry.Add(time.Hour), 1
+	if err := repo.CompareAndExtendLease(lease, container, expiry); err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	if _, ok := cache.get("lease:c1"); ok {
+		t.Fatal("expected the cached lease evicted")
+	}
+	if _, ok := cache.get("container:c1"); ok {
+		t.Fatal("expected the cached container evicted")
+	}
+	if got, _ := repo.GetLease("lease:c1"); got.ExtensionCount != 1 {
+		t.Fatalf("expected the extended lease read from the primary, got %+v", got)
+	}
+	if got, err := containers.GetByID("c1"); err != nil || got.Status != "running" || got.DockerID != "docker-1" || !got.ExpiryAt.Equal(container.ExpiryAt) {
+		t.Fatalf("expected the primary's container with the new expiry, got %+v %v", got, err)
+	}
+
+	if err := repo.DeleteLease("lease:c1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok := cache.get("lease:c1"); ok || primary.leases["lease:c1"] != nil {
+		t.Fatal("expected the lease removed from both stores")
+	}
+}
+
+func TestLeaseRepositoryCompareAndExtend(t *testing.T) {
+	_, client := newFakeRedis(t)
+	repo := NewLeaseRepository(client, slog.Default())
+	containers := NewContainerRepository(client, slog.Default())
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	_ = repo.CreateLease(&domain.Lease{ContainerID: "c1", LeaseKey: "lease:c1", ExpiryTime: expiry, ExtensionCount: 1})
+	_ = containers.Save(&domain.Container{ID: "c1", Status: "running", ExpiryAt: expiry})
+
+	// Another write, such as a pause, already moved the lease past the expiry we read
+	container := &domain.Container{ID: "c1", Status: "running", ExpiryAt: expiry.Add(time.Hour)}
+	lease := &domain.Lease{ContainerID: "c1", LeaseKey: "lease:c1", ExpiryTime: expiry.Add(time.Hour), ExtensionCount: 2}
+	if err := repo.CompareAndExtendLease(lease, container, expiry.Add(-time.Minute)); !errors.Is(err, domain.ErrLeaseChanged) {
+		t.Fatalf("expected lease changed error, got %v", err)
+	}
+	if got, _ := containers.GetByID("c1"); !got.ExpiryAt.Equal(expiry) {
+		t.Fatalf("expected nothing written for a stale extension, got expiry %v", got.ExpiryAt)
+	}
+
+	if err := repo.CompareAndExtendLease(lease, container, expiry); err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	if got, _ := repo.GetLease("lease:c1"); got.ExtensionCount != 2 || !got.ExpiryTime.Equal(lease.ExpiryTime) {
+		t.Fatalf("expected the lease extended, got %+v", got)
+	}
+	if got, _ := containers.GetByID("c1"); !got.ExpiryAt.Equal(container.ExpiryAt) {
+		t.Fatalf("expected the container's expiry moved with the lease, got %v", got.ExpiryAt)
+	}
+}
+
+func TestLeaseRepositoryCompareAndExtendKeepsContainerChanges(t *testing.T) {
+	_, client := newFakeRedis(t)
+	repo := NewLeaseRepository(client, slog.Default())
+	containers := NewContainerRepository(client, slog.Default())
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	_ = repo.CreateLease(&domain.Lease{ContainerID: "c1", LeaseKey: "lease:c1", ExpiryTime: expiry})
+	_ = containers.Save(&domain.Container{ID: "c1", Status: "pending", ExpiryAt: expiry})
+
+	// The extension reads the pending container, then provisioning finishes before it writes
+	read, _ := containers.GetByID("c1")
+	_ = containers.Save(&domain.Container{ID: "c1", DockerID: "docker-1", Status: "running", ExpiryAt: expiry})
+
+	read.ExpiryAt = expiry.Add(30 * time.Minute)
+	lease := &domain.Lease{ContainerID: "c1", LeaseKey: "lease:c1", ExpiryTime: read.ExpiryAt, ExtensionCount: 1}
+	if err := repo.CompareAndExtendLease(lease, read, expiry); err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	got, _ := containers.GetByID("c1")
+	if got.Status != "running" || got.DockerID != "docker-1" || !got.ExpiryAt.Equal(read.ExpiryAt) {
+		t.Fatalf("expected only the expiry changed on the provisioned container, got status=%s docker_id=%q expiry=%v", got.Status, got.DockerID, got.ExpiryAt)
+	}
+}
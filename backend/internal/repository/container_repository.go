@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/aryan0dhankhar/containerlease/internal/domain"
 	"github.com/aryan0dhankhar/containerlease/internal/infrastructure/redis"
+	goredis "github.com/redis/go-redis/v9"
 )
 
 // ContainerRepository implements domain.ContainerRepository using Redis
@@ -42,7 +44,12 @@ func (r *ContainerRepository) Save(container *domain.Container) error {
 		ttl = time.Second
 	}
 
-	if err := r.redis.Set(context.Background(), key, string(data), ttl); err != nil {
+	// The Docker ID index lives as long as the record; lookups check it still matches
+	entries := []redis.SetEntry{{Key: key, Value: string(data), TTL: ttl}}
+	if container.DockerID != "" {
+		entries = append(entries, redis.SetEntry{Key: dockerIDKey(container.DockerID), Value: container.ID, TTL: ttl})
+	}
+	if err := r.redis.SetMany(context.Background(), entries...); err != nil {
 		return fmt.Errorf("failed to store container: %w", err)
 	}
 
@@ -67,6 +74,38 @@ func (r *ContainerRepository) GetByID(id string) (*domain.Container, error) {
 	return &container, nil
 }
 
+// GetByDockerID returns the container with a Docker ID, or nil if none is tracked
+func (r *ContainerRepository) GetByDockerID(dockerID string) (*domain.Container, error) {
+	id, err := r.redis.Get(context.Background(), dockerIDKey(dockerID))
+	if errors.Is(err, goredis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+
+	data, err := r.redis.Get(context.Background(), fmt.Sprintf("container:%s", id))
+	if errors.Is(err, goredis.Nil) {
+		return nil, nil // Deleted; the index entry outlived it
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+	var container domain.Container
+	if err := json.Unmarshal([]byte(data), &container); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal container: %w", err)
+	}
+	if container.DockerID != dockerID {
+		return nil, nil
+	}
+	return &container, nil
+}
+
+// dockerIDKey is the key mapping a Docker ID to its container's ID
+func dockerIDKey(dockerID string) string {
+	return fmt.Sprintf("docker:%s", dockerID)
+}
+
 // Delete removes a container
 func (r *ContainerRepository) Delete(id string) error {
 	key := fmt.Sprintf("container:%s", id)
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisIdempotencyStore implements domain.IdempotencyStore with one expiring key per record
+type RedisIdempotencyStore struct {
+	client *redis.Client
+}
+
+// NewRedisIdempotencyStore creates a new Redis idempotency store
+func NewRedisIdempotencyStore(client *redis.Client) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{client: client}
+}
+
+func idempotencyKey(tenantID, key string) string {
+	return fmt.Sprintf("idempotency:%s:%s", tenantID, key)
+}
+
+// Reserve stores rec unless the key already holds a record, which is returned instead
+func (s *RedisIdempotencyStore) Reserve(ctx context.Context, tenantID, key string, rec *domain.IdempotencyRecord, ttl time.Duration) (*domain.IdempotencyRecord, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+	// The record found may expire before it is read; then try to reserve again
+	for i := 0; i < 3; i++ {
+		ok, err := s.client.SetNX(ctx, idempotencyKey(tenantID, key), data, ttl).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+		}
+		if ok {
+			return nil, nil
+		}
+		existing, err := s.client.Get(ctx, idempotencyKey(tenantID, key)).Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get idempotency record: %w", err)
+		}
+		var found domain.IdempotencyRecord
+		if err := json.Unmarshal(existing, &found); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal idempotency record: %w", err)
+		}
+		return &found, nil
+	}
+	return nil, fmt.Errorf("failed to reserve idempotency key: record keeps expiring")
+}
+
+// Save replaces the record under the key
+func (s *RedisIdempotencyStore) Save(ctx context.Context, tenantID, key string, rec *domain.IdempotencyRecord, ttl time.Duration) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+	if err := s.client.Set(ctx, idempotencyKey(tenantID, key), data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save idempotency record: %w", err)
+	}
+	return nil
+}
+
+// Release deletes the record under the key
+func (s *RedisIdempotencyStore) Release(ctx context.Context, tenantID, key string) error {
+	if err := s.client.Del(ctx, idempotencyKey(tenantID, key)).Err(); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+)
+
+// testIdempotencyStore runs the behaviour every IdempotencyStore must have
+func testIdempotencyStore(t *testing.T, store domain.IdempotencyStore) {
+	t.Helper()
+	ctx := context.Background()
+	pending := &domain.IdempotencyRecord{RequestHash: "h1"}
+
+	if existing, err := store.Reserve(ctx, "t1", "k1", pending, time.Minute); err != nil || existing != nil {
+		t.Fatalf("expected a free key reserved, got %+v %v", existing, err)
+	}
+	existing, err := store.Reserve(ctx, "t1", "k1", &domain.IdempotencyRecord{RequestHash: "h2"}, time.Minute)
+	if err != nil || existing == nil || existing.RequestHash != "h1" || existing.Completed {
+		t.Fatalf("expected the pending record returned, got %+v %v", existing, err)
+	}
+	// Keys are per tenant
+	if existing, err := store.Reserve(ctx, "t2", "k1", pending, time.Minute); err != nil || existing != nil {
+		t.Fatalf("expected another tenant's key to be free, got %+v %v", existing, err)
+	}
+
+	done := &domain.IdempotencyRecord{
+		RequestHash: "h1", Completed: true, Status: http.StatusCreated,
+		Header: http.Header{"Content-Type": {"application/json"}}, Body: []byte(`{"id":"c1"}`),
+	}
+	if err := store.Save(ctx, "t1", "k1", done, time.Hour); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	existing, err = store.Reserve(ctx, "t1", "k1", pending, time.Minute)
+	if err != nil || existing == nil || !existing.Completed || existing.Status != http.StatusCreated ||
+		string(existing.Body) != `{"id":"c1"}` || existing.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("expected the saved response returned, got %+v %v", existing, err)
+	}
+
+	if err := store.Release(ctx, "t1", "k1"); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if existing, err := store.Reserve(ctx, "t1", "k1", pending, 50*time.Millisecond); err != nil || existing != nil {
+		t.Fatalf("expected a released key free again, got %+v %v", existing, err)
+	}
+
+	// Records expire with their TTL
+	time.Sleep(100 * time.Millisecond)
+	if existing, err := store.Reserve(ctx, "t1", "k1", pending, time.Minute); err != nil || existing != nil {
+		t.Fatalf("expected an expired key free again, got %+v %v", existing, err)
+	}
+}
+
+func TestRedisIdempotencyStore(t *testing.T) {
+	_, client := newFakeRedis(t)
+	testIdempotencyStore(t, NewRedisIdempotencyStore(client.Raw()))
+}
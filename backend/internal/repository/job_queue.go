@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys of the job queue
+const (
+	jobStreamKey  = "jobs:stream"  // Jobs ready to run; claimed through the consumer group
+	jobGroup      = "workers"      // Consumer group every server reads the stream through
+	jobDelayedKey = "jobs:delayed" // Jobs waiting to be retried, scored by when they are due (unix ms)
+	jobDeadKey    = "jobs:dead"    // Dead-lettered jobs, newest first
+	jobIDsKey     = "jobs:ids"     // IDs of every job that is queued, running or waiting to retry
+	jobDeadMax    = 1000           // Dead-lettered jobs kept
+	jobClaimBatch = 100
+)
+
+// enqueueScript adds a job to the stream unless its ID is still outstanding
+var enqueueScript = redis.NewScript(`
+if redis.call('SADD', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('XADD', KEYS[2], '*', 'job', ARGV[2])
+return 1
+`)
+
+// promoteScript moves retries that are due from the delayed set back to the stream
+var promoteScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 100)
+for _, job in ipairs(due) do
+	redis.call('XADD', KEYS[2], '*', 'job', job)
+	redis.call('ZREM', KEYS[1], job)
+end
+return #due
+`)
+
+// RedisJobQueue implements domain.JobQueue with a Redis stream and consumer group.
+// A job stays in its consumer's pending list until it is acknowledged, retried or
+// dead-lettered, so jobs of a server that stops mid-way can be reclaimed.
+type RedisJobQueue struct {
+	client     *redis.Client
+	logger     *slog.Logger
+	groupReady atomic.Bool
+}
+
+// NewRedisJobQueue creates a new Redis Streams job queue
+func NewRedisJobQueue(client *redis.Client, logger *slog.Logger) *RedisJobQueue {
+	return &RedisJobQueue{client: client, logger: logger}
+}
+
+// Enqueue adds a job; a job whose ID is still outstanding is not added again
+func (q *RedisJobQueue) Enqueue(ctx context.Context, job *domain.Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	if err := enqueueScript.Run(ctx, q.client, []string{jobIDsKey, jobStreamKey}, job.ID, data).Err(); err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return nil
+}
+
+// Dequeue claims the next job for consumer, waiting up to block for one
+func (q *RedisJobQueue) Dequeue(ctx context.Context, consumer string, block time.Duration) (*domain.Job, error) {
+	if err := q.ensureGroup(ctx); err != nil {
+		return nil, err
+	}
+	if err := promoteScript.Run(ctx, q.client, []string{jobDelayedKey, jobStreamKey}, time.Now().UnixMilli()).Err(); err != nil {
+		return nil, fmt.Errorf("failed to promote due retries: %w", err)
+	}
+
+	streams, err := q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    jobGroup,
+		Consumer: consumer,
+		Streams:  []string{jobStreamKey, ">"},
+		Count:    1,
+		Block:    block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		q.checkGroup(err)
+		return nil, fmt.Errorf("failed to read jobs: %w", err)
+	}
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			job, err := q.decode(msg)
+			if err != nil {
+				// It could never run; keep it from being reclaimed over and over
+				q.client.XAck(ctx, jobStreamKey, jobGroup, msg.ID)
+				return nil, err
+			}
+			return job, nil
+		}
+	}
+	return nil, nil
+}
+
+// Ack removes a finished job
+func (q *RedisJobQueue) Ack(ctx context.Context, job *domain.Job) error {
+	_, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SRem(ctx, jobIDsKey, job.ID)
+		pipe.XAck(ctx, jobStreamKey, jobGroup, job.Receipt)
+		pipe.XDel(ctx, jobStreamKey, job.Receipt)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge job: %w", err)
+	}
+	return nil
+}
+
+// Retry parks the job in the delayed set until at; Dequeue moves it back once it is due
+func (q *RedisJobQueue) Retry(ctx context.Context, job *domain.Job, at time.Time) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, jobDelayedKey, redis.Z{Score: float64(at.UnixMilli()), Member: data})
+		pipe.XAck(ctx, jobStreamKey, jobGroup, job.Receipt)
+		pipe.XDel(ctx, jobStreamKey, job.Receipt)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to schedule job retry: %w", err)
+	}
+	return nil
+}
+
+// DeadLetter moves the job to the dead-letter list, which keeps the latest jobDeadMax jobs
+func (q *RedisJobQueue) DeadLetter(ctx context.Context, job *domain.Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SRem(ctx, jobIDsKey, job.ID)
+		pipe.LPush(ctx, jobDeadKey, data)
+		pipe.LTrim(ctx, jobDeadKey, 0, jobDeadMax-1)
+		pipe.XAck(ctx, jobStreamKey, jobGroup, job.Receipt)
+		pipe.XDel(ctx, jobStreamKey, job.Receipt)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter job: %w", err)
+	}
+	return nil
+}
+
+// Reclaim re-adds jobs left in a consumer's pending list for at least minIdle to the stream
+func (q *RedisJobQueue) Reclaim(ctx context.Context, consumer string, minIdle time.Duration) (int, error) {
+	if err := q.ensureGroup(ctx); err != nil {
+		return 0, err
+	}
+	// Claimed under the consumer being reclaimed, or a name no server uses
+	claimant := consumer
+	if claimant == "" {
+		claimant = "reclaim"
+	}
+
+	reclaimed := 0
+	for {
+		pending, err := q.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream:   jobStreamKey,
+			Group:    jobGroup,
+			Idle:     minIdle,
+			Start:    "-",
+			End:      "+",
+			Count:    jobClaimBatch,
+			Consumer: consumer,
+		}).Result()
+		if err != nil {
+			q.checkGroup(err)
+			return reclaimed, fmt.Errorf("failed to list pending jobs: %w", err)
+		}
+		if len(pending) == 0 {
+			return reclaimed, nil
+		}
+		ids := make([]string, len(pending))
+		for i, p := range pending {
+			ids[i] = p.ID
+		}
+
+		// Claiming first makes sure no other server reclaims the same jobs
+		msgs, err := q.client.XClaim(ctx, &redis.XClaimArgs{
+			Stream:   jobStreamKey,
+			Group:    jobGroup,
+			Consumer: claimant,
+			MinIdle:  minIdle,
+			Messages: ids,
+		}).Result()
+		if err != nil {
+			return reclaimed, fmt.Errorf("failed to claim pending jobs: %w", err)
+		}
+		for _, msg := range msgs {
+			job, err := q.decode(msg)
+			if err != nil {
+				q.logger.Error("dropping undecodable job", slog.String("message_id", msg.ID), slog.String("error", err.Error()))
+				q.client.XAck(ctx, jobStreamKey, jobGroup, msg.ID)
+				continue
+			}
+			job.Attempts++
+			job.LastError = "abandoned by a stopped worker"
+			data, err := json.Marshal(job)
+			if err != nil {
+				return reclaimed, fmt.Errorf("failed to marshal job: %w", err)
+			}
+			_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.XAdd(ctx, &redis.XAddArgs{Stream: jobStreamKey, Values: map[string]interface{}{"job": data}})
+				pipe.XAck(ctx, jobStreamKey, jobGroup, msg.ID)
+				pipe.XDel(ctx, jobStreamKey, msg.ID)
+				return nil
+			})
+			if err != nil {
+				return reclaimed, fmt.Errorf("failed to requeue job: %w", err)
+			}
+			reclaimed++
+		}
+		if len(pending) < jobClaimBatch || len(msgs) == 0 {
+			return reclaimed, nil
+		}
+	}
+}
+
+// ListDead returns the most recently dead-lettered jobs
+func (q *RedisJobQueue) ListDead(ctx context.Context, limit int) ([]*domain.Job, error) {
+	items, err := q.client.LRange(ctx, jobDeadKey, 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead jobs: %w", err)
+	}
+	jobs := make([]*domain.Job, 0, len(items))
+	for _, item := range items {
+		var job domain.Job
+		if err := json.Unmarshal([]byte(item), &job); err != nil {
+			q.logger.Warn("skipping undecodable dead job", slog.String("error", err.Error()))
+			continue
+		}
+		jobs = append(jobs, &job)
+	}
+	return jobs, nil
+}
+
+// ensureGroup creates the stream and consumer group on first use
+func (q *RedisJobQueue) ensureGroup(ctx context.Context) error {
+	if q.groupReady.Load() {
+		return nil
+	}
+	err := q.client.XGroupCreateMkStream(ctx, jobStreamKey, jobGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create job consumer group: %w", err)
+	}
+	q.groupReady.Store(true)
+	return nil
+}
+
+// checkGroup notices a consumer group lost with the Redis data so it is created again
+func (q *RedisJobQueue) checkGroup(err error) {
+	if strings.HasPrefix(err.Error(), "NOGROUP") {
+		q.groupReady.Store(false)
+	}
+}
+
+func (q *RedisJobQueue) decode(msg redis.XMessage) (*domain.Job, error) {
+	raw, ok := msg.Values["job"].(string)
+	if !ok {
+		return nil, fmt.Errorf("job message %s has no job field", msg.ID)
+	}
+	var job domain.Job
+	if err := json.Unmarshal([]byte(raw), &job); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job %s: %w", msg.ID, err)
+	}
+	job.Receipt = msg.ID
+	return &job, nil
+}
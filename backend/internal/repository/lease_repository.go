@@ -3,12 +3,14 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/aryan0dhankhar/containerlease/internal/domain"
 	"github.com/aryan0dhankhar/containerlease/internal/infrastructure/redis"
+	goredis "github.com/redis/go-redis/v9"
 )
 
 // LeaseRepository implements domain.LeaseRepository using Redis
@@ -69,6 +71,70 @@ func (r *LeaseRepository) DeleteLease(leaseKey string) error {
 	return nil
 }
 
+// CompareAndExtendLease rewrites the lease and moves the stored container's expiry, and its
+// Docker ID index, to the container's, provided the lease still expires at expiryTime. The container record is re-read
+// inside the transaction and only its expiry changes, so it never reverts a concurrent save;
+// both keys are watched, so the write fails if either changes between the read and the write.
+func (r *LeaseRepository) CompareAndExtendLease(lease *domain.Lease, container *domain.Container, expiryTime time.Time) error {
+	ctx := context.Background()
+	containerKey := fmt.Sprintf("container:%s", container.ID)
+	leaseData, err := json.Marshal(lease)
+	if err != nil {
+		return fmt.Errorf("failed to marshal lease: %w", err)
+	}
+
+	err = r.redis.Raw().Watch(ctx, func(tx *goredis.Tx) error {
+		data, err := tx.Get(ctx, lease.LeaseKey).Bytes()
+		if err != nil {
+			return fmt.Errorf("failed to get lease: %w", err)
+		}
+		var stored domain.Lease
+		if err := json.Unmarshal(data, &stored); err != nil {
+			return fmt.Errorf("failed to unmarshal lease: %w", err)
+		}
+		if !stored.ExpiryTime.Equal(expiryTime) {
+			return domain.ErrLeaseChanged
+		}
+
+		data, err = tx.Get(ctx, containerKey).Bytes()
+		if err != nil {
+			return fmt.Errorf("failed to get container: %w", err)
+		}
+		var current domain.Container
+		if err := json.Unmarshal(data, &current); err != nil {
+			return fmt.Errorf("failed to unmarshal container: %w", err)
+		}
+		current.ExpiryAt = container.ExpiryAt
+		containerData, err := json.Marshal(&current)
+		if err != nil {
+			return fmt.Errorf("failed to marshal container: %w", err)
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+			pipe.Set(ctx, lease.LeaseKey, string(leaseData), max(time.Until(lease.ExpiryTime), time.Second))
+			pipe.Set(ctx, containerKey, string(containerData), max(time.Until(current.ExpiryAt), time.Second))
+			if current.DockerID != "" {
+				pipe.Set(ctx, dockerIDKey(current.DockerID), current.ID, max(time.Until(current.ExpiryAt), time.Second))
+			}
+			return nil
+		})
+		return err
+	}, lease.LeaseKey, containerKey)
+	if errors.Is(err, goredis.TxFailedErr) || errors.Is(err, domain.ErrLeaseChanged) {
+		return fmt.Errorf("failed to extend lease %s: %w", lease.LeaseKey, domain.ErrLeaseChanged)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extend lease: %w", err)
+	}
+
+	r.logger.Debug("lease extended",
+		slog.String("lease_key", lease.LeaseKey),
+		slog.Time("expiry_time", lease.ExpiryTime),
+		slog.Int("extension_count", lease.ExtensionCount),
+	)
+	return nil
+}
+
 // GetExpiredLeases returns all container IDs with expired leases
 func (r *LeaseRepository) GetExpiredLeases() ([]string, error) {
 	// Get all lease keys
@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/lib/pq"
+)
+
+// PostgresContainerRepository implements domain.ContainerRepository using PostgreSQL.
+// Unlike the Redis repository, records are never expired: terminated containers
+// remain available through GetByID and ListHistoryByTenant.
+type PostgresContainerRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewPostgresContainerRepository creates a new container repository
+func NewPostgresContainerRepository(db *sql.DB, logger *slog.Logger) *PostgresContainerRepository {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &PostgresContainerRepository{db: db, logger: logger}
+}
+
+const containerColumns = `
+	id, docker_id, tenant_id, image_type, status, cpu_milli, memory_mb,
+	created_at, expiry_at, cost, error_message, volume_id, volume_size,
+	restart_count, last_failure_time, failure_reason, max_restarts, log_demo,
+	cost_accrued_at, billed_ms, preset, ports, image, image_digest,
+	entrypoint, command, env, working_dir, init_script, init_status, init_exit_code, init_output,
+	security_profile, egress, paused_at, node_id, node_labels, local_image,
+	uploaded_bytes, terminating_at
+`
+
+// Save inserts or updates a container
+func (r *PostgresContainerRepository) Save(container *domain.Container) error {
+	query := `
+		INSERT INTO containers (` + containerColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24,
+			$25, $26, $27, $28, $29, $30, $31, $32,
+			$33, $34, $35, $36, $37, $38,
+			$39, $40)
+		ON CONFLICT (id) DO UPDATE SET
+			docker_id = EXCLUDED.docker_id,
+			status = EXCLUDED.status,
+			cpu_milli = EXCLUDED.cpu_milli,
+			memory_mb = EXCLUDED.memory_mb,
+			expiry_at = EXCLUDED.expiry_at,
+			cost = EXCLUDED.cost,
+			error_message = EXCLUDED.error_message,
+			volume_id = EXCLUDED.volume_id,
+			volume_size = EXCLUDED.volume_size,
+			restart_count = EXCLUDED.restart_count,
+			last_failure_time = EXCLUDED.last_failure_time,
+			failure_reason = EXCLUDED.failure_reason,
+			max_restarts = EXCLUDED.max_restarts,
+			log_demo = EXCLUDED.log_demo,
+			cost_accrued_at = EXCLUDED.cost_accrued_at,
+			billed_ms = EXCLUDED.billed_ms,
+			preset = EXCLUDED.preset,
+			ports = EXCLUDED.ports,
+			image = EXCLUDED.image,
+			image_digest = EXCLUDED.image_digest,
+			init_status = EXCLUDED.init_status,
+			init_exit_code = EXCLUDED.init_exit_code,
+			init_output = EXCLUDED.init_output,
+			paused_at = EXCLUDED.paused_at,
+			node_id = EXCLUDED.node_id,
+			uploaded_bytes = EXCLUDED.uploaded_bytes,
+			terminating_at = EXCLUDED.terminating_at
+	`
+	env, err := json.Marshal(container.Env)
+	if err != nil {
+		return fmt.Errorf("failed to encode container env: %w", err)
+	}
+	var nodeLabels []byte
+	if len(container.NodeLabels) > 0 {
+		if nodeLabels, err = json.Marshal(container.NodeLabels); err != nil {
+			return fmt.Errorf("failed to encode container node labels: %w", err)
+		}
+	}
+	_, err = r.db.Exec(query,
+		container.ID,
+		nullString(container.DockerID),
+		container.TenantID,
+		container.ImageType,
+		container.Status,
+		container.CPUMilli,
+		container.MemoryMB,
+		container.CreatedAt,
+		container.ExpiryAt,
+		container.Cost,
+		nullString(container.Error),
+		nullString(container.VolumeID),
+		container.VolumeSize,
+		container.RestartCount,
+		nullTime(container.LastFailureTime),
+		nullString(container.FailureReason),
+		container.MaxRestarts,
+		container.LogDemo,
+		nullTime(container.CostAccruedAt),
+		container.BilledDuration.Milliseconds(),
+		nullString(container.Preset),
+		pq.Array(toInt64s(container.Ports)),
+		nullString(container.Image),
+		nullString(container.ImageDigest),
+		pq.Array(container.Entrypoint),
+		pq.Array(container.Command),
+		env,
+		nullString(container.WorkingDir),
+		nullString(container.InitScript),
+		nullString(container.InitStatus),
+		container.InitExitCode,
+		nullString(container.InitOutput),
+		nullString(container.SecurityProfile),
+		nullString(container.Egress),
+		nullTime(container.PausedAt),
+		nullString(container.NodeID),
+		nodeLabels,
+		container.LocalImage,
+		container.UploadedBytes,
+		nullTime(container.TerminatingAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store container: %w", err)
+	}
+
+	r.logger.Debug("container saved", slog.String("container_id", container.ID))
+	return nil
+}
+
+// GetByID retrieves a container by ID, including terminated containers
+func (r *PostgresContainerRepository) GetByID(id string) (*domain.Container, error) {
+	query := `SELECT ` + containerColumns + ` FROM containers WHERE id = $1`
+	c, err := scanContainer(r.db.QueryRow(query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("container not found")
+		}
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+	return c, nil
+}
+
+// GetByDockerID returns the container with a Docker ID, or nil if none is tracked. Docker IDs
+// are not reused, but the newest record wins should two ever share one.
+func (r *PostgresContainerRepository) GetByDockerID(dockerID string) (*domain.Container, error) {
+	query := `SELECT ` + containerColumns + ` FROM containers WHERE docker_id = $1 ORDER BY created_at DESC LIMIT 1`
+	c, err := scanContainer(r.db.QueryRow(query, dockerID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+	return c, nil
+}
+
+// Delete removes a container record permanently
+func (r *PostgresContainerRepository) Delete(id string) error {
+	if _, err := r.db.Exec(`DELETE FROM containers WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete container: %w", err)
+	}
+	r.logger.Debug("container deleted", slog.String("container_id", id))
+	return nil
+}
+
+// List returns live containers and recently terminated ones still inside their retention window,
+// matching what the Redis repository exposes
+func (r *PostgresContainerRepository) List() ([]*domain.Container, error) {
+	query := `
+		SELECT ` + containerColumns + ` FROM containers
+		WHERE status <> 'terminated' OR expiry_at > NOW()
+		ORDER BY created_at DESC
+	`
+	return r.queryContainers(query)
+}
+
+// ListByTenant returns a tenant's live and recently terminated containers
+func (r *PostgresContainerRepository) ListByTenant(tenantID string) ([]*domain.Container, error) {
+	query := `
+		SELECT ` + containerColumns + ` FROM containers
+		WHERE tenant_id = $1 AND (status <> 'terminated' OR expiry_at > NOW())
+		ORDER BY created_at DESC
+	`
+	return r.queryContainers(query, tenantID)
+}
+
+// ListHistoryByTenant returns every container a tenant has ever provisioned
+func (r *PostgresContainerRepository) ListHistoryByTenant(tenantID string) ([]*domain.Container, error) {
+	query := `
+		SELECT ` + containerColumns + ` FROM containers
+		WHERE tenant_id = $1
+		ORDER BY created_at DESC
+	`
+	return r.queryContainers(query, tenantID)
+}
+
+func (r *PostgresContainerRepository) queryContainers(query string, args ...interface{}) ([]*domain.Container, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*domain.Container
+	for rows.Next() {
+		c, err := scanContainer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan container: %w", err)
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanContainer(row rowScanner) (*domain.Container, error) {
+	var (
+		c               domain.Container
+		dockerID        sql.NullString
+		errorMessage    sql.NullString
+		volumeID        sql.NullString
+		lastFailureTime sql.NullTime
+		failureReason   sql.NullString
+		logDemo         sql.NullBool
+		costAccruedAt   sql.NullTime
+		billedMS        int64
+		preset          sql.NullString
+		ports           []int64
+		image           sql.NullString
+		imageDigest     sql.NullString
+		env             []byte
+		workingDir      sql.NullString
+		initScript      sql.NullString
+		initStatus      sql.NullString
+		initOutput      sql.NullString
+		securityProfile sql.NullString
+		egress          sql.NullString
+		pausedAt        sql.NullTime
+		nodeID          sql.NullString
+		nodeLabels      []byte
+		terminatingAt   sql.NullTime
+	)
+	err := row.Scan(
+		&c.ID, &dockerID, &c.TenantID, &c.ImageType, &c.Status, &c.CPUMilli, &c.MemoryMB,
+		&c.CreatedAt, &c.ExpiryAt, &c.Cost, &errorMessage, &volumeID, &c.VolumeSize,
+		&c.RestartCount, &lastFailureTime, &failureReason, &c.MaxRestarts, &logDemo,
+		&costAccruedAt, &billedMS, &preset, pq.Array(&ports), &image, &imageDigest,
+		pq.Array(&c.Entrypoint), pq.Array(&c.Command), &env, &workingDir, &initScript, &initStatus, &c.InitExitCode, &initOutput,
+		&securityProfile, &egress, &pausedAt, &nodeID, &nodeLabels, &c.LocalImage,
+		&c.UploadedBytes, &terminatingAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	c.DockerID = dockerID.String
+	c.Error = errorMessage.String
+	c.VolumeID = volumeID.String
+	c.LastFailureTime = lastFailureTime.Time
+	c.FailureReason = failureReason.String
+	c.LogDemo = logDemo.Bool
+	c.CostAccruedAt = costAccruedAt.Time
+	c.BilledDuration = time.Duration(billedMS) * time.Millisecond
+	c.Preset = preset.String
+	c.Image = image.String
+	c.ImageDigest = imageDigest.String
+	c.WorkingDir = workingDir.String
+	c.InitScript = initScript.String
+	c.InitStatus = initStatus.String
+	c.InitOutput = initOutput.String
+	c.SecurityProfile = securityProfile.String
+	c.Egress = egress.String
+	c.PausedAt = pausedAt.Time
+	c.NodeID = nodeID.String
+	c.TerminatingAt = terminatingAt.Time
+	if len(env) > 0 {
+		if err := json.Unmarshal(env, &c.Env); err != nil {
+			return nil, fmt.Errorf("failed to decode container env: %w", err)
+		}
+	}
+	if len(nodeLabels) > 0 {
+		if err := json.Unmarshal(nodeLabels, &c.NodeLabels); err != nil {
+			return nil, fmt.Errorf("failed to decode container node labels: %w", err)
+		}
+	}
+	for _, p := range ports {
+		c.Ports = append(c.Ports, int(p))
+	}
+	return &c, nil
+}
+
+// nullString maps empty strings to SQL NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullTime maps zero times to SQL NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func toInt64s(v []int) []int64 {
+	out := make([]int64, len(v))
+	for i, n := range v {
+		out[i] = int64(n)
+	}
+	return out
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+)
+
+// fakeResult is what fakeSQL answers a statement with
+type fakeResult struct {
+	rows     [][]driver.Value
+	affected int64
+	err      error
+}
+
+// fakeSQL is a database/sql driver that hands every statement to a handler, so tests can
+// script the database without a Postgres server. Arguments arrive as the driver values
+// lib/pq would send, and rows are scanned the way lib/pq's would be.
+type fakeSQL struct {
+	mu      sync.Mutex
+	handler func(query string, args []driver.Value) fakeResult
+	queries []string
+}
+
+func newFakeSQL(t *testing.T, handler func(query string, args []driver.Value) fakeResult) (*fakeSQL, *sql.DB) {
+	f := &fakeSQL{handler: handler}
+	db := sql.OpenDB(f)
+	t.Cleanup(func() { db.Close() })
+	return f, db
+}
+
+func (f *fakeSQL) Connect(ctx context.Context) (driver.Conn, error) { return &fakeSQLConn{f}, nil }
+func (f *fakeSQL) Driver() driver.Driver                            { return nil }
+
+func (f *fakeSQL) run(query string, named []driver.NamedValue) fakeResult {
+	args := make([]driver.Value, len(named))
+	for i, a := range named {
+		args[i] = a.Value
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries = append(f.queries, strings.Join(strings.Fields(query), " "))
+	return f.handler(query, args)
+}
+
+type fakeSQLConn struct{ f *fakeSQL }
+
+func (c *fakeSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepare is not supported")
+}
+func (c *fakeSQLConn) Close() error              { return nil }
+func (c *fakeSQLConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *fakeSQLConn) Commit() error             { c.f.run("COMMIT", nil); return nil }
+func (c *fakeSQLConn) Rollback() error           { return nil }
+
+func (c *fakeSQLConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	res := c.f.run(query, args)
+	if res.err != nil {
+		return nil, res.err
+	}
+	return driver.RowsAffected(res.affected), nil
+}
+
+func (c *fakeSQLConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	res := c.f.run(query, args)
+	if res.err != nil {
+		return nil, res.err
+	}
+	return &fakeRows{rows: res.rows}, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return []string{"column"}
+	}
+	cols := make([]string, len(r.rows[0]))
+	for i := range cols {
+		cols[i] = fmt.Sprintf("column%d", i)
+	}
+	return cols
+}
+func (r *fakeRows) Close() error { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// fakeTable keeps the rows written by INSERT statements keyed by their first column, and
+// returns them to SELECTs filtering on it. It relies on the repositories selecting the
+// same columns, in the same order, as they insert.
+type fakeTable struct {
+	rows map[string][]driver.Value
+}
+
+func (t *fakeTable) handle(query string, args []driver.Value) fakeResult {
+	switch {
+	case strings.Contains(query, "INSERT INTO"):
+		t.rows[args[0].(string)] = args
+		return fakeResult{affected: 1}
+	case strings.Contains(query, "SELECT") && len(args) == 1:
+		if row, ok := t.rows[args[0].(string)]; ok {
+			return fakeResult{rows: [][]driver.Value{row}}
+		}
+		return fakeResult{}
+	}
+	return fakeResult{err: fmt.Errorf("unexpected statement: %s", query)}
+}
+
+func TestPostgresContainerRowMapping(t *testing.T) {
+	table := &fakeTable{rows: map[string][]driver.Value{}}
+	_, db := newFakeSQL(t, table.handle)
+	repo := NewPostgresContainerRepository(db, slog.Default())
+	at := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+
+	full := &domain.Container{
+		ID: "c1", DockerID: "docker-1", TenantID: "t1", ImageType: "ubuntu", Status: "running",
+		CPUMilli: 500, MemoryMB: 512, CreatedAt: at, ExpiryAt: at.Add(time.Hour), Cost: 0.25,
+		Error: "oom", VolumeID: "vol-1", VolumeSize: 100, RestartCount: 2, LastFailureTime: at.Add(time.Minute),
+		FailureReason: "exit 137", MaxRestarts: 3, LogDemo: true, CostAccruedAt: at.Add(2 * time.Minute),
+		BilledDuration: 90 * time.Second, Preset: "small", Ports: []int{8080, 8888},
+		Image: "docker.io/library/ubuntu:22.04", ImageDigest: "sha256:abc",
+		Entrypoint: []string{"/bin/sh", "-c"}, Command: []string{"sleep", "infinity"},
+		Env:        []domain.EnvVar{{Name: "A", Value: "1"}, {Name: "TOKEN", Value: "s3cret", Secret: true}},
+		WorkingDir: "/work", InitScript: "echo hi", InitStatus: "succeeded", InitExitCode: 0, InitOutput: "hi",
+		SecurityProfile: "restricted", Egress: "internal", PausedAt: at.Add(3 * time.Minute),
+		NodeID: "n2", NodeLabels: map[string]string{"ssd": "true"}, LocalImage: true, UploadedBytes: 4096,
+		TerminatingAt: at.Add(4 * time.Minute),
+	}
+	bare := &domain.Container{ID: "c2", TenantID: "t1", ImageType: "alpine", Status: "pending", CreatedAt: at, ExpiryAt: at}
+
+	for _, want := range []*domain.Container{full, bare} {
+		if err := repo.Save(want); err != nil {
+			t.Fatalf("save %s: %v", want.ID, err)
+		}
+		got, err := repo.GetByID(want.ID)
+		if err != nil {
+			t.Fatalf("get %s: %v", want.ID, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("row mapping changed the container:\n got %+v\nwant %+v", got, want)
+		}
+	}
+
+	// Optional fields are stored as NULL, not as empty values
+	if row := table.rows["c2"]; row[1] != nil || row[10] != nil || row[14] != nil || row[35] != nil || row[39] != nil {
+		t.Fatalf("expected NULLs for the unset docker ID, error, failure time, node and teardown time, got %v", row)
+	}
+
+	if _, err := repo.GetByID("missing"); err == nil || err.Error() != "container not found" {
+		t.Fatalf("expected container not found, got %v", err)
+	}
+}
+
+func TestPostgresContainerHistoryQueries(t *testing.T) {
+	fake, db := newFakeSQL(t, func(query string, args []driver.Value) fakeResult { return fakeResult{} })
+	repo := NewPostgresContainerRepository(db, slog.Default())
+
+	_, _ = repo.ListByTenant("t1")
+	_, _ = repo.ListHistoryByTenant("t1")
+	if !strings.Contains(fake.queries[0], "status <> 'terminated'") {
+		t.Fatalf("expected the live list to leave out old terminated containers: %s", fake.queries[0])
+	}
+	if strings.Contains(fake.queries[1], "terminated") || !strings.Contains(fake.queries[1], "WHERE tenant_id = $1") {
+		t.Fatalf("expected the history to include every container of the tenant: %s", fake.queries[1])
+	}
+}
+
+func TestPostgresContainerGetByDockerID(t *testing.T) {
+	fake, db := newFakeSQL(t, func(query string, args []driver.Value) fakeResult { return fakeResult{} })
+	repo := NewPostgresContainerRepository(db, slog.Default())
+
+	c, err := repo.GetByDockerID("docker-1")
+	if err != nil || c != nil {
+		t.Fatalf("expected no container for an untracked Docker ID, got %+v %v", c, err)
+	}
+	if !strings.Contains(fake.queries[0], "WHERE docker_id = $1") {
+		t.Fatalf("expected a lookup on the indexed docker_id column: %s", fake.queries[0])
+	}
+}
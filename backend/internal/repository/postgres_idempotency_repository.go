@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+)
+
+// idempotencyPurgeInterval is how often Reserve deletes expired records
+const idempotencyPurgeInterval = 10 * time.Minute
+
+// PostgresIdempotencyStore implements domain.IdempotencyStore with an idempotency_keys
+// table, for deployments without Redis
+type PostgresIdempotencyStore struct {
+	db         *sql.DB
+	lastPurged atomic.Int64
+}
+
+// NewPostgresIdempotencyStore creates a new Postgres idempotency store
+func NewPostgresIdempotencyStore(db *sql.DB) *PostgresIdempotencyStore {
+	return &PostgresIdempotencyStore{db: db}
+}
+
+// Reserve stores rec unless the key holds a record that has not expired, which is returned instead
+func (s *PostgresIdempotencyStore) Reserve(ctx context.Context, tenantID, key string, rec *domain.IdempotencyRecord, ttl time.Duration) (*domain.IdempotencyRecord, error) {
+	s.purge(ctx)
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+	for i := 0; i < 3; i++ {
+		var reserved string
+		err := s.db.QueryRowContext(ctx, `
+			INSERT INTO idempotency_keys (tenant_id, key, record, expires_at)
+			VALUES ($1, $2, $3, NOW() + $4 * INTERVAL '1 millisecond')
+			ON CONFLICT (tenant_id, key) DO UPDATE
+				SET record = EXCLUDED.record, expires_at = EXCLUDED.expires_at
+				WHERE idempotency_keys.expires_at <= NOW()
+			RETURNING key
+		`, tenantID, key, data, ttl.Milliseconds()).Scan(&reserved)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+		}
+
+		var existing []byte
+		err = s.db.QueryRowContext(ctx, `
+			SELECT record FROM idempotency_keys
+			WHERE tenant_id = $1 AND key = $2 AND expires_at > NOW()
+		`, tenantID, key).Scan(&existing)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Expired or released since the insert; try to reserve again
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get idempotency record: %w", err)
+		}
+		var found domain.IdempotencyRecord
+		if err := json.Unmarshal(existing, &found); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal idempotency record: %w", err)
+		}
+		return &found, nil
+	}
+	return nil, fmt.Errorf("failed to reserve idempotency key: record keeps expiring")
+}
+
+// Save replaces the record under the key
+func (s *PostgresIdempotencyStore) Save(ctx context.Context, tenantID, key string, rec *domain.IdempotencyRecord, ttl time.Duration) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (tenant_id, key, record, expires_at)
+		VALUES ($1, $2, $3, NOW() + $4 * INTERVAL '1 millisecond')
+		ON CONFLICT (tenant_id, key) DO UPDATE
+			SET record = EXCLUDED.record, expires_at = EXCLUDED.expires_at
+	`, tenantID, key, data, ttl.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("failed to save idempotency record: %w", err)
+	}
+	return nil
+}
+
+// Release deletes the record under the key
+func (s *PostgresIdempotencyStore) Release(ctx context.Context, tenantID, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE tenant_id = $1 AND key = $2`, tenantID, key)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// purge deletes expired records at most once per idempotencyPurgeInterval; failures are
+// left for the next purge since expired records are ignored anyway
+func (s *PostgresIdempotencyStore) purge(ctx context.Context) {
+	now := time.Now().UnixNano()
+	last := s.lastPurged.Load()
+	if now-last < int64(idempotencyPurgeInterval) || !s.lastPurged.CompareAndSwap(last, now) {
+		return
+	}
+	_, _ = s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+}
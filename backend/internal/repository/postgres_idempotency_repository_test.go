@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+)
+
+// idempotencyTable emulates the idempotency_keys statements of PostgresIdempotencyStore
+type idempotencyTable struct {
+	records map[string][]byte
+	expires map[string]time.Time
+	purges  int
+}
+
+func (tb *idempotencyTable) handle(query string, args []driver.Value) fakeResult {
+	now := time.Now()
+	live := func(id string) bool {
+		_, ok := tb.records[id]
+		return ok && now.Before(tb.expires[id])
+	}
+	switch {
+	case strings.Contains(query, "DELETE FROM idempotency_keys WHERE expires_at"):
+		tb.purges++
+		return fakeResult{}
+	case strings.Contains(query, "DELETE"):
+		delete(tb.records, args[0].(string)+":"+args[1].(string))
+		return fakeResult{affected: 1}
+	case strings.Contains(query, "SELECT record"):
+		id := args[0].(string) + ":" + args[1].(string)
+		if !live(id) {
+			return fakeResult{}
+		}
+		return fakeResult{rows: [][]driver.Value{{tb.records[id]}}}
+	case strings.Contains(query, "INSERT INTO idempotency_keys"):
+		id := args[0].(string) + ":" + args[1].(string)
+		reserve := strings.Contains(query, "RETURNING")
+		if reserve && live(id) {
+			return fakeResult{} // The conflict update's WHERE left the live record alone
+		}
+		tb.records[id] = args[2].([]byte)
+		tb.expires[id] = now.Add(time.Duration(args[3].(int64)) * time.Millisecond)
+		if reserve {
+			return fakeResult{rows: [][]driver.Value{{args[1]}}}
+		}
+		return fakeResult{affected: 1}
+	}
+	return fakeResult{}
+}
+
+func TestPostgresIdempotencyStore(t *testing.T) {
+	table := &idempotencyTable{records: map[string][]byte{}, expires: map[string]time.Time{}}
+	_, db := newFakeSQL(t, table.handle)
+	testIdempotencyStore(t, NewPostgresIdempotencyStore(db))
+
+	// Expired records are purged at most once per interval
+	if table.purges != 1 {
+		t.Fatalf("expected one purge, got %d", table.purges)
+	}
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+)
+
+// Job rows move from ready to running when claimed, back to ready when retried or
+// reclaimed, and to dead when they run out of attempts. Finished jobs are deleted.
+const (
+	jobReady   = "ready"
+	jobRunning = "running"
+	jobDead    = "dead"
+
+	jobPollInterval = 500 * time.Millisecond // How often Dequeue looks for due jobs while blocking
+)
+
+// PostgresJobQueue implements domain.JobQueue with a jobs table; workers claim rows
+// with FOR UPDATE SKIP LOCKED so they never block on each other
+type PostgresJobQueue struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewPostgresJobQueue creates a new Postgres job queue
+func NewPostgresJobQueue(db *sql.DB, logger *slog.Logger) *PostgresJobQueue {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &PostgresJobQueue{db: db, logger: logger}
+}
+
+const jobColumns = `id, kind, payload, attempts, last_error, created_at`
+
+// Enqueue adds a job; a job whose ID is still outstanding is left as it is, a dead one is revived
+func (q *PostgresJobQueue) Enqueue(ctx context.Context, job *domain.Job) error {
+	query := `
+		INSERT INTO jobs (id, kind, payload, status, attempts, last_error, run_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), $7)
+		ON CONFLICT (id) DO UPDATE SET
+			payload = EXCLUDED.payload,
+			status = EXCLUDED.status,
+			attempts = EXCLUDED.attempts,
+			last_error = EXCLUDED.last_error,
+			run_at = EXCLUDED.run_at,
+			claimed_by = NULL,
+			claimed_at = NULL,
+			dead_at = NULL,
+			created_at = EXCLUDED.created_at
+		WHERE jobs.status = $8
+	`
+	_, err := q.db.ExecContext(ctx, query,
+		job.ID, job.Kind, []byte(job.Payload), jobReady, job.Attempts, nullString(job.LastError), job.CreatedAt, jobDead,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return nil
+}
+
+// Dequeue claims the oldest due job for consumer, polling for up to block
+func (q *PostgresJobQueue) Dequeue(ctx context.Context, consumer string, block time.Duration) (*domain.Job, error) {
+	query := `
+		UPDATE jobs SET status = $1, claimed_by = $2, claimed_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE status = $3 AND run_at <= NOW()
+			ORDER BY run_at, created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobColumns
+
+	deadline := time.Now().Add(block)
+	for {
+		job, err := scanJob(q.db.QueryRowContext(ctx, query, jobRunning, consumer, jobReady))
+		if err == nil {
+			return job, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to claim job: %w", err)
+		}
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return nil, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(min(wait, jobPollInterval)):
+		}
+	}
+}
+
+// Ack deletes a finished job
+func (q *PostgresJobQueue) Ack(ctx context.Context, job *domain.Job) error {
+	if _, err := q.db.ExecContext(ctx, `DELETE FROM jobs WHERE id = $1`, job.ID); err != nil {
+		return fmt.Errorf("failed to acknowledge job: %w", err)
+	}
+	return nil
+}
+
+// Retry makes the job ready again from at
+func (q *PostgresJobQueue) Retry(ctx context.Context, job *domain.Job, at time.Time) error {
+	query := `
+		UPDATE jobs SET status = $1, attempts = $2, last_error = $3, run_at = $4, claimed_by = NULL, claimed_at = NULL
+		WHERE id = $5
+	`
+	if _, err := q.db.ExecContext(ctx, query, jobReady, job.Attempts, nullString(job.LastError), at, job.ID); err != nil {
+		return fmt.Errorf("failed to schedule job retry: %w", err)
+	}
+	return nil
+}
+
+// DeadLetter marks the job dead; dead jobs are kept until their ID is enqueued again
+func (q *PostgresJobQueue) DeadLetter(ctx context.Context, job *domain.Job) error {
+	query := `
+		UPDATE jobs SET status = $1, attempts = $2, last_error = $3, claimed_by = NULL, claimed_at = NULL, dead_at = NOW()
+		WHERE id = $4
+	`
+	if _, err := q.db.ExecContext(ctx, query, jobDead, job.Attempts, nullString(job.LastError), job.ID); err != nil {
+		return fmt.Errorf("failed to dead-letter job: %w", err)
+	}
+	return nil
+}
+
+// Reclaim makes jobs claimed at least minIdle ago ready again
+func (q *PostgresJobQueue) Reclaim(ctx context.Context, consumer string, minIdle time.Duration) (int, error) {
+	query := `
+		UPDATE jobs SET status = $1, attempts = attempts + 1, last_error = $2, run_at = NOW(), claimed_by = NULL, claimed_at = NULL
+		WHERE status = $3 AND claimed_at <= $4 AND ($5 = '' OR claimed_by = $5)
+	`
+	result, err := q.db.ExecContext(ctx, query, jobReady, "abandoned by a stopped worker", jobRunning, time.Now().Add(-minIdle), consumer)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reclaim jobs: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count reclaimed jobs: %w", err)
+	}
+	return int(n), nil
+}
+
+// ListDead returns the most recently dead-lettered jobs
+func (q *PostgresJobQueue) ListDead(ctx context.Context, limit int) ([]*domain.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE status = $1 ORDER BY dead_at DESC LIMIT $2`
+	rows, err := q.db.QueryContext(ctx, query, jobDead, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*domain.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func scanJob(row rowScanner) (*domain.Job, error) {
+	var job domain.Job
+	var payload []byte
+	var lastError sql.NullString
+	if err := row.Scan(&job.ID, &job.Kind, &payload, &job.Attempts, &lastError, &job.CreatedAt); err != nil {
+		return nil, err
+	}
+	job.Payload = payload
+	job.LastError = lastError.String
+	job.Receipt = job.ID
+	return &job, nil
+}
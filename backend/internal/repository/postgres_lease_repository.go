@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+)
+
+// PostgresLeaseRepository implements domain.LeaseRepository using PostgreSQL
+type PostgresLeaseRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewPostgresLeaseRepository creates a new lease repository
+func NewPostgresLeaseRepository(db *sql.DB, logger *slog.Logger) *PostgresLeaseRepository {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &PostgresLeaseRepository{db: db, logger: logger}
+}
+
+// CreateLease stores a lease, replacing any existing lease for the same key
+func (r *PostgresLeaseRepository) CreateLease(lease *domain.Lease) error {
+	query := `
+		INSERT INTO leases (container_id, lease_key, expiry_time, duration_minutes, created_at, extension_count)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (lease_key) DO UPDATE SET
+			expiry_time = EXCLUDED.expiry_time,
+			duration_minutes = EXCLUDED.duration_minutes,
+			extension_count = EXCLUDED.extension_count
+	`
+	_, err := r.db.Exec(query,
+		lease.ContainerID,
+		lease.LeaseKey,
+		lease.ExpiryTime,
+		lease.DurationMinutes,
+		lease.CreatedAt,
+		lease.ExtensionCount,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store lease: %w", err)
+	}
+
+	r.logger.Debug("lease created", slog.String("lease_key", lease.LeaseKey))
+	return nil
+}
+
+// GetLease retrieves a lease by key
+func (r *PostgresLeaseRepository) GetLease(leaseKey string) (*domain.Lease, error) {
+	query := `
+		SELECT container_id, lease_key, expiry_time, duration_minutes, created_at, extension_count
+		FROM leases WHERE lease_key = $1
+	`
+	var lease domain.Lease
+	err := r.db.QueryRow(query, leaseKey).Scan(
+		&lease.ContainerID,
+		&lease.LeaseKey,
+		&lease.ExpiryTime,
+		&lease.DurationMinutes,
+		&lease.CreatedAt,
+		&lease.ExtensionCount,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("lease not found")
+		}
+		return nil, fmt.Errorf("failed to get lease: %w", err)
+	}
+	return &lease, nil
+}
+
+// DeleteLease removes a lease
+func (r *PostgresLeaseRepository) DeleteLease(leaseKey string) error {
+	if _, err := r.db.Exec(`DELETE FROM leases WHERE lease_key = $1`, leaseKey); err != nil {
+		return fmt.Errorf("failed to delete lease: %w", err)
+	}
+	return nil
+}
+
+// CompareAndExtendLease updates the lease and container expiry in a single transaction,
+// provided the lease still expires at expiryTime
+func (r *PostgresLeaseRepository) CompareAndExtendLease(lease *domain.Lease, container *domain.Container, expiryTime time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`UPDATE leases SET expiry_time = $1, duration_minutes = $2, extension_count = $3 WHERE lease_key = $4 AND expiry_time = $5`,
+		lease.ExpiryTime, lease.DurationMinutes, lease.ExtensionCount, lease.LeaseKey, expiryTime,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to extend lease: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to extend lease: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("failed to extend lease %s: %w", lease.LeaseKey, domain.ErrLeaseChanged)
+	}
+	if _, err := tx.Exec(
+		`UPDATE containers SET expiry_at = $1 WHERE id = $2`,
+		container.ExpiryAt, container.ID,
+	); err != nil {
+		return fmt.Errorf("failed to extend container expiry: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit lease extension: %w", err)
+	}
+
+	r.logger.Debug("lease extended",
+		slog.String("lease_key", lease.LeaseKey),
+		slog.Time("expiry_time", lease.ExpiryTime),
+		slog.Int("extension_count", lease.ExtensionCount),
+	)
+	return nil
+}
+
+// GetExpiredLeases returns all container IDs with expired leases
+func (r *PostgresLeaseRepository) GetExpiredLeases() ([]string, error) {
+	rows, err := r.db.Query(`SELECT container_id FROM leases WHERE expiry_time <= NOW()`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired leases: %w", err)
+	}
+	defer rows.Close()
+
+	var containerIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan lease: %w", err)
+		}
+		containerIDs = append(containerIDs, id)
+	}
+	return containerIDs, rows.Err()
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+)
+
+func TestPostgresLeaseRowMapping(t *testing.T) {
+	leases := map[string][]driver.Value{}
+	_, db := newFakeSQL(t, func(query string, args []driver.Value) fakeResult {
+		switch {
+		case strings.Contains(query, "INSERT INTO leases"):
+			leases[args[1].(string)] = args
+			return fakeResult{affected: 1}
+		case strings.Contains(query, "FROM leases WHERE lease_key"):
+			if row, ok := leases[args[0].(string)]; ok {
+				return fakeResult{rows: [][]driver.Value{row}}
+			}
+			return fakeResult{}
+		case strings.Contains(query, "expiry_time <= NOW()"):
+			return fakeResult{rows: [][]driver.Value{{"c1"}, {"c2"}}}
+		}
+		return fakeResult{affected: 1}
+	})
+	repo := NewPostgresLeaseRepository(db, slog.Default())
+	at := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+
+	want := &domain.Lease{ContainerID: "c1", LeaseKey: "lease:c1", ExpiryTime: at.Add(time.Hour), DurationMinutes: 60, CreatedAt: at, ExtensionCount: 2}
+	if err := repo.CreateLease(want); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	got, err := repo.GetLease("lease:c1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("row mapping changed the lease:\n got %+v\nwant %+v", got, want)
+	}
+	if _, err := repo.GetLease("lease:missing"); err == nil || err.Error() != "lease not found" {
+		t.Fatalf("expected lease not found, got %v", err)
+	}
+
+	expired, err := repo.GetExpiredLeases()
+	if err != nil || !reflect.DeepEqual(expired, []string{"c1", "c2"}) {
+		t.Fatalf("expected the expired container IDs, got %v %v", expired, err)
+	}
+}
+
+func TestPostgresLeaseCompareAndExtend(t *testing.T) {
+	stored := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	fake, db := newFakeSQL(t, func(query string, args []driver.Value) fakeResult {
+		if strings.Contains(query, "UPDATE leases") {
+			if !args[4].(time.Time).Equal(stored) {
+				return fakeResult{}
+			}
+			stored = args[0].(time.Time)
+		}
+		return fakeResult{affected: 1}
+	})
+	repo := NewPostgresLeaseRepository(db, slog.Default())
+	at := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	lease := &domain.Lease{ContainerID: "c1", LeaseKey: "lease:c1", ExpiryTime: at, DurationMinutes: 60, ExtensionCount: 2}
+	container := &domain.Container{ID: "c1", ExpiryAt: at}
+
+	if err := repo.CompareAndExtendLease(lease, container, at.Add(-time.Hour)); !errors.Is(err, domain.ErrLeaseChanged) {
+		t.Fatalf("expected lease changed error, got %v", err)
+	}
+	if len(fake.queries) != 1 || !strings.Contains(fake.queries[0], "expiry_time = $5") {
+		t.Fatalf("expected only the conditional lease update to run, got %v", fake.queries)
+	}
+
+	if err := repo.CompareAndExtendLease(lease, container, time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	if !stored.Equal(at) || fake.queries[len(fake.queries)-1] != "COMMIT" {
+		t.Fatalf("expected the extension committed, got expiry %v after %v", stored, fake.queries)
+	}
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+)
+
+// PostgresQuotaRepository implements domain.QuotaRepository using PostgreSQL
+type PostgresQuotaRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+	// lockSlots caps the connections held by tenant locks at half the pool, so lock
+	// holders always find a connection for the quota queries they run while holding one
+	lockSlots chan struct{}
+}
+
+// NewPostgresQuotaRepository creates a new quota repository
+func NewPostgresQuotaRepository(db *sql.DB, logger *slog.Logger) *PostgresQuotaRepository {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	r := &PostgresQuotaRepository{db: db, logger: logger}
+	if maxOpen := db.Stats().MaxOpenConnections; maxOpen > 0 {
+		r.lockSlots = make(chan struct{}, max(maxOpen/2, 1))
+	}
+	return r
+}
+
+// quotaLockClass namespaces the per-tenant quota advisory locks, keyed by the tenant's hash
+const quotaLockClass int32 = 0x636c71 // "clq"
+
+// tenantLockTimeout bounds the wait for a tenant's quota lock
+const tenantLockTimeout = 10 * time.Second
+
+// LockTenant takes the tenant's quota advisory lock in a transaction, so quota checks
+// on every server see each other's reservations. The lock is transaction-scoped: it is
+// released when the transaction ends, including when its connection is lost.
+func (r *PostgresQuotaRepository) LockTenant(ctx context.Context, tenantID string) (func(), error) {
+	ctx, cancel := context.WithTimeout(ctx, tenantLockTimeout)
+	defer cancel()
+
+	if r.lockSlots != nil {
+		select {
+		case r.lockSlots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to acquire tenant quota lock: %w", ctx.Err())
+		}
+	}
+	releaseSlot := func() {
+		if r.lockSlots != nil {
+			<-r.lockSlots
+		}
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		releaseSlot()
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	// The transaction outlives ctx, which only bounds the wait for the lock
+	tx, err := conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		conn.Close()
+		releaseSlot()
+		return nil, fmt.Errorf("failed to begin tenant quota lock: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, hashtext($2))`, quotaLockClass, tenantID); err != nil {
+		_ = tx.Rollback()
+		conn.Close()
+		releaseSlot()
+		return nil, fmt.Errorf("failed to acquire tenant quota lock: %w", err)
+	}
+
+	unlock := func() {
+		// Nothing is written in the transaction; ending it releases the lock
+		if err := tx.Rollback(); err != nil {
+			r.logger.Warn("failed to release tenant quota lock", slog.String("tenant_id", tenantID), slog.String("error", err.Error()))
+		}
+		conn.Close()
+		releaseSlot()
+	}
+	return unlock, nil
+}
+
+// GetQuota retrieves a tenant's quota, returning nil if none has been set
+func (r *PostgresQuotaRepository) GetQuota(tenantID string) (*domain.TenantQuota, error) {
+	q := &domain.TenantQuota{}
+	query := `
+		SELECT tenant_id, max_containers, max_cpu_milli, max_memory_mb, max_volume_mb, max_snapshots, updated_at
+		FROM tenant_quotas
+		WHERE tenant_id = $1
+	`
+	err := r.db.QueryRow(query, tenantID).Scan(
+		&q.TenantID, &q.MaxContainers, &q.MaxCPUMilli, &q.MaxMemoryMB, &q.MaxVolumeMB, &q.MaxSnapshots, &q.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get quota: %w", err)
+	}
+	return q, nil
+}
+
+// SaveQuota inserts or replaces a tenant's quota
+func (r *PostgresQuotaRepository) SaveQuota(quota *domain.TenantQuota) error {
+	query := `
+		INSERT INTO tenant_quotas (tenant_id, max_containers, max_cpu_milli, max_memory_mb, max_volume_mb, max_snapshots, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			max_containers = EXCLUDED.max_containers,
+			max_cpu_milli = EXCLUDED.max_cpu_milli,
+			max_memory_mb = EXCLUDED.max_memory_mb,
+			max_volume_mb = EXCLUDED.max_volume_mb,
+			max_snapshots = EXCLUDED.max_snapshots,
+			updated_at = NOW()
+		RETURNING updated_at
+	`
+	err := r.db.QueryRow(query,
+		quota.TenantID, quota.MaxContainers, quota.MaxCPUMilli, quota.MaxMemoryMB, quota.MaxVolumeMB, quota.MaxSnapshots,
+	).Scan(&quota.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save quota: %w", err)
+	}
+
+	r.logger.Info("tenant quota updated", slog.String("tenant_id", quota.TenantID))
+	return nil
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestPostgresTenantLockLeavesPoolForQueries(t *testing.T) {
+	_, db := newFakeSQL(t, func(query string, args []driver.Value) fakeResult {
+		return fakeResult{}
+	})
+	db.SetMaxOpenConns(2)
+	repo := NewPostgresQuotaRepository(db, slog.Default())
+
+	unlock, err := repo.LockTenant(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	// Only half the pool may hold locks, so a second tenant waits while queries still run
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := repo.LockTenant(ctx, "t2"); err == nil {
+		t.Fatal("expected the second lock to wait for a free lock slot")
+	}
+	if _, err := repo.GetQuota("t1"); err != nil {
+		t.Fatalf("expected a connection free for quota queries: %v", err)
+	}
+
+	unlock()
+	unlock, err = repo.LockTenant(context.Background(), "t2")
+	if err != nil {
+		t.Fatalf("expected the lock slot free again: %v", err)
+	}
+	unlock()
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/lib/pq"
+)
+
+// PostgresRecordingRepository implements domain.RecordingRepository using PostgreSQL
+type PostgresRecordingRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewPostgresRecordingRepository creates a new recording repository
+func NewPostgresRecordingRepository(db *sql.DB, logger *slog.Logger) *PostgresRecordingRepository {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &PostgresRecordingRepository{db: db, logger: logger}
+}
+
+const recordingColumns = `id, container_id, tenant_id, command, cols, rows, path, size_bytes, started_at, ended_at`
+
+// Create stores the metadata of a recording that has just started
+func (r *PostgresRecordingRepository) Create(rec *domain.Recording) error {
+	query := `
+		INSERT INTO session_recordings (id, container_id, tenant_id, command, cols, rows, path, size_bytes, started_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := r.db.Exec(query,
+		rec.ID, rec.ContainerID, rec.TenantID, pq.Array(rec.Command), rec.Cols, rec.Rows, rec.Path, rec.SizeBytes, rec.StartedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create recording: %w", err)
+	}
+	return nil
+}
+
+// Finish stores the end time and final size of a recording
+func (r *PostgresRecordingRepository) Finish(rec *domain.Recording) error {
+	query := `UPDATE session_recordings SET ended_at = $2, size_bytes = $3 WHERE id = $1`
+	if _, err := r.db.Exec(query, rec.ID, rec.EndedAt, rec.SizeBytes); err != nil {
+		return fmt.Errorf("failed to finish recording: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a recording by ID
+func (r *PostgresRecordingRepository) GetByID(id string) (*domain.Recording, error) {
+	query := `SELECT ` + recordingColumns + ` FROM session_recordings WHERE id = $1`
+	rec, err := scanRecording(r.db.QueryRow(query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("recording not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get recording: %w", err)
+	}
+	return rec, nil
+}
+
+// ListByContainer returns a container's recordings, oldest first
+func (r *PostgresRecordingRepository) ListByContainer(containerID string) ([]*domain.Recording, error) {
+	query := `SELECT ` + recordingColumns + ` FROM session_recordings WHERE container_id = $1 ORDER BY started_at`
+	return r.queryRecordings(query, containerID)
+}
+
+// DeleteEndedBefore removes recordings that ended before cutoff and returns them
+// so the caller can delete their files
+func (r *PostgresRecordingRepository) DeleteEndedBefore(cutoff time.Time) ([]*domain.Recording, error) {
+	query := `DELETE FROM session_recordings WHERE ended_at < $1 RETURNING ` + recordingColumns
+	recs, err := r.queryRecordings(query, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	if len(recs) > 0 {
+		r.logger.Info("expired recordings deleted", slog.Int("count", len(recs)))
+	}
+	return recs, nil
+}
+
+func (r *PostgresRecordingRepository) queryRecordings(query string, args ...any) ([]*domain.Recording, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recordings: %w", err)
+	}
+	defer rows.Close()
+
+	var recs []*domain.Recording
+	for rows.Next() {
+		rec, err := scanRecording(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan recording: %w", err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs, rows.Err()
+}
+
+func scanRecording(row rowScanner) (*domain.Recording, error) {
+	var (
+		rec     domain.Recording
+		cols    int64
+		rowsN   int64
+		endedAt sql.NullTime
+	)
+	err := row.Scan(&rec.ID, &rec.ContainerID, &rec.TenantID, pq.Array(&rec.Command), &cols, &rowsN, &rec.Path, &rec.SizeBytes, &rec.StartedAt, &endedAt)
+	if err != nil {
+		return nil, err
+	}
+	rec.Cols, rec.Rows = uint(cols), uint(rowsN)
+	if endedAt.Valid {
+		rec.EndedAt = &endedAt.Time
+	}
+	return &rec, nil
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/lib/pq"
+)
+
+// PostgresReservationRepository implements domain.ReservationRepository using PostgreSQL
+type PostgresReservationRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewPostgresReservationRepository creates a new reservation repository
+func NewPostgresReservationRepository(db *sql.DB, logger *slog.Logger) *PostgresReservationRepository {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &PostgresReservationRepository{db: db, logger: logger}
+}
+
+const reservationColumns = `id, tenant_id, status, start_at, duration_minutes, count, spec, container_ids, error, created_at,
+	claimed_by, claim_expires_at`
+
+// Save inserts or updates a reservation
+func (r *PostgresReservationRepository) Save(res *domain.Reservation) error {
+	query := `
+		INSERT INTO reservations (` + reservationColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (id) DO UPDATE SET
+			status = EXCLUDED.status,
+			container_ids = EXCLUDED.container_ids,
+			error = EXCLUDED.error,
+			claimed_by = EXCLUDED.claimed_by,
+			claim_expires_at = EXCLUDED.claim_expires_at
+	`
+	spec, err := json.Marshal(res.Spec)
+	if err != nil {
+		return fmt.Errorf("failed to encode reservation spec: %w", err)
+	}
+	_, err = r.db.Exec(query,
+		res.ID, res.TenantID, res.Status, res.StartAt, res.DurationMinutes, res.Count,
+		spec, pq.Array(res.ContainerIDs), nullString(res.Error), res.CreatedAt,
+		nullString(res.ClaimedBy), nullTime(res.ClaimExpiresAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store reservation: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a reservation by ID
+func (r *PostgresReservationRepository) GetByID(id string) (*domain.Reservation, error) {
+	query := `SELECT ` + reservationColumns + ` FROM reservations WHERE id = $1`
+	res, err := scanReservation(r.db.QueryRow(query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("reservation not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get reservation: %w", err)
+	}
+	return res, nil
+}
+
+// ListByTenant returns a tenant's reservations, latest start first
+func (r *PostgresReservationRepository) ListByTenant(tenantID string) ([]*domain.Reservation, error) {
+	query := `SELECT ` + reservationColumns + ` FROM reservations WHERE tenant_id = $1 ORDER BY start_at DESC`
+	return r.queryReservations(query, tenantID)
+}
+
+// ListByStatus returns reservations in any of the given states, earliest start first
+func (r *PostgresReservationRepository) ListByStatus(statuses ...string) ([]*domain.Reservation, error) {
+	query := `SELECT ` + reservationColumns + ` FROM reservations WHERE status = ANY($1) ORDER BY start_at`
+	return r.queryReservations(query, pq.Array(statuses))
+}
+
+// UpdateStatus moves a reservation from one status to another in a single conditional update
+func (r *PostgresReservationRepository) UpdateStatus(id, from, to string) (bool, error) {
+	res, err := r.db.Exec(`UPDATE reservations SET status = $3 WHERE id = $1 AND status = $2`, id, from, to)
+	if err != nil {
+		return false, fmt.Errorf("failed to update reservation status: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update reservation status: %w", err)
+	}
+	return n == 1, nil
+}
+
+// Claim takes a pending reservation, or a provisioning one whose claim has expired, for owner
+func (r *PostgresReservationRepository) Claim(id, owner string, now, until time.Time) (bool, error) {
+	query := `
+		UPDATE reservations SET status = $2, claimed_by = $3, claim_expires_at = $4
+		WHERE id = $1 AND (status = $5 OR (status = $2 AND (claim_expires_at IS NULL OR claim_expires_at <= $6)))
+	`
+	res, err := r.db.Exec(query, id, domain.ReservationProvisioning, owner, until, domain.ReservationPending, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim reservation: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim reservation: %w", err)
+	}
+	return n == 1, nil
+}
+
+// AddContainer appends a lease to a reservation owner has claimed, in a single conditional
+// update, so leases recorded by an earlier claim are never overwritten
+func (r *PostgresReservationRepository) AddContainer(id, owner, containerID string, until time.Time) (bool, error) {
+	query := `
+		UPDATE reservations SET container_ids = array_append(container_ids, $3), claim_expires_at = $4
+		WHERE id = $1 AND claimed_by = $2 AND status = $5
+	`
+	res, err := r.db.Exec(query, id, owner, containerID, until, domain.ReservationProvisioning)
+	if err != nil {
+		return false, fmt.Errorf("failed to record reserved lease: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record reserved lease: %w", err)
+	}
+	return n == 1, nil
+}
+
+// Release frees a reservation owner has claimed, in a single conditional update, so a
+// reservation taken over or cancelled in the meantime is left alone
+func (r *PostgresReservationRepository) Release(id, owner, status, reason string) (bool, error) {
+	query := `
+		UPDATE reservations SET status = $3, error = $4, claimed_by = NULL, claim_expires_at = NULL
+		WHERE id = $1 AND claimed_by = $2 AND status = $5
+	`
+	res, err := r.db.Exec(query, id, owner, status, nullString(reason), domain.ReservationProvisioning)
+	if err != nil {
+		return false, fmt.Errorf("failed to release reservation: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to release reservation: %w", err)
+	}
+	return n == 1, nil
+}
+
+func (r *PostgresReservationRepository) queryReservations(query string, args ...any) ([]*domain.Reservation, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reservations: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*domain.Reservation
+	for rows.Next() {
+		res, err := scanReservation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reservation: %w", err)
+		}
+		out = append(out, res)
+	}
+	return out, rows.Err()
+}
+
+func scanReservation(row rowScanner) (*domain.Reservation, error) {
+	var (
+		res          domain.Reservation
+		spec         []byte
+		errText      sql.NullString
+		claimedBy    sql.NullString
+		claimExpires sql.NullTime
+	)
+	err := row.Scan(&res.ID, &res.TenantID, &res.Status, &res.StartAt, &res.DurationMinutes, &res.Count,
+		&spec, pq.Array(&res.ContainerIDs), &errText, &res.CreatedAt, &claimedBy, &claimExpires)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(spec, &res.Spec); err != nil {
+		return nil, fmt.Errorf("failed to decode reservation spec: %w", err)
+	}
+	res.Error = errText.String
+	res.ClaimedBy = claimedBy.String
+	res.ClaimExpiresAt = claimExpires.Time
+	return &res, nil
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+)
+
+// PostgresScheduleRepository implements domain.ScheduleRepository using PostgreSQL
+type PostgresScheduleRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+// NewPostgresScheduleRepository creates a new schedule repository
+func NewPostgresScheduleRepository(db *sql.DB, logger *slog.Logger) *PostgresScheduleRepository {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &PostgresScheduleRepository{db: db, logger: logger}
+}
+
+const (
+	scheduleColumns    = `id, tenant_id, name, cron, timezone, duration_minutes, spec, restore_snapshot, paused, skip_at, next_run_at, created_at`
+	scheduleRunColumns = `id, schedule_id, scheduled_at, status, container_id, snapshot_id, error, created_at`
+)
+
+// Save inserts or updates a schedule
+func (r *PostgresScheduleRepository) Save(s *domain.Schedule) error {
+	query := `
+		INSERT INTO lease_schedules (` + scheduleColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (id) DO UPDATE SET
+			paused = EXCLUDED.paused,
+			skip_at = EXCLUDED.skip_at,
+			next_run_at = EXCLUDED.next_run_at
+	`
+	spec, err := json.Marshal(s.Spec)
+	if err != nil {
+		return fmt.Errorf("failed to encode schedule spec: %w", err)
+	}
+	_, err = r.db.Exec(query,
+		s.ID, s.TenantID, s.Name, s.Cron, s.Timezone, s.DurationMinutes, spec,
+		s.RestoreSnapshot, s.Paused, nullTime(s.SkipAt), s.NextRunAt, s.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store schedule: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a schedule by ID
+func (r *PostgresScheduleRepository) GetByID(id string) (*domain.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM lease_schedules WHERE id = $1`
+	s, err := scanSchedule(r.db.QueryRow(query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("schedule not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	return s, nil
+}
+
+// ListByTenant returns a tenant's schedules, oldest first
+func (r *PostgresScheduleRepository) ListByTenant(tenantID string) ([]*domain.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM lease_schedules WHERE tenant_id = $1 ORDER BY created_at`
+	return r.querySchedules(query, tenantID)
+}
+
+// ListDue returns unpaused schedules whose next occurrence is at or before now
+func (r *PostgresScheduleRepository) ListDue(now time.Time) ([]*domain.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM lease_schedules WHERE NOT paused AND next_run_at <= $1 ORDER BY next_run_at`
+	return r.querySchedules(query, now)
+}
+
+// ClaimOccurrence moves a schedule on from its due occurrence, only while the stored
+// occurrence is still the due one
+func (r *PostgresScheduleRepository) ClaimOccurrence(s *domain.Schedule, due time.Time) (bool, error) {
+	query := `
+		UPDATE lease_schedules SET next_run_at = $3, skip_at = $4, paused = $5
+		WHERE id = $1 AND next_run_at = $2 AND NOT paused
+	`
+	res, err := r.db.Exec(query, s.ID, due, s.NextRunAt, nullTime(s.SkipAt), s.Paused)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim schedule occurrence: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim schedule occurrence: %w", err)
+	}
+	return n == 1, nil
+}
+
+// Update saves a tenant's change to a schedule, only while the stored occurrence is still
+// the one the change was based on, so an occurrence claimed since is not run again
+func (r *PostgresScheduleRepository) Update(s *domain.Schedule, nextRunAt time.Time) (bool, error) {
+	query := `
+		UPDATE lease_schedules SET next_run_at = $3, skip_at = $4, paused = $5
+		WHERE id = $1 AND next_run_at = $2
+	`
+	res, err := r.db.Exec(query, s.ID, nextRunAt, s.NextRunAt, nullTime(s.SkipAt), s.Paused)
+	if err != nil {
+		return false, fmt.Errorf("failed to update schedule: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update schedule: %w", err)
+	}
+	return n == 1, nil
+}
+
+// Delete removes a schedule; its runs are removed by the foreign key cascade
+func (r *PostgresScheduleRepository) Delete(id string) error {
+	if _, err := r.db.Exec(`DELETE FROM lease_schedules WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete schedule: %w", err)
+	}
+	return nil
+}
+
+// AddRun records the outcome of one occurrence
+func (r *PostgresScheduleRepository) AddRun(run *domain.ScheduleRun) error {
+	query := `INSERT INTO lease_schedule_runs (` + scheduleRunColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err := r.db.Exec(query,
+		run.ID, run.ScheduleID, run.ScheduledAt, run.Status,
+		nullString(run.ContainerID), nullString(run.SnapshotID), nullString(run.Error), run.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to store schedule run: %w", err)
+	}
+	return nil
+}
+
+// ListRuns returns a schedule's most recent runs, latest first
+func (r *PostgresScheduleRepository) ListRuns(scheduleID string, limit int) ([]*domain.ScheduleRun, error) {
+	query := `SELECT ` + scheduleRunColumns + ` FROM lease_schedule_runs WHERE schedule_id = $1 ORDER BY scheduled_at DESC LIMIT $2`
+	rows, err := r.db.Query(query, scheduleID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schedule runs: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*domain.ScheduleRun
+	for rows.Next() {
+		var (
+			run                              domain.ScheduleRun
+			containerID, snapshotID, errText sql.NullString
+		)
+		err := rows.Scan(&run.ID, &run.ScheduleID, &run.ScheduledAt, &run.Status,
+			&containerID, &snapshotID, &errText, &run.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schedule run: %w", err)
+		}
+		run.ContainerID = containerID.String
+		run.SnapshotID = snapshotID.String
+		run.Error = errText.String
+		out = append(out, &run)
+	}
+	return out, rows.Err()
+}
+
+func (r *PostgresScheduleRepository) querySchedules(query string, args ...any) ([]*domain.Schedule, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*domain.Schedule
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schedule: %w", err)
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func scanSchedule(row rowScanner) (*domain.Schedule, error) {
+	var (
+		s      domain.Schedule
+		spec   []byte
+		skipAt sql.NullTime
+	)
+	err := row.Scan(&s.ID, &s.TenantID, &s.Name, &s.Cron, &s.Timezone, &s.DurationMinutes, &spec,
+		&s.RestoreSnapshot, &s.Paused, &skipAt, &s.NextRunAt, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(spec, &s.Spec); err != nil {
+		return nil, fmt.Errorf("failed to decode schedule spec: %w", err)
+	}
+	s.SkipAt = skipAt.Time
+	return &s, nil
+}
@@ -60,7 +60,7 @@ func (tm *TokenManager) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, fmt.Errorf("parse token failed: %w", err)
 	}
 	claims, ok := token.Claims.(*Claims)
-	if !ok || !token.Valid {
+	if !ok || !token.Valid || isShareToken(claims.RegisteredClaims) {
 		return nil, fmt.Errorf("invalid token claims")
 	}
 	return claims, nil
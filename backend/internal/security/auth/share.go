@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// shareAudience marks share tokens so they cannot be used as login tokens and vice versa
+const shareAudience = "containerlease-proxy"
+
+// ShareClaims grant access to one proxied container port without a login
+type ShareClaims struct {
+	ContainerID string `json:"container_id"`
+	Port        int    `json:"port"`
+	jwt.RegisteredClaims
+}
+
+// GenerateShareToken issues a token for /proxy/{containerID}/{port}/ that expires at expiresAt
+func (tm *TokenManager) GenerateShareToken(containerID string, port int, expiresAt time.Time) (string, error) {
+	if containerID == "" || port <= 0 {
+		return "", fmt.Errorf("container_id and port required")
+	}
+	claims := ShareClaims{
+		ContainerID: containerID,
+		Port:        port,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			Issuer:    tm.issuer,
+			Audience:  jwt.ClaimStrings{shareAudience},
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(tm.secret))
+}
+
+// ValidateShareToken parses a share token; login tokens are rejected
+func (tm *TokenManager) ValidateShareToken(tokenString string) (*ShareClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ShareClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(tm.secret), nil
+	}, jwt.WithAudience(shareAudience))
+	if err != nil {
+		return nil, fmt.Errorf("parse share token failed: %w", err)
+	}
+	claims, ok := token.Claims.(*ShareClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid share token claims")
+	}
+	return claims, nil
+}
+
+func isShareToken(claims jwt.RegisteredClaims) bool {
+	return slices.Contains(claims.Audience, shareAudience)
+}
@@ -20,7 +20,12 @@ type Permission string
 const (
 	PermCreateContainer Permission = "create_container"
 	PermDeleteContainer Permission = "delete_container"
+	PermExtendContainer Permission = "extend_container"
 	PermReadContainer   Permission = "read_container"
+	PermExecContainer   Permission = "exec_container"
+	PermTransferFiles   Permission = "transfer_files"
+	PermShareContainer  Permission = "share_container"
+	PermPauseContainer  Permission = "pause_container"
 	PermListContainers  Permission = "list_containers"
 	PermCreateSnapshot  Permission = "create_snapshot"
 	PermDeleteSnapshot  Permission = "delete_snapshot"
@@ -28,6 +33,8 @@ const (
 	PermManageUsers     Permission = "manage_users"
 	PermManageTenant    Permission = "manage_tenant"
 	PermViewAuditLog    Permission = "view_audit_log"
+	PermManageQuotas    Permission = "manage_quotas"
+	PermManageBudget    Permission = "manage_budget"
 )
 
 // RolePermissions maps roles to their permissions
@@ -35,7 +42,12 @@ var RolePermissions = map[Role][]Permission{
 	RoleAdmin: {
 		PermCreateContainer,
 		PermDeleteContainer,
+		PermExtendContainer,
 		PermReadContainer,
+		PermExecContainer,
+		PermTransferFiles,
+		PermShareContainer,
+		PermPauseContainer,
 		PermListContainers,
 		PermCreateSnapshot,
 		PermDeleteSnapshot,
@@ -43,32 +55,47 @@ var RolePermissions = map[Role][]Permission{
 		PermManageUsers,
 		PermManageTenant,
 		PermViewAuditLog,
+		PermManageQuotas,
+		PermManageBudget,
 	},
 	RoleTenantAdmin: {
 		PermCreateContainer,
 		PermDeleteContainer,
+		PermExtendContainer,
 		PermReadContainer,
+		PermExecContainer,
+		PermTransferFiles,
+		PermShareContainer,
+		PermPauseContainer,
 		PermListContainers,
 		PermCreateSnapshot,
 		PermDeleteSnapshot,
 		PermListSnapshots,
 		PermManageUsers,
 		PermViewAuditLog,
+		PermManageBudget,
 	},
 	RoleUser: {
 		PermCreateContainer,
 		PermDeleteContainer,
+		PermExtendContainer,
 		PermReadContainer,
+		PermExecContainer,
+		PermTransferFiles,
+		PermShareContainer,
+		PermPauseContainer,
 		PermListContainers,
 		PermCreateSnapshot,
 		PermDeleteSnapshot,
 		PermListSnapshots,
+		PermManageBudget,
 	},
 }
 
 // AuthorizationService handles authorization checks
 type AuthorizationService struct {
-	logger *slog.Logger
+	logger       *slog.Logger
+	adminTenants map[string]bool
 }
 
 // NewAuthorizationService creates a new authorization service
@@ -81,6 +108,23 @@ func NewAuthorizationService(logger *slog.Logger) *AuthorizationService {
 	}
 }
 
+// WithAdminTenants grants the admin role to every user of the given tenants
+func (as *AuthorizationService) WithAdminTenants(tenantIDs []string) *AuthorizationService {
+	as.adminTenants = make(map[string]bool, len(tenantIDs))
+	for _, id := range tenantIDs {
+		as.adminTenants[id] = true
+	}
+	return as
+}
+
+// RoleForTenant returns the role of a request's tenant (tokens do not carry roles yet)
+func (as *AuthorizationService) RoleForTenant(tenantID string) Role {
+	if as.adminTenants[tenantID] {
+		return RoleAdmin
+	}
+	return RoleUser
+}
+
 // HasPermission checks if a role has a specific permission
 func (as *AuthorizationService) HasPermission(role Role, permission Permission) bool {
 	permissions, exists := RolePermissions[role]
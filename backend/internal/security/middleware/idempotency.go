@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+)
+
+const (
+	// IdempotencyKeyHeader makes a POST or DELETE request safe to retry
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed for a retried request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	idempotencyKeyMaxLen  = 255
+	idempotencyMaxBody    = 1 << 20         // Largest request body that can be sent with a key
+	idempotencyPendingTTL = 5 * time.Minute // How long a key stays reserved by a request that never finishes
+)
+
+// IdempotencyMiddleware replays the stored response when a POST or DELETE request is retried
+// with the same Idempotency-Key. Keys are per tenant and kept for window after the first
+// request; reusing one for a different request is rejected with 422, and retrying while the
+// first request is still being handled with 409. Server errors and panics are not stored, so
+// a request that failed with one can be retried with the same key.
+func IdempotencyMiddleware(store domain.IdempotencyStore, window time.Duration, log *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if store == nil || window <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodDelete) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// Keys are scoped to a tenant, so unauthenticated requests cannot use them
+			tenantID := GetTenantFromContext(r.Context())
+			if tenantID == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > idempotencyKeyMaxLen {
+				http.Error(w, `{"error":"Idempotency-Key must be at most 255 characters"}`, http.StatusBadRequest)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, idempotencyMaxBody+1))
+			if err != nil {
+				http.Error(w, `{"error":"failed to read request body"}`, http.StatusBadRequest)
+				return
+			}
+			if len(body) > idempotencyMaxBody {
+				http.Error(w, `{"error":"request body too large for an Idempotency-Key"}`, http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			logger := log.With(slog.String("tenant_id", tenantID), slog.String("idempotency_key", key))
+			hash := requestHash(r, body)
+			existing, err := store.Reserve(r.Context(), tenantID, key, &domain.IdempotencyRecord{
+				RequestHash: hash,
+				CreatedAt:   time.Now(),
+			}, min(idempotencyPendingTTL, window))
+			if err != nil {
+				logger.Error("failed to reserve idempotency key", slog.String("error", err.Error()))
+				http.Error(w, `{"error":"idempotency keys are unavailable, please retry"}`, http.StatusServiceUnavailable)
+				return
+			}
+			if existing != nil {
+				switch {
+				case existing.RequestHash != hash:
+					http.Error(w, `{"error":"Idempotency-Key was already used for a different request"}`, http.StatusUnprocessableEntity)
+				case !existing.Completed:
+					http.Error(w, `{"error":"a request with this Idempotency-Key is still in progress"}`, http.StatusConflict)
+				default:
+					logger.Info("replaying idempotent response", slog.String("path", r.URL.Path), slog.Int("status", existing.Status))
+					for name, values := range existing.Header {
+						w.Header()[name] = values
+					}
+					w.Header().Set(IdempotentReplayedHeader, "true")
+					w.WriteHeader(existing.Status)
+					w.Write(existing.Body)
+				}
+				return
+			}
+
+			// Store the outcome even if the client has gone away; its retry needs it most
+			ctx := context.WithoutCancel(r.Context())
+			release := func() {
+				if err := store.Release(ctx, tenantID, key); err != nil {
+					logger.Error("failed to release idempotency key", slog.String("error", err.Error()))
+				}
+			}
+			// A panicking handler must not leave the key reserved until it expires
+			defer func() {
+				if p := recover(); p != nil {
+					release()
+					panic(p)
+				}
+			}()
+
+			rec := &idempotencyRecorder{ResponseWriter: w, before: w.Header().Clone()}
+			next.ServeHTTP(rec, r)
+
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+			if rec.status >= http.StatusInternalServerError {
+				release()
+				return
+			}
+			err = store.Save(ctx, tenantID, key, &domain.IdempotencyRecord{
+				RequestHash: hash,
+				Completed:   true,
+				Status:      rec.status,
+				Header:      rec.header,
+				Body:        rec.body.Bytes(),
+				CreatedAt:   time.Now(),
+			}, window)
+			if err != nil {
+				logger.Error("failed to save idempotent response", slog.String("error", err.Error()))
+			}
+		})
+	}
+}
+
+// requestHash identifies a request by its method, path and body, so a key reused for
+// another endpoint counts as a different request
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotencyRecorder passes the response through while keeping a copy of it
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	before http.Header // Headers set before the handler ran, e.g. CORS, which are not stored
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *idempotencyRecorder) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+		w.header = http.Header{}
+		for name, values := range w.Header() {
+			if !slices.Equal(w.before[name], values) {
+				w.header[name] = slices.Clone(values)
+			}
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *idempotencyRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *idempotencyRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
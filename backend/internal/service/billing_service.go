@@ -0,0 +1,114 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// BillingService prices container leases, accrues their cost while they hold
+// resources and writes a billing record when they end
+type BillingService struct {
+	billingRepository domain.BillingRepository
+	logger            *slog.Logger
+	config            *config.Config
+}
+
+// NewBillingService creates a new billing service
+func NewBillingService(billingRepo domain.BillingRepository, logger *slog.Logger, cfg *config.Config) *BillingService {
+	return &BillingService{
+		billingRepository: billingRepo,
+		logger:            logger,
+		config:            cfg,
+	}
+}
+
+// HourlyRate returns the price of holding the given resources for one hour.
+// A preset with its own price replaces the CPU and memory rates; volumes are always charged per MB.
+func (s *BillingService) HourlyRate(preset string, cpuMilli, memoryMB, volumeMB int) float64 {
+	compute := float64(cpuMilli)*s.config.PricePerCPUMilliHour + float64(memoryMB)*s.config.PricePerMemoryMBHour
+	if p, ok := s.config.Presets[preset]; ok && p.PricePerHour > 0 {
+		compute = p.PricePerHour
+	}
+	return compute + float64(volumeMB)*s.config.PricePerVolumeMBHour
+}
+
+// Estimate returns the up-front cost of a provisioning request for its full duration
+func (s *BillingService) Estimate(opts ProvisionOptions) float64 {
+	rate := s.HourlyRate(opts.Preset, opts.CPUMilli, opts.MemoryMB, opts.VolumeSizeMB)
+	return roundCost(rate * float64(opts.DurationMinutes) / 60)
+}
+
+// Billable reports whether a container accrues cost in its current status.
+// Exited and errored containers still hold their lease while self-healing runs;
+// paused containers are not charged until they resume.
+func Billable(status string) bool {
+	return status == "running" || status == "exited" || status == "error"
+}
+
+// Accrue brings container.Cost up to now; the caller persists the container
+func (s *BillingService) Accrue(container *domain.Container, now time.Time) {
+	if container.CostAccruedAt.IsZero() || !now.After(container.CostAccruedAt) {
+		return
+	}
+	if Billable(container.Status) {
+		elapsed := now.Sub(container.CostAccruedAt)
+		container.Cost += s.rateFor(container) * elapsed.Hours()
+		container.BilledDuration += elapsed
+	}
+	container.CostAccruedAt = now
+}
+
+// LiveCost returns what a container has cost so far, without modifying it
+func (s *BillingService) LiveCost(container *domain.Container, now time.Time) float64 {
+	c := *container
+	s.Accrue(&c, now)
+	return roundCost(c.Cost)
+}
+
+// Finalize accrues cost up to end and records the charge. It is safe to call again
+// for the same container: the repository ignores duplicate records.
+func (s *BillingService) Finalize(container *domain.Container, end time.Time) error {
+	s.Accrue(container, end)
+	if container.BilledDuration <= 0 {
+		return nil
+	}
+
+	hours := container.BilledDuration.Hours()
+	record := &domain.BillingRecord{
+		ContainerID:     container.ID,
+		TenantID:        container.TenantID,
+		Preset:          container.Preset,
+		Amount:          roundCost(container.Cost),
+		CPUMilliHours:   float64(container.CPUMilli) * hours,
+		MemoryMBHours:   float64(container.MemoryMB) * hours,
+		VolumeMBHours:   float64(container.VolumeSize) * hours,
+		DurationMinutes: int(math.Ceil(container.BilledDuration.Minutes())),
+		PeriodStart:     container.CreatedAt,
+		PeriodEnd:       end,
+	}
+	if err := s.billingRepository.Create(record); err != nil {
+		return fmt.Errorf("failed to record billing: %w", err)
+	}
+
+	s.logger.Info("container billed",
+		slog.String("container_id", container.ID),
+		slog.String("tenant_id", container.TenantID),
+		slog.Float64("amount", record.Amount),
+		slog.Int("minutes", record.DurationMinutes),
+	)
+	return nil
+}
+
+func (s *BillingService) rateFor(container *domain.Container) float64 {
+	return s.HourlyRate(container.Preset, container.CPUMilli, container.MemoryMB, container.VolumeSize)
+}
+
+// roundCost rounds to a hundredth of a cent
+func roundCost(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
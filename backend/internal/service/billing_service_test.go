@@ -0,0 +1,113 @@
+package service
+
+import (
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+type memBillingRepo struct {
+	records map[string]*domain.BillingRecord
+}
+
+func (m *memBillingRepo) Create(r *domain.BillingRecord) error {
+	if _, ok := m.records[r.ContainerID]; !ok {
+		m.records[r.ContainerID] = r
+	}
+	return nil
+}
+
+func (m *memBillingRepo) SumByTenant(tenantID string, since time.Time) (float64, error) {
+	total := 0.0
+	for _, r := range m.records {
+		if r.TenantID == tenantID && !r.PeriodEnd.Before(since) {
+			total += r.Amount
+		}
+	}
+	return total, nil
+}
+
+func newTestBillingService() (*BillingService, *memBillingRepo) {
+	cfg := &config.Config{
+		PricePerCPUMilliHour: 0.001,
+		PricePerMemoryMBHour: 0.0001,
+		PricePerVolumeMBHour: 0.00001,
+		Presets: map[string]config.Preset{
+			"small": {CPUMilli: 250, MemoryMB: 256, PricePerHour: 0.5},
+		},
+	}
+	repo := &memBillingRepo{records: map[string]*domain.BillingRecord{}}
+	return NewBillingService(repo, slog.Default(), cfg), repo
+}
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestHourlyRate(t *testing.T) {
+	svc, _ := newTestBillingService()
+
+	// 500m * 0.001 + 1024MB * 0.0001 + 1000MB * 0.00001
+	if got := svc.HourlyRate("", 500, 1024, 1000); !approx(got, 0.6124) {
+		t.Fatalf("expected 0.6124/h, got %v", got)
+	}
+	// Preset price replaces compute, volume still charged
+	if got := svc.HourlyRate("small", 250, 256, 1000); !approx(got, 0.51) {
+		t.Fatalf("expected preset rate 0.51/h, got %v", got)
+	}
+	if got := svc.Estimate(ProvisionOptions{Preset: "small", DurationMinutes: 30}); !approx(got, 0.25) {
+		t.Fatalf("expected estimate 0.25, got %v", got)
+	}
+}
+
+func TestAccrueOnlyWhileBillable(t *testing.T) {
+	svc, _ := newTestBillingService()
+	start := time.Now()
+	c := &domain.Container{ID: "c1", Status: "pending", Preset: "small", CostAccruedAt: start}
+
+	svc.Accrue(c, start.Add(time.Hour))
+	if c.Cost != 0 || c.BilledDuration != 0 {
+		t.Fatalf("pending container should not accrue, got cost %v", c.Cost)
+	}
+
+	c.Status = "running"
+	if got := svc.LiveCost(c, start.Add(2*time.Hour)); !approx(got, 0.5) {
+		t.Fatalf("expected live cost 0.5, got %v", got)
+	}
+	if c.Cost != 0 {
+		t.Fatal("LiveCost must not modify the container")
+	}
+}
+
+func TestFinalizeWritesOneRecord(t *testing.T) {
+	svc, repo := newTestBillingService()
+	start := time.Now().Add(-time.Hour)
+	c := &domain.Container{ID: "c1", TenantID: "t1", Status: "running", CPUMilli: 1000, MemoryMB: 0, CreatedAt: start, CostAccruedAt: start}
+
+	end := start.Add(90 * time.Minute)
+	if err := svc.Finalize(c, end); err != nil {
+		t.Fatalf("finalize: %v", err)
+	}
+	if err := svc.Finalize(c, end); err != nil {
+		t.Fatalf("second finalize: %v", err)
+	}
+
+	r, ok := repo.records["c1"]
+	if !ok || len(repo.records) != 1 {
+		t.Fatalf("expected a single billing record, got %d", len(repo.records))
+	}
+	if !approx(r.Amount, 1.5) || r.DurationMinutes != 90 || !approx(r.CPUMilliHours, 1500) {
+		t.Fatalf("unexpected record: %+v", r)
+	}
+
+	// Containers that never ran are not billed
+	never := &domain.Container{ID: "c2", TenantID: "t1", Status: "pending", CreatedAt: start}
+	if err := svc.Finalize(never, end); err != nil {
+		t.Fatalf("finalize: %v", err)
+	}
+	if _, ok := repo.records["c2"]; ok {
+		t.Fatal("container that never ran should not be billed")
+	}
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// ErrBudgetExceeded is wrapped by every BudgetExceededError
+var ErrBudgetExceeded = errors.New("monthly budget exhausted")
+
+// ErrInvalidBudget is returned when a budget update contains out-of-range values
+var ErrInvalidBudget = errors.New("budget requires monthlyLimit > 0, alertThreshold in (0, 1] and graceMinutes >= 0")
+
+// BudgetExceededError is returned when a hard-stopped tenant asks for more resources
+type BudgetExceededError struct {
+	Limit float64
+	Spend float64
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("monthly budget exhausted: spent %.2f of %.2f", e.Spend, e.Limit)
+}
+
+func (e *BudgetExceededError) Unwrap() error { return ErrBudgetExceeded }
+
+// BudgetNotifier delivers budget events to the tenant
+type BudgetNotifier interface {
+	NotifyBudget(ctx context.Context, event *domain.BudgetEvent) error
+}
+
+// BudgetStatus is a tenant's spend against its budget for the current month
+type BudgetStatus struct {
+	Budget *domain.TenantBudget // nil when the tenant has no budget
+	Period time.Time
+	Spend  float64
+	Events []*domain.BudgetEvent
+}
+
+// BudgetService tracks monthly tenant spend, raises alerts and enforces hard stops
+type BudgetService struct {
+	budgetRepository    domain.BudgetRepository
+	billingRepository   domain.BillingRepository
+	containerRepository domain.ContainerRepository
+	leaseRepository     domain.LeaseRepository
+	billing             *BillingService
+	notifier            BudgetNotifier
+	logger              *slog.Logger
+	config              *config.Config
+}
+
+// NewBudgetService creates a new budget service
+func NewBudgetService(
+	budgetRepo domain.BudgetRepository,
+	billingRepo domain.BillingRepository,
+	containerRepo domain.ContainerRepository,
+	leaseRepo domain.LeaseRepository,
+	billing *BillingService,
+	logger *slog.Logger,
+	cfg *config.Config,
+) *BudgetService {
+	return &BudgetService{
+		budgetRepository:    budgetRepo,
+		billingRepository:   billingRepo,
+		containerRepository: containerRepo,
+		leaseRepository:     leaseRepo,
+		billing:             billing,
+		logger:              logger,
+		config:              cfg,
+	}
+}
+
+// WithNotifier delivers alert and stop events; without one they are only logged
+func (s *BudgetService) WithNotifier(notifier BudgetNotifier) *BudgetService {
+	s.notifier = notifier
+	return s
+}
+
+// GetBudget returns the tenant's budget, or nil if none has been set
+func (s *BudgetService) GetBudget(tenantID string) (*domain.TenantBudget, error) {
+	return s.budgetRepository.GetBudget(tenantID)
+}
+
+// DefaultBudget returns an unsaved budget with the configured alert threshold and grace window
+func (s *BudgetService) DefaultBudget(tenantID string) *domain.TenantBudget {
+	return &domain.TenantBudget{
+		TenantID:       tenantID,
+		AlertThreshold: s.config.BudgetAlertThreshold,
+		GraceMinutes:   s.config.BudgetGraceMinutes,
+	}
+}
+
+// UpdateBudget stores a tenant's budget
+func (s *BudgetService) UpdateBudget(budget *domain.TenantBudget) error {
+	if budget.MonthlyLimit <= 0 || budget.AlertThreshold <= 0 || budget.AlertThreshold > 1 || budget.GraceMinutes < 0 {
+		return ErrInvalidBudget
+	}
+	return s.budgetRepository.SaveBudget(budget)
+}
+
+// DeleteBudget removes a tenant's budget
+func (s *BudgetService) DeleteBudget(tenantID string) error {
+	return s.budgetRepository.DeleteBudget(tenantID)
+}
+
+// Spend returns what the tenant has spent in the month containing now:
+// billed leases that ended this month plus the live cost of leases still held
+func (s *BudgetService) Spend(tenantID string, now time.Time) (float64, error) {
+	spend, err := s.billingRepository.SumByTenant(tenantID, budgetPeriod(now))
+	if err != nil {
+		return 0, err
+	}
+
+	containers, err := s.containerRepository.ListByTenant(tenantID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list containers: %w", err)
+	}
+	for _, c := range containers {
+		if c.Status != "terminated" {
+			spend += s.billing.LiveCost(c, now)
+		}
+	}
+	return roundCost(spend), nil
+}
+
+// Status reports the tenant's budget, spend and events for the current month
+func (s *BudgetService) Status(tenantID string, now time.Time) (*BudgetStatus, error) {
+	budget, err := s.budgetRepository.GetBudget(tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load budget: %w", err)
+	}
+	spend, err := s.Spend(tenantID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute spend: %w", err)
+	}
+	period := budgetPeriod(now)
+	events, err := s.budgetRepository.ListEvents(tenantID, period)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list budget events: %w", err)
+	}
+	return &BudgetStatus{Budget: budget, Period: period, Spend: spend, Events: events}, nil
+}
+
+// CheckProvision refuses new resources once a hard-stopped tenant has spent its budget
+func (s *BudgetService) CheckProvision(tenantID string) error {
+	budget, err := s.budgetRepository.GetBudget(tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to load budget: %w", err)
+	}
+	if budget == nil || !budget.HardStop {
+		return nil
+	}
+
+	spend, err := s.Spend(tenantID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to compute spend: %w", err)
+	}
+	if spend < budget.MonthlyLimit {
+		return nil
+	}
+
+	s.logger.Warn("tenant budget exhausted, refusing request",
+		slog.String("tenant_id", tenantID),
+		slog.Float64("spend", spend),
+		slog.Float64("limit", budget.MonthlyLimit),
+	)
+	return &BudgetExceededError{Limit: budget.MonthlyLimit, Spend: spend}
+}
+
+// Enforce checks every tenant budget: it raises alert and stop events once per month
+// and, for hard stops configured to do so, shortens running leases to the grace window
+func (s *BudgetService) Enforce(ctx context.Context, now time.Time) {
+	budgets, err := s.budgetRepository.ListBudgets()
+	if err != nil {
+		s.logger.Error("failed to list budgets", slog.String("error", err.Error()))
+		return
+	}
+
+	for _, b := range budgets {
+		if err := s.enforce(ctx, b, now); err != nil {
+			s.logger.Error("failed to enforce budget",
+				slog.String("tenant_id", b.TenantID),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+func (s *BudgetService) enforce(ctx context.Context, budget *domain.TenantBudget, now time.Time) error {
+	spend, err := s.Spend(budget.TenantID, now)
+	if err != nil {
+		return err
+	}
+
+	if spend >= budget.MonthlyLimit*budget.AlertThreshold {
+		s.raise(ctx, budget, domain.BudgetAlert, spend, now)
+	}
+	if spend < budget.MonthlyLimit {
+		return nil
+	}
+	s.raise(ctx, budget, domain.BudgetStop, spend, now)
+
+	if budget.HardStop && budget.ShortenLeases {
+		return s.shortenLeases(budget, now)
+	}
+	return nil
+}
+
+// raise records an event and notifies the tenant the first time it occurs this month
+func (s *BudgetService) raise(ctx context.Context, budget *domain.TenantBudget, kind string, spend float64, now time.Time) {
+	event := &domain.BudgetEvent{
+		TenantID: budget.TenantID,
+		Kind:     kind,
+		Period:   budgetPeriod(now),
+		Spend:    spend,
+		Limit:    budget.MonthlyLimit,
+	}
+	isNew, err := s.budgetRepository.RecordEvent(event)
+	if err != nil {
+		s.logger.Error("failed to record budget event", slog.String("tenant_id", budget.TenantID), slog.String("error", err.Error()))
+		return
+	}
+	if !isNew {
+		return
+	}
+
+	s.logger.Warn("tenant budget event",
+		slog.String("tenant_id", budget.TenantID),
+		slog.String("kind", kind),
+		slog.Float64("spend", spend),
+		slog.Float64("limit", budget.MonthlyLimit),
+	)
+	if s.notifier != nil {
+		if err := s.notifier.NotifyBudget(ctx, event); err != nil {
+			s.logger.Error("failed to deliver budget notification", slog.String("tenant_id", budget.TenantID), slog.String("error", err.Error()))
+		}
+	}
+}
+
+// shortenLeases moves every lease expiring after the grace window forward to it
+func (s *BudgetService) shortenLeases(budget *domain.TenantBudget, now time.Time) error {
+	containers, err := s.containerRepository.ListByTenant(budget.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	deadline := now.Add(time.Duration(budget.GraceMinutes) * time.Minute)
+	for _, c := range containers {
+		if c.Status == "terminated" || !c.ExpiryAt.After(deadline) {
+			continue
+		}
+		shortened := false
+		_, err := updateLease(s.leaseRepository, c, func(lease *domain.Lease) bool {
+			if shortened = lease.ExpiryTime.After(deadline); !shortened {
+				return false
+			}
+			lease.ExpiryTime = deadline
+			lease.DurationMinutes = int(deadline.Sub(lease.CreatedAt).Minutes())
+			return true
+		})
+		if errors.Is(err, ErrLeaseNotActive) {
+			continue // Already being cleaned up
+		}
+		if err != nil {
+			return fmt.Errorf("failed to shorten lease %s: %w", c.ID, err)
+		}
+		if !shortened {
+			continue
+		}
+		s.logger.Info("lease shortened by budget hard stop",
+			slog.String("tenant_id", budget.TenantID),
+			slog.String("container_id", c.ID),
+			slog.Time("expiry_at", deadline),
+		)
+	}
+	return nil
+}
+
+// budgetPeriod returns the start of the calendar month (UTC) containing t
+func budgetPeriod(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
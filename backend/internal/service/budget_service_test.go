@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+type memBudgetRepo struct {
+	budgets map[string]*domain.TenantBudget
+	events  []*domain.BudgetEvent
+}
+
+func (m *memBudgetRepo) GetBudget(tenantID string) (*domain.TenantBudget, error) {
+	if b, ok := m.budgets[tenantID]; ok {
+		cp := *b
+		return &cp, nil
+	}
+	return nil, nil
+}
+func (m *memBudgetRepo) SaveBudget(b *domain.TenantBudget) error {
+	cp := *b
+	m.budgets[b.TenantID] = &cp
+	return nil
+}
+func (m *memBudgetRepo) DeleteBudget(tenantID string) error { delete(m.budgets, tenantID); return nil }
+func (m *memBudgetRepo) ListBudgets() ([]*domain.TenantBudget, error) {
+	out := []*domain.TenantBudget{}
+	for _, b := range m.budgets {
+		cp := *b
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+func (m *memBudgetRepo) RecordEvent(e *domain.BudgetEvent) (bool, error) {
+	for _, existing := range m.events {
+		if existing.TenantID == e.TenantID && existing.Kind == e.Kind && existing.Period.Equal(e.Period) {
+			return false, nil
+		}
+	}
+	m.events = append(m.events, e)
+	return true, nil
+}
+func (m *memBudgetRepo) ListEvents(tenantID string, period time.Time) ([]*domain.BudgetEvent, error) {
+	out := []*domain.BudgetEvent{}
+	for _, e := range m.events {
+		if e.TenantID == tenantID && e.Period.Equal(period) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+type recordingNotifier struct {
+	events []*domain.BudgetEvent
+}
+
+func (n *recordingNotifier) NotifyBudget(ctx context.Context, e *domain.BudgetEvent) error {
+	n.events = append(n.events, e)
+	return nil
+}
+
+type budgetFixture struct {
+	svc        *BudgetService
+	budgets    *memBudgetRepo
+	billing    *memBillingRepo
+	containers *memContainerRepo
+	leases     *memLeaseRepo
+	notifier   *recordingNotifier
+}
+
+func newBudgetFixture() *budgetFixture {
+	billingSvc, billingRepo := newTestBillingService()
+	f := &budgetFixture{
+		budgets:    &memBudgetRepo{budgets: map[string]*domain.TenantBudget{}},
+		billing:    billingRepo,
+		containers: newMemContainerRepo(),
+		notifier:   &recordingNotifier{},
+	}
+	f.leases = newMemLeaseRepo(f.containers)
+	f.svc = NewBudgetService(f.budgets, f.billing, f.containers, f.leases, billingSvc, slog.Default(), &config.Config{}).
+		WithNotifier(f.notifier)
+	return f
+}
+
+func (f *budgetFixture) bill(tenantID string, amount float64, end time.Time) {
+	id := "billed-" + end.Format(time.RFC3339Nano)
+	f.billing.records[id] = &domain.BillingRecord{ContainerID: id, TenantID: tenantID, Amount: amount, PeriodEnd: end}
+}
+
+func TestBudgetAlertRaisedOncePerMonth(t *testing.T) {
+	f := newBudgetFixture()
+	now := time.Now()
+	_ = f.budgets.SaveBudget(&domain.TenantBudget{TenantID: "t1", MonthlyLimit: 10, AlertThreshold: 0.5})
+	f.bill("t1", 6, now)
+	f.bill("t1", 100, budgetPeriod(now).Add(-time.Hour)) // last month, not counted
+
+	f.svc.Enforce(context.Background(), now)
+	f.svc.Enforce(context.Background(), now)
+
+	if len(f.notifier.events) != 1 || f.notifier.events[0].Kind != domain.BudgetAlert {
+		t.Fatalf("expected a single alert notification, got %+v", f.notifier.events)
+	}
+	if err := f.svc.CheckProvision("t1"); err != nil {
+		t.Fatalf("soft alert must not block provisioning: %v", err)
+	}
+}
+
+func TestBudgetHardStop(t *testing.T) {
+	f := newBudgetFixture()
+	now := time.Now()
+	_ = f.budgets.SaveBudget(&domain.TenantBudget{
+		TenantID: "t1", MonthlyLimit: 10, AlertThreshold: 0.8, HardStop: true, ShortenLeases: true, GraceMinutes: 5,
+	})
+	f.bill("t1", 7, now)
+	// Running lease: 2 accrued earlier plus 1 since (2h at the 0.5/h preset price) reaches the limit
+	seedLease(f.containers, f.leases, "c1", "t1", now.Add(-2*time.Hour), 180)
+	c, _ := f.containers.GetByID("c1")
+	c.Preset, c.CostAccruedAt, c.Cost = "small", now.Add(-2*time.Hour), 2
+	_ = f.containers.Save(c)
+
+	svc := NewContainerService(nil, f.leases, f.containers, slog.Default(), &config.Config{ContainerMaxDuration: 600}).WithBudgets(f.svc)
+	_, err := svc.ProvisionContainer(context.Background(), ProvisionOptions{TenantID: "t1", DurationMinutes: 5})
+	var be *BudgetExceededError
+	if !errors.As(err, &be) || be.Spend < 10 {
+		t.Fatalf("expected budget error, got %v", err)
+	}
+	if _, _, err := svc.ExtendLease(context.Background(), "c1", 10); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected extension to be refused, got %v", err)
+	}
+
+	f.svc.Enforce(context.Background(), now)
+
+	if len(f.notifier.events) != 2 || f.notifier.events[1].Kind != domain.BudgetStop {
+		t.Fatalf("expected alert and stop notifications, got %+v", f.notifier.events)
+	}
+	lease, _ := f.leases.GetLease("lease:c1")
+	c, _ = f.containers.GetByID("c1")
+	want := now.Add(5 * time.Minute)
+	if !lease.ExpiryTime.Equal(want) || !c.ExpiryAt.Equal(want) {
+		t.Fatalf("expected lease shortened to %v, got lease=%v container=%v", want, lease.ExpiryTime, c.ExpiryAt)
+	}
+}
+
+func TestBudgetValidation(t *testing.T) {
+	f := newBudgetFixture()
+	for _, b := range []*domain.TenantBudget{
+		{TenantID: "t1", MonthlyLimit: 0, AlertThreshold: 0.8},
+		{TenantID: "t1", MonthlyLimit: 10, AlertThreshold: 1.5},
+		{TenantID: "t1", MonthlyLimit: 10, AlertThreshold: 0.8, GraceMinutes: -1},
+	} {
+		if err := f.svc.UpdateBudget(b); !errors.Is(err, ErrInvalidBudget) {
+			t.Fatalf("expected invalid budget for %+v, got %v", b, err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// ErrCapacityExceeded is returned when a lease or booking does not fit on the host
+var ErrCapacityExceeded = errors.New("host capacity exceeded")
+
+// Host capacity resource names, reported back to clients when a request does not fit
+const (
+	CapacityCPUMilli = "cpu_milli"
+	CapacityMemoryMB = "memory_mb"
+)
+
+// CapacityExceededError describes which host resource a request would overcommit
+type CapacityExceededError struct {
+	Resource  string
+	Capacity  int
+	Booked    int
+	Requested int
+}
+
+func (e *CapacityExceededError) Error() string {
+	return fmt.Sprintf("%s capacity exceeded: %d booked, %d requested, capacity %d", e.Resource, e.Booked, e.Requested, e.Capacity)
+}
+
+func (e *CapacityExceededError) Unwrap() error { return ErrCapacityExceeded }
+
+// HostCapacity is the CPU and memory the Docker host can give to leases at once (0 = unlimited)
+type HostCapacity struct {
+	CPUMilli int
+	MemoryMB int
+}
+
+// ResolveHostCapacity returns the configured host capacity. With HOST_CAPACITY_FROM_DOCKER,
+// values that are not configured are read from Docker; if Docker cannot be asked they stay unlimited.
+func ResolveHostCapacity(ctx context.Context, docker domain.DockerClient, cfg *config.Config, logger *slog.Logger) HostCapacity {
+	capacity := HostCapacity{CPUMilli: cfg.HostCPUMilli, MemoryMB: cfg.HostMemoryMB}
+	if cfg.HostCapacityDocker && (capacity.CPUMilli == 0 || capacity.MemoryMB == 0) {
+		cpuMilli, memoryMB, err := docker.HostResources(ctx)
+		if err != nil {
+			logger.Warn("failed to read host capacity from docker", slog.String("error", err.Error()))
+			return capacity
+		}
+		if capacity.CPUMilli == 0 {
+			capacity.CPUMilli = cpuMilli
+		}
+		if capacity.MemoryMB == 0 {
+			capacity.MemoryMB = memoryMB
+		}
+	}
+	logger.Info("host capacity", slog.Int("cpu_milli", capacity.CPUMilli), slog.Int("memory_mb", capacity.MemoryMB))
+	return capacity
+}
+
+// Limited reports whether any resource has a capacity
+func (c HostCapacity) Limited() bool {
+	return c.CPUMilli > 0 || c.MemoryMB > 0
+}
+
+// Check returns a CapacityExceededError for the first resource where booked plus requested exceeds capacity
+func (c HostCapacity) Check(bookedCPU, bookedMemory, cpuMilli, memoryMB int) error {
+	checks := []CapacityExceededError{
+		{Resource: CapacityCPUMilli, Capacity: c.CPUMilli, Booked: bookedCPU, Requested: cpuMilli},
+		{Resource: CapacityMemoryMB, Capacity: c.MemoryMB, Booked: bookedMemory, Requested: memoryMB},
+	}
+	for _, check := range checks {
+		if check.Capacity > 0 && check.Booked+check.Requested > check.Capacity {
+			return &check
+		}
+	}
+	return nil
+}
+
+// bookedCapacity sums the CPU and memory of the leases still held at start and of the
+// pending reservations overlapping [start, end)
+func bookedCapacity(containers []*domain.Container, reservations []*domain.Reservation, start, end time.Time) (int, int) {
+	cpuMilli, memoryMB := 0, 0
+	for _, c := range containers {
+		if holdsCapacity(c) && c.ExpiryAt.After(start) {
+			cpuMilli += c.CPUMilli
+			memoryMB += c.MemoryMB
+		}
+	}
+	for _, r := range reservations {
+		if r.Status == domain.ReservationPending && r.Overlaps(start, end) {
+			cpuMilli += r.Count * r.Spec.CPUMilli
+			memoryMB += r.Count * r.Spec.MemoryMB
+		}
+	}
+	return cpuMilli, memoryMB
+}
+
+// holdsCapacity reports whether a container is using, or about to use, host resources.
+// Paused leases count in full since resuming them is not admission controlled. Queued
+// leases wait for capacity and failed ones without a Docker container have none.
+func holdsCapacity(c *domain.Container) bool {
+	switch c.Status {
+	case "terminated", "queued":
+		return false
+	case "error":
+		return c.DockerID != ""
+	}
+	return true
+}
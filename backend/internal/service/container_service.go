@@ -2,9 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math/rand"
+	"sort"
 	"time"
 
 	"github.com/aryan0dhankhar/containerlease/internal/domain"
@@ -19,17 +21,44 @@ type ContainerService struct {
 	containerRepository domain.ContainerRepository
 	logger              *slog.Logger
 	config              *config.Config
+	quotas              *QuotaService
+	billing             *BillingService
+	budgets             *BudgetService
+	queue               *ProvisionQueue
+	jobs                domain.JobQueue
+	placement           *PlacementService
 }
 
+// Lease extension errors, mapped to HTTP status codes by the handler layer
+var (
+	ErrInvalidExtension      = errors.New("extension must be a positive number of minutes")
+	ErrLeaseNotActive        = errors.New("lease is not active")
+	ErrExtensionLimitReached = errors.New("lease extension limit reached")
+	ErrMaxDurationExceeded   = errors.New("extension exceeds maximum lease duration")
+	ErrNotPaused             = errors.New("container is not paused")
+)
+
 // ProvisionOptions captures a resource request
 type ProvisionOptions struct {
 	TenantID        string
-	ImageType       string
+	ImageType       string // Image as requested
+	Image           string // Fully qualified reference resolved by ImagePolicy
 	DurationMinutes int
 	CPUMilli        int
 	MemoryMB        int
 	LogDemo         bool
 	VolumeSizeMB    int
+	Preset          string // Preset the resources came from, for preset pricing
+	Ports           []int  // Container ports to reach through the proxy
+	Entrypoint      []string
+	Command         []string
+	Env             []domain.EnvVar
+	WorkingDir      string
+	InitScript      string            // Run with sh -c once the container has started
+	ExpiryAt        time.Time         // Fixed end of the lease, e.g. a reservation's window (zero = now + DurationMinutes)
+	LocalImage      bool              // Image only exists locally (e.g. a snapshot) and must not be pulled
+	NodeLabels      map[string]string // Labels the node running the lease must have, e.g. ssd=true
+	NodeID          string            // Node the lease must run on, e.g. the one holding its local image
 }
 
 // NewContainerService creates a new container service
@@ -49,26 +78,142 @@ func NewContainerService(
 	}
 }
 
+// WithQuotas enables per-tenant quota enforcement on provisioning
+func (s *ContainerService) WithQuotas(quotas *QuotaService) *ContainerService {
+	s.quotas = quotas
+	return s
+}
+
+// WithBilling enables cost accrual and billing records
+func (s *ContainerService) WithBilling(billing *BillingService) *ContainerService {
+	s.billing = billing
+	return s
+}
+
+// WithBudgets refuses provisions and extensions for tenants whose hard-stop budget is spent
+func (s *ContainerService) WithBudgets(budgets *BudgetService) *ContainerService {
+	s.budgets = budgets
+	return s
+}
+
+// WithQueue admits leases against the host capacity; leases that do not fit wait in the queue
+func (s *ContainerService) WithQueue(queue *ProvisionQueue) *ContainerService {
+	s.queue = queue
+	queue.start = s.startQueued
+	return s
+}
+
+// WithJobs runs provisioning and Docker teardown as jobs on the durable job queue, so
+// they survive restarts and are retried with backoff, instead of in goroutines
+func (s *ContainerService) WithJobs(jobs domain.JobQueue) *ContainerService {
+	s.jobs = jobs
+	return s
+}
+
+// WithPlacement places each lease on a node of the Docker node pool
+func (s *ContainerService) WithPlacement(placement *PlacementService) *ContainerService {
+	s.placement = placement
+	return s
+}
+
+// QueuePosition returns a queued container's place in the provisioning queue (0 = not queued)
+func (s *ContainerService) QueuePosition(containerID string) int {
+	if s.queue == nil {
+		return 0
+	}
+	return s.queue.Position(containerID)
+}
+
+// EstimateCost returns the expected cost of a provisioning request (0 when billing is disabled)
+func (s *ContainerService) EstimateCost(opts ProvisionOptions) float64 {
+	if s.billing == nil {
+		return 0
+	}
+	return s.billing.Estimate(opts)
+}
+
 // ProvisionContainer creates a new Docker container with a time-limited lease (async)
 func (s *ContainerService) ProvisionContainer(ctx context.Context, opts ProvisionOptions) (*domain.Container, error) {
+	// 0. Enforce tenant quotas; the lock is held until the pending container is saved
+	// so concurrent requests from the same tenant see each other's reservations
+	unlockQuota := func() {}
+	if s.quotas != nil {
+		unlock, err := s.quotas.LockTenant(ctx, opts.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		unlockQuota = func() {
+			if unlock != nil {
+				unlock()
+				unlock = nil
+			}
+		}
+		defer unlockQuota()
+		if err := s.quotas.CheckProvision(opts.TenantID, opts.CPUMilli, opts.MemoryMB, opts.VolumeSizeMB); err != nil {
+			return nil, err
+		}
+	}
+	if s.budgets != nil {
+		if err := s.budgets.CheckProvision(opts.TenantID); err != nil {
+			return nil, err
+		}
+	}
+	if s.queue != nil {
+		if err := s.queue.CheckRequest(opts.CPUMilli, opts.MemoryMB); err != nil {
+			return nil, err
+		}
+	}
+	if s.placement != nil {
+		if err := s.placement.Check(opts); err != nil {
+			return nil, err
+		}
+	}
+
 	// 1. Create domain entity with pending status
 	now := time.Now()
 	expiryTime := now.Add(time.Duration(opts.DurationMinutes) * time.Minute)
+	if !opts.ExpiryAt.IsZero() {
+		expiryTime = opts.ExpiryAt
+	}
 
 	container := &domain.Container{
 		ID:          generateContainerID(), // Generate temp ID
 		TenantID:    opts.TenantID,
 		ImageType:   opts.ImageType,
+		Image:       opts.Image,
 		CPUMilli:    opts.CPUMilli,
 		MemoryMB:    opts.MemoryMB,
+		LogDemo:     opts.LogDemo,
+		VolumeSize:  opts.VolumeSizeMB, // Reserved up front so quota usage counts it while pending
+		Preset:      opts.Preset,
+		Ports:       opts.Ports,
+		Entrypoint:  opts.Entrypoint,
+		Command:     opts.Command,
+		Env:         opts.Env,
+		WorkingDir:  opts.WorkingDir,
+		InitScript:  opts.InitScript,
+		NodeLabels:  opts.NodeLabels,
+		NodeID:      opts.NodeID, // Pinned leases are placed on it when provisioned
+		LocalImage:  opts.LocalImage,
 		Status:      "pending", // Status is PENDING initially
 		CreatedAt:   now,
 		ExpiryAt:    expiryTime,
 		MaxRestarts: 3, // Phase 2: Self-healing default max restarts
 	}
+	if opts.InitScript != "" {
+		container.InitStatus = domain.InitPending
+	}
+	if s.queue != nil {
+		container.Status = "queued" // Until the provisioning queue admits it
+	}
+	// Recorded so a recreated container gets the same hardening and network
+	container.SecurityProfile = s.config.SecurityProfileFor(opts.TenantID, opts.Preset)
+	container.Egress = s.config.EgressFor(opts.TenantID)
 
-	// 2. Store container in repository with pending status
-	if err := s.containerRepository.Save(container); err != nil {
+	// 2. Store container in repository with pending status; it now counts against the quota
+	err := s.containerRepository.Save(container)
+	unlockQuota()
+	if err != nil {
 		return nil, fmt.Errorf("failed to save container: %w", err)
 	}
 
@@ -85,16 +230,253 @@ func (s *ContainerService) ProvisionContainer(ctx context.Context, opts Provisio
 		return nil, fmt.Errorf("failed to create lease: %w", err)
 	}
 
-	// 4. Start async provisioning in background goroutine
-	go s.asyncProvisionContainer(context.Background(), container.ID, opts.ImageType, opts.CPUMilli, opts.MemoryMB, opts.LogDemo, opts.VolumeSizeMB)
+	// 4. Wait for host capacity, or start provisioning in the background
+	if s.queue != nil {
+		s.queue.Enqueue(container.ID, opts)
+		s.queue.Process(ctx)
+		if current, err := s.containerRepository.GetByID(container.ID); err == nil {
+			container = current
+		}
+		return container, nil
+	}
+	if err := s.startProvisioning(ctx, container.ID, opts); err != nil {
+		_ = s.leaseRepository.DeleteLease(lease.LeaseKey)
+		_ = s.containerRepository.Delete(container.ID)
+		return nil, err
+	}
 
 	return container, nil
 }
 
+// startQueued provisions a lease the queue has admitted. Its lease runs from now
+// unless it has a fixed end, such as a reservation's window.
+func (s *ContainerService) startQueued(ctx context.Context, containerID string, opts ProvisionOptions) error {
+	container, err := s.containerRepository.GetByID(containerID)
+	if err != nil {
+		return fmt.Errorf("container not found: %w", err)
+	}
+
+	if opts.ExpiryAt.IsZero() {
+		expiry := time.Now().Add(time.Duration(opts.DurationMinutes) * time.Minute)
+		if _, err := updateLease(s.leaseRepository, container, func(lease *domain.Lease) bool {
+			lease.ExpiryTime = expiry
+			return true
+		}); err != nil {
+			return fmt.Errorf("failed to restart lease clock: %w", err)
+		}
+	}
+	container.Status = "pending"
+	if err := s.containerRepository.Save(container); err != nil {
+		return fmt.Errorf("failed to save container: %w", err)
+	}
+
+	return s.startProvisioning(ctx, containerID, opts)
+}
+
+// RestoreQueue puts leases left queued by a restart back in the provisioning queue in
+// request order. Their lease runs for its full duration from when it is admitted.
+func (s *ContainerService) RestoreQueue(ctx context.Context) error {
+	if s.queue == nil {
+		return nil
+	}
+	containers, err := s.containerRepository.List()
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+	sort.Slice(containers, func(i, j int) bool { return containers[i].CreatedAt.Before(containers[j].CreatedAt) })
+
+	restored := 0
+	for _, c := range containers {
+		if c.Status != "queued" {
+			continue
+		}
+		// Without a lease the cleanup worker removes the container anyway
+		lease, err := s.leaseRepository.GetLease(fmt.Sprintf("lease:%s", c.ID))
+		if err != nil {
+			continue
+		}
+		s.queue.Enqueue(c.ID, restoredOptions(c, lease))
+		restored++
+	}
+	if restored > 0 {
+		s.logger.Info("provisioning queue restored", slog.Int("queued", restored))
+	}
+	s.queue.Process(ctx)
+	return nil
+}
+
+// ReconcilePending provisions leases left pending by a restart. With a job queue their
+// provision jobs are normally still queued and queueing them again does nothing; leases
+// whose job was lost, or that were being provisioned in a goroutine, get a new one.
+func (s *ContainerService) ReconcilePending(ctx context.Context) error {
+	containers, err := s.containerRepository.List()
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	reconciled := 0
+	for _, c := range containers {
+		if c.Status != "pending" || c.DockerID != "" {
+			continue
+		}
+		lease, err := s.leaseRepository.GetLease(fmt.Sprintf("lease:%s", c.ID))
+		if err != nil {
+			continue
+		}
+		opts := restoredOptions(c, lease)
+		opts.ExpiryAt = c.ExpiryAt // The lease clock started when it left the queue
+		if err := s.startProvisioning(ctx, c.ID, opts); err != nil {
+			s.logger.Error("failed to reconcile pending lease", slog.String("container_id", c.ID), slog.String("error", err.Error()))
+			continue
+		}
+		reconciled++
+	}
+	if reconciled > 0 {
+		s.logger.Info("pending leases reconciled", slog.Int("pending", reconciled))
+	}
+	return nil
+}
+
+// restoredOptions rebuilds the provisioning request of a stored lease. A lease whose
+// image only exists locally stays pinned to the node holding the image.
+func restoredOptions(c *domain.Container, lease *domain.Lease) ProvisionOptions {
+	opts := ProvisionOptions{
+		TenantID:        c.TenantID,
+		ImageType:       c.ImageType,
+		Image:           c.Image,
+		DurationMinutes: lease.DurationMinutes,
+		CPUMilli:        c.CPUMilli,
+		MemoryMB:        c.MemoryMB,
+		LogDemo:         c.LogDemo,
+		VolumeSizeMB:    c.VolumeSize,
+		Preset:          c.Preset,
+		Ports:           c.Ports,
+		Entrypoint:      c.Entrypoint,
+		Command:         c.Command,
+		Env:             c.Env,
+		WorkingDir:      c.WorkingDir,
+		InitScript:      c.InitScript,
+		NodeLabels:      c.NodeLabels,
+		LocalImage:      c.LocalImage,
+	}
+	if c.LocalImage {
+		opts.NodeID = c.NodeID
+	}
+	return opts
+}
+
+// startProvisioning queues a provision job for a pending lease, or without a job queue
+// provisions it in a background goroutine
+func (s *ContainerService) startProvisioning(ctx context.Context, containerID string, opts ProvisionOptions) error {
+	if s.jobs != nil {
+		return enqueueJob(ctx, s.jobs, domain.JobProvision, containerID, provisionJob{ContainerID: containerID})
+	}
+	go s.asyncProvisionContainer(context.Background(), containerID, opts)
+	return nil
+}
+
 // asyncProvisionContainer runs the actual Docker provisioning in background
-func (s *ContainerService) asyncProvisionContainer(ctx context.Context, tempID string, imageType string, cpuMilli int, memoryMB int, logDemo bool, volumeSizeMB int) {
+func (s *ContainerService) asyncProvisionContainer(ctx context.Context, tempID string, opts ProvisionOptions) {
+	if err := s.provision(ctx, tempID, opts); err != nil {
+		s.failProvisioning(tempID, err.Error())
+	}
+}
+
+// RunProvisionJob provisions the pending lease of a provision job. Leases that are no
+// longer pending, e.g. provisioned by an earlier delivery or deleted, are left alone.
+func (s *ContainerService) RunProvisionJob(ctx context.Context, job *domain.Job) error {
+	var p provisionJob
+	if err := decodeJob(job, &p); err != nil {
+		return err
+	}
+	container, err := s.containerRepository.GetByID(p.ContainerID)
+	if err != nil {
+		return fmt.Errorf("container not found: %w", err)
+	}
+	if container.Status != "pending" || container.DockerID != "" {
+		s.logger.Debug("provision job skipped", slog.String("container_id", p.ContainerID), slog.String("status", container.Status))
+		return nil
+	}
+	lease, err := s.leaseRepository.GetLease(fmt.Sprintf("lease:%s", p.ContainerID))
+	if err != nil {
+		return fmt.Errorf("lease not found: %w", err)
+	}
+
+	if err := s.provision(ctx, p.ContainerID, restoredOptions(container, lease)); err != nil {
+		// Still pending until the last attempt; the error says why it is taking longer
+		if current, getErr := s.containerRepository.GetByID(p.ContainerID); getErr == nil && current.Status == "pending" {
+			current.Error = err.Error()
+			_ = s.containerRepository.Save(current)
+		}
+		return err
+	}
+	return nil
+}
+
+// FailProvisionJob marks the lease of a provision job that ran out of attempts as failed
+func (s *ContainerService) FailProvisionJob(ctx context.Context, job *domain.Job) {
+	var p provisionJob
+	if err := decodeJob(job, &p); err != nil {
+		s.logger.Error("failed to decode provision job", slog.String("job_id", job.ID), slog.String("error", err.Error()))
+		return
+	}
+	s.failProvisioning(p.ContainerID, job.LastError)
+}
+
+// failProvisioning marks a lease that could not be provisioned, unless it has moved on meanwhile
+func (s *ContainerService) failProvisioning(containerID, reason string) {
+	container, err := s.containerRepository.GetByID(containerID)
+	if err != nil || container.Status != "pending" {
+		return
+	}
+	container.Status = "error"
+	container.Error = reason
+	_ = s.containerRepository.Save(container)
+}
+
+// provision pulls the image and creates the volume and Docker container of a pending lease.
+// Whatever it created is removed again if a later step fails.
+func (s *ContainerService) provision(ctx context.Context, tempID string, opts ProvisionOptions) error {
 	s.logger.Info("starting async provisioning", slog.String("temp_id", tempID))
 	start := time.Now()
+	volumeSizeMB := opts.VolumeSizeMB
+
+	// Everything below happens on the node chosen for the lease
+	var nodeID string
+	if s.placement != nil {
+		node, err := s.placement.Assign(tempID, opts)
+		if err != nil {
+			s.logger.Warn("failed to place lease", slog.String("temp_id", tempID), slog.String("error", err.Error()))
+			metrics.ObserveProvision("error", time.Since(start))
+			return err
+		}
+		nodeID = node.ID
+		ctx = domain.WithNode(ctx, nodeID)
+	}
+
+	// Pull first so the container runs exactly the digest that gets recorded
+	image := opts.Image
+	if image == "" {
+		image = opts.ImageType
+	}
+	var digest string
+	var err error
+	if !opts.LocalImage {
+		digest, err = s.dockerClient.PullImage(ctx, image)
+	}
+	if err != nil {
+		s.logger.Error("failed to pull image",
+			slog.String("temp_id", tempID),
+			slog.String("image", image),
+			slog.String("error", err.Error()),
+		)
+		metrics.ObserveProvision("error", time.Since(start))
+		return err
+	}
+	runImage := image
+	if digest != "" {
+		runImage = digest
+	}
 
 	// Create volume if requested
 	var volumeID string
@@ -108,20 +490,28 @@ func (s *ContainerService) asyncProvisionContainer(ctx context.Context, tempID s
 				slog.String("error", err.Error()),
 			)
 			metrics.ObserveProvision("error", time.Since(start))
-			existingContainer, _ := s.containerRepository.GetByID(tempID)
-			if existingContainer != nil {
-				existingContainer.Status = "error"
-				existingContainer.Error = fmt.Sprintf("failed to create volume: %v", err)
-				_ = s.containerRepository.Save(existingContainer)
-			}
-			return
+			return fmt.Errorf("failed to create volume: %w", err)
 		}
 		volumeID = volName
 		s.logger.Info("volume created", slog.String("temp_id", tempID), slog.String("volume_id", volumeID))
 	}
 
 	// Create actual Docker container
-	dockerID, err := s.dockerClient.CreateContainer(ctx, imageType, cpuMilli, memoryMB, logDemo, volumeID)
+	dockerID, err := s.dockerClient.CreateContainer(ctx, domain.ContainerSpec{
+		Image:      runImage,
+		CPUMilli:   opts.CPUMilli,
+		MemoryMB:   opts.MemoryMB,
+		VolumeID:   volumeID,
+		Entrypoint: opts.Entrypoint,
+		Cmd:        opts.Command,
+		Env:        opts.Env,
+		WorkingDir: opts.WorkingDir,
+		LogDemo:    opts.LogDemo,
+
+		SecurityProfile: s.config.SecurityProfileFor(opts.TenantID, opts.Preset),
+		TenantID:        opts.TenantID,
+		Egress:          s.config.EgressFor(opts.TenantID),
+	})
 	if err != nil {
 		s.logger.Error("failed to create container",
 			slog.String("temp_id", tempID),
@@ -130,30 +520,37 @@ func (s *ContainerService) asyncProvisionContainer(ctx context.Context, tempID s
 		metrics.ObserveProvision("error", time.Since(start))
 		// Clean up volume if it was created
 		if volumeID != "" {
-			_ = s.dockerClient.RemoveVolume(context.Background(), volumeID)
+			_ = s.dockerClient.RemoveVolume(domain.WithNode(context.Background(), nodeID), volumeID)
 		}
-		// Mark as error and update
-		existingContainer, _ := s.containerRepository.GetByID(tempID)
-		if existingContainer != nil {
-			existingContainer.Status = "error"
-			existingContainer.Error = err.Error()
-			_ = s.containerRepository.Save(existingContainer)
-		}
-		return
+		return err
 	}
 
 	// Update container with real Docker ID, running status, and volume
 	existingContainer, _ := s.containerRepository.GetByID(tempID)
+	if existingContainer != nil && existingContainer.Status == "terminated" {
+		// Deleted while it was being provisioned
+		s.logger.Info("lease deleted during provisioning, removing its container", slog.String("temp_id", tempID), slog.String("docker_id", dockerID))
+		s.teardown(context.Background(), deleteJob{ContainerID: tempID, DockerID: dockerID, VolumeID: volumeID, NodeID: nodeID})
+		return nil
+	}
 	if existingContainer != nil {
 		existingContainer.DockerID = dockerID
+		existingContainer.ImageDigest = digest
 		existingContainer.Status = "running"
+		existingContainer.Error = ""
 		existingContainer.VolumeID = volumeID
 		existingContainer.VolumeSize = volumeSizeMB
+		existingContainer.CostAccruedAt = time.Now() // Billing starts once the container runs
 		_ = s.containerRepository.Save(existingContainer)
 		s.logger.Info("container successfully created", slog.String("temp_id", tempID), slog.String("docker_id", dockerID), slog.String("volume_id", volumeID))
 		metrics.ObserveProvision("success", time.Since(start))
 		metrics.IncrementActive()
 	}
+
+	if opts.InitScript != "" {
+		s.runInitScript(ctx, tempID, dockerID, opts)
+	}
+	return nil
 }
 
 // GetContainer retrieves container details
@@ -174,21 +571,33 @@ func (s *ContainerService) DeleteContainer(ctx context.Context, containerID stri
 	}
 	wasRunning := container.Status == "running"
 
-	// Only try to stop/remove if we have a Docker ID (not still pending)
-	if container.DockerID != "" {
-		// Stop and remove from Docker
-		if err := s.dockerClient.StopContainer(context.Background(), container.DockerID); err != nil {
-			s.logger.Warn("failed to stop container", slog.String("docker_id", container.DockerID), slog.String("error", err.Error()))
+	// Remove the Docker container and volume, as a job when there is a job queue
+	teardown := deleteJob{
+		ContainerID: containerID,
+		DockerID:    container.DockerID,
+		VolumeID:    container.VolumeID,
+		NodeID:      container.NodeID,
+		Paused:      container.Status == "paused",
+	}
+	if teardown.DockerID != "" || teardown.VolumeID != "" {
+		// Marked first, so the exit the teardown causes is not taken for a failure
+		container.TerminatingAt = time.Now()
+		if err := s.containerRepository.Save(container); err != nil {
+			return fmt.Errorf("failed to persist container record: %w", err)
 		}
-		if err := s.dockerClient.RemoveContainer(context.Background(), container.DockerID); err != nil {
-			s.logger.Warn("failed to remove container", slog.String("docker_id", container.DockerID), slog.String("error", err.Error()))
+		if s.jobs != nil {
+			if err := enqueueJob(ctx, s.jobs, domain.JobDelete, containerID, teardown); err != nil {
+				return err
+			}
+		} else if err := s.teardown(context.Background(), teardown); err != nil {
+			s.logger.Warn("failed to remove container", slog.String("container_id", containerID), slog.String("error", err.Error()))
 		}
 	}
 
-	// Remove volume if attached
-	if container.VolumeID != "" {
-		if err := s.dockerClient.RemoveVolume(context.Background(), container.VolumeID); err != nil {
-			s.logger.Warn("failed to remove volume", slog.String("container_id", containerID), slog.String("volume_id", container.VolumeID), slog.String("error", err.Error()))
+	// Finalize billing before the container leaves a billable status
+	if s.billing != nil {
+		if err := s.billing.Finalize(container, time.Now()); err != nil {
+			s.logger.Error("failed to finalize billing", slog.String("container_id", containerID), slog.String("error", err.Error()))
 		}
 	}
 
@@ -209,9 +618,244 @@ func (s *ContainerService) DeleteContainer(ctx context.Context, containerID stri
 	}
 	metrics.ObserveCleanup("manual", "success")
 
+	// The freed capacity may let queued leases start
+	if s.queue != nil {
+		s.queue.Process(ctx)
+	}
+
 	return nil
 }
 
+// RunDeleteJob removes the Docker container and volume of a deleted lease
+func (s *ContainerService) RunDeleteJob(ctx context.Context, job *domain.Job) error {
+	var p deleteJob
+	if err := decodeJob(job, &p); err != nil {
+		return err
+	}
+	return s.teardown(ctx, p)
+}
+
+// teardown stops and removes a lease's Docker container and volume. Stopping is best
+// effort, as removal forces the container down; removing something already gone succeeds.
+func (s *ContainerService) teardown(ctx context.Context, d deleteJob) error {
+	ctx = domain.WithNode(ctx, d.NodeID)
+	if d.DockerID != "" {
+		if d.Paused {
+			if err := s.dockerClient.UnpauseContainer(ctx, d.DockerID); err != nil {
+				s.logger.Warn("failed to unpause container", slog.String("docker_id", d.DockerID), slog.String("error", err.Error()))
+			}
+		}
+		if err := s.dockerClient.StopContainer(ctx, d.DockerID); err != nil {
+			s.logger.Warn("failed to stop container", slog.String("docker_id", d.DockerID), slog.String("error", err.Error()))
+		}
+		if err := s.dockerClient.RemoveContainer(ctx, d.DockerID); err != nil {
+			return fmt.Errorf("failed to remove container %s: %w", d.DockerID, err)
+		}
+	}
+	if d.VolumeID != "" {
+		if err := s.dockerClient.RemoveVolume(ctx, d.VolumeID); err != nil {
+			return fmt.Errorf("failed to remove volume %s: %w", d.VolumeID, err)
+		}
+	}
+	return nil
+}
+
+// PauseContainer freezes a running lease with docker pause. The container keeps its memory
+// and filesystem; its CPU is given back and it is not billed until it is resumed.
+// With the extend lease policy the lease is pushed out by MaxPauseMinutes, and given back
+// whatever part of that goes unused on resume, so it cannot expire while paused.
+func (s *ContainerService) PauseContainer(ctx context.Context, containerID string) (*domain.Container, error) {
+	container, err := s.containerRepository.GetByID(containerID)
+	if err != nil {
+		return nil, fmt.Errorf("container not found: %w", err)
+	}
+	if container.Status != "running" || container.DockerID == "" || !time.Now().Before(container.ExpiryAt) {
+		return nil, fmt.Errorf("%w: container is %s", ErrLeaseNotActive, container.Status)
+	}
+	if _, err := s.leaseRepository.GetLease(fmt.Sprintf("lease:%s", containerID)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLeaseNotActive, err)
+	}
+
+	if err := s.dockerClient.PauseContainer(ctx, container.DockerID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if s.billing != nil {
+		s.billing.Accrue(container, now) // Charged up to the pause, at the running rate
+	}
+	container.Status = "paused"
+	container.PausedAt = now
+	if s.config.PauseLeasePolicy == config.PauseLeaseExtend {
+		if _, err := updateLease(s.leaseRepository, container, func(lease *domain.Lease) bool {
+			lease.ExpiryTime = lease.ExpiryTime.Add(s.maxPause())
+			return true
+		}); err != nil {
+			return nil, fmt.Errorf("failed to persist lease: %w", err)
+		}
+	}
+	if err := s.containerRepository.Save(container); err != nil {
+		return nil, fmt.Errorf("failed to persist container record: %w", err)
+	}
+	metrics.DecrementActive()
+
+	s.logger.Info("lease paused", slog.String("container_id", containerID), slog.Time("expiry_at", container.ExpiryAt))
+	return container, nil
+}
+
+// ResumeContainer unpauses a paused lease and restarts its billing
+func (s *ContainerService) ResumeContainer(ctx context.Context, containerID string) (*domain.Container, error) {
+	container, err := s.containerRepository.GetByID(containerID)
+	if err != nil {
+		return nil, fmt.Errorf("container not found: %w", err)
+	}
+	if container.Status != "paused" {
+		return nil, fmt.Errorf("%w: container is %s", ErrNotPaused, container.Status)
+	}
+
+	if err := s.dockerClient.UnpauseContainer(ctx, container.DockerID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if s.billing != nil {
+		s.billing.Accrue(container, now) // Moves the accrual point past the paused time without charging it
+	}
+	pausedFor := now.Sub(container.PausedAt)
+	if pausedFor > s.maxPause() || container.PausedAt.IsZero() {
+		pausedFor = s.maxPause()
+	}
+	container.Status = "running"
+	container.PausedAt = time.Time{}
+	if s.config.PauseLeasePolicy == config.PauseLeaseExtend {
+		// Only the time actually spent paused is kept
+		if _, err := updateLease(s.leaseRepository, container, func(lease *domain.Lease) bool {
+			lease.ExpiryTime = lease.ExpiryTime.Add(pausedFor - s.maxPause())
+			return true
+		}); err != nil {
+			return nil, fmt.Errorf("failed to persist lease: %w", err)
+		}
+	}
+	if err := s.containerRepository.Save(container); err != nil {
+		return nil, fmt.Errorf("failed to persist container record: %w", err)
+	}
+	metrics.IncrementActive()
+
+	s.logger.Info("lease resumed",
+		slog.String("container_id", containerID),
+		slog.Duration("paused_for", pausedFor),
+		slog.Time("expiry_at", container.ExpiryAt),
+	)
+	return container, nil
+}
+
+// maxPause returns how long a lease may stay paused
+func (s *ContainerService) maxPause() time.Duration {
+	return time.Duration(s.config.MaxPauseMinutes) * time.Minute
+}
+
+// leaseWriteAttempts bounds how often a lease write is retried after losing to a concurrent one
+const leaseWriteAttempts = 3
+
+// updateLease applies change to the stored lease and writes it together with container,
+// provided no other write changed the lease in between; if one did, change runs again on the
+// lease as it is now. change returns false to leave the lease as it is.
+func updateLease(leases domain.LeaseRepository, container *domain.Container, change func(*domain.Lease) bool) (*domain.Lease, error) {
+	for attempt := 1; ; attempt++ {
+		lease, err := leases.GetLease(fmt.Sprintf("lease:%s", container.ID))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrLeaseNotActive, err)
+		}
+		read := lease.ExpiryTime
+		if !change(lease) {
+			return lease, nil
+		}
+		container.ExpiryAt = lease.ExpiryTime
+		err = leases.CompareAndExtendLease(lease, container, read)
+		if errors.Is(err, domain.ErrLeaseChanged) && attempt < leaseWriteAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return lease, nil
+	}
+}
+
+// ExtendLease adds minutes to a running lease without re-provisioning.
+// The total lifetime since creation is capped by ContainerMaxDuration.
+func (s *ContainerService) ExtendLease(ctx context.Context, containerID string, minutes int) (*domain.Container, *domain.Lease, error) {
+	if minutes <= 0 {
+		return nil, nil, ErrInvalidExtension
+	}
+	// The lease is only written if no other extension landed since it was read; if one
+	// did, the checks run again on the lease as it is now
+	for attempt := 1; ; attempt++ {
+		container, lease, err := s.extendLease(ctx, containerID, minutes)
+		if errors.Is(err, domain.ErrLeaseChanged) && attempt < leaseWriteAttempts {
+			continue
+		}
+		return container, lease, err
+	}
+}
+
+func (s *ContainerService) extendLease(ctx context.Context, containerID string, minutes int) (*domain.Container, *domain.Lease, error) {
+	// A client that gave up should not keep retrying
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	container, err := s.containerRepository.GetByID(containerID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("container not found: %w", err)
+	}
+	if container.Status != "running" && container.Status != "pending" {
+		return nil, nil, fmt.Errorf("%w: container is %s", ErrLeaseNotActive, container.Status)
+	}
+
+	leaseKey := fmt.Sprintf("lease:%s", containerID)
+	lease, err := s.leaseRepository.GetLease(leaseKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrLeaseNotActive, err)
+	}
+
+	// A hard stop may have shortened this lease; extending would undo it
+	if s.budgets != nil {
+		if err := s.budgets.CheckProvision(container.TenantID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	limit := s.config.LeaseExtensionLimit(container.TenantID)
+	if limit >= 0 && lease.ExtensionCount >= limit {
+		return nil, nil, fmt.Errorf("%w: %d of %d used", ErrExtensionLimitReached, lease.ExtensionCount, limit)
+	}
+
+	newExpiry := lease.ExpiryTime.Add(time.Duration(minutes) * time.Minute)
+	maxExpiry := container.CreatedAt.Add(time.Duration(s.config.ContainerMaxDuration) * time.Minute)
+	if newExpiry.After(maxExpiry) {
+		return nil, nil, fmt.Errorf("%w: lease may not run past %s", ErrMaxDurationExceeded, maxExpiry.Format(time.RFC3339))
+	}
+
+	read := lease.ExpiryTime
+	lease.ExpiryTime = newExpiry
+	lease.DurationMinutes += minutes
+	lease.ExtensionCount++
+	container.ExpiryAt = newExpiry
+
+	if err := s.leaseRepository.CompareAndExtendLease(lease, container, read); err != nil {
+		return nil, nil, fmt.Errorf("failed to persist lease extension: %w", err)
+	}
+
+	s.logger.Info("lease extended",
+		slog.String("container_id", containerID),
+		slog.Int("minutes", minutes),
+		slog.Time("expiry_at", newExpiry),
+		slog.Int("extension_count", lease.ExtensionCount),
+	)
+
+	return container, lease, nil
+}
+
 // generateContainerID generates a unique container ID
 func generateContainerID() string {
 	return fmt.Sprintf("container-%d", rand.Int63())
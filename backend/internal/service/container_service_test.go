@@ -0,0 +1,423 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+type memContainerRepo struct {
+	byID map[string]*domain.Container
+}
+
+func newMemContainerRepo() *memContainerRepo {
+	return &memContainerRepo{byID: map[string]*domain.Container{}}
+}
+
+func (m *memContainerRepo) GetByID(id string) (*domain.Container, error) {
+	if c, ok := m.byID[id]; ok {
+		cp := *c
+		return &cp, nil
+	}
+	return nil, errors.New("not found")
+}
+func (m *memContainerRepo) Save(c *domain.Container) error {
+	cp := *c
+	m.byID[c.ID] = &cp
+	return nil
+}
+func (m *memContainerRepo) Delete(id string) error { delete(m.byID, id); return nil }
+func (m *memContainerRepo) List() ([]*domain.Container, error) {
+	out := []*domain.Container{}
+	for _, c := range m.byID {
+		cp := *c
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+func (m *memContainerRepo) ListByTenant(tenantID string) ([]*domain.Container, error) {
+	out := []*domain.Container{}
+	for _, c := range m.byID {
+		if c.TenantID == tenantID {
+			cp := *c
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+type memLeaseRepo struct {
+	byKey      map[string]*domain.Lease
+	containers *memContainerRepo
+	// beforeExtend runs ahead of each compare-and-extend, to simulate another server
+	beforeExtend func()
+}
+
+func newMemLeaseRepo(containers *memContainerRepo) *memLeaseRepo {
+	return &memLeaseRepo{byKey: map[string]*domain.Lease{}, containers: containers}
+}
+
+func (m *memLeaseRepo) CreateLease(l *domain.Lease) error {
+	cp := *l
+	m.byKey[l.LeaseKey] = &cp
+	return nil
+}
+func (m *memLeaseRepo) GetLease(key string) (*domain.Lease, error) {
+	if l, ok := m.byKey[key]; ok {
+		cp := *l
+		return &cp, nil
+	}
+	return nil, errors.New("not found")
+}
+func (m *memLeaseRepo) DeleteLease(key string) error { delete(m.byKey, key); return nil }
+func (m *memLeaseRepo) GetExpiredLeases() ([]string, error) {
+	out := []string{}
+	for _, l := range m.byKey {
+		if !l.ExpiryTime.After(time.Now()) {
+			out = append(out, l.ContainerID)
+		}
+	}
+	return out, nil
+}
+func (m *memLeaseRepo) CompareAndExtendLease(l *domain.Lease, c *domain.Container, expiryTime time.Time) error {
+	if m.beforeExtend != nil {
+		m.beforeExtend()
+	}
+	if stored, ok := m.byKey[l.LeaseKey]; !ok || !stored.ExpiryTime.Equal(expiryTime) {
+		return domain.ErrLeaseChanged
+	}
+	_ = m.CreateLease(l)
+	return m.containers.Save(c)
+}
+
+func newTestContainerService(cfg *config.Config) (*ContainerService, *memContainerRepo, *memLeaseRepo) {
+	containers := newMemContainerRepo()
+	leases := newMemLeaseRepo(containers)
+	return NewContainerService(nil, leases, containers, slog.Default(), cfg), containers, leases
+}
+
+func seedLease(containers *memContainerRepo, leases *memLeaseRepo, id, tenantID string, created time.Time, minutes int) {
+	expiry := created.Add(time.Duration(minutes) * time.Minute)
+	_ = containers.Save(&domain.Container{ID: id, TenantID: tenantID, Status: "running", CreatedAt: created, ExpiryAt: expiry})
+	_ = leases.CreateLease(&domain.Lease{ContainerID: id, LeaseKey: "lease:" + id, ExpiryTime: expiry, DurationMinutes: minutes, CreatedAt: created})
+}
+
+func TestExtendLease(t *testing.T) {
+	cfg := &config.Config{ContainerMaxDuration: 60, MaxLeaseExtensions: 3}
+	s, containers, leases := newTestContainerService(cfg)
+	created := time.Now()
+	seedLease(containers, leases, "c1", "tenant-1", created, 30)
+
+	c, l, err := s.ExtendLease(context.Background(), "c1", 15)
+	if err != nil {
+		t.Fatalf("extend failed: %v", err)
+	}
+	want := created.Add(45 * time.Minute)
+	if !c.ExpiryAt.Equal(want) || !l.ExpiryTime.Equal(want) {
+		t.Fatalf("expected expiry %v, got container=%v lease=%v", want, c.ExpiryAt, l.ExpiryTime)
+	}
+	if l.ExtensionCount != 1 || l.DurationMinutes != 45 {
+		t.Fatalf("expected 1 extension and 45 minutes, got %d and %d", l.ExtensionCount, l.DurationMinutes)
+	}
+
+	// Both records must have been persisted
+	stored, _ := containers.GetByID("c1")
+	if !stored.ExpiryAt.Equal(want) {
+		t.Fatalf("container record not updated")
+	}
+
+	// Total lifetime is capped by ContainerMaxDuration
+	if _, _, err := s.ExtendLease(context.Background(), "c1", 20); !errors.Is(err, ErrMaxDurationExceeded) {
+		t.Fatalf("expected max duration error, got %v", err)
+	}
+
+	// Non-positive extensions are rejected
+	if _, _, err := s.ExtendLease(context.Background(), "c1", 0); !errors.Is(err, ErrInvalidExtension) {
+		t.Fatalf("expected invalid extension error, got %v", err)
+	}
+}
+
+func TestExtendLeaseTenantLimit(t *testing.T) {
+	cfg := &config.Config{
+		ContainerMaxDuration:  120,
+		MaxLeaseExtensions:    3,
+		TenantLeaseExtensions: map[string]int{"tenant-strict": 1},
+	}
+	s, containers, leases := newTestContainerService(cfg)
+	seedLease(containers, leases, "c1", "tenant-strict", time.Now(), 10)
+
+	if _, _, err := s.ExtendLease(context.Background(), "c1", 5); err != nil {
+		t.Fatalf("first extension failed: %v", err)
+	}
+	_, _, err := s.ExtendLease(context.Background(), "c1", 5)
+	if !errors.Is(err, ErrExtensionLimitReached) {
+		t.Fatalf("expected extension limit error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "1 of 1") {
+		t.Fatalf("expected usage in error, got %q", err.Error())
+	}
+}
+
+func TestExtendLeaseConcurrentExtension(t *testing.T) {
+	cfg := &config.Config{ContainerMaxDuration: 120, MaxLeaseExtensions: 2}
+	s, containers, leases := newTestContainerService(cfg)
+	created := time.Now()
+	seedLease(containers, leases, "c1", "tenant-1", created, 10)
+
+	// Another server extends the lease between our read and our write
+	raced := false
+	leases.beforeExtend = func() {
+		if !raced {
+			raced = true
+			leases.byKey["lease:c1"].ExtensionCount++
+			leases.byKey["lease:c1"].ExpiryTime = leases.byKey["lease:c1"].ExpiryTime.Add(5 * time.Minute)
+		}
+	}
+	_, l, err := s.ExtendLease(context.Background(), "c1", 5)
+	if err != nil {
+		t.Fatalf("expected the extension retried, got %v", err)
+	}
+	if l.ExtensionCount != 2 || !l.ExpiryTime.Equal(created.Add(20*time.Minute)) {
+		t.Fatalf("expected the extension applied on top of the other one, got %d extensions until %v", l.ExtensionCount, l.ExpiryTime)
+	}
+
+	// The limit counts the concurrent extension too
+	if _, _, err := s.ExtendLease(context.Background(), "c1", 5); !errors.Is(err, ErrExtensionLimitReached) {
+		t.Fatalf("expected extension limit error, got %v", err)
+	}
+
+	// A lease that keeps changing gives up instead of looping
+	seedLease(containers, leases, "c2", "tenant-1", created, 10)
+	leases.beforeExtend = func() { leases.byKey["lease:c2"].ExpiryTime = leases.byKey["lease:c2"].ExpiryTime.Add(time.Second) }
+	if _, _, err := s.ExtendLease(context.Background(), "c2", 5); !errors.Is(err, domain.ErrLeaseChanged) {
+		t.Fatalf("expected lease changed error, got %v", err)
+	}
+}
+
+func TestExtendLeaseTerminated(t *testing.T) {
+	s, containers, leases := newTestContainerService(&config.Config{ContainerMaxDuration: 120, MaxLeaseExtensions: -1})
+	seedLease(containers, leases, "c1", "tenant-1", time.Now(), 10)
+	c, _ := containers.GetByID("c1")
+	c.Status = "terminated"
+	_ = containers.Save(c)
+
+	if _, _, err := s.ExtendLease(context.Background(), "c1", 5); !errors.Is(err, ErrLeaseNotActive) {
+		t.Fatalf("expected lease not active error, got %v", err)
+	}
+}
+
+func newPausableLease(cfg *config.Config) (*ContainerService, *memContainerRepo, time.Time) {
+	containers := newMemContainerRepo()
+	leases := newMemLeaseRepo(containers)
+	s := NewContainerService(&pauseDocker{paused: map[string]bool{}}, leases, containers, slog.Default(), cfg)
+	seedLease(containers, leases, "c1", "tenant-1", time.Now(), 30)
+	c, _ := containers.GetByID("c1")
+	c.DockerID = "docker-1"
+	c.Preset = "small"
+	c.CostAccruedAt = c.CreatedAt
+	_ = containers.Save(c)
+	return s, containers, c.ExpiryAt
+}
+
+// pausedFor moves a paused lease back in time, as if it was paused d ago
+func pausedFor(containers *memContainerRepo, d time.Duration) {
+	c, _ := containers.GetByID("c1")
+	c.PausedAt = c.PausedAt.Add(-d)
+	c.CostAccruedAt = c.CostAccruedAt.Add(-d)
+	_ = containers.Save(c)
+}
+
+func TestPauseExtendPolicyKeepsPausedTime(t *testing.T) {
+	s, containers, expiry := newPausableLease(&config.Config{ContainerMaxDuration: 120, PauseLeasePolicy: config.PauseLeaseExtend, MaxPauseMinutes: 60})
+
+	c, err := s.PauseContainer(context.Background(), "c1")
+	if err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	// Held open for the longest possible pause until the lease is resumed
+	if c.Status != "paused" || !c.ExpiryAt.Equal(expiry.Add(time.Hour)) {
+		t.Fatalf("expected paused lease expiring at %v, got %s %v", expiry.Add(time.Hour), c.Status, c.ExpiryAt)
+	}
+	if _, _, err := s.ExtendLease(context.Background(), "c1", 5); !errors.Is(err, ErrLeaseNotActive) {
+		t.Fatalf("paused lease must not be extended, got %v", err)
+	}
+
+	pausedFor(containers, 10*time.Minute)
+	if c, err = s.ResumeContainer(context.Background(), "c1"); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if d := c.ExpiryAt.Sub(expiry.Add(10 * time.Minute)); c.Status != "running" || d < 0 || d > time.Second {
+		t.Fatalf("expected running lease expiring 10 minutes later than %v, got %s %v", expiry, c.Status, c.ExpiryAt)
+	}
+	if _, err := s.ResumeContainer(context.Background(), "c1"); !errors.Is(err, ErrNotPaused) {
+		t.Fatalf("expected not paused error, got %v", err)
+	}
+}
+
+func TestPauseKeepsConcurrentExtension(t *testing.T) {
+	s, _, expiry := newPausableLease(&config.Config{ContainerMaxDuration: 120, MaxLeaseExtensions: -1, PauseLeasePolicy: config.PauseLeaseExtend, MaxPauseMinutes: 60})
+	leases := s.leaseRepository.(*memLeaseRepo)
+
+	// Another server extends the lease while the pause is being written
+	raced := false
+	leases.beforeExtend = func() {
+		if !raced {
+			raced = true
+			leases.byKey["lease:c1"].ExtensionCount++
+			leases.byKey["lease:c1"].ExpiryTime = leases.byKey["lease:c1"].ExpiryTime.Add(15 * time.Minute)
+		}
+	}
+	c, err := s.PauseContainer(context.Background(), "c1")
+	if err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	want := expiry.Add(15*time.Minute + time.Hour)
+	if l := leases.byKey["lease:c1"]; !c.ExpiryAt.Equal(want) || !l.ExpiryTime.Equal(want) || l.ExtensionCount != 1 {
+		t.Fatalf("expected the pause applied on top of the extension until %v, got %v with %d extensions", want, l.ExpiryTime, l.ExtensionCount)
+	}
+}
+
+func TestPauseFreezesBilling(t *testing.T) {
+	billing, _ := newTestBillingService()
+	s, containers, expiry := newPausableLease(&config.Config{PauseLeasePolicy: config.PauseLeaseRun, MaxPauseMinutes: 180})
+	s.WithBilling(billing)
+
+	// One hour at the preset's 0.5/h before the pause
+	c, _ := containers.GetByID("c1")
+	c.CostAccruedAt = c.CostAccruedAt.Add(-time.Hour)
+	_ = containers.Save(c)
+	if _, err := s.PauseContainer(context.Background(), "c1"); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	pausedFor(containers, 2*time.Hour)
+
+	c, err := s.ResumeContainer(context.Background(), "c1")
+	if err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if math.Abs(c.Cost-0.5) > 1e-3 {
+		t.Fatalf("expected only the running hour charged (0.5), got %v", c.Cost)
+	}
+	if !c.ExpiryAt.Equal(expiry) {
+		t.Fatalf("run policy must not move the expiry, got %v want %v", c.ExpiryAt, expiry)
+	}
+}
+
+// pullDocker resolves every pull to a fixed digest and records what was run
+type pullDocker struct {
+	domain.DockerClient
+	digest string
+	ran    domain.ContainerSpec
+	script string // Init script output
+	exit   int
+}
+
+func (d *pullDocker) PullImage(ctx context.Context, image string) (string, error) {
+	return d.digest, nil
+}
+func (d *pullDocker) CreateContainer(ctx context.Context, spec domain.ContainerSpec) (string, error) {
+	d.ran = spec
+	return "docker-1", nil
+}
+func (d *pullDocker) RunCommand(ctx context.Context, containerID string, cmd []string, output io.Writer) (int, error) {
+	_, _ = io.WriteString(output, d.script)
+	return d.exit, nil
+}
+
+func TestProvisionRunsAndRecordsResolvedDigest(t *testing.T) {
+	docker := &pullDocker{digest: "docker.io/library/alpine@sha256:" + strings.Repeat("c", 64)}
+	containers := newMemContainerRepo()
+	s := NewContainerService(docker, newMemLeaseRepo(containers), containers, slog.Default(), &config.Config{})
+	_ = containers.Save(&domain.Container{ID: "c1", TenantID: "tenant-1", Status: "pending"})
+
+	s.asyncProvisionContainer(context.Background(), "c1", ProvisionOptions{ImageType: "alpine", Image: "docker.io/library/alpine:latest"})
+
+	c, _ := containers.GetByID("c1")
+	if c.Status != "running" || c.ImageDigest != docker.digest || docker.ran.Image != docker.digest {
+		t.Fatalf("expected container pinned to %s, got status=%s digest=%q ran=%q", docker.digest, c.Status, c.ImageDigest, docker.ran.Image)
+	}
+}
+
+func TestProvisionRunsInitScriptAndRedactsSecrets(t *testing.T) {
+	docker := &pullDocker{script: "connecting with hunter22\nready\n", exit: 3}
+	containers := newMemContainerRepo()
+	s := NewContainerService(docker, newMemLeaseRepo(containers), containers, slog.Default(), &config.Config{InitTimeoutSeconds: 5})
+	_ = containers.Save(&domain.Container{ID: "c1", TenantID: "tenant-1", Status: "pending", InitStatus: domain.InitPending})
+
+	env := []domain.EnvVar{{Name: "MODE", Value: "dev"}, {Name: "DB_PASSWORD", Value: "hunter22", Secret: true}}
+	s.asyncProvisionContainer(context.Background(), "c1", ProvisionOptions{
+		Image: "docker.io/library/alpine:latest", Command: []string{"python", "app.py"}, Env: env,
+		WorkingDir: "/srv", InitScript: "echo setup",
+	})
+
+	if docker.ran.WorkingDir != "/srv" || len(docker.ran.Cmd) != 2 || docker.ran.Env[1].Value != "hunter22" {
+		t.Fatalf("spec not passed to docker: %+v", docker.ran)
+	}
+	c, _ := containers.GetByID("c1")
+	if c.InitStatus != domain.InitFailed || c.InitExitCode != 3 {
+		t.Fatalf("expected failed init with exit 3, got %s %d", c.InitStatus, c.InitExitCode)
+	}
+	if strings.Contains(c.InitOutput, "hunter22") || !strings.Contains(c.InitOutput, RedactedValue+"\nready") {
+		t.Fatalf("expected secret redacted from output, got %q", c.InitOutput)
+	}
+	if redacted := RedactEnv(env); redacted[0].Value != "dev" || redacted[1].Value != RedactedValue {
+		t.Fatalf("unexpected redacted env: %+v", redacted)
+	}
+}
+
+func TestProvisionAppliesAssignedSecurityProfile(t *testing.T) {
+	docker := &pullDocker{}
+	containers := newMemContainerRepo()
+	cfg := &config.Config{
+		DefaultSecurityProfile: config.SecurityBaseline,
+		TenantSecurityProfiles: map[string]string{"tenant-strict": config.SecurityRestricted},
+		PresetSecurityProfiles: map[string]string{"untrusted": config.SecurityRestricted},
+	}
+	s := NewContainerService(docker, newMemLeaseRepo(containers), containers, slog.Default(), cfg)
+
+	cases := []struct {
+		tenant, preset, want string
+	}{
+		{"tenant-1", "", config.SecurityBaseline},
+		{"tenant-1", "untrusted", config.SecurityRestricted},
+		{"tenant-strict", "", config.SecurityRestricted},
+	}
+	for _, tc := range cases {
+		_ = containers.Save(&domain.Container{ID: "c1", TenantID: tc.tenant, Status: "pending"})
+		s.asyncProvisionContainer(context.Background(), "c1", ProvisionOptions{
+			TenantID: tc.tenant, Preset: tc.preset, Image: "docker.io/library/alpine:latest",
+		})
+		if docker.ran.SecurityProfile != tc.want {
+			t.Errorf("tenant %s preset %q: expected profile %s, got %q", tc.tenant, tc.preset, tc.want, docker.ran.SecurityProfile)
+		}
+	}
+}
+
+func TestProvisionJoinsTenantNetworkWithEgress(t *testing.T) {
+	docker := &pullDocker{}
+	containers := newMemContainerRepo()
+	cfg := &config.Config{DefaultEgress: config.EgressFull, TenantEgress: map[string]string{"tenant-offline": config.EgressNone}}
+	s := NewContainerService(docker, newMemLeaseRepo(containers), containers, slog.Default(), cfg)
+
+	for tenant, want := range map[string]string{"tenant-1": config.EgressFull, "tenant-offline": config.EgressNone} {
+		_ = containers.Save(&domain.Container{ID: "c1", TenantID: tenant, Status: "pending"})
+		s.asyncProvisionContainer(context.Background(), "c1", ProvisionOptions{TenantID: tenant, Image: "docker.io/library/alpine:latest"})
+		if docker.ran.TenantID != tenant || docker.ran.Egress != want {
+			t.Errorf("expected %s on its own network with %s egress, got tenant=%q egress=%q", tenant, want, docker.ran.TenantID, docker.ran.Egress)
+		}
+	}
+
+	// Self-healing recreates the container on the same network
+	recorded := &domain.Container{TenantID: "tenant-offline", Egress: config.EgressNone}
+	if spec := recorded.Spec(); spec.TenantID != "tenant-offline" || spec.Egress != config.EgressNone {
+		t.Fatalf("recreate spec lost the network: %+v", spec)
+	}
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+var (
+	ErrPathNotAllowed = errors.New("path is outside the allowed files root")
+	ErrInvalidArchive = errors.New("invalid tar archive")
+	ErrUploadTooLarge = errors.New("upload exceeds the size limit")
+)
+
+// UploadTooLargeError is returned when an upload would not fit in the container's storage allowance
+type UploadTooLargeError struct {
+	LimitBytes int64
+}
+
+func (e *UploadTooLargeError) Error() string {
+	return fmt.Sprintf("upload exceeds the size limit of %d bytes", e.LimitBytes)
+}
+
+func (e *UploadTooLargeError) Unwrap() error { return ErrUploadTooLarge }
+
+// FileDownload is a file's contents or, for a directory, a tar archive of it
+type FileDownload struct {
+	io.ReadCloser
+	Stat    *domain.FileStat
+	Archive bool
+}
+
+// FileService copies files into and out of leased containers, confined to the configured files root
+type FileService struct {
+	dockerClient  domain.DockerClient
+	containerRepo domain.ContainerRepository
+	quotas        *QuotaService
+	logger        *slog.Logger
+	config        *config.Config
+	mu            sync.Mutex
+	uploading     map[string]*uploadLock // Container ID -> lock held while uploading into it
+}
+
+// uploadLock serialises uploads into one container so each sees the bytes the last one wrote
+type uploadLock struct {
+	sync.Mutex
+	waiters int
+}
+
+// NewFileService creates a new file service
+func NewFileService(dockerClient domain.DockerClient, containerRepo domain.ContainerRepository, logger *slog.Logger, cfg *config.Config) *FileService {
+	return &FileService{
+		dockerClient:  dockerClient,
+		containerRepo: containerRepo,
+		logger:        logger,
+		config:        cfg,
+		uploading:     map[string]*uploadLock{},
+	}
+}
+
+// WithQuotas limits uploads into containers without a volume to the tenant's remaining volume quota
+func (s *FileService) WithQuotas(quotas *QuotaService) *FileService {
+	s.quotas = quotas
+	return s
+}
+
+// ResolvePath returns the absolute, cleaned form of p. Relative paths are taken from the files root.
+func (s *FileService) ResolvePath(p string) (string, error) {
+	if p == "" {
+		return "", ErrPathNotAllowed
+	}
+	root := s.config.FilesRoot
+	if !path.IsAbs(p) {
+		p = path.Join(root, p)
+	}
+	p = path.Clean(p)
+	if p != root && !strings.HasPrefix(p, root+"/") {
+		return "", ErrPathNotAllowed
+	}
+	return p, nil
+}
+
+// UploadLimit returns the largest upload, in bytes, a container accepts.
+// Uploads into a volume are capped at the volume's size. Otherwise every upload is
+// counted on the container: together they may use the per-container volume maximum,
+// and they count against the tenant's volume quota.
+func (s *FileService) UploadLimit(container *domain.Container) (int64, error) {
+	if container.VolumeSize > 0 {
+		return int64(container.VolumeSize) << 20, nil
+	}
+	limit := int64(s.config.MaxVolumeMB)<<20 - container.UploadedBytes
+	if s.quotas != nil {
+		quota, err := s.quotas.GetQuota(container.TenantID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load quota: %w", err)
+		}
+		if quota.MaxVolumeMB != domain.Unlimited {
+			usage, err := s.quotas.GetUsage(container.TenantID)
+			if err != nil {
+				return 0, fmt.Errorf("failed to load usage: %w", err)
+			}
+			limit = min(limit, int64(quota.MaxVolumeMB-usage.VolumeMB)<<20)
+		}
+	}
+	return max(limit, 0), nil
+}
+
+// Upload writes a file of the given size to dst, or, when archive is set,
+// extracts a tar stream into the directory dst. Missing parent directories are created.
+func (s *FileService) Upload(ctx context.Context, container *domain.Container, dst string, body io.Reader, size int64, archive bool) error {
+	dst, err := s.ResolvePath(dst)
+	if err != nil {
+		return err
+	}
+	if !archive && dst == s.config.FilesRoot {
+		return ErrPathNotAllowed // A file cannot replace the root itself
+	}
+
+	unlock := s.lockContainer(container.ID)
+	defer unlock()
+	// Re-read the container for the bytes earlier uploads wrote
+	if current, err := s.containerRepo.GetByID(container.ID); err == nil {
+		container = current
+	}
+	limit, err := s.UploadLimit(container)
+	if err != nil {
+		return err
+	}
+	if !archive && size > limit {
+		return &UploadTooLargeError{LimitBytes: limit}
+	}
+
+	// Entries are re-rooted at the files root so Docker creates missing parents
+	pr, pw := io.Pipe()
+	werr := make(chan error, 1)
+	written := size
+	go func() {
+		var err error
+		if archive {
+			written, err = s.rewriteArchive(pw, body, dst, limit)
+		} else {
+			err = s.writeFile(pw, body, dst, size)
+		}
+		pw.CloseWithError(err)
+		werr <- err
+	}()
+
+	err = s.dockerClient.CopyTo(ctx, container.DockerID, s.config.FilesRoot, pr)
+	pr.CloseWithError(io.ErrClosedPipe) // Unblock the writer if Docker stopped reading early
+	if archiveErr := <-werr; archiveErr != nil && !errors.Is(archiveErr, io.ErrClosedPipe) {
+		return archiveErr
+	}
+	if err != nil {
+		return err
+	}
+
+	// Files written outside a volume use the host's disk until the lease ends
+	if container.VolumeSize == 0 {
+		current, err := s.containerRepo.GetByID(container.ID)
+		if err != nil {
+			return fmt.Errorf("failed to record upload size: %w", err)
+		}
+		current.UploadedBytes += written
+		if err := s.containerRepo.Save(current); err != nil {
+			return fmt.Errorf("failed to record upload size: %w", err)
+		}
+	}
+
+	s.logger.Info("files uploaded to container",
+		slog.String("container_id", container.ID),
+		slog.String("path", dst),
+		slog.Bool("archive", archive),
+		slog.Int64("bytes", written),
+	)
+	return nil
+}
+
+// lockContainer serialises uploads into a container and returns the unlock function
+func (s *FileService) lockContainer(containerID string) func() {
+	s.mu.Lock()
+	l, ok := s.uploading[containerID]
+	if !ok {
+		l = &uploadLock{}
+		s.uploading[containerID] = l
+	}
+	l.waiters++
+	s.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		s.mu.Lock()
+		if l.waiters--; l.waiters == 0 {
+			delete(s.uploading, containerID)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Download returns the contents of the file at src, or a tar archive if src is a directory
+func (s *FileService) Download(ctx context.Context, container *domain.Container, src string) (*FileDownload, error) {
+	src, err := s.ResolvePath(src)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, stat, err := s.dockerClient.CopyFrom(ctx, container.DockerID, src)
+	if err != nil {
+		return nil, err
+	}
+	if !stat.Mode.IsRegular() {
+		return &FileDownload{ReadCloser: rc, Stat: stat, Archive: true}, nil
+	}
+
+	// A single file arrives as a one-entry archive; unwrap it
+	tr := tar.NewReader(rc)
+	if _, err := tr.Next(); err != nil {
+		rc.Close()
+		return nil, fmt.Errorf("failed to read file from container: %w", err)
+	}
+	return &FileDownload{ReadCloser: readCloser{Reader: tr, Closer: rc}, Stat: stat}, nil
+}
+
+// writeFile wraps a single file in a tar stream
+func (s *FileService) writeFile(w io.Writer, body io.Reader, dst string, size int64) error {
+	tw := tar.NewWriter(w)
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     s.entryName(dst),
+		Mode:     0o644,
+		Size:     size,
+		ModTime:  time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tw, io.LimitReader(body, size)); err != nil {
+		return err
+	}
+	return tw.Close() // Fails if the body was shorter than size
+}
+
+// rewriteArchive copies a client tar stream, moving every entry under dst and
+// enforcing the size limit on the extracted contents. It returns their total size.
+func (s *FileService) rewriteArchive(w io.Writer, body io.Reader, dst string, limit int64) (int64, error) {
+	tr := tar.NewReader(body)
+	tw := tar.NewWriter(w)
+	var total int64
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+
+		target, err := s.archiveTarget(dst, hdr.Name)
+		if err != nil {
+			return 0, err
+		}
+		hdr.Name = s.entryName(target)
+		if hdr.Typeflag == tar.TypeDir {
+			hdr.Name += "/"
+		}
+		if hdr.Typeflag == tar.TypeLink {
+			link, err := s.archiveTarget(dst, hdr.Linkname)
+			if err != nil {
+				return 0, err
+			}
+			hdr.Linkname = s.entryName(link)
+		}
+
+		total += hdr.Size
+		if total > limit {
+			return 0, &UploadTooLargeError{LimitBytes: limit}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return 0, err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+		}
+	}
+	return total, tw.Close()
+}
+
+// archiveTarget resolves an archive entry name against dst, refusing entries that escape it
+func (s *FileService) archiveTarget(dst, name string) (string, error) {
+	clean := path.Clean(name)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("%w: entry %q escapes the upload directory", ErrInvalidArchive, name)
+	}
+	return path.Join(dst, clean), nil
+}
+
+// entryName returns p relative to the files root, as a tar entry name
+func (s *FileService) entryName(p string) string {
+	if p == s.config.FilesRoot {
+		return "."
+	}
+	return strings.TrimPrefix(p, s.config.FilesRoot+"/")
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
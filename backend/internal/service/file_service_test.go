@@ -0,0 +1,234 @@
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// copyDocker records archives copied into a container and serves one path out of it
+type copyDocker struct {
+	domain.DockerClient
+	dstDir  string
+	entries map[string]string // Extracted entry name -> contents
+	stat    *domain.FileStat
+	archive []byte
+}
+
+func (d *copyDocker) CopyTo(ctx context.Context, containerID string, dstDir string, archive io.Reader) error {
+	d.dstDir = dstDir
+	d.entries = map[string]string{}
+	tr := tar.NewReader(archive)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		d.entries[hdr.Name] = string(data)
+	}
+}
+
+func (d *copyDocker) CopyFrom(ctx context.Context, containerID string, srcPath string) (io.ReadCloser, *domain.FileStat, error) {
+	if d.stat == nil {
+		return nil, nil, domain.ErrPathNotFound
+	}
+	return io.NopCloser(bytes.NewReader(d.archive)), d.stat, nil
+}
+
+func newTestFileService(containers *memContainerRepo, quotas *QuotaService) (*FileService, *copyDocker) {
+	docker := &copyDocker{}
+	cfg := &config.Config{FilesRoot: "/data", MaxVolumeMB: 10}
+	if containers == nil {
+		containers = newMemContainerRepo()
+	}
+	svc := NewFileService(docker, containers, slog.Default(), cfg)
+	if quotas != nil {
+		svc.WithQuotas(quotas)
+	}
+	return svc, docker
+}
+
+func makeTar(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, data := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = tw.Write([]byte(data))
+	}
+	_ = tw.Close()
+	return buf.Bytes()
+}
+
+func TestResolvePathStaysUnderRoot(t *testing.T) {
+	svc, _ := newTestFileService(nil, nil)
+
+	cases := map[string]string{
+		"fixtures/in.txt":     "/data/fixtures/in.txt",
+		"/data/out":           "/data/out",
+		"/data/a/../b":        "/data/b",
+		"/data":               "/data",
+		"/etc/passwd":         "",
+		"../etc/passwd":       "",
+		"/data/../etc":        "",
+		"/database/lookalike": "",
+		"":                    "",
+	}
+	for in, want := range cases {
+		got, err := svc.ResolvePath(in)
+		if want == "" {
+			if !errors.Is(err, ErrPathNotAllowed) {
+				t.Errorf("ResolvePath(%q) = %q, %v; want ErrPathNotAllowed", in, got, err)
+			}
+			continue
+		}
+		if err != nil || got != want {
+			t.Errorf("ResolvePath(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+}
+
+func TestUploadFileCreatesEntryUnderRoot(t *testing.T) {
+	containers := newMemContainerRepo()
+	svc, docker := newTestFileService(containers, nil)
+	container := &domain.Container{ID: "c1", DockerID: "docker-1", TenantID: "tenant-1"}
+	_ = containers.Save(container)
+
+	body := "hello"
+	if err := svc.Upload(context.Background(), container, "fixtures/in.txt", strings.NewReader(body), int64(len(body)), false); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if docker.dstDir != "/data" || docker.entries["fixtures/in.txt"] != body {
+		t.Fatalf("unexpected copy: dir=%q entries=%v", docker.dstDir, docker.entries)
+	}
+}
+
+func TestUploadArchiveIsRerootedAndRejectsEscapes(t *testing.T) {
+	containers := newMemContainerRepo()
+	svc, docker := newTestFileService(containers, nil)
+	container := &domain.Container{ID: "c1", DockerID: "docker-1", TenantID: "tenant-1"}
+	_ = containers.Save(container)
+
+	archive := makeTar(t, map[string]string{"a.txt": "a", "sub/b.txt": "b"})
+	if err := svc.Upload(context.Background(), container, "/data/in", bytes.NewReader(archive), -1, true); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if docker.entries["in/a.txt"] != "a" || docker.entries["in/sub/b.txt"] != "b" {
+		t.Fatalf("unexpected entries: %v", docker.entries)
+	}
+
+	evil := makeTar(t, map[string]string{"../../etc/passwd": "x"})
+	err := svc.Upload(context.Background(), container, "/data/in", bytes.NewReader(evil), -1, true)
+	if !errors.Is(err, ErrInvalidArchive) {
+		t.Fatalf("expected ErrInvalidArchive, got %v", err)
+	}
+}
+
+func TestUploadLimitFollowsVolumeQuota(t *testing.T) {
+	containers := newMemContainerRepo()
+	_ = containers.Save(&domain.Container{ID: "other", TenantID: "tenant-1", Status: "running", VolumeSize: 3})
+	quotaRepo := &memQuotaRepo{byTenant: map[string]*domain.TenantQuota{
+		"tenant-1": {TenantID: "tenant-1", MaxContainers: -1, MaxCPUMilli: -1, MaxMemoryMB: -1, MaxVolumeMB: 4, MaxSnapshots: -1},
+	}}
+	quotas := NewQuotaService(quotaRepo, containers, nil, slog.Default(), &config.Config{})
+	svc, _ := newTestFileService(containers, quotas)
+
+	// With a volume, uploads are capped at the volume size
+	withVolume := &domain.Container{ID: "c1", DockerID: "docker-1", TenantID: "tenant-1", VolumeSize: 2}
+	if limit, _ := svc.UploadLimit(withVolume); limit != 2<<20 {
+		t.Fatalf("expected 2MB limit, got %d", limit)
+	}
+
+	// Without one, only the tenant's remaining volume quota is available
+	noVolume := &domain.Container{ID: "c2", DockerID: "docker-2", TenantID: "tenant-1"}
+	if limit, _ := svc.UploadLimit(noVolume); limit != 1<<20 {
+		t.Fatalf("expected 1MB limit, got %d", limit)
+	}
+
+	big := makeTar(t, map[string]string{"big.bin": strings.Repeat("x", 1<<20+1)})
+	err := svc.Upload(context.Background(), noVolume, "/data", bytes.NewReader(big), -1, true)
+	var tooLarge *UploadTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.LimitBytes != 1<<20 {
+		t.Fatalf("expected UploadTooLargeError, got %v", err)
+	}
+	err = svc.Upload(context.Background(), noVolume, "/data/big.bin", strings.NewReader(""), 1<<20+1, false)
+	if !errors.Is(err, ErrUploadTooLarge) {
+		t.Fatalf("expected ErrUploadTooLarge for a raw file, got %v", err)
+	}
+}
+
+func TestUploadsWithoutVolumeAreCounted(t *testing.T) {
+	containers := newMemContainerRepo()
+	_ = containers.Save(&domain.Container{ID: "other", TenantID: "tenant-1", Status: "running", VolumeSize: 3})
+	quotaRepo := &memQuotaRepo{byTenant: map[string]*domain.TenantQuota{
+		"tenant-1": {TenantID: "tenant-1", MaxContainers: -1, MaxCPUMilli: -1, MaxMemoryMB: -1, MaxVolumeMB: 4, MaxSnapshots: -1},
+	}}
+	quotas := NewQuotaService(quotaRepo, containers, nil, slog.Default(), &config.Config{})
+	svc, _ := newTestFileService(containers, quotas)
+	container := &domain.Container{ID: "c1", DockerID: "docker-1", TenantID: "tenant-1", Status: "running"}
+	_ = containers.Save(container)
+
+	archive := makeTar(t, map[string]string{"a.bin": strings.Repeat("x", 600<<10)})
+	if err := svc.Upload(context.Background(), container, "/data", bytes.NewReader(archive), -1, true); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if c, _ := containers.GetByID("c1"); c.UploadedBytes != 600<<10 {
+		t.Fatalf("expected 600KB recorded, got %d", c.UploadedBytes)
+	}
+	if usage, _ := quotas.GetUsage("tenant-1"); usage.VolumeMB != 4 {
+		t.Fatalf("expected the upload to use the volume quota, got %d MB", usage.VolumeMB)
+	}
+
+	// The quota is used up, so repeating the upload is refused
+	err := svc.Upload(context.Background(), container, "/data", bytes.NewReader(archive), -1, true)
+	if !errors.Is(err, ErrUploadTooLarge) {
+		t.Fatalf("expected ErrUploadTooLarge once the quota is used, got %v", err)
+	}
+
+	// Without a quota, all uploads into a container together stay under MAX_VOLUME_MB
+	unlimited, _ := newTestFileService(containers, nil)
+	c, _ := containers.GetByID("c1")
+	c.UploadedBytes = 10<<20 - 4
+	_ = containers.Save(c)
+	if err := unlimited.Upload(context.Background(), c, "/data/f", strings.NewReader("hello"), 5, false); !errors.Is(err, ErrUploadTooLarge) {
+		t.Fatalf("expected the per-container cap enforced, got %v", err)
+	}
+}
+
+func TestDownloadUnwrapsSingleFile(t *testing.T) {
+	svc, docker := newTestFileService(nil, nil)
+	container := &domain.Container{ID: "c1", DockerID: "docker-1", TenantID: "tenant-1"}
+	docker.stat = &domain.FileStat{Name: "out.txt", Size: 3}
+	docker.archive = makeTar(t, map[string]string{"out.txt": "abc"})
+
+	download, err := svc.Download(context.Background(), container, "out.txt")
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	defer download.Close()
+	data, _ := io.ReadAll(download)
+	if download.Archive || string(data) != "abc" {
+		t.Fatalf("expected raw file contents, got archive=%v data=%q", download.Archive, data)
+	}
+
+	docker.stat = nil
+	if _, err := svc.Download(context.Background(), container, "missing"); !errors.Is(err, domain.ErrPathNotFound) {
+		t.Fatalf("expected ErrPathNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/observability/metrics"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// IdleNotifier delivers idle events (e.g. to a webhook)
+type IdleNotifier interface {
+	NotifyIdle(ctx context.Context, event *domain.IdleEvent) error
+}
+
+// IdleService tracks user activity on leases, applies each tenant's idle policy
+// to leases left idle and resumes paused leases when their user comes back
+type IdleService struct {
+	containers *ContainerService
+	snapshots  *SnapshotService
+	notifier   IdleNotifier
+	logger     *slog.Logger
+	config     *config.Config
+
+	mu       sync.Mutex
+	activity map[string]time.Time // Container ID -> last terminal input or log output
+}
+
+// NewIdleService creates a new idle service
+func NewIdleService(containers *ContainerService, logger *slog.Logger, cfg *config.Config) *IdleService {
+	return &IdleService{
+		containers: containers,
+		logger:     logger,
+		config:     cfg,
+		activity:   map[string]time.Time{},
+	}
+}
+
+// WithSnapshots enables the snapshot-and-terminate policy; without it such leases are left running
+func (s *IdleService) WithSnapshots(snapshots *SnapshotService) *IdleService {
+	s.snapshots = snapshots
+	return s
+}
+
+// WithNotifier sends an event whenever an idle policy is applied
+func (s *IdleService) WithNotifier(notifier IdleNotifier) *IdleService {
+	s.notifier = notifier
+	return s
+}
+
+// Touch records user activity on a lease
+func (s *IdleService) Touch(containerID string) {
+	s.mu.Lock()
+	s.activity[containerID] = time.Now()
+	s.mu.Unlock()
+}
+
+// LastActivity returns when a lease was last used (zero if never)
+func (s *IdleService) LastActivity(containerID string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.activity[containerID]
+}
+
+// Forget drops the activity of a lease that is no longer running
+func (s *IdleService) Forget(containerID string) {
+	s.mu.Lock()
+	delete(s.activity, containerID)
+	s.mu.Unlock()
+}
+
+// Wake records activity on a lease a user is connecting to, resuming it first if it is paused.
+// It returns the container as it is after resuming.
+func (s *IdleService) Wake(ctx context.Context, container *domain.Container) (*domain.Container, error) {
+	s.Touch(container.ID)
+	if container.Status != "paused" {
+		return container, nil
+	}
+	return s.containers.ResumeContainer(ctx, container.ID)
+}
+
+// Reclaim applies the tenant's idle policy to a lease that has been idle for idleFor
+func (s *IdleService) Reclaim(ctx context.Context, container *domain.Container, idleFor time.Duration) error {
+	policy := s.config.IdlePolicyFor(container.TenantID)
+	if policy == config.IdleOff {
+		return nil
+	}
+	logger := s.logger.With(
+		slog.String("container_id", container.ID),
+		slog.String("tenant_id", container.TenantID),
+		slog.String("policy", policy),
+		slog.Duration("idle_for", idleFor),
+	)
+
+	var err error
+	switch policy {
+	case config.IdlePause:
+		_, err = s.containers.PauseContainer(ctx, container.ID)
+	case config.IdleSnapshot:
+		// The lease is only terminated once its state is safe
+		if s.snapshots == nil {
+			err = fmt.Errorf("snapshots are not available")
+			break
+		}
+		_, err = s.snapshots.SnapshotAndTerminate(ctx, container.ID, "Automatic snapshot of idle lease")
+	case config.IdleTerminate:
+		err = s.containers.DeleteContainer(ctx, container.ID)
+	}
+	if err != nil {
+		metrics.ObserveIdleAction(container, policy, "error")
+		logger.Error("failed to apply idle policy", slog.String("error", err.Error()))
+		return err
+	}
+	metrics.ObserveIdleAction(container, policy, "success")
+	logger.Info("idle policy applied")
+
+	if s.notifier != nil {
+		event := &domain.IdleEvent{
+			ContainerID: container.ID,
+			TenantID:    container.TenantID,
+			Action:      policy,
+			IdleFor:     idleFor,
+			CreatedAt:   time.Now(),
+		}
+		if err := s.notifier.NotifyIdle(ctx, event); err != nil {
+			logger.Warn("failed to deliver idle notification", slog.String("error", err.Error()))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// pauseDocker records pause and unpause calls
+type pauseDocker struct {
+	domain.DockerClient
+	paused map[string]bool
+}
+
+func (d *pauseDocker) PauseContainer(ctx context.Context, id string) error {
+	d.paused[id] = true
+	return nil
+}
+func (d *pauseDocker) UnpauseContainer(ctx context.Context, id string) error {
+	delete(d.paused, id)
+	return nil
+}
+
+type recordingIdleNotifier struct {
+	events []*domain.IdleEvent
+}
+
+func (n *recordingIdleNotifier) NotifyIdle(ctx context.Context, e *domain.IdleEvent) error {
+	n.events = append(n.events, e)
+	return nil
+}
+
+func TestIdlePausePolicyAndWake(t *testing.T) {
+	docker := &pauseDocker{paused: map[string]bool{}}
+	containers := newMemContainerRepo()
+	leases := newMemLeaseRepo(containers)
+	cfg := &config.Config{IdlePolicy: config.IdleNotify, TenantIdlePolicies: map[string]string{"tenant-1": config.IdlePause}, MaxPauseMinutes: 60}
+	notifier := &recordingIdleNotifier{}
+	idle := NewIdleService(NewContainerService(docker, leases, containers, slog.Default(), cfg), slog.Default(), cfg).
+		WithNotifier(notifier)
+	expiry := time.Now().Add(time.Hour)
+	_ = containers.Save(&domain.Container{ID: "c1", DockerID: "docker-1", TenantID: "tenant-1", Status: "running", ExpiryAt: expiry})
+	_ = leases.CreateLease(&domain.Lease{ContainerID: "c1", LeaseKey: "lease:c1", ExpiryTime: expiry})
+
+	c, _ := containers.GetByID("c1")
+	if err := idle.Reclaim(context.Background(), c, 30*time.Minute); err != nil {
+		t.Fatalf("reclaim: %v", err)
+	}
+	c, _ = containers.GetByID("c1")
+	if c.Status != "paused" || !docker.paused["docker-1"] {
+		t.Fatalf("expected c1 paused, got status=%s paused=%v", c.Status, docker.paused)
+	}
+	if len(notifier.events) != 1 || notifier.events[0].Action != config.IdlePause {
+		t.Fatalf("expected one pause event, got %+v", notifier.events)
+	}
+
+	// Opening a terminal or logs resumes the lease and counts as activity
+	before := time.Now()
+	c, err := idle.Wake(context.Background(), c)
+	if err != nil || c.Status != "running" || docker.paused["docker-1"] {
+		t.Fatalf("expected c1 resumed, got status=%s err=%v", c.Status, err)
+	}
+	if idle.LastActivity("c1").Before(before) {
+		t.Fatal("expected wake to record activity")
+	}
+}
+
+func TestIdleNotifyPolicyLeavesLeaseRunning(t *testing.T) {
+	docker := &pauseDocker{paused: map[string]bool{}}
+	containers := newMemContainerRepo()
+	cfg := &config.Config{IdlePolicy: config.IdleNotify}
+	notifier := &recordingIdleNotifier{}
+	idle := NewIdleService(NewContainerService(docker, newMemLeaseRepo(containers), containers, slog.Default(), cfg), slog.Default(), cfg).
+		WithNotifier(notifier)
+	c := &domain.Container{ID: "c1", DockerID: "docker-1", TenantID: "tenant-2", Status: "running", ExpiryAt: time.Now().Add(time.Hour)}
+	_ = containers.Save(c)
+
+	if err := idle.Reclaim(context.Background(), c, 45*time.Minute); err != nil {
+		t.Fatalf("reclaim: %v", err)
+	}
+	c, _ = containers.GetByID("c1")
+	if c.Status != "running" || len(docker.paused) != 0 {
+		t.Fatalf("notify must not touch the container, got status=%s", c.Status)
+	}
+	if len(notifier.events) != 1 || notifier.events[0].IdleFor != 45*time.Minute {
+		t.Fatalf("expected one notify event, got %+v", notifier.events)
+	}
+}
+
+func TestIdleSnapshotPolicyNeedsSnapshots(t *testing.T) {
+	containers := newMemContainerRepo()
+	cfg := &config.Config{IdlePolicy: config.IdleSnapshot}
+	idle := NewIdleService(NewContainerService(&pauseDocker{}, newMemLeaseRepo(containers), containers, slog.Default(), cfg), slog.Default(), cfg)
+	c := &domain.Container{ID: "c1", DockerID: "docker-1", TenantID: "tenant-1", Status: "running", ExpiryAt: time.Now().Add(time.Hour)}
+	_ = containers.Save(c)
+
+	if err := idle.Reclaim(context.Background(), c, time.Hour); err == nil {
+		t.Fatal("expected an error without a snapshot service")
+	}
+	if c, _ = containers.GetByID("c1"); c.Status != "running" {
+		t.Fatalf("lease must not be terminated without a snapshot, got %s", c.Status)
+	}
+}
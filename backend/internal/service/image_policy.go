@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+	"github.com/distribution/reference"
+)
+
+// Image policy errors, mapped to 400 Bad Request by the handler layer
+var (
+	ErrInvalidImage    = errors.New("invalid image reference")
+	ErrImageNotAllowed = errors.New("image is not in the allowlist")
+	ErrImageDenied     = errors.New("image is denied by policy")
+	ErrDigestRequired  = errors.New("image must be pinned to a digest")
+)
+
+// ImagePolicy decides which images may be provisioned.
+// Patterns match the normalized registry/repository name, e.g. docker.io/library/ubuntu:
+// "*" matches within one path segment and "**" across segments. Patterns without a
+// registry are normalized like image names, so "ubuntu" means docker.io/library/ubuntu.
+type ImagePolicy struct {
+	allow         []*regexp.Regexp
+	deny          []*regexp.Regexp
+	aliases       map[string]string
+	requireDigest bool
+}
+
+// NewImagePolicy builds the policy from ALLOWED_IMAGES, DENIED_IMAGES, IMAGE_ALIASES and REQUIRE_IMAGE_DIGEST
+func NewImagePolicy(cfg *config.Config) *ImagePolicy {
+	p := &ImagePolicy{aliases: cfg.ImageAliases, requireDigest: cfg.RequireImageDigest}
+	for _, pattern := range cfg.AllowedImages {
+		p.allow = append(p.allow, compileImagePattern(pattern))
+	}
+	for _, pattern := range cfg.DeniedImages {
+		p.deny = append(p.deny, compileImagePattern(pattern))
+	}
+	return p
+}
+
+// Resolve checks an image reference or alias against the policy and returns the
+// fully qualified reference to pull, e.g. "alpine" -> "docker.io/library/alpine:latest"
+func (p *ImagePolicy) Resolve(image string) (string, error) {
+	if alias, ok := p.aliases[image]; ok {
+		image = alias
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if _, pinned := named.(reference.Canonical); p.requireDigest && !pinned {
+		return "", ErrDigestRequired
+	}
+
+	name := named.Name()
+	for _, re := range p.deny {
+		if re.MatchString(name) {
+			return "", fmt.Errorf("%w: %s", ErrImageDenied, name)
+		}
+	}
+	for _, re := range p.allow {
+		if re.MatchString(name) {
+			return reference.TagNameOnly(named).String(), nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrImageNotAllowed, name)
+}
+
+// compileImagePattern turns a repository glob into an anchored regular expression
+func compileImagePattern(pattern string) *regexp.Regexp {
+	pattern = normalizeImagePattern(pattern)
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			b.WriteString("[^/]*")
+		case pattern[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// normalizeImagePattern adds the implicit Docker Hub registry and library/ namespace,
+// the same way image names are normalized
+func normalizeImagePattern(pattern string) string {
+	if strings.HasPrefix(pattern, "**") {
+		return pattern // Any registry
+	}
+	domain, rest, found := strings.Cut(pattern, "/")
+	if !found || (!strings.ContainsAny(domain, ".:") && domain != "localhost") {
+		domain, rest = "docker.io", pattern
+	}
+	domain = config.RegistryHost(domain)
+	if domain == "docker.io" && !strings.Contains(rest, "/") {
+		rest = "library/" + rest
+	}
+	return domain + "/" + rest
+}
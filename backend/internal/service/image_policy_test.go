@@ -0,0 +1,67 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+func TestImagePolicyResolve(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	policy := NewImagePolicy(&config.Config{
+		AllowedImages: []string{"ubuntu", "alpine", "ghcr.io/acme/*", "registry.example.com/**"},
+		DeniedImages:  []string{"ghcr.io/acme/legacy-*"},
+		ImageAliases:  map[string]string{"ubuntu": "ubuntu:22.04"},
+	})
+
+	cases := []struct {
+		image string
+		want  string
+		err   error
+	}{
+		{"ubuntu", "docker.io/library/ubuntu:22.04", nil},
+		{"alpine", "docker.io/library/alpine:latest", nil},
+		{"docker.io/library/alpine:3.20", "docker.io/library/alpine:3.20", nil},
+		{"alpine@" + digest, "docker.io/library/alpine@" + digest, nil},
+		{"ghcr.io/acme/tool:1.2", "ghcr.io/acme/tool:1.2", nil},
+		{"registry.example.com/team/sub/app", "registry.example.com/team/sub/app:latest", nil},
+		{"ghcr.io/acme/nested/tool", "", ErrImageNotAllowed},
+		{"ghcr.io/acme/legacy-tool:1", "", ErrImageDenied},
+		{"debian", "", ErrImageNotAllowed},
+		{"someone/ubuntu", "", ErrImageNotAllowed},
+		{"Not A Reference", "", ErrInvalidImage},
+	}
+	for _, tc := range cases {
+		got, err := policy.Resolve(tc.image)
+		if tc.err != nil {
+			if !errors.Is(err, tc.err) {
+				t.Errorf("Resolve(%q) = %q, %v; want %v", tc.image, got, err, tc.err)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", tc.image, got, err, tc.want)
+		}
+	}
+}
+
+func TestImagePolicyRequireDigest(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("b", 64)
+	policy := NewImagePolicy(&config.Config{
+		AllowedImages:      []string{"**"},
+		ImageAliases:       map[string]string{"pinned": "alpine@" + digest},
+		RequireImageDigest: true,
+	})
+
+	if _, err := policy.Resolve("alpine:3.20"); !errors.Is(err, ErrDigestRequired) {
+		t.Fatalf("expected ErrDigestRequired for a tag, got %v", err)
+	}
+	if got, err := policy.Resolve("pinned"); err != nil || got != "docker.io/library/alpine@"+digest {
+		t.Fatalf("expected alias to a digest to pass, got %q %v", got, err)
+	}
+	if got, err := policy.Resolve("quay.io/org/app@" + digest); err != nil || got != "quay.io/org/app@"+digest {
+		t.Fatalf("expected ** to allow any registry, got %q %v", got, err)
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+)
+
+// maxInitOutputBytes caps how much init script output is kept on the container
+const maxInitOutputBytes = 64 << 10
+
+// minRedactedSecretLen keeps very short secret values from garbling unrelated output
+const minRedactedSecretLen = 4
+
+// RedactedValue replaces secret values wherever they would be shown
+const RedactedValue = "[REDACTED]"
+
+// runInitScript runs the container's init script and records its result and output
+func (s *ContainerService) runInitScript(ctx context.Context, containerID, dockerID string, opts ProvisionOptions) {
+	s.setInitResult(containerID, domain.InitRunning, 0, "")
+
+	timeout := time.Duration(s.config.InitTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	output := &cappedBuffer{limit: maxInitOutputBytes}
+	exitCode, err := s.dockerClient.RunCommand(runCtx, dockerID, []string{"sh", "-c", opts.InitScript}, output)
+	status := domain.InitSucceeded
+	if err != nil || exitCode != 0 {
+		status = domain.InitFailed
+	}
+	text := output.String()
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("init script timed out after %s", timeout)
+		}
+		text += "\n" + err.Error()
+	}
+	text = redactSecrets(text, opts.Env)
+
+	s.setInitResult(containerID, status, exitCode, text)
+	s.logger.Info("init script finished",
+		slog.String("container_id", containerID),
+		slog.String("status", status),
+		slog.Int("exit_code", exitCode),
+	)
+}
+
+// setInitResult stores init script progress on the latest copy of the container
+func (s *ContainerService) setInitResult(containerID, status string, exitCode int, output string) {
+	container, err := s.containerRepository.GetByID(containerID)
+	if err != nil {
+		return
+	}
+	container.InitStatus = status
+	container.InitExitCode = exitCode
+	container.InitOutput = output
+	if err := s.containerRepository.Save(container); err != nil {
+		s.logger.Error("failed to save init script result", slog.String("container_id", containerID), slog.String("error", err.Error()))
+	}
+}
+
+// redactSecrets replaces the values of secret environment variables in text
+func redactSecrets(text string, env []domain.EnvVar) string {
+	for _, e := range env {
+		if e.Secret && len(e.Value) >= minRedactedSecretLen {
+			text = strings.ReplaceAll(text, e.Value, RedactedValue)
+		}
+	}
+	return text
+}
+
+// RedactEnv returns env with secret values replaced, for API responses
+func RedactEnv(env []domain.EnvVar) []domain.EnvVar {
+	if env == nil {
+		return nil
+	}
+	out := make([]domain.EnvVar, len(env))
+	for i, e := range env {
+		if e.Secret {
+			e.Value = RedactedValue
+		}
+		out[i] = e
+	}
+	return out
+}
+
+// cappedBuffer keeps the first limit bytes written to it and discards the rest
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *cappedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + "\n[output truncated]"
+	}
+	return b.buf.String()
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+)
+
+// provisionJob creates the Docker container of a pending lease. The request is read back
+// from the container record, so secret environment values never enter the queue.
+type provisionJob struct {
+	ContainerID string
+}
+
+// deleteJob removes what a terminated lease left in Docker
+type deleteJob struct {
+	ContainerID string
+	DockerID    string
+	VolumeID    string
+	NodeID      string
+	Paused      bool
+}
+
+// snapshotJob commits a pending snapshot and, for snapshot-and-terminate, then ends its lease
+type snapshotJob struct {
+	SnapshotID string
+	Terminate  bool
+}
+
+// enqueueJob queues a job of the given kind. The ID names the work, e.g. the lease it
+// is for, so queueing the same work while it is still outstanding does nothing.
+func enqueueJob(ctx context.Context, jobs domain.JobQueue, kind, id string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s job: %w", kind, err)
+	}
+	err = jobs.Enqueue(ctx, &domain.Job{
+		ID:        kind + ":" + id,
+		Kind:      kind,
+		Payload:   data,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to queue %s job: %w", kind, err)
+	}
+	return nil
+}
+
+func decodeJob(job *domain.Job, payload any) error {
+	if err := json.Unmarshal(job.Payload, payload); err != nil {
+		return fmt.Errorf("invalid %s job payload: %w", job.Kind, err)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// memJobQueue keeps outstanding jobs by ID, like the real queues
+type memJobQueue struct {
+	jobs map[string]*domain.Job
+}
+
+func (q *memJobQueue) Enqueue(ctx context.Context, job *domain.Job) error {
+	if _, ok := q.jobs[job.ID]; !ok {
+		q.jobs[job.ID] = job
+	}
+	return nil
+}
+func (q *memJobQueue) Dequeue(ctx context.Context, consumer string, block time.Duration) (*domain.Job, error) {
+	return nil, nil
+}
+func (q *memJobQueue) Ack(ctx context.Context, job *domain.Job) error { return nil }
+func (q *memJobQueue) Retry(ctx context.Context, job *domain.Job, at time.Time) error {
+	return nil
+}
+func (q *memJobQueue) DeadLetter(ctx context.Context, job *domain.Job) error { return nil }
+func (q *memJobQueue) Reclaim(ctx context.Context, consumer string, minIdle time.Duration) (int, error) {
+	return 0, nil
+}
+func (q *memJobQueue) ListDead(ctx context.Context, limit int) ([]*domain.Job, error) {
+	return nil, nil
+}
+
+// pullFailDocker fails every image pull
+type pullFailDocker struct {
+	domain.DockerClient
+	pulls int
+}
+
+func (d *pullFailDocker) PullImage(ctx context.Context, image string) (string, error) {
+	d.pulls++
+	return "", errors.New("registry unreachable")
+}
+
+func TestProvisionRunsAsRetriableJob(t *testing.T) {
+	s, containers, _ := newTestContainerService(&config.Config{})
+	jobs := &memJobQueue{jobs: map[string]*domain.Job{}}
+	docker := &pullFailDocker{}
+	s.WithJobs(jobs)
+	s.dockerClient = docker
+
+	env := []domain.EnvVar{{Name: "DB_PASSWORD", Value: "hunter2", Secret: true}}
+	c, err := s.ProvisionContainer(context.Background(), ProvisionOptions{TenantID: "t1", ImageType: "ubuntu", DurationMinutes: 30, CPUMilli: 250, MemoryMB: 256, Env: env})
+	if err != nil {
+		t.Fatalf("provision: %v", err)
+	}
+	job := jobs.jobs["provision:"+c.ID]
+	if job == nil || job.Kind != domain.JobProvision || docker.pulls != 0 {
+		t.Fatalf("expected a queued provision job and no Docker work yet, got %v", jobs.jobs)
+	}
+	if strings.Contains(string(job.Payload), "hunter2") {
+		t.Fatalf("secret values must not be stored in the job queue, got %s", job.Payload)
+	}
+
+	// A failed attempt leaves the lease pending for the retry
+	if err := s.RunProvisionJob(context.Background(), job); err == nil {
+		t.Fatal("expected the pull error to be returned for a retry")
+	}
+	if got, _ := containers.GetByID(c.ID); got.Status != "pending" || got.Error != "registry unreachable" {
+		t.Fatalf("expected pending with the last error, got %s %q", got.Status, got.Error)
+	}
+
+	job.LastError = "registry unreachable"
+	s.FailProvisionJob(context.Background(), job)
+	if got, _ := containers.GetByID(c.ID); got.Status != "error" {
+		t.Fatalf("expected error once the job is dead-lettered, got %s", got.Status)
+	}
+	// Redelivered after the lease moved on: nothing to do
+	if err := s.RunProvisionJob(context.Background(), job); err != nil || docker.pulls != 1 {
+		t.Fatalf("expected the job skipped, got %v after %d pulls", err, docker.pulls)
+	}
+}
+
+func TestDeleteQueuesTeardownJob(t *testing.T) {
+	s, containers, leases := newTestContainerService(&config.Config{})
+	jobs := &memJobQueue{jobs: map[string]*domain.Job{}}
+	s.WithJobs(jobs)
+	seedLease(containers, leases, "c1", "t1", time.Now(), 30)
+	c, _ := containers.GetByID("c1")
+	c.DockerID = "docker-1"
+	c.VolumeID = "vol-c1"
+	_ = containers.Save(c)
+
+	// The nil Docker client would panic if it were called during the request
+	if err := s.DeleteContainer(context.Background(), "c1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if got, _ := containers.GetByID("c1"); got.Status != "terminated" {
+		t.Fatalf("expected terminated, got %s", got.Status)
+	}
+	var p deleteJob
+	job := jobs.jobs["delete:c1"]
+	if job == nil || decodeJob(job, &p) != nil || p.DockerID != "docker-1" || p.VolumeID != "vol-c1" {
+		t.Fatalf("expected a delete job for the container and volume, got %v", jobs.jobs)
+	}
+}
+
+// teardownDocker records whether the container was marked terminating when it was stopped
+type teardownDocker struct {
+	domain.DockerClient
+	containers *memContainerRepo
+	marked     bool
+}
+
+func (d *teardownDocker) StopContainer(ctx context.Context, id string) error {
+	c, _ := d.containers.GetByID("c1")
+	d.marked = !c.TerminatingAt.IsZero()
+	return nil
+}
+func (d *teardownDocker) RemoveContainer(ctx context.Context, id string) error { return nil }
+
+func TestDeleteMarksTerminatingBeforeTeardown(t *testing.T) {
+	containers := newMemContainerRepo()
+	leases := newMemLeaseRepo(containers)
+	docker := &teardownDocker{containers: containers}
+	s := NewContainerService(docker, leases, containers, slog.Default(), &config.Config{})
+	seedLease(containers, leases, "c1", "t1", time.Now(), 30)
+	c, _ := containers.GetByID("c1")
+	c.DockerID = "docker-1"
+	_ = containers.Save(c)
+
+	if err := s.DeleteContainer(context.Background(), "c1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if !docker.marked {
+		t.Fatal("expected the container marked terminating before it was stopped")
+	}
+	if got, _ := containers.GetByID("c1"); got.Status != "terminated" {
+		t.Fatalf("expected terminated, got %s", got.Status)
+	}
+}
+
+func TestReconcilePendingQueuesEachLeaseOnce(t *testing.T) {
+	s, containers, leases := newTestContainerService(&config.Config{})
+	jobs := &memJobQueue{jobs: map[string]*domain.Job{}}
+	s.WithJobs(jobs)
+	now := time.Now()
+	seedLease(containers, leases, "pending", "t1", now, 30)
+	seedLease(containers, leases, "running", "t1", now, 30)
+	c, _ := containers.GetByID("pending")
+	c.Status = "pending"
+	c.Image = "docker.io/library/ubuntu:22.04"
+	_ = containers.Save(c)
+
+	for i := 0; i < 2; i++ {
+		if err := s.ReconcilePending(context.Background()); err != nil {
+			t.Fatalf("reconcile: %v", err)
+		}
+	}
+	if len(jobs.jobs) != 1 {
+		t.Fatalf("expected one provision job, got %v", jobs.jobs)
+	}
+	var p provisionJob
+	if err := decodeJob(jobs.jobs["provision:pending"], &p); err != nil || p.ContainerID != "pending" {
+		t.Fatalf("expected a job for the pending lease, got %+v (%v)", p, err)
+	}
+}
+
+func TestRestoredOptionsKeepLocalImageOnItsNode(t *testing.T) {
+	lease := &domain.Lease{DurationMinutes: 30}
+	snapshot := &domain.Container{Image: "snapshot-1", LocalImage: true, NodeID: "n2"}
+	if opts := restoredOptions(snapshot, lease); !opts.LocalImage || opts.NodeID != "n2" {
+		t.Fatalf("expected a snapshot lease pinned to the node holding its image, got %+v", opts)
+	}
+	// Other leases are placed afresh
+	placed := &domain.Container{Image: "docker.io/library/ubuntu:22.04", NodeID: "n2"}
+	if opts := restoredOptions(placed, lease); opts.LocalImage || opts.NodeID != "" {
+		t.Fatalf("expected a pulled image free to run on any node, got %+v", opts)
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+var (
+	// ErrNoMatchingNode is returned when no node has the labels a lease asks for
+	ErrNoMatchingNode = errors.New("no docker node has the requested labels")
+	// ErrNoNodeAvailable is returned when no healthy node has room for a lease right now
+	ErrNoNodeAvailable = errors.New("no docker node available")
+)
+
+// NodeUsage is a node with the resources its leases hold
+type NodeUsage struct {
+	domain.Node
+	Containers   int `json:"containers"`
+	UsedCPUMilli int `json:"usedCpuMilli"`
+	UsedMemoryMB int `json:"usedMemoryMB"`
+}
+
+// fits reports whether the node has room for cpuMilli and memoryMB more
+func (u *NodeUsage) fits(cpuMilli, memoryMB int) bool {
+	return (u.CPUMilli == 0 || u.UsedCPUMilli+cpuMilli <= u.CPUMilli) &&
+		(u.MemoryMB == 0 || u.UsedMemoryMB+memoryMB <= u.MemoryMB)
+}
+
+// load is the larger of the CPU and memory shares in use, 0 for a node without limits
+func (u *NodeUsage) load() float64 {
+	load := 0.0
+	if u.CPUMilli > 0 {
+		load = float64(u.UsedCPUMilli) / float64(u.CPUMilli)
+	}
+	if u.MemoryMB > 0 {
+		load = max(load, float64(u.UsedMemoryMB)/float64(u.MemoryMB))
+	}
+	return load
+}
+
+// PlacementService chooses the Docker node each lease runs on
+type PlacementService struct {
+	nodes      domain.NodeRegistry
+	containers domain.ContainerRepository
+	strategy   string
+	logger     *slog.Logger
+	mu         sync.Mutex // Keeps concurrent placements from seeing the same free capacity
+}
+
+// NewPlacementService creates a placement service using PLACEMENT_STRATEGY
+func NewPlacementService(nodes domain.NodeRegistry, containers domain.ContainerRepository, logger *slog.Logger, cfg *config.Config) *PlacementService {
+	return &PlacementService{
+		nodes:      nodes,
+		containers: containers,
+		strategy:   cfg.PlacementStrategy,
+		logger:     logger,
+	}
+}
+
+// Limited reports whether any node has a capacity, so leases may have to wait for room
+func (s *PlacementService) Limited() bool {
+	for _, node := range s.nodes.Nodes() {
+		if node.CPUMilli > 0 || node.MemoryMB > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Check rejects leases no node could ever run, healthy or not: ErrNoMatchingNode when
+// no node it may use has its labels, a CapacityExceededError when none of those is
+// large enough. Leases with ports may only use the default node.
+func (s *PlacementService) Check(opts ProvisionOptions) error {
+	var tooSmall error
+	for i, node := range s.nodes.Nodes() {
+		if !eligible(i, node, opts) {
+			continue
+		}
+		capacity := HostCapacity{CPUMilli: node.CPUMilli, MemoryMB: node.MemoryMB}
+		if tooSmall = capacity.Check(0, 0, opts.CPUMilli, opts.MemoryMB); tooSmall == nil {
+			return nil
+		}
+	}
+	if tooSmall != nil {
+		return tooSmall
+	}
+	if len(opts.Ports) > 0 {
+		return fmt.Errorf("%w: leases with ports run on the default node, which lacks %v", ErrNoMatchingNode, opts.NodeLabels)
+	}
+	return fmt.Errorf("%w: %v", ErrNoMatchingNode, opts.NodeLabels)
+}
+
+// eligible reports whether a lease may run on the i-th node of the pool. The proxy
+// reaches containers by their bridge address, which only the default node's are
+// reachable at, so leases with ports run there.
+func eligible(i int, node domain.Node, opts ProvisionOptions) bool {
+	if opts.NodeID != "" && node.ID != opts.NodeID {
+		return false
+	}
+	if len(opts.Ports) > 0 && i != 0 {
+		return false
+	}
+	return node.HasLabels(opts.NodeLabels)
+}
+
+// Usage returns every node with the resources held by the leases on it
+func (s *PlacementService) Usage() ([]NodeUsage, error) {
+	return s.usage("")
+}
+
+// usage returns the node usage, leaving out the container being placed
+func (s *PlacementService) usage(excludeID string) ([]NodeUsage, error) {
+	nodes := s.nodes.Nodes()
+	usage := make([]NodeUsage, len(nodes))
+	index := map[string]int{}
+	for i, node := range nodes {
+		usage[i] = NodeUsage{Node: node}
+		index[node.ID] = i
+	}
+	if len(nodes) == 0 {
+		return usage, nil
+	}
+
+	containers, err := s.containers.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	for _, c := range containers {
+		// Pending leases hold capacity on the node they were placed on, if any yet
+		if c.ID == excludeID || !holdsCapacity(c) || (c.Status == "pending" && c.NodeID == "") {
+			continue
+		}
+		i, ok := 0, true // Containers without a node run on the default node
+		if c.NodeID != "" {
+			i, ok = index[c.NodeID]
+		}
+		if !ok {
+			continue
+		}
+		usage[i].Containers++
+		usage[i].UsedCPUMilli += c.CPUMilli
+		usage[i].UsedMemoryMB += c.MemoryMB
+	}
+	return usage, nil
+}
+
+// Assign places a lease and records the node on its container, so the leases placed
+// after it count it against that node. A lease already placed, e.g. when the queue
+// admitted it, keeps its node while that still has room.
+func (s *PlacementService) Assign(containerID string, opts ProvisionOptions) (*domain.Node, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	container, err := s.containers.GetByID(containerID)
+	if err != nil {
+		return nil, fmt.Errorf("container not found: %w", err)
+	}
+	if container.NodeID != "" && opts.NodeID == "" {
+		placed := opts
+		placed.NodeID = container.NodeID
+		if node, err := s.Place(containerID, placed); err == nil {
+			return node, nil
+		}
+	}
+	node, err := s.Place(containerID, opts)
+	if err != nil {
+		return nil, err
+	}
+	if container.NodeID == node.ID {
+		return node, nil
+	}
+	container.NodeID = node.ID
+	if err := s.containers.Save(container); err != nil {
+		return nil, fmt.Errorf("failed to record node: %w", err)
+	}
+	return node, nil
+}
+
+// Place picks a healthy node for a lease: one with its node labels and room for its
+// resources, chosen by the placement strategy. A lease pinned to a node, e.g. one
+// restored from a snapshot image on that node, can only run there.
+func (s *PlacementService) Place(containerID string, opts ProvisionOptions) (*domain.Node, error) {
+	usage, err := s.usage(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []NodeUsage
+	for i, u := range usage {
+		if u.Healthy && eligible(i, u.Node, opts) && u.fits(opts.CPUMilli, opts.MemoryMB) {
+			candidates = append(candidates, u)
+		}
+	}
+	if len(candidates) == 0 {
+		if opts.NodeID != "" {
+			return nil, fmt.Errorf("%w: node %s is unhealthy or full", ErrNoNodeAvailable, opts.NodeID)
+		}
+		return nil, fmt.Errorf("%w for %d millicores and %d MB with labels %v", ErrNoNodeAvailable, opts.CPUMilli, opts.MemoryMB, opts.NodeLabels)
+	}
+
+	// Spread prefers the least loaded node, binpack the most loaded; ties go by the number
+	// of containers, then by the order of the nodes
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if s.strategy == domain.PlacementBinpack {
+			a, b = b, a
+		}
+		if a.load() != b.load() {
+			return a.load() < b.load()
+		}
+		return a.Containers < b.Containers
+	})
+	node := candidates[0].Node
+	s.logger.Debug("lease placed",
+		slog.String("container_id", containerID),
+		slog.String("node", node.ID),
+		slog.String("strategy", s.strategy),
+	)
+	return &node, nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// fakeNodes is a fixed node registry
+type fakeNodes []domain.Node
+
+func (f fakeNodes) Nodes() []domain.Node { return f }
+
+func newTestPlacement(strategy string) (*PlacementService, *memContainerRepo) {
+	nodes := fakeNodes{
+		{ID: "n1", CPUMilli: 4000, MemoryMB: 8192, Healthy: true},
+		{ID: "n2", CPUMilli: 4000, MemoryMB: 8192, Healthy: true, Labels: map[string]string{"ssd": "true"}},
+		{ID: "n3", CPUMilli: 4000, MemoryMB: 8192, Healthy: false, Labels: map[string]string{"gpu": "true"}},
+	}
+	containers := newMemContainerRepo()
+	return NewPlacementService(nodes, containers, slog.Default(), &config.Config{PlacementStrategy: strategy}), containers
+}
+
+func TestPlacementStrategies(t *testing.T) {
+	opts := ProvisionOptions{CPUMilli: 1000, MemoryMB: 1024}
+
+	spread, containers := newTestPlacement(domain.PlacementSpread)
+	_ = containers.Save(&domain.Container{ID: "a", Status: "running", NodeID: "n1", CPUMilli: 1000, MemoryMB: 1024})
+	if node, err := spread.Place("b", opts); err != nil || node.ID != "n2" {
+		t.Fatalf("expected spread to pick the empty node n2, got %v %v", node, err)
+	}
+
+	binpack, containers := newTestPlacement(domain.PlacementBinpack)
+	_ = containers.Save(&domain.Container{ID: "a", Status: "running", NodeID: "n1", CPUMilli: 1000, MemoryMB: 1024})
+	if node, err := binpack.Place("b", opts); err != nil || node.ID != "n1" {
+		t.Fatalf("expected binpack to fill n1 first, got %v %v", node, err)
+	}
+
+	// A full node is skipped even by binpack
+	_ = containers.Save(&domain.Container{ID: "c", Status: "running", NodeID: "n1", CPUMilli: 3000, MemoryMB: 1024})
+	if node, err := binpack.Place("b", opts); err != nil || node.ID != "n2" {
+		t.Fatalf("expected binpack to move on from the full n1, got %v %v", node, err)
+	}
+}
+
+func TestPlacementLabelsAndHealth(t *testing.T) {
+	s, _ := newTestPlacement(domain.PlacementSpread)
+
+	node, err := s.Place("a", ProvisionOptions{NodeLabels: map[string]string{"ssd": "true"}})
+	if err != nil || node.ID != "n2" {
+		t.Fatalf("expected the ssd lease on n2, got %v %v", node, err)
+	}
+
+	// n3 has the gpu label but is unhealthy: the labels are valid, the placement waits
+	gpu := map[string]string{"gpu": "true"}
+	if err := s.Check(ProvisionOptions{NodeLabels: gpu}); err != nil {
+		t.Fatalf("expected gpu labels to be known, got %v", err)
+	}
+	if _, err := s.Place("a", ProvisionOptions{NodeLabels: gpu}); !errors.Is(err, ErrNoNodeAvailable) {
+		t.Fatalf("expected ErrNoNodeAvailable on an unhealthy node, got %v", err)
+	}
+
+	if err := s.Check(ProvisionOptions{NodeLabels: map[string]string{"ssd": "false"}}); !errors.Is(err, ErrNoMatchingNode) {
+		t.Fatalf("expected ErrNoMatchingNode, got %v", err)
+	}
+
+	// Every node could hold half of this lease, none the whole of it
+	var capacityErr *CapacityExceededError
+	if err := s.Check(ProvisionOptions{CPUMilli: 6000}); !errors.As(err, &capacityErr) {
+		t.Fatalf("expected a lease larger than any node rejected, got %v", err)
+	}
+}
+
+func TestPlacementKeepsLeasesWithPortsOnTheDefaultNode(t *testing.T) {
+	s, containers := newTestPlacement(domain.PlacementSpread)
+	_ = containers.Save(&domain.Container{ID: "a", Status: "running", NodeID: "n1", CPUMilli: 3500, MemoryMB: 1024})
+
+	web := ProvisionOptions{CPUMilli: 1000, Ports: []int{8080}}
+	if _, err := s.Place("b", web); !errors.Is(err, ErrNoNodeAvailable) {
+		t.Fatalf("expected the lease with ports to wait for the default node, got %v", err)
+	}
+	web.CPUMilli = 500
+	if node, err := s.Place("b", web); err != nil || node.ID != "n1" {
+		t.Fatalf("expected the lease with ports on n1, got %v %v", node, err)
+	}
+	if err := s.Check(ProvisionOptions{Ports: []int{8080}, NodeLabels: map[string]string{"ssd": "true"}}); !errors.Is(err, ErrNoMatchingNode) {
+		t.Fatalf("expected ports and another node's labels rejected, got %v", err)
+	}
+}
+
+func TestPlacementAssignRecordsNode(t *testing.T) {
+	s, containers := newTestPlacement(domain.PlacementSpread)
+	_ = containers.Save(&domain.Container{ID: "a", Status: "pending", CPUMilli: 1000, MemoryMB: 1024})
+	_ = containers.Save(&domain.Container{ID: "b", Status: "pending", CPUMilli: 1000, MemoryMB: 1024})
+
+	first, err := s.Assign("a", ProvisionOptions{CPUMilli: 1000, MemoryMB: 1024})
+	if err != nil {
+		t.Fatalf("assign: %v", err)
+	}
+	if c, _ := containers.GetByID("a"); c.NodeID != first.ID {
+		t.Fatalf("expected node %s recorded, got %q", first.ID, c.NodeID)
+	}
+	// The first lease now holds capacity, so spread puts the second one elsewhere
+	second, err := s.Assign("b", ProvisionOptions{CPUMilli: 1000, MemoryMB: 1024})
+	if err != nil || second.ID == first.ID {
+		t.Fatalf("expected the second lease on another node, got %v %v", second, err)
+	}
+
+	// Pinned leases only run on their node
+	if node, err := s.Place("c", ProvisionOptions{NodeID: "n1"}); err != nil || node.ID != "n1" {
+		t.Fatalf("expected the pinned lease on n1, got %v %v", node, err)
+	}
+	if _, err := s.Place("c", ProvisionOptions{NodeID: "n3"}); !errors.Is(err, ErrNoNodeAvailable) {
+		t.Fatalf("expected a lease pinned to an unhealthy node to wait, got %v", err)
+	}
+
+	// A lease placed before keeps its node
+	_ = containers.Save(&domain.Container{ID: "d", Status: "pending", NodeID: "n1"})
+	if node, err := s.Assign("d", ProvisionOptions{}); err != nil || node.ID != "n1" {
+		t.Fatalf("expected the lease kept on n1, got %v %v", node, err)
+	}
+
+	usage, _ := s.Usage()
+	if usage[0].Containers+usage[1].Containers != 3 {
+		t.Fatalf("expected two leases counted, got %+v", usage)
+	}
+}
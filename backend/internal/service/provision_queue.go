@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/observability/metrics"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// ProvisionQueue admits leases against the host capacity. Every lease enters the queue
+// with status queued and starts once it is at the head and fits next to the leases
+// already running; the queue is strictly ordered, so a large lease is not starved by
+// smaller ones behind it. With the priority policy tenants with a higher priority go first.
+type ProvisionQueue struct {
+	containers   domain.ContainerRepository
+	reservations domain.ReservationRepository
+	capacity     HostCapacity
+	placement    *PlacementService
+	start        func(ctx context.Context, containerID string, opts ProvisionOptions) error
+	logger       *slog.Logger
+	config       *config.Config
+
+	mu      sync.Mutex
+	entries []*queueEntry // In start order
+	seq     int64
+}
+
+type queueEntry struct {
+	containerID string
+	priority    int
+	seq         int64 // Request order, breaking priority ties
+	opts        ProvisionOptions
+}
+
+// NewProvisionQueue creates a queue for the given host capacity
+func NewProvisionQueue(containers domain.ContainerRepository, capacity HostCapacity, logger *slog.Logger, cfg *config.Config) *ProvisionQueue {
+	return &ProvisionQueue{
+		containers: containers,
+		capacity:   capacity,
+		logger:     logger,
+		config:     cfg,
+	}
+}
+
+// WithReservations keeps capacity booked by pending reservations free for them
+func (q *ProvisionQueue) WithReservations(reservations domain.ReservationRepository) *ProvisionQueue {
+	q.reservations = reservations
+	return q
+}
+
+// WithPlacement also waits for a node with room for each lease, and places it there
+func (q *ProvisionQueue) WithPlacement(placement *PlacementService) *ProvisionQueue {
+	q.placement = placement
+	return q
+}
+
+// CheckRequest rejects leases larger than the whole host, which could never start
+func (q *ProvisionQueue) CheckRequest(cpuMilli, memoryMB int) error {
+	return q.capacity.Check(0, 0, cpuMilli, memoryMB)
+}
+
+// Enqueue adds a queued container to the queue; Process starts it when its turn comes
+func (q *ProvisionQueue) Enqueue(containerID string, opts ProvisionOptions) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.seq++
+	q.entries = append(q.entries, &queueEntry{
+		containerID: containerID,
+		priority:    q.config.QueuePriorityFor(opts.TenantID),
+		seq:         q.seq,
+		opts:        opts,
+	})
+	sort.SliceStable(q.entries, func(i, j int) bool {
+		if q.entries[i].priority != q.entries[j].priority {
+			return q.entries[i].priority > q.entries[j].priority
+		}
+		return q.entries[i].seq < q.entries[j].seq
+	})
+	metrics.SetQueuedLeases(len(q.entries))
+}
+
+// Position returns a container's place in the queue, starting at 1 (0 = not queued)
+func (q *ProvisionQueue) Position(containerID string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, e := range q.entries {
+		if e.containerID == containerID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Len returns the number of queued leases
+func (q *ProvisionQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
+
+// Process starts queued leases in order for as long as the one at the head fits.
+// Leases deleted or expired while queued are dropped.
+func (q *ProvisionQueue) Process(ctx context.Context) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	defer func() { metrics.SetQueuedLeases(len(q.entries)) }()
+
+	for len(q.entries) > 0 {
+		head := q.entries[0]
+		now := time.Now()
+		c, err := q.containers.GetByID(head.containerID)
+		if err != nil || c.Status != "queued" || !now.Before(c.ExpiryAt) {
+			q.entries = q.entries[1:]
+			continue
+		}
+
+		bookedCPU, bookedMemory, err := q.booked(now, head.opts)
+		if err != nil {
+			q.logger.Error("failed to check host capacity", slog.String("error", err.Error()))
+			return
+		}
+		if q.capacity.Check(bookedCPU, bookedMemory, head.opts.CPUMilli, head.opts.MemoryMB) != nil {
+			return
+		}
+		// The host as a whole has room, but the lease must also fit on one node
+		if q.placement != nil {
+			if _, err := q.placement.Assign(head.containerID, head.opts); err != nil {
+				if !errors.Is(err, ErrNoNodeAvailable) {
+					q.logger.Error("failed to place queued lease", slog.String("container_id", head.containerID), slog.String("error", err.Error()))
+				}
+				return
+			}
+		}
+
+		q.entries = q.entries[1:]
+		if err := q.start(ctx, head.containerID, head.opts); err != nil {
+			q.logger.Error("failed to start queued lease", slog.String("container_id", head.containerID), slog.String("error", err.Error()))
+			continue
+		}
+		q.logger.Info("queued lease admitted",
+			slog.String("container_id", head.containerID),
+			slog.Int("still_queued", len(q.entries)),
+		)
+	}
+}
+
+// booked returns the capacity held by running leases, and by pending reservations
+// that overlap the window a lease started now would run for
+func (q *ProvisionQueue) booked(now time.Time, opts ProvisionOptions) (int, int, error) {
+	containers, err := q.containers.List()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list containers: %w", err)
+	}
+	var pending []*domain.Reservation
+	if q.reservations != nil {
+		if pending, err = q.reservations.ListByStatus(domain.ReservationPending); err != nil {
+			return 0, 0, fmt.Errorf("failed to list reservations: %w", err)
+		}
+	}
+	end := opts.ExpiryAt
+	if end.IsZero() {
+		end = now.Add(time.Duration(opts.DurationMinutes) * time.Minute)
+	}
+	cpuMilli, memoryMB := bookedCapacity(containers, pending, now, end)
+	return cpuMilli, memoryMB, nil
+}
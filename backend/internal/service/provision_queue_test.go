@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+type queueFixture struct {
+	queue      *ProvisionQueue
+	containers *memContainerRepo
+	started    []string
+}
+
+func newQueueFixture(cfg *config.Config, capacity HostCapacity) *queueFixture {
+	f := &queueFixture{containers: newMemContainerRepo()}
+	f.queue = NewProvisionQueue(f.containers, capacity, slog.Default(), cfg)
+	f.queue.start = func(ctx context.Context, id string, opts ProvisionOptions) error {
+		c, _ := f.containers.GetByID(id)
+		c.Status = "pending"
+		f.started = append(f.started, id)
+		return f.containers.Save(c)
+	}
+	return f
+}
+
+// add stores a container with the given status and, if queued, enqueues it
+func (f *queueFixture) add(id, tenantID, status string, cpuMilli int) {
+	_ = f.containers.Save(&domain.Container{ID: id, TenantID: tenantID, Status: status, CPUMilli: cpuMilli, MemoryMB: 64, ExpiryAt: time.Now().Add(time.Hour)})
+	if status == "queued" {
+		f.queue.Enqueue(id, ProvisionOptions{TenantID: tenantID, CPUMilli: cpuMilli, MemoryMB: 64, DurationMinutes: 60})
+	}
+}
+
+func TestQueueStartsInOrderAsCapacityFrees(t *testing.T) {
+	f := newQueueFixture(&config.Config{QueuePolicy: config.QueueFIFO}, HostCapacity{CPUMilli: 1000})
+	f.add("running", "t1", "running", 600)
+	f.add("big", "t2", "queued", 500)
+	f.add("small", "t3", "queued", 100)
+
+	// The small lease would fit, but waits behind the big one
+	f.queue.Process(context.Background())
+	if len(f.started) != 0 || f.queue.Position("big") != 1 || f.queue.Position("small") != 2 {
+		t.Fatalf("expected nothing to start, got %v", f.started)
+	}
+
+	running, _ := f.containers.GetByID("running")
+	running.Status = "terminated"
+	_ = f.containers.Save(running)
+	f.queue.Process(context.Background())
+	if len(f.started) != 2 || f.started[0] != "big" || f.started[1] != "small" || f.queue.Len() != 0 {
+		t.Fatalf("expected both to start in order, got %v", f.started)
+	}
+	if f.queue.Position("big") != 0 {
+		t.Fatal("started leases must leave the queue")
+	}
+}
+
+func TestQueuePriorityAndDroppedEntries(t *testing.T) {
+	cfg := &config.Config{QueuePolicy: config.QueuePriority, TenantQueuePriorities: map[string]int{"gold": 10}}
+	f := newQueueFixture(cfg, HostCapacity{CPUMilli: 1000})
+	f.add("running", "t1", "running", 1000)
+	f.add("first", "t1", "queued", 500)
+	f.add("deleted", "t1", "queued", 500)
+	f.add("vip", "gold", "queued", 500)
+
+	if f.queue.Position("vip") != 1 || f.queue.Position("first") != 2 {
+		t.Fatalf("higher priority must go first, got vip=%d first=%d", f.queue.Position("vip"), f.queue.Position("first"))
+	}
+
+	// Deleted while queued: skipped over once capacity frees
+	c, _ := f.containers.GetByID("deleted")
+	c.Status = "terminated"
+	_ = f.containers.Save(c)
+	_ = f.containers.Delete("running")
+	f.queue.Process(context.Background())
+	if len(f.started) != 2 || f.started[0] != "vip" || f.started[1] != "first" || f.queue.Len() != 0 {
+		t.Fatalf("expected vip then first, got %v (queue %d)", f.started, f.queue.Len())
+	}
+}
+
+func TestQueueKeepsReservedCapacityFree(t *testing.T) {
+	f := newQueueFixture(&config.Config{}, HostCapacity{CPUMilli: 1000})
+	reservations := &memReservationRepo{byID: map[string]*domain.Reservation{}}
+	f.queue.WithReservations(reservations)
+	res := workshop("t9", time.Now().Add(30*time.Minute), 3) // 750m from 30 minutes from now
+	res.Status = domain.ReservationPending
+	_ = reservations.Save(res)
+
+	f.add("lease", "t1", "queued", 500)
+	f.queue.Process(context.Background())
+	if len(f.started) != 0 {
+		t.Fatal("a lease overlapping the reservation must wait")
+	}
+
+	if err := f.queue.CheckRequest(2000, 0); !errors.Is(err, ErrCapacityExceeded) {
+		t.Fatalf("requests larger than the host must be refused, got %v", err)
+	}
+}
+
+func TestProvisionQueuesWhenHostIsFull(t *testing.T) {
+	cfg := &config.Config{}
+	svc, containers, leases := newTestContainerService(cfg)
+	_ = containers.Save(&domain.Container{ID: "busy", TenantID: "t2", Status: "running", MemoryMB: 1024, ExpiryAt: time.Now().Add(time.Hour)})
+	svc.WithQueue(NewProvisionQueue(containers, HostCapacity{MemoryMB: 1024}, slog.Default(), cfg))
+
+	c, err := svc.ProvisionContainer(context.Background(), ProvisionOptions{TenantID: "t1", MemoryMB: 512, DurationMinutes: 30})
+	if err != nil {
+		t.Fatalf("provision: %v", err)
+	}
+	if c.Status != "queued" || svc.QueuePosition(c.ID) != 1 {
+		t.Fatalf("expected queued at position 1, got %s %d", c.Status, svc.QueuePosition(c.ID))
+	}
+	if _, err := leases.GetLease("lease:" + c.ID); err != nil {
+		t.Fatalf("queued lease must exist so cleanup keeps the container: %v", err)
+	}
+
+	_, err = svc.ProvisionContainer(context.Background(), ProvisionOptions{TenantID: "t1", MemoryMB: 2048, DurationMinutes: 30})
+	var ce *CapacityExceededError
+	if !errors.As(err, &ce) || ce.Resource != CapacityMemoryMB {
+		t.Fatalf("expected memory capacity error, got %v", err)
+	}
+}
+
+func TestQueueWaitsForANodeWithRoom(t *testing.T) {
+	f := newQueueFixture(&config.Config{}, HostCapacity{CPUMilli: 2000})
+	nodes := fakeNodes{
+		{ID: "n1", CPUMilli: 1000, Healthy: true},
+		{ID: "n2", CPUMilli: 1000, Healthy: true},
+	}
+	f.queue.WithPlacement(NewPlacementService(nodes, f.containers, slog.Default(), &config.Config{}))
+	_ = f.containers.Save(&domain.Container{ID: "a", Status: "running", NodeID: "n1", CPUMilli: 600, ExpiryAt: time.Now().Add(time.Hour)})
+	_ = f.containers.Save(&domain.Container{ID: "b", Status: "running", NodeID: "n2", CPUMilli: 600, ExpiryAt: time.Now().Add(time.Hour)})
+
+	// The host has 800m left in total, but no node has it on its own
+	f.add("lease", "t1", "queued", 800)
+	f.queue.Process(context.Background())
+	if len(f.started) != 0 {
+		t.Fatal("a lease no single node can hold must wait")
+	}
+
+	a, _ := f.containers.GetByID("a")
+	a.Status = "terminated"
+	_ = f.containers.Save(a)
+	f.queue.Process(context.Background())
+	if len(f.started) != 1 {
+		t.Fatal("expected the lease started once n1 frees up")
+	}
+	if c, _ := f.containers.GetByID("lease"); c.NodeID != "n1" {
+		t.Fatalf("expected the lease placed on n1, got %q", c.NodeID)
+	}
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// Quota limit names, reported back to clients when a limit is hit
+const (
+	QuotaContainers = "containers"
+	QuotaCPUMilli   = "cpu_milli"
+	QuotaMemoryMB   = "memory_mb"
+	QuotaVolumeMB   = "volume_mb"
+	QuotaSnapshots  = "snapshots"
+)
+
+// ErrQuotaExceeded is wrapped by every QuotaExceededError
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// ErrInvalidQuota is returned when a quota update contains out-of-range values
+var ErrInvalidQuota = errors.New("quota limits must be -1 (unlimited) or greater")
+
+// QuotaExceededError describes which tenant limit a request would break
+type QuotaExceededError struct {
+	Limit     string
+	Max       int
+	Used      int
+	Requested int
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s quota exceeded: %d in use, %d requested, limit %d", e.Limit, e.Used, e.Requested, e.Max)
+}
+
+func (e *QuotaExceededError) Unwrap() error { return ErrQuotaExceeded }
+
+// ExceedsLimit reports whether the request is larger than the quota itself,
+// i.e. it can never succeed no matter what the tenant frees up
+func (e *QuotaExceededError) ExceedsLimit() bool {
+	return e.Requested > e.Max
+}
+
+// QuotaService resolves, enforces and reports per-tenant resource quotas
+type QuotaService struct {
+	quotaRepository     domain.QuotaRepository
+	containerRepository domain.ContainerRepository
+	snapshotRepository  domain.SnapshotRepository
+	reservations        domain.ReservationRepository
+	locker              domain.TenantLocker
+	logger              *slog.Logger
+	config              *config.Config
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// NewQuotaService creates a new quota service
+func NewQuotaService(
+	quotaRepo domain.QuotaRepository,
+	containerRepo domain.ContainerRepository,
+	snapshotRepo domain.SnapshotRepository,
+	logger *slog.Logger,
+	cfg *config.Config,
+) *QuotaService {
+	return &QuotaService{
+		quotaRepository:     quotaRepo,
+		containerRepository: containerRepo,
+		snapshotRepository:  snapshotRepo,
+		logger:              logger,
+		config:              cfg,
+		locks:               map[string]*sync.Mutex{},
+	}
+}
+
+// WithReservations counts booked reservations against the tenant's quota
+func (s *QuotaService) WithReservations(reservations domain.ReservationRepository) *QuotaService {
+	s.reservations = reservations
+	return s
+}
+
+// WithTenantLocks also serialises each tenant's quota sections with other servers
+func (s *QuotaService) WithTenantLocks(locker domain.TenantLocker) *QuotaService {
+	s.locker = locker
+	return s
+}
+
+// GetQuota returns the tenant's quota, falling back to the configured defaults
+func (s *QuotaService) GetQuota(tenantID string) (*domain.TenantQuota, error) {
+	quota, err := s.quotaRepository.GetQuota(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if quota != nil {
+		return quota, nil
+	}
+	return &domain.TenantQuota{
+		TenantID:      tenantID,
+		MaxContainers: s.config.TenantMaxContainers,
+		MaxCPUMilli:   s.config.TenantMaxCPUMilli,
+		MaxMemoryMB:   s.config.TenantMaxMemoryMB,
+		MaxVolumeMB:   s.config.TenantMaxVolumeMB,
+		MaxSnapshots:  s.config.TenantMaxSnapshots,
+	}, nil
+}
+
+// UpdateQuota stores a tenant-specific quota
+func (s *QuotaService) UpdateQuota(quota *domain.TenantQuota) error {
+	for _, v := range []int{quota.MaxContainers, quota.MaxCPUMilli, quota.MaxMemoryMB, quota.MaxVolumeMB, quota.MaxSnapshots} {
+		if v < domain.Unlimited {
+			return ErrInvalidQuota
+		}
+	}
+	return s.quotaRepository.SaveQuota(quota)
+}
+
+// GetUsage totals the resources currently held by a tenant
+func (s *QuotaService) GetUsage(tenantID string) (*domain.TenantUsage, error) {
+	containers, err := s.containerRepository.ListByTenant(tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	usage := &domain.TenantUsage{}
+	for _, c := range containers {
+		if c.Status == "terminated" {
+			continue
+		}
+		usage.Containers++
+		usage.CPUMilli += c.CPUMilli
+		usage.MemoryMB += c.MemoryMB
+		usage.VolumeMB += heldVolumeMB(c)
+	}
+
+	// Snapshots are only tracked when a snapshot store is configured
+	if s.snapshotRepository != nil {
+		snapshots, err := s.snapshotRepository.GetByTenant(tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list snapshots: %w", err)
+		}
+		for _, snapshot := range snapshots {
+			// Failed snapshots have no image
+			if snapshot.Status != domain.SnapshotFailed {
+				usage.Snapshots++
+			}
+		}
+	}
+
+	return usage, nil
+}
+
+// CheckProvision verifies that one more container of the given size fits in the tenant's quota.
+// Reservations that start before the new lease could end count as already held.
+func (s *QuotaService) CheckProvision(tenantID string, cpuMilli, memoryMB, volumeMB int) error {
+	quota, usage, err := s.load(tenantID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := s.addReserved(tenantID, usage, now, now.Add(time.Duration(s.config.ContainerMaxDuration)*time.Minute)); err != nil {
+		return err
+	}
+	return s.fits(tenantID, quota, usage, 1, cpuMilli, memoryMB, volumeMB)
+}
+
+// CheckReservation verifies that count containers of the given size fit in the tenant's quota
+// from start to end, next to the leases still held at start and the other reservations in the window
+func (s *QuotaService) CheckReservation(tenantID string, start, end time.Time, count, cpuMilli, memoryMB, volumeMB int) error {
+	quota, err := s.GetQuota(tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to load quota: %w", err)
+	}
+	containers, err := s.containerRepository.ListByTenant(tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	usage := &domain.TenantUsage{}
+	for _, c := range containers {
+		if c.Status == "terminated" || !c.ExpiryAt.After(start) {
+			continue
+		}
+		usage.Containers++
+		usage.CPUMilli += c.CPUMilli
+		usage.MemoryMB += c.MemoryMB
+		usage.VolumeMB += heldVolumeMB(c)
+	}
+	if err := s.addReserved(tenantID, usage, start, end); err != nil {
+		return err
+	}
+	return s.fits(tenantID, quota, usage, count, count*cpuMilli, count*memoryMB, count*volumeMB)
+}
+
+// CheckSnapshot verifies that the tenant may keep one more snapshot
+func (s *QuotaService) CheckSnapshot(tenantID string) error {
+	quota, usage, err := s.load(tenantID)
+	if err != nil {
+		return err
+	}
+	return s.check(tenantID, QuotaExceededError{Limit: QuotaSnapshots, Max: quota.MaxSnapshots, Used: usage.Snapshots, Requested: 1})
+}
+
+// LockTenant serialises quota check-and-reserve sections for a tenant, within this
+// process and, with tenant locks, across servers.
+// The caller must hold the lock until the new resource has been recorded.
+func (s *QuotaService) LockTenant(ctx context.Context, tenantID string) (func(), error) {
+	s.mu.Lock()
+	l, ok := s.locks[tenantID]
+	if !ok {
+		l = &sync.Mutex{}
+		s.locks[tenantID] = l
+	}
+	s.mu.Unlock()
+
+	l.Lock()
+	if s.locker == nil {
+		return l.Unlock, nil
+	}
+	unlock, err := s.locker.LockTenant(ctx, tenantID)
+	if err != nil {
+		l.Unlock()
+		return nil, err
+	}
+	return func() {
+		unlock()
+		l.Unlock()
+	}, nil
+}
+
+func (s *QuotaService) load(tenantID string) (*domain.TenantQuota, *domain.TenantUsage, error) {
+	quota, err := s.GetQuota(tenantID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load quota: %w", err)
+	}
+	usage, err := s.GetUsage(tenantID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load usage: %w", err)
+	}
+	return quota, usage, nil
+}
+
+// addReserved adds the pending reservations that overlap [start, end) to usage.
+// Reservations that have started provisioning are counted through their containers.
+func (s *QuotaService) addReserved(tenantID string, usage *domain.TenantUsage, start, end time.Time) error {
+	if s.reservations == nil {
+		return nil
+	}
+	reservations, err := s.reservations.ListByTenant(tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to list reservations: %w", err)
+	}
+	for _, r := range reservations {
+		if r.Status != domain.ReservationPending || !r.Overlaps(start, end) {
+			continue
+		}
+		usage.Containers += r.Count
+		usage.CPUMilli += r.Count * r.Spec.CPUMilli
+		usage.MemoryMB += r.Count * r.Spec.MemoryMB
+		usage.VolumeMB += r.Count * r.Spec.VolumeSizeMB
+	}
+	return nil
+}
+
+// fits checks a request for containers and resources against every container limit
+func (s *QuotaService) fits(tenantID string, quota *domain.TenantQuota, usage *domain.TenantUsage, containers, cpuMilli, memoryMB, volumeMB int) error {
+	checks := []QuotaExceededError{
+		{Limit: QuotaContainers, Max: quota.MaxContainers, Used: usage.Containers, Requested: containers},
+		{Limit: QuotaCPUMilli, Max: quota.MaxCPUMilli, Used: usage.CPUMilli, Requested: cpuMilli},
+		{Limit: QuotaMemoryMB, Max: quota.MaxMemoryMB, Used: usage.MemoryMB, Requested: memoryMB},
+		{Limit: QuotaVolumeMB, Max: quota.MaxVolumeMB, Used: usage.VolumeMB, Requested: volumeMB},
+	}
+	for _, c := range checks {
+		if err := s.check(tenantID, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *QuotaService) check(tenantID string, c QuotaExceededError) error {
+	if c.Max == domain.Unlimited || c.Requested == 0 || c.Used+c.Requested <= c.Max {
+		return nil
+	}
+	s.logger.Warn("tenant quota exceeded",
+		slog.String("tenant_id", tenantID),
+		slog.String("limit", c.Limit),
+		slog.Int("max", c.Max),
+		slog.Int("used", c.Used),
+		slog.Int("requested", c.Requested),
+	)
+	return &c
+}
+
+// heldVolumeMB is the storage a container holds: its volume, or the files uploaded into it
+// without one, rounded up to whole megabytes
+func heldVolumeMB(c *domain.Container) int {
+	return c.VolumeSize + int((c.UploadedBytes+1<<20-1)>>20)
+}
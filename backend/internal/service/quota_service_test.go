@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+type memQuotaRepo struct {
+	byTenant map[string]*domain.TenantQuota
+}
+
+func (m *memQuotaRepo) GetQuota(tenantID string) (*domain.TenantQuota, error) {
+	if q, ok := m.byTenant[tenantID]; ok {
+		cp := *q
+		return &cp, nil
+	}
+	return nil, nil
+}
+func (m *memQuotaRepo) SaveQuota(q *domain.TenantQuota) error {
+	cp := *q
+	m.byTenant[q.TenantID] = &cp
+	return nil
+}
+
+type memSnapshotRepo struct {
+	snapshots []*domain.Snapshot
+}
+
+func (m *memSnapshotRepo) Create(s *domain.Snapshot) error {
+	m.snapshots = append(m.snapshots, s)
+	return nil
+}
+func (m *memSnapshotRepo) GetByID(id string) (*domain.Snapshot, error) {
+	return nil, errors.New("not found")
+}
+func (m *memSnapshotRepo) GetByContainerID(containerID string) ([]*domain.Snapshot, error) {
+	return nil, nil
+}
+func (m *memSnapshotRepo) GetByTenant(tenantID string) ([]*domain.Snapshot, error) {
+	out := []*domain.Snapshot{}
+	for _, s := range m.snapshots {
+		if s.TenantID == tenantID {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+func (m *memSnapshotRepo) Delete(id string) error                       { return nil }
+func (m *memSnapshotRepo) DeleteByContainerID(containerID string) error { return nil }
+
+func newTestQuotaService(containers *memContainerRepo, snapshots *memSnapshotRepo) (*QuotaService, *memQuotaRepo) {
+	cfg := &config.Config{
+		TenantMaxContainers: 2,
+		TenantMaxCPUMilli:   1000,
+		TenantMaxMemoryMB:   1024,
+		TenantMaxVolumeMB:   domain.Unlimited,
+		TenantMaxSnapshots:  1,
+	}
+	quotas := &memQuotaRepo{byTenant: map[string]*domain.TenantQuota{}}
+	return NewQuotaService(quotas, containers, snapshots, slog.Default(), cfg), quotas
+}
+
+func TestProvisionRejectedWhenOverQuota(t *testing.T) {
+	containers := newMemContainerRepo()
+	_ = containers.Save(&domain.Container{ID: "c1", TenantID: "t1", Status: "running", CPUMilli: 250, MemoryMB: 256})
+	_ = containers.Save(&domain.Container{ID: "c2", TenantID: "t1", Status: "pending", CPUMilli: 250, MemoryMB: 256})
+	_ = containers.Save(&domain.Container{ID: "c3", TenantID: "t1", Status: "terminated", CPUMilli: 250, MemoryMB: 256})
+	quotas, _ := newTestQuotaService(containers, &memSnapshotRepo{})
+
+	svc := NewContainerService(nil, newMemLeaseRepo(containers), containers, slog.Default(), &config.Config{}).WithQuotas(quotas)
+	_, err := svc.ProvisionContainer(context.Background(), ProvisionOptions{TenantID: "t1", CPUMilli: 250, MemoryMB: 256, DurationMinutes: 5})
+
+	var qe *QuotaExceededError
+	if !errors.As(err, &qe) || !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected quota error, got %v", err)
+	}
+	if qe.Limit != QuotaContainers || qe.Used != 2 || qe.Max != 2 || qe.ExceedsLimit() {
+		t.Fatalf("unexpected quota error: %+v", qe)
+	}
+	if len(containers.byID) != 3 {
+		t.Fatalf("expected no container to be recorded, got %d", len(containers.byID))
+	}
+}
+
+func TestQuotaRequestLargerThanLimit(t *testing.T) {
+	quotas, _ := newTestQuotaService(newMemContainerRepo(), &memSnapshotRepo{})
+
+	err := quotas.CheckProvision("t1", 250, 2048, 0)
+	var qe *QuotaExceededError
+	if !errors.As(err, &qe) || qe.Limit != QuotaMemoryMB || !qe.ExceedsLimit() {
+		t.Fatalf("expected memory limit to be exceeded outright, got %v", err)
+	}
+
+	if err := quotas.CheckProvision("t1", 250, 512, 100000); err != nil {
+		t.Fatalf("expected unlimited volume quota to allow request, got %v", err)
+	}
+}
+
+func TestQuotaOverridesAndSnapshots(t *testing.T) {
+	snapshots := &memSnapshotRepo{}
+	_ = snapshots.Create(&domain.Snapshot{ID: "s1", TenantID: "t1"})
+	quotas, _ := newTestQuotaService(newMemContainerRepo(), snapshots)
+
+	if err := quotas.CheckSnapshot("t1"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected snapshot quota error, got %v", err)
+	}
+
+	q, err := quotas.GetQuota("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.MaxSnapshots = domain.Unlimited
+	if err := quotas.UpdateQuota(q); err != nil {
+		t.Fatal(err)
+	}
+	if err := quotas.CheckSnapshot("t1"); err != nil {
+		t.Fatalf("expected override to lift snapshot limit, got %v", err)
+	}
+
+	q.MaxContainers = -5
+	if err := quotas.UpdateQuota(q); !errors.Is(err, ErrInvalidQuota) {
+		t.Fatalf("expected invalid quota error, got %v", err)
+	}
+}
+
+// countingLocker stands in for the Postgres advisory locks
+type countingLocker struct {
+	held int
+	err  error
+}
+
+func (l *countingLocker) LockTenant(ctx context.Context, tenantID string) (func(), error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	l.held++
+	return func() { l.held-- }, nil
+}
+
+// commitDocker checks the tenant lock is free while a snapshot is committed
+type commitDocker struct {
+	domain.DockerClient
+	locker   *countingLocker
+	heldSeen int
+}
+
+func (d *commitDocker) CommitContainer(ctx context.Context, containerID, imageName string) error {
+	d.heldSeen = d.locker.held
+	return nil
+}
+
+func TestQuotaTenantLocks(t *testing.T) {
+	quotas, _ := newTestQuotaService(newMemContainerRepo(), &memSnapshotRepo{})
+	locker := &countingLocker{}
+	quotas.WithTenantLocks(locker)
+
+	unlock, err := quotas.LockTenant(context.Background(), "t1")
+	if err != nil || locker.held != 1 {
+		t.Fatalf("expected the tenant lock taken, got %d %v", locker.held, err)
+	}
+	unlock()
+	if locker.held != 0 {
+		t.Fatal("expected the tenant lock released")
+	}
+
+	// A failed lock leaves the in-process lock free for the next attempt
+	locker.err = errors.New("connection refused")
+	if _, err := quotas.LockTenant(context.Background(), "t1"); err == nil {
+		t.Fatal("expected the lock error returned")
+	}
+	locker.err = nil
+	unlock, err = quotas.LockTenant(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("expected the lock free again, got %v", err)
+	}
+	unlock()
+}
+
+func TestSnapshotCommitsWithoutTheQuotaLock(t *testing.T) {
+	containers := newMemContainerRepo()
+	_ = containers.Save(&domain.Container{ID: "c1", TenantID: "t1", Status: "running", DockerID: "docker-1"})
+	snapshots := &memSnapshotRepo{}
+	quotas, _ := newTestQuotaService(containers, snapshots)
+	locker := &countingLocker{}
+	quotas.WithTenantLocks(locker)
+	docker := &commitDocker{locker: locker, heldSeen: -1}
+	svc := NewSnapshotService(docker, containers, snapshots, slog.Default(), &config.Config{}).WithQuotas(quotas)
+
+	snapshot, err := svc.CreateSnapshot(context.Background(), "c1", "")
+	if err != nil || snapshot.Status != domain.SnapshotReady {
+		t.Fatalf("create snapshot: %+v %v", snapshot, err)
+	}
+	if docker.heldSeen != 0 {
+		t.Fatalf("expected the quota lock released before the commit, %d held", docker.heldSeen)
+	}
+	// The pending record counted against the quota, so a second snapshot is refused
+	if _, err := svc.CreateSnapshot(context.Background(), "c1", ""); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected snapshot quota error, got %v", err)
+	}
+}
+
+// lockCheckingJobs records how many tenant locks are held when a job is enqueued
+type lockCheckingJobs struct {
+	memJobQueue
+	locker   *countingLocker
+	heldSeen int
+}
+
+func (q *lockCheckingJobs) Enqueue(ctx context.Context, job *domain.Job) error {
+	q.heldSeen = q.locker.held
+	return q.memJobQueue.Enqueue(ctx, job)
+}
+
+func TestProvisionReleasesQuotaLockOnceSaved(t *testing.T) {
+	s, containers, _ := newTestContainerService(&config.Config{})
+	quotas, _ := newTestQuotaService(containers, &memSnapshotRepo{})
+	locker := &countingLocker{}
+	quotas.WithTenantLocks(locker)
+	jobs := &lockCheckingJobs{memJobQueue: memJobQueue{jobs: map[string]*domain.Job{}}, locker: locker, heldSeen: -1}
+	s.WithQuotas(quotas).WithJobs(jobs)
+
+	c, err := s.ProvisionContainer(context.Background(), ProvisionOptions{TenantID: "t1", ImageType: "ubuntu", DurationMinutes: 30, CPUMilli: 250, MemoryMB: 256})
+	if err != nil {
+		t.Fatalf("provision: %v", err)
+	}
+	if jobs.heldSeen != 0 || locker.held != 0 {
+		t.Fatalf("expected the quota lock released before the job was enqueued, %d held", jobs.heldSeen)
+	}
+	if _, err := containers.GetByID(c.ID); err != nil {
+		t.Fatalf("expected the pending container saved: %v", err)
+	}
+}
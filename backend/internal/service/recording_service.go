@@ -0,0 +1,268 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// Terminal size recorded when the client does not send one
+const (
+	defaultRecordingCols = 80
+	defaultRecordingRows = 24
+)
+
+// asciicastHeader is the first line of an asciicast v2 file
+type asciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     uint              `json:"width"`
+	Height    uint              `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Command   string            `json:"command,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// RecordingService records terminal sessions as asciicast v2 files and enforces their retention
+type RecordingService struct {
+	recordingRepository domain.RecordingRepository
+	logger              *slog.Logger
+	config              *config.Config
+}
+
+// NewRecordingService creates a new recording service
+func NewRecordingService(recordingRepo domain.RecordingRepository, logger *slog.Logger, cfg *config.Config) *RecordingService {
+	return &RecordingService{
+		recordingRepository: recordingRepo,
+		logger:              logger,
+		config:              cfg,
+	}
+}
+
+// ShouldRecord applies the tenant's recording policy to a client's request
+func (s *RecordingService) ShouldRecord(tenantID string, requested bool) bool {
+	switch s.config.RecordingPolicyFor(tenantID) {
+	case config.RecordingMandatory:
+		return true
+	case config.RecordingOptIn:
+		return requested
+	default:
+		return false
+	}
+}
+
+// Required reports whether sessions must not run unrecorded for this tenant
+func (s *RecordingService) Required(tenantID string) bool {
+	return s.config.RecordingPolicyFor(tenantID) == config.RecordingMandatory
+}
+
+// Start opens a new recording for a session in the given container
+func (s *RecordingService) Start(container *domain.Container, opts domain.ExecOptions) (*Recorder, error) {
+	cols, rows := opts.Cols, opts.Rows
+	if cols == 0 || rows == 0 {
+		cols, rows = defaultRecordingCols, defaultRecordingRows
+	}
+
+	now := time.Now()
+	rec := &domain.Recording{
+		ID:          fmt.Sprintf("rec-%d", now.UnixNano()),
+		ContainerID: container.ID,
+		TenantID:    container.TenantID,
+		Command:     opts.Cmd,
+		Cols:        cols,
+		Rows:        rows,
+		StartedAt:   now,
+	}
+
+	dir := filepath.Join(s.config.RecordingsDir, container.ID)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create recordings directory: %w", err)
+	}
+	rec.Path = filepath.Join(dir, rec.ID+".cast")
+
+	file, err := os.OpenFile(rec.Path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording file: %w", err)
+	}
+
+	r := &Recorder{
+		recording: rec,
+		repo:      s.recordingRepository,
+		logger:    s.logger,
+		file:      file,
+		w:         bufio.NewWriter(file),
+	}
+	header := asciicastHeader{
+		Version:   2,
+		Width:     cols,
+		Height:    rows,
+		Timestamp: now.Unix(),
+		Command:   strings.Join(opts.Cmd, " "),
+		Env:       map[string]string{"TERM": "xterm"},
+	}
+	if err := r.writeLine(header); err != nil {
+		file.Close()
+		os.Remove(rec.Path)
+		return nil, fmt.Errorf("failed to write recording header: %w", err)
+	}
+
+	if err := s.recordingRepository.Create(rec); err != nil {
+		file.Close()
+		os.Remove(rec.Path)
+		return nil, err
+	}
+
+	s.logger.Info("session recording started",
+		slog.String("recording_id", rec.ID),
+		slog.String("container_id", container.ID),
+		slog.String("tenant_id", container.TenantID),
+	)
+	return r, nil
+}
+
+// List returns a container's recordings, including those of terminated containers
+func (s *RecordingService) List(containerID string) ([]*domain.Recording, error) {
+	return s.recordingRepository.ListByContainer(containerID)
+}
+
+// Get returns a recording's metadata
+func (s *RecordingService) Get(id string) (*domain.Recording, error) {
+	return s.recordingRepository.GetByID(id)
+}
+
+// Open returns the asciicast file of a recording
+func (s *RecordingService) Open(rec *domain.Recording) (*os.File, error) {
+	return os.Open(rec.Path)
+}
+
+// Purge deletes recordings whose session ended more than the retention period ago
+func (s *RecordingService) Purge(now time.Time) {
+	if s.config.RecordingRetentionDays <= 0 {
+		return // Keep forever
+	}
+
+	cutoff := now.AddDate(0, 0, -s.config.RecordingRetentionDays)
+	expired, err := s.recordingRepository.DeleteEndedBefore(cutoff)
+	if err != nil {
+		s.logger.Error("failed to purge recordings", slog.String("error", err.Error()))
+		return
+	}
+	for _, rec := range expired {
+		if err := os.Remove(rec.Path); err != nil && !os.IsNotExist(err) {
+			s.logger.Warn("failed to remove recording file", slog.String("path", rec.Path), slog.String("error", err.Error()))
+		}
+		// Drop the container's directory once its last recording is gone
+		_ = os.Remove(filepath.Dir(rec.Path))
+	}
+}
+
+// Recorder appends the events of one terminal session to an asciicast v2 file.
+// It is safe for concurrent use by the output and input pumps.
+type Recorder struct {
+	mu        sync.Mutex
+	recording *domain.Recording
+	repo      domain.RecordingRepository
+	logger    *slog.Logger
+	file      *os.File
+	w         *bufio.Writer
+	size      int64
+	pending   []byte // Trailing bytes of a UTF-8 sequence split across reads
+	closed    bool
+}
+
+// Recording returns the recording's metadata
+func (r *Recorder) Recording() *domain.Recording {
+	return r.recording
+}
+
+// Output records terminal output
+func (r *Recorder) Output(p []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+
+	data := append(r.pending, p...)
+	cut := len(data)
+	// Hold back an incomplete rune so it is not mangled into U+FFFD
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	r.pending = append([]byte(nil), data[cut:]...)
+	if cut == 0 {
+		return nil
+	}
+	return r.event("o", string(data[:cut]))
+}
+
+// Resize records a terminal size change
+func (r *Recorder) Resize(cols, rows uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	return r.event("r", fmt.Sprintf("%dx%d", cols, rows))
+}
+
+// Close flushes the file and stores the recording's end time and size
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	if len(r.pending) > 0 {
+		_ = r.event("o", string(r.pending))
+	}
+	r.closed = true
+
+	err := r.w.Flush()
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+
+	ended := time.Now()
+	r.recording.EndedAt = &ended
+	r.recording.SizeBytes = r.size
+	if ferr := r.repo.Finish(r.recording); ferr != nil && err == nil {
+		err = ferr
+	}
+	r.logger.Info("session recording finished",
+		slog.String("recording_id", r.recording.ID),
+		slog.Int64("size_bytes", r.size),
+	)
+	return err
+}
+
+func (r *Recorder) event(kind, data string) error {
+	elapsed := math.Round(time.Since(r.recording.StartedAt).Seconds()*1e6) / 1e6
+	return r.writeLine([]any{elapsed, kind, data})
+}
+
+func (r *Recorder) writeLine(v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	n, err := r.w.Write(line)
+	r.size += int64(n)
+	return err
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+type memRecordingRepo struct {
+	mu   sync.Mutex
+	recs map[string]*domain.Recording
+}
+
+func (m *memRecordingRepo) Create(rec *domain.Recording) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := *rec
+	m.recs[rec.ID] = &cp
+	return nil
+}
+
+func (m *memRecordingRepo) Finish(rec *domain.Recording) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, ok := m.recs[rec.ID]
+	if !ok {
+		return fmt.Errorf("recording not found: %s", rec.ID)
+	}
+	stored.EndedAt, stored.SizeBytes = rec.EndedAt, rec.SizeBytes
+	return nil
+}
+
+func (m *memRecordingRepo) GetByID(id string) (*domain.Recording, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec, ok := m.recs[id]
+	if !ok {
+		return nil, fmt.Errorf("recording not found: %s", id)
+	}
+	return rec, nil
+}
+
+func (m *memRecordingRepo) ListByContainer(containerID string) ([]*domain.Recording, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*domain.Recording
+	for _, rec := range m.recs {
+		if rec.ContainerID == containerID {
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+func (m *memRecordingRepo) DeleteEndedBefore(cutoff time.Time) ([]*domain.Recording, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*domain.Recording
+	for id, rec := range m.recs {
+		if rec.EndedAt != nil && rec.EndedAt.Before(cutoff) {
+			out = append(out, rec)
+			delete(m.recs, id)
+		}
+	}
+	return out, nil
+}
+
+func newTestRecordingService(t *testing.T, cfg *config.Config) (*RecordingService, *memRecordingRepo) {
+	cfg.RecordingsDir = t.TempDir()
+	repo := &memRecordingRepo{recs: map[string]*domain.Recording{}}
+	return NewRecordingService(repo, slog.Default(), cfg), repo
+}
+
+func TestShouldRecordAppliesTenantPolicy(t *testing.T) {
+	svc, _ := newTestRecordingService(t, &config.Config{
+		RecordingPolicy: config.RecordingOff,
+		TenantRecordingPolicies: map[string]string{
+			"opt":    config.RecordingOptIn,
+			"strict": config.RecordingMandatory,
+		},
+	})
+
+	cases := []struct {
+		tenant    string
+		requested bool
+		want      bool
+	}{
+		{"other", true, false},
+		{"opt", false, false},
+		{"opt", true, true},
+		{"strict", false, true},
+	}
+	for _, c := range cases {
+		if got := svc.ShouldRecord(c.tenant, c.requested); got != c.want {
+			t.Errorf("ShouldRecord(%q, %v) = %v, want %v", c.tenant, c.requested, got, c.want)
+		}
+	}
+	if !svc.Required("strict") || svc.Required("opt") {
+		t.Fatal("only mandatory tenants should require recording")
+	}
+}
+
+func TestRecorderWritesAsciicast(t *testing.T) {
+	svc, repo := newTestRecordingService(t, &config.Config{})
+	container := &domain.Container{ID: "c1", TenantID: "tenant-1"}
+
+	rec, err := svc.Start(container, domain.ExecOptions{Cmd: []string{"/bin/sh"}, Cols: 100, Rows: 30})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	euro := []byte("€") // 3 bytes, split across two reads
+	_ = rec.Output([]byte("$ "))
+	_ = rec.Output(euro[:1])
+	_ = rec.Output(euro[1:])
+	_ = rec.Resize(120, 40)
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	file, err := os.Open(rec.Recording().Path)
+	if err != nil {
+		t.Fatalf("open recording: %v", err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+
+	scanner.Scan()
+	var header asciicastHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		t.Fatalf("header: %v", err)
+	}
+	if header.Version != 2 || header.Width != 100 || header.Height != 30 || header.Command != "/bin/sh" {
+		t.Fatalf("unexpected header: %+v", header)
+	}
+
+	var events [][]any
+	for scanner.Scan() {
+		var e []any
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("event %q: %v", scanner.Text(), err)
+		}
+		events = append(events, e)
+	}
+	want := [][2]string{{"o", "$ "}, {"o", "€"}, {"r", "120x40"}}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %v", len(want), events)
+	}
+	for i, e := range events {
+		if _, ok := e[0].(float64); !ok || e[1] != want[i][0] || e[2] != want[i][1] {
+			t.Errorf("event %d = %v, want %v", i, e, want[i])
+		}
+	}
+
+	stored, _ := repo.GetByID(rec.Recording().ID)
+	info, _ := os.Stat(stored.Path)
+	if stored.EndedAt == nil || stored.SizeBytes != info.Size() {
+		t.Fatalf("recording not finished: %+v (file size %d)", stored, info.Size())
+	}
+}
+
+func TestPurgeKeepsRecordingsWithinRetention(t *testing.T) {
+	svc, repo := newTestRecordingService(t, &config.Config{RecordingRetentionDays: 30})
+	container := &domain.Container{ID: "c1", TenantID: "tenant-1"}
+
+	old, _ := svc.Start(container, domain.ExecOptions{})
+	_ = old.Close()
+	recent, _ := svc.Start(container, domain.ExecOptions{})
+	_ = recent.Close()
+	ended := time.Now().AddDate(0, 0, -31)
+	repo.recs[old.Recording().ID].EndedAt = &ended
+
+	svc.Purge(time.Now())
+
+	if _, err := os.Stat(old.Recording().Path); !os.IsNotExist(err) {
+		t.Fatalf("expected expired recording file to be removed, got %v", err)
+	}
+	recs, _ := svc.List("c1")
+	if len(recs) != 1 || recs[0].ID != recent.Recording().ID {
+		t.Fatalf("expected only the recent recording to remain, got %v", recs)
+	}
+}
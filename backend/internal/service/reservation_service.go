@@ -0,0 +1,370 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// Reservation errors, mapped to HTTP status codes by the handler layer
+var (
+	ErrInvalidReservation = errors.New("invalid reservation")
+	ErrReservationStarted = errors.New("reservation is no longer pending")
+)
+
+// reservationClaimTimeout is the claim timeout used when no job claim timeout is configured
+const reservationClaimTimeout = 15 * time.Minute
+
+// LeaseProvisioner creates leases; satisfied by ContainerService
+type LeaseProvisioner interface {
+	ProvisionContainer(ctx context.Context, opts ProvisionOptions) (*domain.Container, error)
+}
+
+// ReservationService books leases for future time windows and provisions them shortly
+// before they start. A booking holds its resources against the tenant's quota and the
+// host capacity from the moment it is made, so overbooking is refused up front.
+type ReservationService struct {
+	reservations        domain.ReservationRepository
+	containerRepository domain.ContainerRepository
+	provisioner         LeaseProvisioner
+	dockerClient        domain.DockerClient
+	quotas              *QuotaService
+	capacity            HostCapacity
+	logger              *slog.Logger
+	config              *config.Config
+
+	mu sync.Mutex // Serialises capacity checks across tenants
+}
+
+// NewReservationService creates a new reservation service
+func NewReservationService(
+	reservations domain.ReservationRepository,
+	containerRepo domain.ContainerRepository,
+	provisioner LeaseProvisioner,
+	docker domain.DockerClient,
+	logger *slog.Logger,
+	cfg *config.Config,
+) *ReservationService {
+	return &ReservationService{
+		reservations:        reservations,
+		containerRepository: containerRepo,
+		provisioner:         provisioner,
+		dockerClient:        docker,
+		capacity:            HostCapacity{CPUMilli: cfg.HostCPUMilli, MemoryMB: cfg.HostMemoryMB},
+		logger:              logger,
+		config:              cfg,
+	}
+}
+
+// WithQuotas checks bookings against the tenant's quota for the reserved window
+func (s *ReservationService) WithQuotas(quotas *QuotaService) *ReservationService {
+	s.quotas = quotas
+	return s
+}
+
+// WithCapacity books against the given host capacity instead of the configured one
+func (s *ReservationService) WithCapacity(capacity HostCapacity) *ReservationService {
+	s.capacity = capacity
+	return s
+}
+
+// Book validates and stores a reservation of res.Count leases from res.StartAt for
+// res.DurationMinutes. The spec must already have passed provisioning validation.
+func (s *ReservationService) Book(ctx context.Context, res *domain.Reservation) (*domain.Reservation, error) {
+	now := time.Now()
+	if !res.StartAt.After(now) {
+		return nil, fmt.Errorf("%w: startTime must be in the future", ErrInvalidReservation)
+	}
+	if res.StartAt.After(now.AddDate(0, 0, s.config.ReservationMaxDays)) {
+		return nil, fmt.Errorf("%w: startTime may be at most %d days ahead", ErrInvalidReservation, s.config.ReservationMaxDays)
+	}
+	if res.Count < 1 || res.Count > s.config.ReservationMaxLeases {
+		return nil, fmt.Errorf("%w: count must be between 1 and %d", ErrInvalidReservation, s.config.ReservationMaxLeases)
+	}
+
+	// Both locks are held until the reservation is saved so concurrent bookings see each other
+	if s.quotas != nil {
+		unlock, err := s.quotas.LockTenant(ctx, res.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.quotas != nil {
+		err := s.quotas.CheckReservation(res.TenantID, res.StartAt, res.EndAt(), res.Count, res.Spec.CPUMilli, res.Spec.MemoryMB, res.Spec.VolumeSizeMB)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.checkCapacity(res.StartAt, res.EndAt(), res.Count*res.Spec.CPUMilli, res.Count*res.Spec.MemoryMB); err != nil {
+		return nil, err
+	}
+
+	res.ID = fmt.Sprintf("reservation-%d", rand.Int63())
+	res.Status = domain.ReservationPending
+	res.ContainerIDs = nil
+	res.CreatedAt = now
+	if err := s.reservations.Save(res); err != nil {
+		return nil, fmt.Errorf("failed to save reservation: %w", err)
+	}
+
+	s.logger.Info("reservation booked",
+		slog.String("reservation_id", res.ID),
+		slog.String("tenant_id", res.TenantID),
+		slog.Time("start_at", res.StartAt),
+		slog.Int("count", res.Count),
+	)
+	return res, nil
+}
+
+// GetReservation retrieves a reservation
+func (s *ReservationService) GetReservation(ctx context.Context, id string) (*domain.Reservation, error) {
+	return s.reservations.GetByID(id)
+}
+
+// ListReservations returns a tenant's reservations, latest start first
+func (s *ReservationService) ListReservations(ctx context.Context, tenantID string) ([]*domain.Reservation, error) {
+	return s.reservations.ListByTenant(tenantID)
+}
+
+// Cancel releases a reservation that has not started provisioning yet
+func (s *ReservationService) Cancel(ctx context.Context, id string) (*domain.Reservation, error) {
+	res, err := s.reservations.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if res.Status != domain.ReservationPending {
+		return nil, fmt.Errorf("%w: reservation is %s", ErrReservationStarted, res.Status)
+	}
+	// Conditional, so a reservation claimed for provisioning since is not cancelled
+	cancelled, err := s.reservations.UpdateStatus(id, domain.ReservationPending, domain.ReservationCancelled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save reservation: %w", err)
+	}
+	if !cancelled {
+		return nil, fmt.Errorf("%w: reservation is being provisioned", ErrReservationStarted)
+	}
+	res.Status = domain.ReservationCancelled
+	s.logger.Info("reservation cancelled", slog.String("reservation_id", id))
+	return res, nil
+}
+
+// Process moves reservations through their states: due ones are provisioned, ones left
+// provisioning by a restart are finished and active ones complete when their window ends
+func (s *ReservationService) Process(ctx context.Context, now time.Time) {
+	reservations, err := s.reservations.ListByStatus(domain.ReservationPending, domain.ReservationProvisioning, domain.ReservationActive)
+	if err != nil {
+		s.logger.Error("failed to list reservations", slog.String("error", err.Error()))
+		return
+	}
+
+	lead := time.Duration(s.config.ReservationLeadMinutes) * time.Minute
+	for _, res := range reservations {
+		switch {
+		case !now.Before(res.EndAt()):
+			if res.Status == domain.ReservationActive {
+				s.setStatus(res, domain.ReservationCompleted)
+			} else if claimed, ok := s.claim(res); ok {
+				s.fail(claimed, "the reserved window ended before its leases were provisioned")
+			}
+		case res.Status == domain.ReservationActive:
+			if s.leasesEnded(res) {
+				s.setStatus(res, domain.ReservationCompleted)
+			}
+		case !now.Before(res.StartAt.Add(-lead)):
+			s.provision(ctx, res, now)
+		}
+	}
+}
+
+// provision pre-pulls the image, then creates the reservation's remaining leases.
+// Errors before the start time are retried on the next run; after it the reservation fails.
+// Leases already created are kept either way.
+func (s *ReservationService) provision(ctx context.Context, res *domain.Reservation, now time.Time) {
+	logger := s.logger.With(slog.String("reservation_id", res.ID), slog.String("tenant_id", res.TenantID))
+
+	pending := res.Status == domain.ReservationPending
+	res, ok := s.claim(res)
+	if !ok {
+		return
+	}
+	if pending {
+		logger.Info("provisioning reservation", slog.Int("count", res.Count), slog.Time("start_at", res.StartAt))
+
+		// Pulled once up front so every lease starts from the local image cache
+		image := res.Spec.Image
+		if image == "" {
+			image = res.Spec.ImageType
+		}
+		if _, err := s.dockerClient.PullImage(ctx, image); err != nil {
+			s.retryOrFail(res, now, fmt.Sprintf("image pre-pull failed: %v", err))
+			return
+		}
+	} else {
+		logger.Info("resuming reservation provisioning", slog.Int("count", res.Count), slog.Int("created", len(res.ContainerIDs)))
+	}
+
+	for len(res.ContainerIDs) < res.Count {
+		container, err := s.provisioner.ProvisionContainer(ctx, s.options(res))
+		if err != nil {
+			s.retryOrFail(res, now, fmt.Sprintf("failed to provision lease %d of %d: %v", len(res.ContainerIDs)+1, res.Count, err))
+			return
+		}
+		recorded, err := s.reservations.AddContainer(res.ID, s.config.JobConsumer, container.ID, time.Now().Add(s.claimTimeout()))
+		if err != nil {
+			logger.Error("failed to record reserved lease", slog.String("container_id", container.ID), slog.String("error", err.Error()))
+			return
+		}
+		if !recorded {
+			logger.Error("reservation claim lost, lease not recorded", slog.String("container_id", container.ID))
+			return
+		}
+		res.ContainerIDs = append(res.ContainerIDs, container.ID)
+	}
+
+	res.Error = ""
+	s.setStatus(res, domain.ReservationActive)
+	logger.Info("reservation active", slog.Int("count", res.Count))
+}
+
+// claim takes a pending reservation, or a provisioning one whose claim has expired, for
+// this server. It returns the reservation as stored, with the leases earlier claims
+// recorded, or false if it was cancelled or is claimed by another run in the meantime.
+func (s *ReservationService) claim(res *domain.Reservation) (*domain.Reservation, bool) {
+	now := time.Now()
+	claimed, err := s.reservations.Claim(res.ID, s.config.JobConsumer, now, now.Add(s.claimTimeout()))
+	if err != nil {
+		s.logger.Error("failed to claim reservation", slog.String("reservation_id", res.ID), slog.String("error", err.Error()))
+		return nil, false
+	}
+	if !claimed {
+		return nil, false
+	}
+	current, err := s.reservations.GetByID(res.ID)
+	if err != nil {
+		s.logger.Error("failed to reload claimed reservation", slog.String("reservation_id", res.ID), slog.String("error", err.Error()))
+		return nil, false
+	}
+	return current, true
+}
+
+// claimTimeout is how long a claim holds without a lease being recorded, after which
+// the provisioning server is presumed stopped
+func (s *ReservationService) claimTimeout() time.Duration {
+	if s.config.JobClaimTimeoutSeconds > 0 {
+		return time.Duration(s.config.JobClaimTimeoutSeconds) * time.Second
+	}
+	return reservationClaimTimeout
+}
+
+// retryOrFail frees the reservation for the next run before its start time, and fails it
+// after. One without leases goes back to pending, so its image is pre-pulled again and it
+// can still be cancelled.
+func (s *ReservationService) retryOrFail(res *domain.Reservation, now time.Time, reason string) {
+	if !now.Before(res.StartAt) {
+		s.fail(res, reason)
+		return
+	}
+	s.logger.Warn("reservation provisioning failed, will retry", slog.String("reservation_id", res.ID), slog.String("error", reason))
+	status := domain.ReservationProvisioning
+	if len(res.ContainerIDs) == 0 {
+		status = domain.ReservationPending
+	}
+	released, err := s.reservations.Release(res.ID, s.config.JobConsumer, status, reason)
+	if err != nil {
+		s.logger.Error("failed to release reservation", slog.String("reservation_id", res.ID), slog.String("error", err.Error()))
+		return
+	}
+	if !released {
+		s.logger.Warn("reservation claim lost before release", slog.String("reservation_id", res.ID))
+	}
+}
+
+func (s *ReservationService) fail(res *domain.Reservation, reason string) {
+	res.Error = reason
+	s.setStatus(res, domain.ReservationFailed)
+	s.logger.Error("reservation failed", slog.String("reservation_id", res.ID), slog.String("error", reason))
+}
+
+func (s *ReservationService) setStatus(res *domain.Reservation, status string) {
+	res.Status = status
+	res.ClaimedBy, res.ClaimExpiresAt = "", time.Time{}
+	if err := s.reservations.Save(res); err != nil {
+		s.logger.Error("failed to save reservation", slog.String("reservation_id", res.ID), slog.String("error", err.Error()))
+	}
+}
+
+// leasesEnded reports whether every lease of the reservation has been terminated
+func (s *ReservationService) leasesEnded(res *domain.Reservation) bool {
+	for _, id := range res.ContainerIDs {
+		// Terminated records are dropped after a while
+		if c, err := s.containerRepository.GetByID(id); err == nil && c.Status != "terminated" {
+			return false
+		}
+	}
+	return true
+}
+
+// options builds the provisioning request for one of the reservation's leases
+func (s *ReservationService) options(res *domain.Reservation) ProvisionOptions {
+	return ProvisionOptions{
+		TenantID:        res.TenantID,
+		ImageType:       res.Spec.ImageType,
+		Image:           res.Spec.Image,
+		DurationMinutes: res.DurationMinutes,
+		CPUMilli:        res.Spec.CPUMilli,
+		MemoryMB:        res.Spec.MemoryMB,
+		LogDemo:         res.Spec.LogDemo,
+		VolumeSizeMB:    res.Spec.VolumeSizeMB,
+		Preset:          res.Spec.Preset,
+		Ports:           res.Spec.Ports,
+		Entrypoint:      res.Spec.Entrypoint,
+		Command:         res.Spec.Command,
+		Env:             res.Spec.Env,
+		WorkingDir:      res.Spec.WorkingDir,
+		InitScript:      res.Spec.InitScript,
+		NodeLabels:      res.Spec.NodeLabels,
+		ExpiryAt:        res.EndAt(),
+	}
+}
+
+// checkCapacity verifies that the host can hold cpuMilli and memoryMB more from start to end,
+// next to every lease still held at start and every other reservation in the window
+func (s *ReservationService) checkCapacity(start, end time.Time, cpuMilli, memoryMB int) error {
+	if !s.capacity.Limited() {
+		return nil
+	}
+	containers, err := s.containerRepository.List()
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+	pending, err := s.reservations.ListByStatus(domain.ReservationPending)
+	if err != nil {
+		return fmt.Errorf("failed to list reservations: %w", err)
+	}
+
+	bookedCPU, bookedMemory := bookedCapacity(containers, pending, start, end)
+	if err := s.capacity.Check(bookedCPU, bookedMemory, cpuMilli, memoryMB); err != nil {
+		var ce *CapacityExceededError
+		if errors.As(err, &ce) {
+			s.logger.Warn("host capacity exceeded",
+				slog.String("resource", ce.Resource),
+				slog.Int("capacity", ce.Capacity),
+				slog.Int("booked", ce.Booked),
+				slog.Int("requested", ce.Requested),
+			)
+		}
+		return err
+	}
+	return nil
+}
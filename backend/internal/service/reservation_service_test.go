@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+type memReservationRepo struct {
+	byID map[string]*domain.Reservation
+}
+
+func (m *memReservationRepo) Save(r *domain.Reservation) error {
+	cp := *r
+	cp.ContainerIDs = append([]string(nil), r.ContainerIDs...)
+	m.byID[r.ID] = &cp
+	return nil
+}
+func (m *memReservationRepo) GetByID(id string) (*domain.Reservation, error) {
+	if r, ok := m.byID[id]; ok {
+		cp := *r
+		return &cp, nil
+	}
+	return nil, errors.New("not found")
+}
+func (m *memReservationRepo) ListByTenant(tenantID string) ([]*domain.Reservation, error) {
+	out := []*domain.Reservation{}
+	for _, r := range m.byID {
+		if r.TenantID == tenantID {
+			cp := *r
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+func (m *memReservationRepo) ListByStatus(statuses ...string) ([]*domain.Reservation, error) {
+	out := []*domain.Reservation{}
+	for _, r := range m.byID {
+		for _, s := range statuses {
+			if r.Status == s {
+				cp := *r
+				out = append(out, &cp)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (m *memReservationRepo) UpdateStatus(id, from, to string) (bool, error) {
+	r, ok := m.byID[id]
+	if !ok || r.Status != from {
+		return false, nil
+	}
+	r.Status = to
+	return true, nil
+}
+
+func (m *memReservationRepo) Claim(id, owner string, now, until time.Time) (bool, error) {
+	r, ok := m.byID[id]
+	if !ok {
+		return false, nil
+	}
+	expired := r.Status == domain.ReservationProvisioning && !r.ClaimExpiresAt.After(now)
+	if r.Status != domain.ReservationPending && !expired {
+		return false, nil
+	}
+	r.Status, r.ClaimedBy, r.ClaimExpiresAt = domain.ReservationProvisioning, owner, until
+	return true, nil
+}
+
+func (m *memReservationRepo) AddContainer(id, owner, containerID string, until time.Time) (bool, error) {
+	r, ok := m.byID[id]
+	if !ok || r.Status != domain.ReservationProvisioning || r.ClaimedBy != owner {
+		return false, nil
+	}
+	r.ContainerIDs = append(r.ContainerIDs, containerID)
+	r.ClaimExpiresAt = until
+	return true, nil
+}
+
+func (m *memReservationRepo) Release(id, owner, status, reason string) (bool, error) {
+	r, ok := m.byID[id]
+	if !ok || r.Status != domain.ReservationProvisioning || r.ClaimedBy != owner {
+		return false, nil
+	}
+	r.Status, r.Error, r.ClaimedBy, r.ClaimExpiresAt = status, reason, "", time.Time{}
+	return true, nil
+}
+
+// fakeProvisioner records the leases it is asked for and stores them as pending containers
+type fakeProvisioner struct {
+	containers *memContainerRepo
+	opts       []ProvisionOptions
+	err        error
+	// during runs inside each call, to simulate another run while a lease is provisioned
+	during func()
+}
+
+func (p *fakeProvisioner) ProvisionContainer(ctx context.Context, opts ProvisionOptions) (*domain.Container, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if during := p.during; during != nil {
+		p.during = nil
+		during()
+	}
+	p.opts = append(p.opts, opts)
+	c := &domain.Container{ID: fmt.Sprintf("c%d", len(p.opts)), TenantID: opts.TenantID, Status: "pending", CPUMilli: opts.CPUMilli, MemoryMB: opts.MemoryMB, ExpiryAt: opts.ExpiryAt}
+	_ = p.containers.Save(c)
+	return c, nil
+}
+
+// prepullDocker counts image pulls, failing them with err when set
+type prepullDocker struct {
+	domain.DockerClient
+	pulled []string
+	err    error
+}
+
+func (d *prepullDocker) PullImage(ctx context.Context, image string) (string, error) {
+	d.pulled = append(d.pulled, image)
+	return "", d.err
+}
+
+type reservationFixture struct {
+	svc          *ReservationService
+	reservations *memReservationRepo
+	containers   *memContainerRepo
+	provisioner  *fakeProvisioner
+	docker       *prepullDocker
+}
+
+func newReservationFixture(cfg *config.Config) *reservationFixture {
+	f := &reservationFixture{
+		reservations: &memReservationRepo{byID: map[string]*domain.Reservation{}},
+		containers:   newMemContainerRepo(),
+		docker:       &prepullDocker{},
+	}
+	f.provisioner = &fakeProvisioner{containers: f.containers}
+	cfg.ReservationMaxDays = 30
+	cfg.ReservationMaxLeases = 50
+	cfg.ReservationLeadMinutes = 5
+	f.svc = NewReservationService(f.reservations, f.containers, f.provisioner, f.docker, slog.Default(), cfg)
+	return f
+}
+
+func workshop(tenantID string, start time.Time, count int) *domain.Reservation {
+	return &domain.Reservation{
+		TenantID: tenantID, StartAt: start, DurationMinutes: 60, Count: count,
+		Spec: domain.LeaseSpec{ImageType: "ubuntu", Image: "docker.io/library/ubuntu:22.04", CPUMilli: 250, MemoryMB: 256},
+	}
+}
+
+func TestReservationRejectsOverbooking(t *testing.T) {
+	f := newReservationFixture(&config.Config{})
+	quotas, _ := newTestQuotaService(f.containers, &memSnapshotRepo{})
+	quotas.WithReservations(f.reservations)
+	f.svc.WithQuotas(quotas)
+	start := time.Now().Add(24 * time.Hour)
+
+	if _, err := f.svc.Book(context.Background(), workshop("t1", start, 2)); err != nil {
+		t.Fatalf("book: %v", err)
+	}
+	// The tenant may hold two containers; the window is already full
+	_, err := f.svc.Book(context.Background(), workshop("t1", start.Add(30*time.Minute), 1))
+	var qe *QuotaExceededError
+	if !errors.As(err, &qe) || qe.Limit != QuotaContainers || qe.Used != 2 {
+		t.Fatalf("expected container quota error, got %v", err)
+	}
+	// The next morning is free again
+	if _, err := f.svc.Book(context.Background(), workshop("t1", start.Add(24*time.Hour), 2)); err != nil {
+		t.Fatalf("non-overlapping booking: %v", err)
+	}
+
+	// Leases started now are refused if they could still be running when the booking starts
+	quotas.config.ContainerMaxDuration = 120
+	f.reservations.byID["soon"] = workshop("t1", time.Now().Add(time.Hour), 2)
+	f.reservations.byID["soon"].Status = domain.ReservationPending
+	if err := quotas.CheckProvision("t1", 250, 256, 0); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected quota error from the upcoming reservation, got %v", err)
+	}
+}
+
+func TestReservationRejectsOverCapacity(t *testing.T) {
+	f := newReservationFixture(&config.Config{HostCPUMilli: 1000})
+	start := time.Now().Add(time.Hour)
+	// Still running when the reservation starts
+	_ = f.containers.Save(&domain.Container{ID: "busy", TenantID: "t2", Status: "running", CPUMilli: 500, ExpiryAt: start.Add(time.Minute)})
+
+	if _, err := f.svc.Book(context.Background(), workshop("t1", start, 2)); err != nil {
+		t.Fatalf("book: %v", err)
+	}
+	_, err := f.svc.Book(context.Background(), workshop("t3", start, 1))
+	var ce *CapacityExceededError
+	if !errors.As(err, &ce) || ce.Resource != CapacityCPUMilli || ce.Booked != 1000 || ce.Requested != 250 {
+		t.Fatalf("expected CPU capacity error, got %v", err)
+	}
+	if _, err := f.svc.Book(context.Background(), workshop("t1", start, 0)); !errors.Is(err, ErrInvalidReservation) {
+		t.Fatalf("expected invalid count error, got %v", err)
+	}
+}
+
+func TestReservationLifecycle(t *testing.T) {
+	f := newReservationFixture(&config.Config{})
+	start := time.Now().Add(time.Hour)
+	res, err := f.svc.Book(context.Background(), workshop("t1", start, 3))
+	if err != nil {
+		t.Fatalf("book: %v", err)
+	}
+
+	f.svc.Process(context.Background(), start.Add(-10*time.Minute))
+	if got, _ := f.reservations.GetByID(res.ID); got.Status != domain.ReservationPending || len(f.provisioner.opts) != 0 {
+		t.Fatalf("nothing must happen before the lead time, got %s", got.Status)
+	}
+
+	f.svc.Process(context.Background(), start.Add(-4*time.Minute))
+	got, _ := f.reservations.GetByID(res.ID)
+	if got.Status != domain.ReservationActive || len(got.ContainerIDs) != 3 {
+		t.Fatalf("expected 3 leases and active status, got %s %v", got.Status, got.ContainerIDs)
+	}
+	if len(f.docker.pulled) != 1 || f.docker.pulled[0] != "docker.io/library/ubuntu:22.04" {
+		t.Fatalf("expected a single pre-pull, got %v", f.docker.pulled)
+	}
+	if opts := f.provisioner.opts[0]; !opts.ExpiryAt.Equal(res.EndAt()) || opts.TenantID != "t1" || opts.CPUMilli != 250 {
+		t.Fatalf("lease must end with the reserved window, got %+v", opts)
+	}
+
+	// Completed once every lease has ended
+	for _, id := range got.ContainerIDs {
+		c, _ := f.containers.GetByID(id)
+		c.Status = "terminated"
+		_ = f.containers.Save(c)
+	}
+	f.svc.Process(context.Background(), start.Add(30*time.Minute))
+	if got, _ = f.reservations.GetByID(res.ID); got.Status != domain.ReservationCompleted {
+		t.Fatalf("expected completed, got %s", got.Status)
+	}
+}
+
+func TestReservationCancelAndFailure(t *testing.T) {
+	f := newReservationFixture(&config.Config{})
+	start := time.Now().Add(time.Hour)
+	cancelled, _ := f.svc.Book(context.Background(), workshop("t1", start, 1))
+	failing, _ := f.svc.Book(context.Background(), workshop("t1", start, 1))
+
+	if _, err := f.svc.Cancel(context.Background(), cancelled.ID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	// Provisioning errors are retried until the start time, then the reservation fails
+	f.provisioner.err = errors.New("docker unavailable")
+	f.svc.Process(context.Background(), start.Add(-4*time.Minute))
+	if got, _ := f.reservations.GetByID(failing.ID); got.Status != domain.ReservationPending || got.Error == "" {
+		t.Fatalf("expected pending with an error to retry, got %s %q", got.Status, got.Error)
+	}
+	f.svc.Process(context.Background(), start.Add(time.Minute))
+	if got, _ := f.reservations.GetByID(failing.ID); got.Status != domain.ReservationFailed {
+		t.Fatalf("expected failed after the start time, got %s", got.Status)
+	}
+	if got, _ := f.reservations.GetByID(cancelled.ID); got.Status != domain.ReservationCancelled {
+		t.Fatalf("cancelled reservation must not be provisioned, got %s", got.Status)
+	}
+	if _, err := f.svc.Cancel(context.Background(), failing.ID); !errors.Is(err, ErrReservationStarted) {
+		t.Fatalf("expected not pending error, got %v", err)
+	}
+}
+
+func TestReservationPrePullRetried(t *testing.T) {
+	f := newReservationFixture(&config.Config{})
+	start := time.Now().Add(time.Hour)
+	res, _ := f.svc.Book(context.Background(), workshop("t1", start, 1))
+
+	// A failed pre-pull hands the reservation back as pending
+	f.docker.err = errors.New("registry unavailable")
+	f.svc.Process(context.Background(), start.Add(-4*time.Minute))
+	got, _ := f.reservations.GetByID(res.ID)
+	if got.Status != domain.ReservationPending || got.Error == "" || got.ClaimedBy != "" {
+		t.Fatalf("expected pending with an error and no claim, got %s %q claimed by %q", got.Status, got.Error, got.ClaimedBy)
+	}
+
+	// The next run pulls the image again
+	f.svc.Process(context.Background(), start.Add(-3*time.Minute))
+	if len(f.docker.pulled) != 2 || len(f.provisioner.opts) != 0 {
+		t.Fatalf("expected the pre-pull retried without provisioning, got %d pulls and %d leases", len(f.docker.pulled), len(f.provisioner.opts))
+	}
+
+	// And it can still be cancelled
+	if _, err := f.svc.Cancel(context.Background(), res.ID); err != nil {
+		t.Fatalf("cancel after a failed pre-pull: %v", err)
+	}
+}
+
+func TestReservationIsClaimedOnce(t *testing.T) {
+	f := newReservationFixture(&config.Config{})
+	start := time.Now().Add(time.Hour)
+	res, _ := f.svc.Book(context.Background(), workshop("t1", start, 2))
+
+	// Another server listed the reservation as pending too, but claims it second
+	stale, _ := f.reservations.GetByID(res.ID)
+	f.svc.Process(context.Background(), start.Add(-time.Minute))
+	f.svc.provision(context.Background(), stale, start.Add(-time.Minute))
+	if len(f.provisioner.opts) != 2 {
+		t.Fatalf("expected the reservation's two leases provisioned once, got %d", len(f.provisioner.opts))
+	}
+}
+
+func TestReservationResumedByOneRunAtATime(t *testing.T) {
+	f := newReservationFixture(&config.Config{JobConsumer: "server-a"})
+	start := time.Now().Add(time.Hour)
+	res, _ := f.svc.Book(context.Background(), workshop("t1", start, 3))
+
+	// Left provisioning with one lease by a server that has since stopped
+	stored := f.reservations.byID[res.ID]
+	stored.Status, stored.ContainerIDs = domain.ReservationProvisioning, []string{"c0"}
+	stored.ClaimedBy, stored.ClaimExpiresAt = "server-b", time.Now().Add(time.Minute)
+
+	f.svc.Process(context.Background(), start.Add(-time.Minute))
+	if len(f.provisioner.opts) != 0 {
+		t.Fatalf("a reservation claimed by another server must be left to it, got %d leases", len(f.provisioner.opts))
+	}
+
+	// Once the claim expires it is resumed; a run overlapping the slow provisioning skips it
+	stored.ClaimExpiresAt = time.Now().Add(-time.Second)
+	f.provisioner.during = func() {
+		f.svc.Process(context.Background(), start.Add(-time.Minute))
+	}
+	f.svc.Process(context.Background(), start.Add(-time.Minute))
+	got, _ := f.reservations.GetByID(res.ID)
+	if len(f.provisioner.opts) != 2 || got.Status != domain.ReservationActive {
+		t.Fatalf("expected the two remaining leases provisioned once, got %d (%s)", len(f.provisioner.opts), got.Status)
+	}
+	if len(got.ContainerIDs) != 3 || got.ContainerIDs[0] != "c0" || got.ClaimedBy != "" {
+		t.Fatalf("expected the earlier lease kept and the claim released, got %v claimed by %q", got.ContainerIDs, got.ClaimedBy)
+	}
+}
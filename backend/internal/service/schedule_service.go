@@ -0,0 +1,371 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+	"github.com/aryan0dhankhar/containerlease/pkg/cron"
+)
+
+// Schedule errors, mapped to HTTP status codes by the handler layer
+var (
+	ErrInvalidSchedule = errors.New("invalid schedule")
+	ErrSchedulePaused  = errors.New("schedule is paused")
+)
+
+// scheduleSnapshotRuns is how many recent runs are searched for a snapshot to restore
+const scheduleSnapshotRuns = 100
+
+// scheduleUpdateAttempts is how often a tenant's change is re-applied when occurrences
+// are claimed while it is saved
+const scheduleUpdateAttempts = 3
+
+// ScheduleService stores recurring lease schedules and creates a lease at each occurrence
+type ScheduleService struct {
+	schedules   domain.ScheduleRepository
+	provisioner LeaseProvisioner
+	snapshots   domain.SnapshotRepository
+	logger      *slog.Logger
+	config      *config.Config
+
+	mu sync.Mutex // Serialises tenant changes with the scheduler's updates
+}
+
+// NewScheduleService creates a new schedule service
+func NewScheduleService(
+	schedules domain.ScheduleRepository,
+	provisioner LeaseProvisioner,
+	logger *slog.Logger,
+	cfg *config.Config,
+) *ScheduleService {
+	return &ScheduleService{
+		schedules:   schedules,
+		provisioner: provisioner,
+		logger:      logger,
+		config:      cfg,
+	}
+}
+
+// WithSnapshots lets schedules restore the latest snapshot of an earlier run
+func (s *ScheduleService) WithSnapshots(snapshots domain.SnapshotRepository) *ScheduleService {
+	s.snapshots = snapshots
+	return s
+}
+
+// Create validates and stores a schedule. The spec must already have passed provisioning validation.
+func (s *ScheduleService) Create(ctx context.Context, sch *domain.Schedule) (*domain.Schedule, error) {
+	sch.Name = strings.TrimSpace(sch.Name)
+	if sch.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidSchedule)
+	}
+	if sch.Timezone == "" {
+		sch.Timezone = "UTC"
+	}
+	if sch.RestoreSnapshot && s.snapshots == nil {
+		return nil, fmt.Errorf("%w: snapshots are not available on this server", ErrInvalidSchedule)
+	}
+
+	now := time.Now()
+	next, err := nextOccurrence(sch, now)
+	if err != nil {
+		return nil, err
+	}
+	sch.ID = fmt.Sprintf("schedule-%d", rand.Int63())
+	sch.Paused = false
+	sch.SkipAt = time.Time{}
+	sch.NextRunAt = next
+	sch.CreatedAt = now
+	if err := s.schedules.Save(sch); err != nil {
+		return nil, fmt.Errorf("failed to save schedule: %w", err)
+	}
+
+	s.logger.Info("schedule created",
+		slog.String("schedule_id", sch.ID),
+		slog.String("tenant_id", sch.TenantID),
+		slog.String("cron", sch.Cron),
+		slog.Time("next_run_at", sch.NextRunAt),
+	)
+	return sch, nil
+}
+
+// GetSchedule retrieves a schedule
+func (s *ScheduleService) GetSchedule(ctx context.Context, id string) (*domain.Schedule, error) {
+	return s.schedules.GetByID(id)
+}
+
+// ListSchedules returns a tenant's schedules
+func (s *ScheduleService) ListSchedules(ctx context.Context, tenantID string) ([]*domain.Schedule, error) {
+	return s.schedules.ListByTenant(tenantID)
+}
+
+// ListRuns returns a schedule's most recent runs, latest first
+func (s *ScheduleService) ListRuns(ctx context.Context, id string, limit int) ([]*domain.ScheduleRun, error) {
+	return s.schedules.ListRuns(id, limit)
+}
+
+// Delete removes a schedule and its history; leases it already created are kept
+func (s *ScheduleService) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.schedules.Delete(id); err != nil {
+		return err
+	}
+	s.logger.Info("schedule deleted", slog.String("schedule_id", id))
+	return nil
+}
+
+// Pause stops a schedule from creating leases until it is resumed
+func (s *ScheduleService) Pause(ctx context.Context, id string) (*domain.Schedule, error) {
+	return s.update(id, func(sch *domain.Schedule) error {
+		sch.Paused = true
+		return nil
+	})
+}
+
+// Resume restarts a paused schedule from its next occurrence; occurrences while paused are not run
+func (s *ScheduleService) Resume(ctx context.Context, id string) (*domain.Schedule, error) {
+	return s.update(id, func(sch *domain.Schedule) error {
+		if !sch.Paused {
+			return nil
+		}
+		next, err := nextOccurrence(sch, time.Now())
+		if err != nil {
+			return err
+		}
+		sch.Paused = false
+		sch.SkipAt = time.Time{}
+		sch.NextRunAt = next
+		return nil
+	})
+}
+
+// Skip skips the schedule's next occurrence; the one after runs as usual
+func (s *ScheduleService) Skip(ctx context.Context, id string) (*domain.Schedule, error) {
+	return s.update(id, func(sch *domain.Schedule) error {
+		if sch.Paused {
+			return ErrSchedulePaused
+		}
+		sch.SkipAt = sch.NextRunAt
+		return nil
+	})
+}
+
+// update applies change to the stored schedule. The change is saved only if no server
+// claimed an occurrence since the schedule was read; otherwise it is applied again.
+func (s *ScheduleService) update(id string, change func(*domain.Schedule) error) (*domain.Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for attempt := 0; attempt < scheduleUpdateAttempts; attempt++ {
+		sch, err := s.schedules.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		read := sch.NextRunAt
+		if err := change(sch); err != nil {
+			return nil, err
+		}
+		saved, err := s.schedules.Update(sch, read)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save schedule: %w", err)
+		}
+		if saved {
+			return sch, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to save schedule: its occurrences kept moving on")
+}
+
+// Process runs every schedule whose next occurrence has come. If the server was down for
+// several occurrences only the latest is considered, and it is recorded as missed once its
+// window is over. With several servers each occurrence is run by the one that claims it.
+func (s *ScheduleService) Process(ctx context.Context, now time.Time) {
+	// Only the claims are serialised with tenant changes; leases are provisioned after
+	var runs []*claimedRun
+	s.mu.Lock()
+	due, err := s.schedules.ListDue(now)
+	if err != nil {
+		s.mu.Unlock()
+		s.logger.Error("failed to list due schedules", slog.String("error", err.Error()))
+		return
+	}
+	for _, sch := range due {
+		if run := s.claim(sch, now); run != nil {
+			runs = append(runs, run)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, run := range runs {
+		s.run(ctx, run, now)
+	}
+}
+
+// claimedRun is an occurrence this server has claimed and still has to handle
+type claimedRun struct {
+	schedule *domain.Schedule
+	due      time.Time
+	skipped  bool
+}
+
+// claim claims the schedule's current occurrence by moving it to the next one.
+// It returns nil if the occurrence was claimed by another server.
+func (s *ScheduleService) claim(sch *domain.Schedule, now time.Time) *claimedRun {
+	logger := s.logger.With(slog.String("schedule_id", sch.ID), slog.String("tenant_id", sch.TenantID))
+
+	due := sch.NextRunAt
+	skipped := sch.SkipAt.Equal(due)
+	if skipped {
+		sch.SkipAt = time.Time{}
+	}
+	next, err := nextOccurrence(sch, now)
+	if err != nil {
+		// Only possible if the stored expression or timezone became invalid
+		logger.Error("schedule has no next occurrence, pausing it", slog.String("error", err.Error()))
+		sch.Paused = true
+	} else {
+		sch.NextRunAt = next
+	}
+	claimed, err := s.schedules.ClaimOccurrence(sch, due)
+	if err != nil {
+		logger.Error("failed to save schedule", slog.String("error", err.Error()))
+		return nil
+	}
+	if !claimed {
+		logger.Debug("schedule occurrence claimed by another server", slog.Time("scheduled_at", due))
+		return nil
+	}
+	return &claimedRun{schedule: sch, due: due, skipped: skipped}
+}
+
+// run handles a claimed occurrence and records the outcome
+func (s *ScheduleService) run(ctx context.Context, claimed *claimedRun, now time.Time) {
+	sch, due := claimed.schedule, claimed.due
+	run := &domain.ScheduleRun{
+		ID:          fmt.Sprintf("run-%d", rand.Int63()),
+		ScheduleID:  sch.ID,
+		ScheduledAt: due,
+		CreatedAt:   now,
+	}
+	end := due.Add(time.Duration(sch.DurationMinutes) * time.Minute)
+	switch {
+	case claimed.skipped:
+		run.Status = domain.ScheduleRunSkipped
+	case !now.Before(end):
+		run.Status = domain.ScheduleRunMissed
+	default:
+		s.provision(ctx, sch, run, end)
+	}
+	if err := s.schedules.AddRun(run); err != nil {
+		s.logger.Error("failed to record schedule run", slog.String("schedule_id", sch.ID), slog.String("error", err.Error()))
+	}
+}
+
+// provision creates the occurrence's lease, ending with the occurrence's window
+func (s *ScheduleService) provision(ctx context.Context, sch *domain.Schedule, run *domain.ScheduleRun, end time.Time) {
+	opts := ProvisionOptions{
+		TenantID:        sch.TenantID,
+		ImageType:       sch.Spec.ImageType,
+		Image:           sch.Spec.Image,
+		DurationMinutes: sch.DurationMinutes,
+		CPUMilli:        sch.Spec.CPUMilli,
+		MemoryMB:        sch.Spec.MemoryMB,
+		LogDemo:         sch.Spec.LogDemo,
+		VolumeSizeMB:    sch.Spec.VolumeSizeMB,
+		Preset:          sch.Spec.Preset,
+		Ports:           sch.Spec.Ports,
+		Entrypoint:      sch.Spec.Entrypoint,
+		Command:         sch.Spec.Command,
+		Env:             sch.Spec.Env,
+		WorkingDir:      sch.Spec.WorkingDir,
+		InitScript:      sch.Spec.InitScript,
+		NodeLabels:      sch.Spec.NodeLabels,
+		ExpiryAt:        end,
+	}
+	if sch.RestoreSnapshot {
+		// The first run, or one whose predecessors were never snapshotted, starts fresh
+		if snapshot := s.latestSnapshot(sch); snapshot != nil {
+			opts.Image = snapshot.ImageName
+			opts.LocalImage = true
+			opts.NodeID = snapshot.NodeID // The image only exists on the node it was committed on
+			run.SnapshotID = snapshot.ID
+		}
+	}
+
+	container, err := s.provisioner.ProvisionContainer(ctx, opts)
+	if err != nil {
+		run.Status = domain.ScheduleRunFailed
+		run.Error = err.Error()
+		s.logger.Error("scheduled lease failed",
+			slog.String("schedule_id", sch.ID),
+			slog.Time("scheduled_at", run.ScheduledAt),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+	run.Status = domain.ScheduleRunProvisioned
+	run.ContainerID = container.ID
+	s.logger.Info("scheduled lease created",
+		slog.String("schedule_id", sch.ID),
+		slog.String("container_id", container.ID),
+		slog.String("snapshot_id", run.SnapshotID),
+		slog.Time("expiry_at", end),
+	)
+}
+
+// latestSnapshot returns the newest committed snapshot taken of a lease the schedule created
+func (s *ScheduleService) latestSnapshot(sch *domain.Schedule) *domain.Snapshot {
+	if s.snapshots == nil {
+		return nil
+	}
+	runs, err := s.schedules.ListRuns(sch.ID, scheduleSnapshotRuns)
+	if err != nil {
+		s.logger.Warn("failed to list schedule runs", slog.String("schedule_id", sch.ID), slog.String("error", err.Error()))
+		return nil
+	}
+	leases := make(map[string]bool, len(runs))
+	for _, run := range runs {
+		if run.ContainerID != "" {
+			leases[run.ContainerID] = true
+		}
+	}
+	snapshots, err := s.snapshots.GetByTenant(sch.TenantID)
+	if err != nil {
+		s.logger.Warn("failed to list snapshots", slog.String("schedule_id", sch.ID), slog.String("error", err.Error()))
+		return nil
+	}
+
+	var latest *domain.Snapshot
+	for _, snapshot := range snapshots {
+		if leases[snapshot.ContainerID] && snapshot.Ready() && (latest == nil || snapshot.CreatedAt.After(latest.CreatedAt)) {
+			latest = snapshot
+		}
+	}
+	return latest
+}
+
+// nextOccurrence returns the schedule's first occurrence after t in its timezone
+func nextOccurrence(sch *domain.Schedule, t time.Time) (time.Time, error) {
+	expr, err := cron.Parse(sch.Cron)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	loc, err := time.LoadLocation(sch.Timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: unknown timezone %q", ErrInvalidSchedule, sch.Timezone)
+	}
+	next := expr.Next(t.In(loc))
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("%w: cron expression %q never occurs", ErrInvalidSchedule, sch.Cron)
+	}
+	return next, nil
+}
@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+type memScheduleRepo struct {
+	byID map[string]*domain.Schedule
+	runs []*domain.ScheduleRun
+	// beforeUpdate runs ahead of each conditional update, to simulate another server
+	beforeUpdate func()
+}
+
+func (m *memScheduleRepo) Save(s *domain.Schedule) error {
+	cp := *s
+	m.byID[s.ID] = &cp
+	return nil
+}
+func (m *memScheduleRepo) GetByID(id string) (*domain.Schedule, error) {
+	if s, ok := m.byID[id]; ok {
+		cp := *s
+		return &cp, nil
+	}
+	return nil, errors.New("not found")
+}
+func (m *memScheduleRepo) ListByTenant(tenantID string) ([]*domain.Schedule, error) {
+	out := []*domain.Schedule{}
+	for _, s := range m.byID {
+		if s.TenantID == tenantID {
+			cp := *s
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+func (m *memScheduleRepo) ListDue(now time.Time) ([]*domain.Schedule, error) {
+	out := []*domain.Schedule{}
+	for _, s := range m.byID {
+		if !s.Paused && !s.NextRunAt.After(now) {
+			cp := *s
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+func (m *memScheduleRepo) ClaimOccurrence(s *domain.Schedule, due time.Time) (bool, error) {
+	stored, ok := m.byID[s.ID]
+	if !ok || stored.Paused || !stored.NextRunAt.Equal(due) {
+		return false, nil
+	}
+	return true, m.Save(s)
+}
+func (m *memScheduleRepo) Update(s *domain.Schedule, nextRunAt time.Time) (bool, error) {
+	if before := m.beforeUpdate; before != nil {
+		m.beforeUpdate = nil
+		before()
+	}
+	stored, ok := m.byID[s.ID]
+	if !ok || !stored.NextRunAt.Equal(nextRunAt) {
+		return false, nil
+	}
+	return true, m.Save(s)
+}
+func (m *memScheduleRepo) Delete(id string) error {
+	delete(m.byID, id)
+	return nil
+}
+func (m *memScheduleRepo) AddRun(run *domain.ScheduleRun) error {
+	m.runs = append(m.runs, run)
+	return nil
+}
+func (m *memScheduleRepo) ListRuns(scheduleID string, limit int) ([]*domain.ScheduleRun, error) {
+	out := []*domain.ScheduleRun{}
+	for _, run := range m.runs {
+		if run.ScheduleID == scheduleID {
+			out = append(out, run)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ScheduledAt.After(out[j].ScheduledAt) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+type scheduleFixture struct {
+	svc         *ScheduleService
+	schedules   *memScheduleRepo
+	provisioner *fakeProvisioner
+	snapshots   *memSnapshotRepo
+}
+
+func newScheduleFixture() *scheduleFixture {
+	f := &scheduleFixture{
+		schedules: &memScheduleRepo{byID: map[string]*domain.Schedule{}},
+		snapshots: &memSnapshotRepo{},
+	}
+	f.provisioner = &fakeProvisioner{containers: newMemContainerRepo()}
+	f.svc = NewScheduleService(f.schedules, f.provisioner, slog.Default(), &config.Config{}).WithSnapshots(f.snapshots)
+	return f
+}
+
+// nightly creates a schedule for 01:00-03:00 every weekday
+func (f *scheduleFixture) nightly(t *testing.T, restore bool) *domain.Schedule {
+	t.Helper()
+	sch, err := f.svc.Create(context.Background(), &domain.Schedule{
+		TenantID: "t1", Name: "nightly integration", Cron: "0 1 * * 1-5", DurationMinutes: 120,
+		RestoreSnapshot: restore,
+		Spec:            domain.LeaseSpec{ImageType: "ubuntu", Image: "docker.io/library/ubuntu:22.04", CPUMilli: 250, MemoryMB: 256},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	return sch
+}
+
+// runAt makes the schedule due at the given occurrence and processes it at now
+func (f *scheduleFixture) runAt(id string, occurrence, now time.Time) {
+	f.schedules.byID[id].NextRunAt = occurrence
+	f.svc.Process(context.Background(), now)
+}
+
+func TestScheduleCreatesLeasePerOccurrence(t *testing.T) {
+	f := newScheduleFixture()
+	sch := f.nightly(t, false)
+	if sch.Timezone != "UTC" || sch.NextRunAt.Hour() != 1 || sch.NextRunAt.Weekday() == time.Saturday || sch.NextRunAt.Weekday() == time.Sunday {
+		t.Fatalf("expected the next weekday at 01:00 UTC, got %v", sch.NextRunAt)
+	}
+
+	// Friday 2026-01-23 01:00, processed a little late
+	occurrence := time.Date(2026, 1, 23, 1, 0, 0, 0, time.UTC)
+	f.runAt(sch.ID, occurrence, occurrence.Add(20*time.Second))
+
+	if len(f.provisioner.opts) != 1 {
+		t.Fatalf("expected one lease, got %d", len(f.provisioner.opts))
+	}
+	opts := f.provisioner.opts[0]
+	if !opts.ExpiryAt.Equal(occurrence.Add(2*time.Hour)) || opts.TenantID != "t1" || opts.LocalImage {
+		t.Fatalf("lease must run from the fresh image until 03:00, got %+v", opts)
+	}
+	runs, _ := f.svc.ListRuns(context.Background(), sch.ID, 10)
+	if len(runs) != 1 || runs[0].Status != domain.ScheduleRunProvisioned || runs[0].ContainerID == "" {
+		t.Fatalf("expected a provisioned run, got %+v", runs)
+	}
+	got, _ := f.schedules.GetByID(sch.ID)
+	if want := time.Date(2026, 1, 26, 1, 0, 0, 0, time.UTC); !got.NextRunAt.Equal(want) {
+		t.Fatalf("expected Monday as the next run, got %v", got.NextRunAt)
+	}
+
+	// Nothing is due until then
+	f.svc.Process(context.Background(), occurrence.Add(time.Hour))
+	if len(f.provisioner.opts) != 1 {
+		t.Fatalf("expected no further leases, got %d", len(f.provisioner.opts))
+	}
+}
+
+func TestScheduleOccurrenceRunsOnce(t *testing.T) {
+	f := newScheduleFixture()
+	sch := f.nightly(t, false)
+	occurrence := time.Date(2026, 1, 23, 1, 0, 0, 0, time.UTC)
+	f.schedules.byID[sch.ID].NextRunAt = occurrence
+
+	// Another server listed the schedule as due too, but claims it second
+	stale, _ := f.schedules.GetByID(sch.ID)
+	f.svc.Process(context.Background(), occurrence)
+	if run := f.svc.claim(stale, occurrence); run != nil {
+		f.svc.run(context.Background(), run, occurrence)
+	}
+	if len(f.provisioner.opts) != 1 || len(f.schedules.runs) != 1 {
+		t.Fatalf("expected the occurrence run once, got %d leases and %d runs", len(f.provisioner.opts), len(f.schedules.runs))
+	}
+}
+
+func TestScheduleChangeKeepsClaimedOccurrence(t *testing.T) {
+	f := newScheduleFixture()
+	sch := f.nightly(t, false)
+	occurrence := time.Date(2026, 1, 23, 1, 0, 0, 0, time.UTC)
+	f.schedules.byID[sch.ID].NextRunAt = occurrence
+
+	// Another server claims the occurrence after the skip read the schedule
+	other := NewScheduleService(f.schedules, f.provisioner, slog.Default(), &config.Config{})
+	f.schedules.beforeUpdate = func() {
+		other.Process(context.Background(), occurrence)
+	}
+	skipped, err := f.svc.Skip(context.Background(), sch.ID)
+	if err != nil {
+		t.Fatalf("skip: %v", err)
+	}
+	got, _ := f.schedules.GetByID(sch.ID)
+	if !got.NextRunAt.After(occurrence) || !got.SkipAt.Equal(got.NextRunAt) || !skipped.SkipAt.Equal(got.NextRunAt) {
+		t.Fatalf("expected the claimed occurrence kept and the following one skipped, got %+v", got)
+	}
+
+	// The claimed occurrence is not run a second time
+	f.svc.Process(context.Background(), occurrence)
+	if len(f.provisioner.opts) != 1 {
+		t.Fatalf("expected the occurrence run once, got %d leases", len(f.provisioner.opts))
+	}
+}
+
+func TestSchedulePauseNotBlockedByProvisioning(t *testing.T) {
+	f := newScheduleFixture()
+	sch := f.nightly(t, false)
+	occurrence := time.Date(2026, 1, 23, 1, 0, 0, 0, time.UTC)
+
+	// A tenant pauses the schedule while its lease is being provisioned
+	f.provisioner.during = func() {
+		done := make(chan error, 1)
+		go func() {
+			_, err := f.svc.Pause(context.Background(), sch.ID)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("pause: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Error("pause waited for provisioning to finish")
+			<-done
+		}
+	}
+	f.runAt(sch.ID, occurrence, occurrence)
+	if got, _ := f.schedules.GetByID(sch.ID); !got.Paused || len(f.provisioner.opts) != 1 {
+		t.Fatalf("expected the lease provisioned and the schedule paused, got %+v", got)
+	}
+}
+
+func TestScheduleSkipPauseAndMissed(t *testing.T) {
+	f := newScheduleFixture()
+	sch := f.nightly(t, false)
+
+	skipped, err := f.svc.Skip(context.Background(), sch.ID)
+	if err != nil || !skipped.SkipAt.Equal(skipped.NextRunAt) {
+		t.Fatalf("skip: %v", err)
+	}
+	f.svc.Process(context.Background(), skipped.NextRunAt)
+	if len(f.provisioner.opts) != 0 || len(f.schedules.runs) != 1 || f.schedules.runs[0].Status != domain.ScheduleRunSkipped {
+		t.Fatalf("expected a skipped run and no lease, got %+v", f.schedules.runs)
+	}
+	if got, _ := f.schedules.GetByID(sch.ID); !got.SkipAt.IsZero() || !got.NextRunAt.After(skipped.NextRunAt) {
+		t.Fatalf("only the one occurrence must be skipped, got %+v", got)
+	}
+
+	// Paused schedules are not due, and cannot skip
+	if _, err := f.svc.Pause(context.Background(), sch.ID); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	f.svc.Process(context.Background(), time.Now().AddDate(0, 0, 7))
+	if len(f.schedules.runs) != 1 {
+		t.Fatalf("paused schedule must not run, got %+v", f.schedules.runs)
+	}
+	if _, err := f.svc.Skip(context.Background(), sch.ID); !errors.Is(err, ErrSchedulePaused) {
+		t.Fatalf("expected paused error, got %v", err)
+	}
+	resumed, err := f.svc.Resume(context.Background(), sch.ID)
+	if err != nil || resumed.Paused || !resumed.NextRunAt.After(time.Now()) {
+		t.Fatalf("resume must continue from the next future occurrence, got %+v %v", resumed, err)
+	}
+
+	// An occurrence whose window passed while the server was down is recorded as missed
+	occurrence := time.Date(2026, 1, 23, 1, 0, 0, 0, time.UTC)
+	f.runAt(sch.ID, occurrence, occurrence.Add(3*time.Hour))
+	if last := f.schedules.runs[len(f.schedules.runs)-1]; last.Status != domain.ScheduleRunMissed || len(f.provisioner.opts) != 0 {
+		t.Fatalf("expected a missed run, got %+v", last)
+	}
+
+	// Provisioning errors such as quota are recorded on the run
+	f.provisioner.err = ErrQuotaExceeded
+	f.runAt(sch.ID, occurrence.AddDate(0, 0, 3), occurrence.AddDate(0, 0, 3))
+	if last := f.schedules.runs[len(f.schedules.runs)-1]; last.Status != domain.ScheduleRunFailed || last.Error == "" {
+		t.Fatalf("expected a failed run, got %+v", last)
+	}
+}
+
+func TestScheduleRestoresLatestSnapshot(t *testing.T) {
+	f := newScheduleFixture()
+	sch := f.nightly(t, true)
+	monday := time.Date(2026, 1, 26, 1, 0, 0, 0, time.UTC)
+
+	// No snapshot yet: the first run starts from the fresh image
+	f.runAt(sch.ID, monday, monday)
+	first := f.schedules.runs[0]
+	if opts := f.provisioner.opts[0]; opts.LocalImage || opts.Image != "docker.io/library/ubuntu:22.04" || first.SnapshotID != "" {
+		t.Fatalf("expected a fresh image, got %+v", opts)
+	}
+
+	// Snapshots of the schedule's own lease are restored, newest first; others are ignored
+	_ = f.snapshots.Create(&domain.Snapshot{ID: "old", ContainerID: first.ContainerID, TenantID: "t1", ImageName: "snapshot-old", CreatedAt: monday.Add(time.Hour)})
+	_ = f.snapshots.Create(&domain.Snapshot{ID: "new", ContainerID: first.ContainerID, TenantID: "t1", ImageName: "snapshot-new", CreatedAt: monday.Add(2 * time.Hour)})
+	_ = f.snapshots.Create(&domain.Snapshot{ID: "other", ContainerID: "unrelated", TenantID: "t1", ImageName: "snapshot-other", CreatedAt: monday.Add(3 * time.Hour)})
+
+	tuesday := monday.AddDate(0, 0, 1)
+	f.runAt(sch.ID, tuesday, tuesday)
+	if opts := f.provisioner.opts[1]; !opts.LocalImage || opts.Image != "snapshot-new" || f.schedules.runs[1].SnapshotID != "new" {
+		t.Fatalf("expected the latest snapshot to be restored, got %+v", opts)
+	}
+
+	// Without a snapshot store the option is refused up front
+	plain := NewScheduleService(f.schedules, f.provisioner, slog.Default(), &config.Config{})
+	_, err := plain.Create(context.Background(), &domain.Schedule{TenantID: "t1", Name: "x", Cron: "@daily", DurationMinutes: 60, RestoreSnapshot: true})
+	if !errors.Is(err, ErrInvalidSchedule) {
+		t.Fatalf("expected invalid schedule error, got %v", err)
+	}
+}
+
+func TestScheduleValidation(t *testing.T) {
+	f := newScheduleFixture()
+	for _, sch := range []*domain.Schedule{
+		{Name: "", Cron: "@daily"},
+		{Name: "bad cron", Cron: "0 25 * * *"},
+		{Name: "never", Cron: "0 0 30 2 *"},
+		{Name: "bad zone", Cron: "@daily", Timezone: "Mars/Olympus"},
+	} {
+		sch.TenantID, sch.DurationMinutes = "t1", 60
+		if _, err := f.svc.Create(context.Background(), sch); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("%q: expected invalid schedule error, got %v", sch.Name, err)
+		}
+	}
+
+	// Occurrences follow the schedule's timezone
+	sch, err := f.svc.Create(context.Background(), &domain.Schedule{TenantID: "t1", Name: "berlin", Cron: "0 1 * * *", Timezone: "Europe/Berlin", DurationMinutes: 60})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if local := sch.NextRunAt.In(time.FixedZone("", 0)); local.Hour() != 0 && local.Hour() != 23 {
+		t.Fatalf("01:00 in Berlin is 23:00 or 00:00 UTC, got %v", sch.NextRunAt)
+	}
+}
@@ -17,6 +17,14 @@ type SnapshotService struct {
 	snapshotRepository  domain.SnapshotRepository
 	logger              *slog.Logger
 	config              *config.Config
+	quotas              *QuotaService
+	jobs                domain.JobQueue
+	leases              LeaseTerminator
+}
+
+// LeaseTerminator ends leases; satisfied by ContainerService
+type LeaseTerminator interface {
+	DeleteContainer(ctx context.Context, containerID string) error
 }
 
 // NewSnapshotService creates a new snapshot service
@@ -36,9 +44,41 @@ func NewSnapshotService(
 	}
 }
 
+// WithQuotas enables per-tenant snapshot quota enforcement
+func (s *SnapshotService) WithQuotas(quotas *QuotaService) *SnapshotService {
+	s.quotas = quotas
+	return s
+}
+
+// WithJobs commits snapshots in jobs on the durable job queue instead of during the request
+func (s *SnapshotService) WithJobs(jobs domain.JobQueue) *SnapshotService {
+	s.jobs = jobs
+	return s
+}
+
+// WithLeases enables SnapshotAndTerminate
+func (s *SnapshotService) WithLeases(leases LeaseTerminator) *SnapshotService {
+	s.leases = leases
+	return s
+}
+
 // CreateSnapshot saves a running container's state as a snapshot
-// This creates a Docker image from the container
+// This creates a Docker image from the container. With a job queue the snapshot is
+// returned pending and committed by a snapshot job.
 func (s *SnapshotService) CreateSnapshot(ctx context.Context, containerID string, description string) (*domain.Snapshot, error) {
+	return s.create(ctx, containerID, description, false)
+}
+
+// SnapshotAndTerminate snapshots a running lease and terminates it once the snapshot
+// has been committed. If the snapshot fails the lease is left running.
+func (s *SnapshotService) SnapshotAndTerminate(ctx context.Context, containerID string, description string) (*domain.Snapshot, error) {
+	if s.leases == nil {
+		return nil, fmt.Errorf("snapshot service cannot terminate leases")
+	}
+	return s.create(ctx, containerID, description, true)
+}
+
+func (s *SnapshotService) create(ctx context.Context, containerID string, description string, terminate bool) (*domain.Snapshot, error) {
 	// Get container
 	container, err := s.containerRepository.GetByID(containerID)
 	if err != nil {
@@ -61,38 +101,135 @@ func (s *SnapshotService) CreateSnapshot(ctx context.Context, containerID string
 		CreatedAt:   time.Now(),
 		Description: description,
 		TenantID:    container.TenantID,
+		Status:      domain.SnapshotPending,
+		NodeID:      container.NodeID,
+	}
+	if err := s.reserve(ctx, snapshot); err != nil {
+		return nil, err
+	}
+
+	if s.jobs != nil {
+		if err := enqueueJob(ctx, s.jobs, domain.JobSnapshot, snapshot.ID, snapshotJob{SnapshotID: snapshot.ID, Terminate: terminate}); err != nil {
+			_ = s.snapshotRepository.Delete(snapshot.ID)
+			return nil, err
+		}
+		s.logger.Info("snapshot queued", slog.String("container_id", containerID), slog.String("snapshot_id", snapshot.ID))
+		return snapshot, nil
+	}
+
+	if err := s.commit(ctx, snapshot, container.DockerID); err != nil {
+		_ = s.snapshotRepository.Delete(snapshot.ID)
+		return nil, err
+	}
+	if terminate {
+		if err := s.leases.DeleteContainer(ctx, containerID); err != nil {
+			return snapshot, err
+		}
+	}
+	return snapshot, nil
+}
+
+// reserve records a pending snapshot, so it counts against the tenant's snapshot quota
+// while it is committed. The quota lock is only held until then.
+func (s *SnapshotService) reserve(ctx context.Context, snapshot *domain.Snapshot) error {
+	if s.quotas != nil {
+		unlock, err := s.quotas.LockTenant(ctx, snapshot.TenantID)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		if err := s.quotas.CheckSnapshot(snapshot.TenantID); err != nil {
+			return err
+		}
+	}
+	if err := s.snapshotRepository.Create(snapshot); err != nil {
+		return fmt.Errorf("failed to save snapshot: %w", err)
 	}
+	return nil
+}
 
+// commit commits the container to the snapshot's image and records the snapshot
+func (s *SnapshotService) commit(ctx context.Context, snapshot *domain.Snapshot, dockerID string) error {
 	logger := s.logger.With(
-		slog.String("container_id", containerID),
+		slog.String("container_id", snapshot.ContainerID),
 		slog.String("snapshot_id", snapshot.ID),
 		slog.String("image_name", snapshot.ImageName),
 	)
 
 	logger.Info("creating snapshot from running container")
+	ctx = domain.WithNode(ctx, snapshot.NodeID)
 
 	// Commit Docker container to image
-	if err := s.dockerClient.CommitContainer(ctx, container.DockerID, snapshot.ImageName); err != nil {
+	if err := s.dockerClient.CommitContainer(ctx, dockerID, snapshot.ImageName); err != nil {
 		logger.Error("failed to commit container to image",
 			slog.String("error", err.Error()),
 		)
-		return nil, fmt.Errorf("failed to commit container: %w", err)
+		return fmt.Errorf("failed to commit container: %w", err)
 	}
 
 	logger.Debug("container committed to image")
 
-	// Save snapshot metadata
+	// Save snapshot metadata; Create overwrites a pending record
+	snapshot.Status = domain.SnapshotReady
 	if err := s.snapshotRepository.Create(snapshot); err != nil {
 		logger.Error("failed to save snapshot metadata",
 			slog.String("error", err.Error()),
 		)
 		// Clean up the image since we failed to save metadata
 		_ = s.dockerClient.RemoveImage(ctx, snapshot.ImageName)
-		return nil, fmt.Errorf("failed to save snapshot: %w", err)
+		return fmt.Errorf("failed to save snapshot: %w", err)
 	}
 
 	logger.Info("snapshot created successfully", slog.String("snapshot_id", snapshot.ID))
-	return snapshot, nil
+	return nil
+}
+
+// RunSnapshotJob commits a pending snapshot, then terminates its lease if asked to.
+// A snapshot committed by an earlier delivery is not committed again.
+func (s *SnapshotService) RunSnapshotJob(ctx context.Context, job *domain.Job) error {
+	var p snapshotJob
+	if err := decodeJob(job, &p); err != nil {
+		return err
+	}
+	snapshot, err := s.snapshotRepository.GetByID(p.SnapshotID)
+	if err != nil {
+		return fmt.Errorf("snapshot not found: %w", err)
+	}
+	container, err := s.containerRepository.GetByID(snapshot.ContainerID)
+	if err != nil {
+		return fmt.Errorf("container not found: %w", err)
+	}
+
+	if snapshot.Status == domain.SnapshotPending {
+		if container.Status != "running" && container.Status != "paused" {
+			return fmt.Errorf("can only snapshot running containers, current status: %s", container.Status)
+		}
+		if err := s.commit(ctx, snapshot, container.DockerID); err != nil {
+			return err
+		}
+	}
+	if p.Terminate && container.Status != "terminated" {
+		return s.leases.DeleteContainer(ctx, container.ID)
+	}
+	return nil
+}
+
+// FailSnapshotJob marks the snapshot of a snapshot job that ran out of attempts as failed
+func (s *SnapshotService) FailSnapshotJob(ctx context.Context, job *domain.Job) {
+	var p snapshotJob
+	if err := decodeJob(job, &p); err != nil {
+		s.logger.Error("failed to decode snapshot job", slog.String("job_id", job.ID), slog.String("error", err.Error()))
+		return
+	}
+	snapshot, err := s.snapshotRepository.GetByID(p.SnapshotID)
+	if err != nil || snapshot.Status != domain.SnapshotPending {
+		return
+	}
+	snapshot.Status = domain.SnapshotFailed
+	snapshot.Error = job.LastError
+	if err := s.snapshotRepository.Create(snapshot); err != nil {
+		s.logger.Error("failed to save snapshot", slog.String("snapshot_id", snapshot.ID), slog.String("error", err.Error()))
+	}
 }
 
 // RestoreSnapshot creates a new container from a snapshot
@@ -102,6 +239,9 @@ func (s *SnapshotService) RestoreSnapshot(ctx context.Context, snapshotID string
 	if err != nil {
 		return nil, fmt.Errorf("snapshot not found: %w", err)
 	}
+	if !snapshot.Ready() {
+		return nil, fmt.Errorf("snapshot is %s, not ready to restore", snapshot.Status)
+	}
 
 	logger := s.logger.With(
 		slog.String("snapshot_id", snapshotID),
@@ -110,6 +250,18 @@ func (s *SnapshotService) RestoreSnapshot(ctx context.Context, snapshotID string
 
 	logger.Info("restoring container from snapshot")
 
+	// A restored container counts against the same quotas as a fresh one
+	if s.quotas != nil {
+		unlock, err := s.quotas.LockTenant(ctx, snapshot.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+		if err := s.quotas.CheckProvision(snapshot.TenantID, opts.CPUMilli, opts.MemoryMB, 0); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create new container entity
 	now := time.Now()
 	expiryTime := now.Add(time.Duration(opts.DurationMinutes) * time.Minute)
@@ -192,7 +344,7 @@ func (s *SnapshotService) DeleteSnapshot(ctx context.Context, snapshotID string)
 	)
 
 	// Remove Docker image
-	if err := s.dockerClient.RemoveImage(ctx, snapshot.ImageName); err != nil {
+	if err := s.dockerClient.RemoveImage(domain.WithNode(ctx, snapshot.NodeID), snapshot.ImageName); err != nil {
 		logger.Warn("failed to remove Docker image",
 			slog.String("error", err.Error()),
 		)
@@ -167,6 +167,7 @@ func (cm *ChaosMonkey) killContainer(ctx context.Context, container *domain.Cont
 
 	logger.Info("container killed by chaos monkey")
 	metrics.ObserveChaosMoney("kill", 1)
+	metrics.ObserveContainerFailure("chaos_monkey")
 }
 
 // SetKillProbability updates the kill probability at runtime
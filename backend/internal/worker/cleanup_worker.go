@@ -20,9 +20,38 @@ type CleanupWorker struct {
 	logger              *slog.Logger
 	interval            time.Duration
 	maxRetries          int
+	restartBackoff      time.Duration // Base delay between self-healing restarts, doubled per attempt
+	billing             Biller
+	budgets             BudgetEnforcer
+	recordings          RecordingPurger
+	pauses              PauseResumer
+	maxPause            time.Duration
 }
 
-const archiveRetention = 15 * time.Minute
+// Biller finalizes a container's cost when its lease ends
+type Biller interface {
+	Finalize(container *domain.Container, end time.Time) error
+}
+
+// BudgetEnforcer raises budget events and applies hard stops to running leases
+type BudgetEnforcer interface {
+	Enforce(ctx context.Context, now time.Time)
+}
+
+// RecordingPurger deletes session recordings past their retention period
+type RecordingPurger interface {
+	Purge(now time.Time)
+}
+
+// PauseResumer resumes paused leases
+type PauseResumer interface {
+	ResumeContainer(ctx context.Context, containerID string) (*domain.Container, error)
+}
+
+const (
+	archiveRetention  = 15 * time.Minute
+	maxRestartBackoff = 5 * time.Minute
+)
 
 // NewCleanupWorker creates a new cleanup worker
 func NewCleanupWorker(
@@ -39,9 +68,35 @@ func NewCleanupWorker(
 		logger:              logger,
 		interval:            interval,
 		maxRetries:          3,
+		restartBackoff:      10 * time.Second,
 	}
 }
 
+// WithBilling finalizes billing for every container the worker terminates
+func (w *CleanupWorker) WithBilling(billing Biller) *CleanupWorker {
+	w.billing = billing
+	return w
+}
+
+// WithBudgets enforces tenant budgets on every cleanup pass
+func (w *CleanupWorker) WithBudgets(budgets BudgetEnforcer) *CleanupWorker {
+	w.budgets = budgets
+	return w
+}
+
+// WithRecordings applies session recording retention on every cleanup pass
+func (w *CleanupWorker) WithRecordings(recordings RecordingPurger) *CleanupWorker {
+	w.recordings = recordings
+	return w
+}
+
+// WithPauses resumes leases that have been paused for maxPause
+func (w *CleanupWorker) WithPauses(pauses PauseResumer, maxPause time.Duration) *CleanupWorker {
+	w.pauses = pauses
+	w.maxPause = maxPause
+	return w
+}
+
 // Start begins the cleanup worker loop
 // This runs continuously in a goroutine checking for expired leases
 func (w *CleanupWorker) Start(ctx context.Context) {
@@ -65,6 +120,14 @@ func (w *CleanupWorker) Start(ctx context.Context) {
 func (w *CleanupWorker) cleanupExpiredContainers(ctx context.Context) {
 	w.logger.Info("running cleanup check for expired or orphaned containers")
 
+	// Budget hard stops shorten leases first so this pass already sees the new expiries
+	if w.budgets != nil {
+		w.budgets.Enforce(ctx, time.Now())
+	}
+	if w.recordings != nil {
+		w.recordings.Purge(time.Now())
+	}
+
 	containers, err := w.containerRepository.List()
 	if err != nil {
 		w.logger.Error("failed to list containers",
@@ -82,6 +145,11 @@ func (w *CleanupWorker) cleanupExpiredContainers(ctx context.Context) {
 
 	now := time.Now()
 	for _, c := range containers {
+		// Terminated records are kept for history only; their resources are already gone
+		if c.Status == "terminated" {
+			continue
+		}
+
 		if now.After(c.ExpiryAt) || now.Equal(c.ExpiryAt) {
 			w.cleanupContainer(ctx, c.ID)
 			continue
@@ -91,6 +159,22 @@ func (w *CleanupWorker) cleanupExpiredContainers(ctx context.Context) {
 		if _, err := w.leaseRepository.GetLease(leaseKey); err != nil {
 			w.logger.Info("missing lease for container, cleaning up", slog.String("container_id", c.ID))
 			w.cleanupContainer(ctx, c.ID)
+			continue
+		}
+
+		// Paused leases are resumed once they reach the maximum pause duration
+		if c.Status == "paused" && w.pauses != nil && !c.PausedAt.IsZero() && now.Sub(c.PausedAt) >= w.maxPause {
+			if _, err := w.pauses.ResumeContainer(ctx, c.ID); err != nil {
+				w.logger.Error("failed to resume container after maximum pause", slog.String("container_id", c.ID), slog.String("error", err.Error()))
+			} else {
+				w.logger.Info("maximum pause reached, container resumed", slog.String("container_id", c.ID))
+			}
+			continue
+		}
+
+		// Phase 2: SELF-HEALING - failed containers with time left on their lease are restarted
+		if needsHealing(c) {
+			w.healContainer(ctx, c)
 		}
 	}
 }
@@ -156,24 +240,27 @@ func (w *CleanupWorker) performCleanup(ctx context.Context, containerID string)
 		return true
 	}
 
-	// Phase 2: SELF-HEALING - Attempt restart before termination
-	if container.Status == "exited" || container.Status == "error" {
-		if container.RestartCount < container.MaxRestarts {
-			if w.attemptRestart(container, logger) {
-				container.RestartCount++
-				if err := w.containerRepository.Save(container); err != nil {
-					logger.Error("failed to save container after restart", slog.String("error", err.Error()))
-				}
-				metrics.ObserveCleanup("self-healing", "restart_attempted")
-				return true // Restart successful, don't proceed to cleanup
-			}
+	ctx = domain.WithNode(ctx, container.NodeID)
+
+	// Marked first, so the event watcher does not take the exit the stop causes for a failure
+	if container.TerminatingAt.IsZero() {
+		container.TerminatingAt = time.Now()
+		if err := w.containerRepository.Save(container); err != nil {
+			logger.Error("failed to mark container terminating", slog.String("error", err.Error()))
+			return false
+		}
+	}
+
+	// A paused container cannot handle the stop signal; unpause it so it shuts down gracefully
+	if container.Status == "paused" {
+		if err := w.dockerClient.UnpauseContainer(ctx, container.DockerID); err != nil {
+			logger.Warn("failed to unpause container before stop", slog.String("docker_id", container.DockerID), slog.String("error", err.Error()))
 		}
 	}
 
-	// If restart failed or max restarts exceeded, proceed with cleanup
 	// Step 1: Stop Docker container
 	if err := w.dockerClient.StopContainer(ctx, container.DockerID); err != nil {
-		if !strings.Contains(strings.ToLower(err.Error()), "no such container") {
+		if !isNoSuchContainer(err) {
 			logger.Error("failed to stop container", slog.String("docker_id", container.DockerID), slog.String("error", err.Error()))
 			return false
 		}
@@ -184,7 +271,7 @@ func (w *CleanupWorker) performCleanup(ctx context.Context, containerID string)
 
 	// Step 2: Remove Docker container
 	if err := w.dockerClient.RemoveContainer(ctx, container.DockerID); err != nil {
-		if !strings.Contains(strings.ToLower(err.Error()), "no such container") {
+		if !isNoSuchContainer(err) {
 			logger.Error("failed to remove container", slog.String("docker_id", container.DockerID), slog.String("error", err.Error()))
 			return false
 		}
@@ -202,7 +289,18 @@ func (w *CleanupWorker) performCleanup(ctx context.Context, containerID string)
 		logger.Debug("volume removed", slog.String("volume_id", container.VolumeID))
 	}
 
-	// Step 4: Mark terminated and retain record briefly
+	// Step 4: Finalize billing; expired leases are charged up to their expiry, not cleanup time
+	if w.billing != nil {
+		end := time.Now()
+		if container.ExpiryAt.Before(end) {
+			end = container.ExpiryAt
+		}
+		if err := w.billing.Finalize(container, end); err != nil {
+			logger.Error("failed to finalize billing", slog.String("error", err.Error()))
+		}
+	}
+
+	// Step 5: Mark terminated and retain record briefly
 	container.Status = "terminated"
 	container.ExpiryAt = time.Now().Add(archiveRetention)
 	if err := w.containerRepository.Save(container); err != nil {
@@ -210,7 +308,7 @@ func (w *CleanupWorker) performCleanup(ctx context.Context, containerID string)
 		return false
 	}
 
-	// Step 5: Delete lease from Redis
+	// Step 6: Delete lease from Redis
 	leaseKey := fmt.Sprintf("lease:%s", containerID)
 	if err := w.leaseRepository.DeleteLease(leaseKey); err != nil {
 		logger.Error("failed to delete lease", slog.String("error", err.Error()))
@@ -225,24 +323,136 @@ func (w *CleanupWorker) performCleanup(ctx context.Context, containerID string)
 	return true
 }
 
-// attemptRestart attempts to restart a failed container (Phase 2: Self-Healing)
-func (w *CleanupWorker) attemptRestart(container *domain.Container, logger *slog.Logger) bool {
-	logger = logger.With(
-		slog.Int("restart_attempt", container.RestartCount+1),
+// needsHealing reports whether a container has failed and is not being torn down
+func needsHealing(c *domain.Container) bool {
+	return (c.Status == "exited" || c.Status == "error") && c.DockerID != "" && c.TerminatingAt.IsZero()
+}
+
+// healContainer restarts a failed container, backing off between attempts,
+// and terminates it once MaxRestarts has been used up (Phase 2: Self-Healing).
+// The record is read again first, since the copy listed at the start of the pass may be stale.
+func (w *CleanupWorker) healContainer(ctx context.Context, listed *domain.Container) {
+	container, err := w.containerRepository.GetByID(listed.ID)
+	if err != nil {
+		w.logger.Error("failed to get container before restart", slog.String("container_id", listed.ID), slog.String("error", err.Error()))
+		return
+	}
+	if !needsHealing(container) {
+		return // Extended, paused, resumed or deleted since the pass started
+	}
+
+	logger := w.logger.With(
+		slog.String("container_id", container.ID),
+		slog.Int("restart_count", container.RestartCount),
 		slog.Int("max_restarts", container.MaxRestarts),
 	)
 
-	// Try to start the container again
-	// In a real implementation, this might require reading the container's
-	// original configuration from a persistent store or creating a new container
-	// For now, we simulate by attempting to start the Docker container
-	logger.Info("attempting to restart failed container")
+	// The failure itself was counted when it was detected; only restart attempts are counted here
+	if container.RestartCount >= container.MaxRestarts {
+		logger.Warn("max restarts exceeded, terminating container")
+		w.cleanupContainer(ctx, container.ID)
+		return
+	}
+
+	if !container.LastFailureTime.IsZero() {
+		if wait := w.restartDelay(container.RestartCount); time.Since(container.LastFailureTime) < wait {
+			logger.Debug("restart backoff in effect", slog.Duration("wait", wait))
+			return
+		}
+	}
+
+	restarted := *container
+	result := w.attemptRestart(ctx, &restarted, logger)
+	metrics.ObserveContainerRestart(result)
+
+	// Only the restart's own fields are written over whatever was saved while Docker was busy
+	latest, err := w.containerRepository.GetByID(container.ID)
+	if err != nil {
+		logger.Error("failed to get container after restart", slog.String("error", err.Error()))
+		return
+	}
+	if latest.Status == "terminated" || !latest.TerminatingAt.IsZero() {
+		logger.Info("container deleted during restart, leaving it to its teardown")
+		return
+	}
+	latest.RestartCount++
+	latest.Status = restarted.Status
+	latest.DockerID = restarted.DockerID
+	latest.Error = restarted.Error
+	latest.FailureReason = restarted.FailureReason
+	latest.LastFailureTime = restarted.LastFailureTime
+	if err := w.containerRepository.Save(latest); err != nil {
+		logger.Error("failed to save container after restart", slog.String("error", err.Error()))
+	}
+}
+
+// restartDelay returns how long to wait after the last failure before restart attempt n+1
+func (w *CleanupWorker) restartDelay(restartCount int) time.Duration {
+	if restartCount == 0 {
+		return 0
+	}
+	delay := w.restartBackoff
+	for i := 1; i < restartCount && delay < maxRestartBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxRestartBackoff {
+		delay = maxRestartBackoff
+	}
+	return delay
+}
+
+// attemptRestart restarts a failed container in Docker (Phase 2: Self-Healing).
+// If the Docker container no longer exists it is recreated from the stored provisioning spec.
+// Returns the restart result: "restarted", "recreated" or "error".
+func (w *CleanupWorker) attemptRestart(ctx context.Context, container *domain.Container, logger *slog.Logger) string {
+	logger.Info("attempting to restart failed container", slog.String("docker_id", container.DockerID))
+	ctx = domain.WithNode(ctx, container.NodeID) // Its volume is on that node too
+
+	err := w.dockerClient.StartContainer(ctx, container.DockerID)
+	if err == nil {
+		markRestarted(container)
+		logger.Info("container restarted")
+		return "restarted"
+	}
+
+	if !isNoSuchContainer(err) {
+		logger.Error("failed to restart container", slog.String("error", err.Error()))
+		container.LastFailureTime = time.Now()
+		container.FailureReason = fmt.Sprintf("restart failed: %v", err)
+		return "error"
+	}
+
+	// Docker container is gone (e.g. removed by chaos monkey) - recreate it from the spec
+	// The spec prefers the recorded digest so the replacement runs the same image.
+	// The init script is not re-run: it ran once against the original container.
+	spec := container.Spec()
+	logger.Info("docker container removed, recreating from provisioning spec",
+		slog.String("image", spec.Image),
+		slog.String("volume_id", container.VolumeID),
+	)
+	dockerID, err := w.dockerClient.CreateContainer(ctx, spec)
+	if err != nil {
+		logger.Error("failed to recreate container", slog.String("error", err.Error()))
+		container.LastFailureTime = time.Now()
+		container.FailureReason = fmt.Sprintf("recreate failed: %v", err)
+		return "error"
+	}
+
+	container.DockerID = dockerID
+	markRestarted(container)
+	logger.Info("container recreated", slog.String("docker_id", dockerID))
+	return "recreated"
+}
 
-	// This would need the StartContainer method on DockerClient
-	// For now, mark as attempted
+// markRestarted clears failure state after a successful restart
+func markRestarted(container *domain.Container) {
 	container.Status = "running"
-	container.LastFailureTime = time.Time{} // Clear previous failure
+	container.Error = ""
 	container.FailureReason = ""
+	container.LastFailureTime = time.Time{}
+}
 
-	return true
+// isNoSuchContainer reports whether a Docker error means the container does not exist
+func isNoSuchContainer(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "no such container")
 }
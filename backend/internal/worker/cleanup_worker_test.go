@@ -0,0 +1,424 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type memContainerRepo struct {
+	byID map[string]*domain.Container
+}
+
+func (m *memContainerRepo) GetByID(id string) (*domain.Container, error) {
+	if c, ok := m.byID[id]; ok {
+		cp := *c
+		return &cp, nil
+	}
+	return nil, errors.New("not found")
+}
+func (m *memContainerRepo) Save(c *domain.Container) error {
+	cp := *c
+	m.byID[c.ID] = &cp
+	return nil
+}
+func (m *memContainerRepo) Delete(id string) error { delete(m.byID, id); return nil }
+func (m *memContainerRepo) List() ([]*domain.Container, error) {
+	out := []*domain.Container{}
+	for _, c := range m.byID {
+		cp := *c
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+func (m *memContainerRepo) ListByTenant(tenantID string) ([]*domain.Container, error) {
+	return m.List()
+}
+
+type memLeaseRepo struct {
+	byKey map[string]*domain.Lease
+}
+
+func (m *memLeaseRepo) CreateLease(l *domain.Lease) error { m.byKey[l.LeaseKey] = l; return nil }
+func (m *memLeaseRepo) GetLease(key string) (*domain.Lease, error) {
+	if l, ok := m.byKey[key]; ok {
+		return l, nil
+	}
+	return nil, errors.New("not found")
+}
+func (m *memLeaseRepo) DeleteLease(key string) error        { delete(m.byKey, key); return nil }
+func (m *memLeaseRepo) GetExpiredLeases() ([]string, error) { return nil, nil }
+func (m *memLeaseRepo) CompareAndExtendLease(l *domain.Lease, c *domain.Container, expiryTime time.Time) error {
+	return nil
+}
+
+// fakeDocker tracks which Docker containers exist; unknown IDs behave like removed containers
+type fakeDocker struct {
+	existing map[string]bool
+	started  []string
+	created  int
+	image    string // Image of the last created container
+}
+
+func (f *fakeDocker) PullImage(ctx context.Context, image string) (string, error) { return "", nil }
+func (f *fakeDocker) CreateContainer(ctx context.Context, spec domain.ContainerSpec) (string, error) {
+	f.created++
+	f.image = spec.Image
+	id := fmt.Sprintf("docker-new-%d", f.created)
+	f.existing[id] = true
+	return id, nil
+}
+func (f *fakeDocker) RunCommand(ctx context.Context, id string, cmd []string, output io.Writer) (int, error) {
+	return 0, nil
+}
+func (f *fakeDocker) StopContainer(ctx context.Context, id string) error { return nil }
+func (f *fakeDocker) RemoveContainer(ctx context.Context, id string) error {
+	delete(f.existing, id)
+	return nil
+}
+func (f *fakeDocker) StartContainer(ctx context.Context, id string) error {
+	if !f.existing[id] {
+		return fmt.Errorf("Error response from daemon: No such container: %s", id)
+	}
+	f.started = append(f.started, id)
+	return nil
+}
+func (f *fakeDocker) PauseContainer(ctx context.Context, id string) error   { return nil }
+func (f *fakeDocker) UnpauseContainer(ctx context.Context, id string) error { return nil }
+func (f *fakeDocker) StreamLogs(ctx context.Context, id string) (io.ReadCloser, error) {
+	return nil, nil
+}
+func (f *fakeDocker) CreateVolume(ctx context.Context, id string, sizeMB int) (string, error) {
+	return id, nil
+}
+func (f *fakeDocker) RemoveVolume(ctx context.Context, id string) error                  { return nil }
+func (f *fakeDocker) CommitContainer(ctx context.Context, id string, image string) error { return nil }
+func (f *fakeDocker) SaveImage(ctx context.Context, image string, path string) error     { return nil }
+func (f *fakeDocker) LoadImage(ctx context.Context, path string) (string, error)         { return "", nil }
+func (f *fakeDocker) RemoveImage(ctx context.Context, image string) error                { return nil }
+
+func (f *fakeDocker) WatchEvents(ctx context.Context, since time.Time) (<-chan domain.ContainerEvent, <-chan error) {
+	return make(chan domain.ContainerEvent), make(chan error)
+}
+func (f *fakeDocker) InspectContainer(ctx context.Context, id string) (*domain.ContainerState, error) {
+	if !f.existing[id] {
+		return nil, fmt.Errorf("Error response from daemon: No such container: %s", id)
+	}
+	return &domain.ContainerState{Running: true, Status: "running"}, nil
+}
+
+func (f *fakeDocker) Exec(ctx context.Context, id string, opts domain.ExecOptions) (domain.ExecSession, error) {
+	return nil, fmt.Errorf("exec not supported")
+}
+
+func (f *fakeDocker) CopyTo(ctx context.Context, containerID string, dstDir string, archive io.Reader) error {
+	return nil
+}
+
+func (f *fakeDocker) CopyFrom(ctx context.Context, containerID string, srcPath string) (io.ReadCloser, *domain.FileStat, error) {
+	return nil, nil, domain.ErrPathNotFound
+}
+
+func (f *fakeDocker) Stats(ctx context.Context, containerID string) (*domain.ContainerStats, error) {
+	return &domain.ContainerStats{Time: time.Now()}, nil
+}
+
+func (f *fakeDocker) StreamStats(ctx context.Context, containerID string) (<-chan domain.ContainerStats, <-chan error) {
+	out := make(chan domain.ContainerStats)
+	close(out)
+	return out, make(chan error)
+}
+
+func (f *fakeDocker) HostResources(ctx context.Context) (int, int, error) {
+	return 4000, 8192, nil
+}
+
+func newTestCleanupWorker(containers ...*domain.Container) (*CleanupWorker, *memContainerRepo, *fakeDocker) {
+	repo := &memContainerRepo{byID: map[string]*domain.Container{}}
+	leases := &memLeaseRepo{byKey: map[string]*domain.Lease{}}
+	docker := &fakeDocker{existing: map[string]bool{}}
+	for _, c := range containers {
+		_ = repo.Save(c)
+		leases.byKey["lease:"+c.ID] = &domain.Lease{ContainerID: c.ID, LeaseKey: "lease:" + c.ID, ExpiryTime: c.ExpiryAt}
+	}
+	w := NewCleanupWorker(leases, repo, docker, slog.Default(), time.Minute)
+	w.maxRetries = 1
+	return w, repo, docker
+}
+
+func TestSelfHealingRestartsStoppedContainer(t *testing.T) {
+	w, repo, docker := newTestCleanupWorker(&domain.Container{
+		ID: "c1", DockerID: "docker-1", Status: "exited", MaxRestarts: 3,
+		ExpiryAt: time.Now().Add(time.Hour), LastFailureTime: time.Now(),
+	})
+	docker.existing["docker-1"] = true
+
+	w.cleanupExpiredContainers(context.Background())
+
+	c, _ := repo.GetByID("c1")
+	if c.Status != "running" || c.DockerID != "docker-1" || c.RestartCount != 1 {
+		t.Fatalf("expected restarted container, got status=%s docker_id=%s restarts=%d", c.Status, c.DockerID, c.RestartCount)
+	}
+	if len(docker.started) != 1 || docker.created != 0 {
+		t.Fatalf("expected a single docker start and no recreate, got started=%v created=%d", docker.started, docker.created)
+	}
+}
+
+func TestSelfHealingRecreatesRemovedContainer(t *testing.T) {
+	w, repo, docker := newTestCleanupWorker(&domain.Container{
+		ID: "c1", DockerID: "docker-gone", Status: "exited", MaxRestarts: 3,
+		ImageType: "alpine", CPUMilli: 250, MemoryMB: 256, VolumeID: "vol-c1",
+		Image: "docker.io/library/alpine:latest", ImageDigest: "docker.io/library/alpine@sha256:" + strings.Repeat("a", 64),
+		ExpiryAt: time.Now().Add(time.Hour),
+	})
+
+	w.cleanupExpiredContainers(context.Background())
+
+	c, _ := repo.GetByID("c1")
+	if c.Status != "running" || c.DockerID != "docker-new-1" || docker.created != 1 {
+		t.Fatalf("expected recreated container, got status=%s docker_id=%s", c.Status, c.DockerID)
+	}
+	if docker.image != c.ImageDigest {
+		t.Fatalf("expected recreate from the pinned digest, got %q", docker.image)
+	}
+	if c.FailureReason != "" || !c.LastFailureTime.IsZero() {
+		t.Fatalf("expected failure state to be cleared")
+	}
+}
+
+func TestSelfHealingBackoff(t *testing.T) {
+	w, repo, docker := newTestCleanupWorker(&domain.Container{
+		ID: "c1", DockerID: "docker-1", Status: "exited", MaxRestarts: 3, RestartCount: 2,
+		ExpiryAt: time.Now().Add(time.Hour), LastFailureTime: time.Now(),
+	})
+	docker.existing["docker-1"] = true
+
+	w.cleanupExpiredContainers(context.Background())
+
+	if len(docker.started) != 0 {
+		t.Fatalf("expected restart to be deferred during backoff")
+	}
+	c, _ := repo.GetByID("c1")
+	if c.Status != "exited" || c.RestartCount != 2 {
+		t.Fatalf("expected container untouched, got status=%s restarts=%d", c.Status, c.RestartCount)
+	}
+
+	if got := w.restartDelay(2); got != 20*time.Second {
+		t.Fatalf("expected 20s backoff after two restarts, got %v", got)
+	}
+	if got := w.restartDelay(50); got != maxRestartBackoff {
+		t.Fatalf("expected backoff to be capped, got %v", got)
+	}
+}
+
+func TestSelfHealingGivesUpAfterMaxRestarts(t *testing.T) {
+	w, repo, docker := newTestCleanupWorker(&domain.Container{
+		ID: "c1", DockerID: "docker-1", Status: "exited", MaxRestarts: 3, RestartCount: 3,
+		ExpiryAt: time.Now().Add(time.Hour),
+	})
+	docker.existing["docker-1"] = true
+
+	w.cleanupExpiredContainers(context.Background())
+
+	c, _ := repo.GetByID("c1")
+	if c.Status != "terminated" {
+		t.Fatalf("expected container to be terminated, got %s", c.Status)
+	}
+	if docker.existing["docker-1"] {
+		t.Fatalf("expected docker container to be removed")
+	}
+}
+
+// extendingDocker extends the lease while it restarts the container, like a concurrent request
+type extendingDocker struct {
+	*fakeDocker
+	repo *memContainerRepo
+	to   time.Time
+}
+
+func (d *extendingDocker) StartContainer(ctx context.Context, id string) error {
+	c, _ := d.repo.GetByID("c1")
+	c.ExpiryAt = d.to
+	_ = d.repo.Save(c)
+	return d.fakeDocker.StartContainer(ctx, id)
+}
+
+func TestSelfHealingKeepsChangesMadeDuringThePass(t *testing.T) {
+	listed := &domain.Container{
+		ID: "c1", DockerID: "docker-1", Status: "exited", MaxRestarts: 3,
+		ExpiryAt: time.Now().Add(time.Hour),
+	}
+	w, repo, docker := newTestCleanupWorker(listed)
+	docker.existing["docker-1"] = true
+
+	// Paused since the pass listed it: not restarted
+	c, _ := repo.GetByID("c1")
+	c.Status, c.PausedAt = "paused", time.Now()
+	_ = repo.Save(c)
+	w.healContainer(context.Background(), listed)
+	if c, _ := repo.GetByID("c1"); len(docker.started) != 0 || c.Status != "paused" || c.RestartCount != 0 {
+		t.Fatalf("expected the paused container left alone, got status=%s restarts=%d started=%v", c.Status, c.RestartCount, docker.started)
+	}
+
+	// Extended while Docker restarts it: the new expiry survives the restart's save
+	c.Status, c.PausedAt = "exited", time.Time{}
+	_ = repo.Save(c)
+	extended := listed.ExpiryAt.Add(time.Hour)
+	w.dockerClient = &extendingDocker{fakeDocker: docker, repo: repo, to: extended}
+	w.healContainer(context.Background(), listed)
+	c, _ = repo.GetByID("c1")
+	if c.Status != "running" || c.RestartCount != 1 || !c.ExpiryAt.Equal(extended) {
+		t.Fatalf("expected the restart saved onto the extended lease, got status=%s restarts=%d expiry=%v", c.Status, c.RestartCount, c.ExpiryAt)
+	}
+}
+
+// busyDocker fails every start with an error other than a missing container
+type busyDocker struct {
+	*fakeDocker
+}
+
+func (d busyDocker) StartContainer(ctx context.Context, id string) error {
+	return errors.New("Error response from daemon: device or resource busy")
+}
+
+// counterValue reads a counter from the default registry by one of its label values
+func counterValue(t *testing.T, name, label, value string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("gather metrics: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == label && l.GetValue() == value {
+					return m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestSelfHealingCountsEachFailureOnce(t *testing.T) {
+	w, repo, docker := newTestCleanupWorker(&domain.Container{
+		ID: "c1", DockerID: "docker-1", Status: "running", MaxRestarts: 5,
+		ExpiryAt: time.Now().Add(time.Hour),
+	})
+	docker.existing["docker-1"] = true
+	w.dockerClient = busyDocker{docker}
+	w.restartBackoff = 0
+	failures := counterValue(t, "containerlease_container_failures_total", "reason", "exited")
+	restarts := counterValue(t, "containerlease_container_restarts_total", "result", "error")
+
+	NewEventWatcher(repo, docker, slog.Default()).handleEvent(domain.ContainerEvent{DockerID: "docker-1", Action: "die", ExitCode: 1, Time: time.Now()})
+	w.cleanupExpiredContainers(context.Background())
+	w.cleanupExpiredContainers(context.Background())
+
+	if c, _ := repo.GetByID("c1"); c.Status != "exited" || c.RestartCount != 2 {
+		t.Fatalf("expected two failed restarts, got status=%s restarts=%d", c.Status, c.RestartCount)
+	}
+	if got := counterValue(t, "containerlease_container_failures_total", "reason", "exited") - failures; got != 1 {
+		t.Fatalf("expected the failure counted once, got %v", got)
+	}
+	if got := counterValue(t, "containerlease_container_restarts_total", "result", "error") - restarts; got != 2 {
+		t.Fatalf("expected both restart attempts counted, got %v", got)
+	}
+}
+
+// markCheckDocker records whether a container was marked terminating when it was stopped
+type markCheckDocker struct {
+	*fakeDocker
+	repo   *memContainerRepo
+	marked bool
+}
+
+func (d *markCheckDocker) StopContainer(ctx context.Context, id string) error {
+	c, _ := d.repo.GetByID("c1")
+	d.marked = !c.TerminatingAt.IsZero()
+	return nil
+}
+
+func TestCleanupMarksTerminatingBeforeStop(t *testing.T) {
+	// The lease record is gone, so the container is removed before its expiry
+	w, repo, docker := newTestCleanupWorker(&domain.Container{
+		ID: "c1", DockerID: "docker-1", Status: "running", ExpiryAt: time.Now().Add(time.Hour),
+	})
+	docker.existing["docker-1"] = true
+	delete(w.leaseRepository.(*memLeaseRepo).byKey, "lease:c1")
+	check := &markCheckDocker{fakeDocker: docker, repo: repo}
+	w.dockerClient = check
+
+	w.cleanupExpiredContainers(context.Background())
+
+	if !check.marked {
+		t.Fatal("expected the container marked terminating before it was stopped")
+	}
+	if c, _ := repo.GetByID("c1"); c.Status != "terminated" {
+		t.Fatalf("expected terminated, got %s", c.Status)
+	}
+}
+
+type fakeBiller struct {
+	ends map[string]time.Time
+}
+
+func (f *fakeBiller) Finalize(c *domain.Container, end time.Time) error {
+	f.ends[c.ID] = end
+	return nil
+}
+
+func TestCleanupBillsExpiredLeaseUpToExpiry(t *testing.T) {
+	expiry := time.Now().Add(-10 * time.Minute)
+	w, repo, docker := newTestCleanupWorker(&domain.Container{
+		ID: "c1", DockerID: "docker-1", Status: "running", ExpiryAt: expiry,
+	})
+	docker.existing["docker-1"] = true
+	biller := &fakeBiller{ends: map[string]time.Time{}}
+	w.WithBilling(biller)
+
+	w.cleanupExpiredContainers(context.Background())
+
+	c, _ := repo.GetByID("c1")
+	if c.Status != "terminated" {
+		t.Fatalf("expected container to be terminated, got %s", c.Status)
+	}
+	if end, ok := biller.ends["c1"]; !ok || !end.Equal(expiry) {
+		t.Fatalf("expected billing to end at lease expiry %v, got %v", expiry, end)
+	}
+}
+
+type fakeResumer struct {
+	resumed []string
+}
+
+func (f *fakeResumer) ResumeContainer(ctx context.Context, id string) (*domain.Container, error) {
+	f.resumed = append(f.resumed, id)
+	return nil, nil
+}
+
+func TestCleanupResumesLeasePausedTooLong(t *testing.T) {
+	w, _, _ := newTestCleanupWorker(
+		&domain.Container{ID: "c1", DockerID: "docker-1", Status: "paused", PausedAt: time.Now().Add(-61 * time.Minute), ExpiryAt: time.Now().Add(time.Hour)},
+		&domain.Container{ID: "c2", DockerID: "docker-2", Status: "paused", PausedAt: time.Now().Add(-5 * time.Minute), ExpiryAt: time.Now().Add(time.Hour)},
+	)
+	resumer := &fakeResumer{}
+	w.WithPauses(resumer, time.Hour)
+
+	w.cleanupExpiredContainers(context.Background())
+
+	if len(resumer.resumed) != 1 || resumer.resumed[0] != "c1" {
+		t.Fatalf("expected only c1 resumed after the maximum pause, got %v", resumer.resumed)
+	}
+}
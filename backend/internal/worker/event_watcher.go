@@ -0,0 +1,260 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/observability/metrics"
+)
+
+// EventWatcher subscribes to the Docker events stream and keeps container records in sync
+// with what actually happens in Docker (process exits, OOM kills, external removals).
+// Failures it records are picked up by the CleanupWorker's self-healing path.
+type EventWatcher struct {
+	containerRepository domain.ContainerRepository
+	dockerClient        domain.DockerClient
+	logger              *slog.Logger
+	reconnectDelay      time.Duration
+}
+
+const (
+	oomFailureReason     = "out of memory (OOM killed)"
+	removedFailureReason = "container removed outside ContainerLease"
+
+	// oomDieWindow is how long after an oom event a die event is attributed to the OOM kill
+	oomDieWindow = 10 * time.Second
+)
+
+// NewEventWatcher creates a new Docker event watcher
+func NewEventWatcher(
+	containerRepo domain.ContainerRepository,
+	dockerClient domain.DockerClient,
+	logger *slog.Logger,
+) *EventWatcher {
+	return &EventWatcher{
+		containerRepository: containerRepo,
+		dockerClient:        dockerClient,
+		logger:              logger,
+		reconnectDelay:      5 * time.Second,
+	}
+}
+
+// Start consumes Docker events until the context is cancelled.
+// When the stream breaks it reconnects, replays events since the last one seen
+// and resyncs every tracked container against Docker to cover anything missed.
+func (w *EventWatcher) Start(ctx context.Context) {
+	w.logger.Info("docker event watcher started")
+
+	since := time.Now()
+	w.resync(ctx)
+
+	for {
+		lastSeen, err := w.watch(ctx, since)
+		if ctx.Err() != nil {
+			w.logger.Info("docker event watcher stopped")
+			return
+		}
+		if !lastSeen.IsZero() {
+			since = lastSeen
+		}
+
+		w.logger.Warn("docker event stream interrupted, reconnecting",
+			slog.String("error", err.Error()),
+			slog.Duration("delay", w.reconnectDelay),
+		)
+
+		select {
+		case <-ctx.Done():
+			w.logger.Info("docker event watcher stopped")
+			return
+		case <-time.After(w.reconnectDelay):
+		}
+
+		w.resync(ctx)
+	}
+}
+
+// watch reads events until the stream fails, returning the time of the last event handled
+func (w *EventWatcher) watch(ctx context.Context, since time.Time) (time.Time, error) {
+	events, errs := w.dockerClient.WatchEvents(ctx, since)
+	var lastSeen time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return lastSeen, ctx.Err()
+		case err := <-errs:
+			if errors.Is(err, domain.ErrNodeEventsInterrupted) {
+				// One node of a pool is reconnecting; the other nodes' events keep coming
+				w.logger.Warn("docker node event stream interrupted", slog.String("error", err.Error()))
+				continue
+			}
+			return lastSeen, err
+		case event, ok := <-events:
+			if !ok {
+				// Event channel closed; the error (if any) follows on errs
+				select {
+				case err := <-errs:
+					return lastSeen, err
+				case <-ctx.Done():
+					return lastSeen, ctx.Err()
+				}
+			}
+			w.handleEvent(event)
+			lastSeen = event.Time
+		}
+	}
+}
+
+// handleEvent maps a Docker event back to its container record and updates its state
+func (w *EventWatcher) handleEvent(event domain.ContainerEvent) {
+	container, err := domain.FindByDockerID(w.containerRepository, event.DockerID)
+	if err != nil {
+		w.logger.Error("failed to look up container for docker event",
+			slog.String("docker_id", event.DockerID),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+	if container == nil {
+		return
+	}
+
+	logger := w.logger.With(
+		slog.String("container_id", container.ID),
+		slog.String("docker_id", event.DockerID),
+		slog.String("action", event.Action),
+	)
+
+	if !applyEvent(container, event) {
+		return
+	}
+
+	if err := w.containerRepository.Save(container); err != nil {
+		logger.Error("failed to save container after docker event", slog.String("error", err.Error()))
+		return
+	}
+	logger.Info("container state updated from docker event",
+		slog.String("status", container.Status),
+		slog.String("failure_reason", container.FailureReason),
+	)
+}
+
+// applyEvent updates a container from a Docker event and reports whether anything changed
+func applyEvent(container *domain.Container, event domain.ContainerEvent) bool {
+	switch event.Action {
+	case "start":
+		if container.Status != "exited" && container.Status != "error" {
+			return false
+		}
+		container.Status = "running"
+		return true
+
+	case "oom":
+		if container.Status != "running" {
+			return false
+		}
+		container.FailureReason = oomFailureReason
+		container.LastFailureTime = event.Time
+		return true
+
+	case "die":
+		if (container.Status != "running" && container.Status != "paused") || terminating(container, event.Time) {
+			return false
+		}
+		oomKilled := container.FailureReason == oomFailureReason && event.Time.Sub(container.LastFailureTime) < oomDieWindow
+		container.Status = "exited"
+		container.LastFailureTime = event.Time
+		if oomKilled {
+			metrics.ObserveContainerFailure("oom_killed")
+		} else {
+			container.FailureReason = fmt.Sprintf("process exited with code %d", event.ExitCode)
+			metrics.ObserveContainerFailure("exited")
+		}
+		return true
+
+	case "destroy":
+		if (container.Status != "running" && container.Status != "paused") || terminating(container, event.Time) {
+			return false
+		}
+		container.Status = "exited"
+		container.FailureReason = removedFailureReason
+		container.LastFailureTime = event.Time
+		metrics.ObserveContainerFailure("removed")
+		return true
+	}
+	return false
+}
+
+// terminating reports whether a container was being torn down at the time of an event: its
+// teardown has started, or its lease ran out and the cleanup worker is about to remove it.
+// The exit that causes is not a failure to heal.
+func terminating(container *domain.Container, at time.Time) bool {
+	if !container.TerminatingAt.IsZero() {
+		return true
+	}
+	return !container.ExpiryAt.IsZero() && !at.Before(container.ExpiryAt)
+}
+
+// resync inspects every tracked container and corrects records that drifted while events were missed
+func (w *EventWatcher) resync(ctx context.Context) {
+	containers, err := w.containerRepository.List()
+	if err != nil {
+		w.logger.Error("failed to list containers for resync", slog.String("error", err.Error()))
+		return
+	}
+
+	updated := 0
+	for _, c := range containers {
+		if c.DockerID == "" || (c.Status != "running" && c.Status != "paused" && c.Status != "exited" && c.Status != "error") {
+			continue
+		}
+
+		state, err := w.dockerClient.InspectContainer(ctx, c.DockerID)
+		if err != nil && !isNoSuchContainer(err) {
+			w.logger.Warn("failed to inspect container during resync",
+				slog.String("container_id", c.ID),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+
+		if !applyState(c, state, time.Now()) {
+			continue
+		}
+		if err := w.containerRepository.Save(c); err != nil {
+			w.logger.Error("failed to save container during resync",
+				slog.String("container_id", c.ID),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		updated++
+	}
+
+	w.logger.Info("docker state resync complete",
+		slog.Int("checked", len(containers)),
+		slog.Int("updated", updated),
+	)
+}
+
+// applyState reconciles a container with its inspected Docker state (nil = container is gone)
+func applyState(container *domain.Container, state *domain.ContainerState, now time.Time) bool {
+	switch {
+	case state == nil:
+		return applyEvent(container, domain.ContainerEvent{Action: "destroy", Time: now})
+	case state.Running:
+		return applyEvent(container, domain.ContainerEvent{Action: "start", Time: now})
+	case container.Status == "running" || container.Status == "paused":
+		if state.OOMKilled {
+			container.FailureReason = oomFailureReason
+			container.LastFailureTime = now
+		}
+		return applyEvent(container, domain.ContainerEvent{Action: "die", ExitCode: state.ExitCode, Time: now})
+	}
+	return false
+}
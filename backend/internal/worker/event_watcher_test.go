@@ -0,0 +1,149 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+)
+
+func TestEventWatcherMapsEventsToContainers(t *testing.T) {
+	repo := &memContainerRepo{byID: map[string]*domain.Container{}}
+	_ = repo.Save(&domain.Container{ID: "c1", DockerID: "docker-1", Status: "running"})
+	w := NewEventWatcher(repo, &fakeDocker{existing: map[string]bool{}}, slog.Default())
+
+	now := time.Now()
+	w.handleEvent(domain.ContainerEvent{DockerID: "docker-1", Action: "oom", Time: now})
+	w.handleEvent(domain.ContainerEvent{DockerID: "docker-1", Action: "die", ExitCode: 137, Time: now.Add(time.Second)})
+
+	c, _ := repo.GetByID("c1")
+	if c.Status != "exited" || c.FailureReason != oomFailureReason {
+		t.Fatalf("expected OOM-killed exit, got status=%s reason=%q", c.Status, c.FailureReason)
+	}
+	if !c.LastFailureTime.Equal(now.Add(time.Second)) {
+		t.Fatalf("expected last failure time from die event, got %v", c.LastFailureTime)
+	}
+
+	w.handleEvent(domain.ContainerEvent{DockerID: "docker-1", Action: "start", Time: now.Add(time.Minute)})
+	c, _ = repo.GetByID("c1")
+	if c.Status != "running" {
+		t.Fatalf("expected running after start event, got %s", c.Status)
+	}
+
+	w.handleEvent(domain.ContainerEvent{DockerID: "docker-1", Action: "die", ExitCode: 1, Time: now.Add(2 * time.Minute)})
+	c, _ = repo.GetByID("c1")
+	if c.FailureReason != "process exited with code 1" {
+		t.Fatalf("expected plain exit reason, got %q", c.FailureReason)
+	}
+
+	// Events for unknown Docker IDs are ignored
+	w.handleEvent(domain.ContainerEvent{DockerID: "docker-other", Action: "die", Time: now})
+}
+
+// dockerIndexedRepo looks containers up by Docker ID and refuses to list them
+type dockerIndexedRepo struct {
+	*memContainerRepo
+}
+
+func (r dockerIndexedRepo) List() ([]*domain.Container, error) {
+	return nil, errors.New("listing every container for an event")
+}
+func (r dockerIndexedRepo) GetByDockerID(dockerID string) (*domain.Container, error) {
+	for _, c := range r.byID {
+		if c.DockerID == dockerID {
+			cp := *c
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestEventWatcherSkipsTerminatingContainers(t *testing.T) {
+	repo := dockerIndexedRepo{&memContainerRepo{byID: map[string]*domain.Container{}}}
+	now := time.Now()
+	_ = repo.Save(&domain.Container{ID: "live", DockerID: "docker-live", Status: "running", ExpiryAt: now.Add(time.Hour)})
+	_ = repo.Save(&domain.Container{ID: "expired", DockerID: "docker-expired", Status: "running", ExpiryAt: now.Add(-time.Second)})
+	_ = repo.Save(&domain.Container{ID: "done", DockerID: "docker-done", Status: "terminated", ExpiryAt: now.Add(time.Hour)})
+	_ = repo.Save(&domain.Container{ID: "deleting", DockerID: "docker-deleting", Status: "running", ExpiryAt: now.Add(time.Hour), TerminatingAt: now})
+	w := NewEventWatcher(repo, &fakeDocker{existing: map[string]bool{}}, slog.Default())
+
+	for _, id := range []string{"docker-live", "docker-expired", "docker-done", "docker-deleting"} {
+		w.handleEvent(domain.ContainerEvent{DockerID: id, Action: "die", ExitCode: 137, Time: now})
+	}
+
+	if c, _ := repo.GetByID("live"); c.Status != "exited" {
+		t.Fatalf("expected the live container's exit recorded, got %s", c.Status)
+	}
+	// The cleanup worker is stopping the expired one; its exit must not be healed
+	if c, _ := repo.GetByID("expired"); c.Status != "running" || c.FailureReason != "" {
+		t.Fatalf("expected the expired container left to cleanup, got status=%s reason=%q", c.Status, c.FailureReason)
+	}
+	// A delete, idle termination or give-up after too many restarts is removing this one
+	if c, _ := repo.GetByID("deleting"); c.Status != "running" || c.FailureReason != "" {
+		t.Fatalf("expected the container being deleted left alone, got status=%s reason=%q", c.Status, c.FailureReason)
+	}
+	if c, _ := repo.GetByID("done"); c.Status != "terminated" {
+		t.Fatalf("expected the terminated container left alone, got %s", c.Status)
+	}
+}
+
+func TestEventWatcherResync(t *testing.T) {
+	repo := &memContainerRepo{byID: map[string]*domain.Container{}}
+	_ = repo.Save(&domain.Container{ID: "gone", DockerID: "docker-gone", Status: "running"})
+	_ = repo.Save(&domain.Container{ID: "back", DockerID: "docker-back", Status: "exited"})
+	_ = repo.Save(&domain.Container{ID: "done", DockerID: "docker-done", Status: "terminated"})
+	docker := &fakeDocker{existing: map[string]bool{"docker-back": true}}
+	w := NewEventWatcher(repo, docker, slog.Default())
+
+	w.resync(context.Background())
+
+	if c, _ := repo.GetByID("gone"); c.Status != "exited" || c.FailureReason != removedFailureReason {
+		t.Fatalf("expected removed container to be marked exited, got status=%s reason=%q", c.Status, c.FailureReason)
+	}
+	if c, _ := repo.GetByID("back"); c.Status != "running" {
+		t.Fatalf("expected running container to be marked running, got %s", c.Status)
+	}
+	if c, _ := repo.GetByID("done"); c.Status != "terminated" {
+		t.Fatalf("expected terminated container to be left alone, got %s", c.Status)
+	}
+}
+
+// streamDocker hands out event and error channels the test writes to
+type streamDocker struct {
+	fakeDocker
+	events chan domain.ContainerEvent
+	errs   chan error
+}
+
+func (f *streamDocker) WatchEvents(ctx context.Context, since time.Time) (<-chan domain.ContainerEvent, <-chan error) {
+	return f.events, f.errs
+}
+
+func TestEventWatcherKeepsWatchingWhenOneNodeIsInterrupted(t *testing.T) {
+	repo := &memContainerRepo{byID: map[string]*domain.Container{}}
+	_ = repo.Save(&domain.Container{ID: "c1", DockerID: "docker-1", Status: "running"})
+	docker := &streamDocker{events: make(chan domain.ContainerEvent), errs: make(chan error)}
+	w := NewEventWatcher(repo, docker, slog.Default())
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.watch(context.Background(), time.Time{})
+		done <- err
+	}()
+
+	docker.errs <- fmt.Errorf("%w: node remote: connection reset", domain.ErrNodeEventsInterrupted)
+	docker.events <- domain.ContainerEvent{DockerID: "docker-1", Action: "die", ExitCode: 1, Time: time.Now()}
+	broken := errors.New("docker event stream closed")
+	docker.errs <- broken
+
+	if err := <-done; !errors.Is(err, broken) {
+		t.Fatalf("expected only the stream's own error to end the watch, got %v", err)
+	}
+	if c, _ := repo.GetByID("c1"); c.Status != "exited" {
+		t.Fatalf("expected the event after the interruption handled, got %s", c.Status)
+	}
+}
@@ -0,0 +1,189 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/observability/metrics"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// IdleReclaimer reports user activity on leases and applies the idle policy to leases left idle
+type IdleReclaimer interface {
+	LastActivity(containerID string) time.Time
+	Forget(containerID string)
+	Reclaim(ctx context.Context, container *domain.Container, idleFor time.Duration) error
+}
+
+// idleState follows one running lease between checks
+type idleState struct {
+	idleSince time.Time // Zero while the lease is in use
+	netBytes  uint64    // Network total (rx + tx) at the last sample
+	sampledAt time.Time
+	reclaimed bool // The policy has been applied for the current idle period
+}
+
+// IdleWorker samples running leases and hands the ones that stay below the CPU and
+// network thresholds, with no terminal or log activity, to the reclaimer
+type IdleWorker struct {
+	containerRepository domain.ContainerRepository
+	dockerClient        domain.DockerClient
+	reclaimer           IdleReclaimer
+	logger              *slog.Logger
+	interval            time.Duration
+	idleAfter           time.Duration
+	cpuPercent          float64
+	netBytesPerSec      float64
+	leases              map[string]*idleState
+}
+
+// NewIdleWorker creates an idle worker using the IDLE_* settings
+func NewIdleWorker(containerRepo domain.ContainerRepository, dockerClient domain.DockerClient, reclaimer IdleReclaimer, logger *slog.Logger, cfg *config.Config) *IdleWorker {
+	return &IdleWorker{
+		containerRepository: containerRepo,
+		dockerClient:        dockerClient,
+		reclaimer:           reclaimer,
+		logger:              logger,
+		interval:            time.Duration(cfg.IdleCheckSeconds) * time.Second,
+		idleAfter:           time.Duration(cfg.IdleMinutes) * time.Minute,
+		cpuPercent:          cfg.IdleCPUPercent,
+		netBytesPerSec:      cfg.IdleNetBytesPerSec,
+		leases:              map[string]*idleState{},
+	}
+}
+
+// Start checks running leases for idleness until the context is cancelled
+func (w *IdleWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.logger.Info("idle worker started", slog.Duration("interval", w.interval), slog.Duration("idle_after", w.idleAfter))
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("idle worker stopped")
+			return
+		case <-ticker.C:
+			w.check(ctx)
+		}
+	}
+}
+
+// check samples every running lease and reclaims those idle for longer than idleAfter
+func (w *IdleWorker) check(ctx context.Context) {
+	containers, err := w.containerRepository.List()
+	if err != nil {
+		w.logger.Error("failed to list containers for idle check", slog.String("error", err.Error()))
+		return
+	}
+
+	now := time.Now()
+	running := []*domain.Container{}
+	pausedCPU := 0
+	for _, c := range containers {
+		if c.DockerID == "" || !now.Before(c.ExpiryAt) {
+			continue
+		}
+		switch c.Status {
+		case "running":
+			running = append(running, c)
+		case "paused":
+			pausedCPU += c.CPUMilli
+		}
+	}
+
+	samples := w.sample(ctx, running)
+	idle := 0
+	seen := map[string]bool{}
+	for _, c := range running {
+		seen[c.ID] = true
+		stats, ok := samples[c.ID]
+		if !ok {
+			continue
+		}
+		state := w.leases[c.ID]
+		if state == nil {
+			state = &idleState{}
+			w.leases[c.ID] = state
+		}
+		w.observe(c, state, stats, now)
+		if state.idleSince.IsZero() {
+			continue
+		}
+		idle++
+
+		idleFor := now.Sub(state.idleSince)
+		if state.reclaimed || idleFor < w.idleAfter {
+			continue
+		}
+		if err := w.reclaimer.Reclaim(ctx, c, idleFor); err != nil {
+			continue // Retried on the next check
+		}
+		state.reclaimed = true
+	}
+
+	for id := range w.leases {
+		if !seen[id] {
+			delete(w.leases, id)
+			w.reclaimer.Forget(id)
+		}
+	}
+	metrics.SetIdleLeases(idle, pausedCPU)
+}
+
+// observe updates a lease's idle state from a new sample. The network rate needs a
+// previous sample, so a lease is never considered idle on its first check.
+func (w *IdleWorker) observe(c *domain.Container, state *idleState, stats domain.ContainerStats, now time.Time) {
+	netBytes := stats.NetRxBytes + stats.NetTxBytes
+	quiet := false
+	if !state.sampledAt.IsZero() && netBytes >= state.netBytes {
+		rate := float64(netBytes-state.netBytes) / now.Sub(state.sampledAt).Seconds()
+		quiet = stats.CPUPercent(c.CPUMilli) < w.cpuPercent && rate < w.netBytesPerSec
+	}
+
+	switch {
+	case !quiet:
+		state.idleSince = time.Time{}
+		state.reclaimed = false
+	case state.idleSince.IsZero():
+		state.idleSince = state.sampledAt // Quiet since the previous sample
+	}
+	// Terminal input or log output restarts the idle period
+	if last := w.reclaimer.LastActivity(c.ID); !state.idleSince.IsZero() && last.After(state.idleSince) {
+		state.idleSince = last
+		state.reclaimed = false
+	}
+
+	state.netBytes = netBytes
+	state.sampledAt = now
+}
+
+// sample takes one stats sample of each lease in parallel; leases that fail to sample are left out
+func (w *IdleWorker) sample(ctx context.Context, containers []*domain.Container) map[string]domain.ContainerStats {
+	out := map[string]domain.ContainerStats{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, statsConcurrency)
+	for _, container := range containers {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(container *domain.Container) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			stats, err := w.dockerClient.Stats(ctx, container.DockerID)
+			if err != nil {
+				w.logger.Debug("failed to sample container for idle check", slog.String("container_id", container.ID), slog.String("error", err.Error()))
+				return
+			}
+			mu.Lock()
+			out[container.ID] = *stats
+			mu.Unlock()
+		}(container)
+	}
+	wg.Wait()
+	return out
+}
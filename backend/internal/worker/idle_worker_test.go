@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// fakeReclaimer records reclaimed leases and reports fixed activity times
+type fakeReclaimer struct {
+	activity  map[string]time.Time
+	reclaimed []string
+	forgotten []string
+}
+
+func (f *fakeReclaimer) LastActivity(id string) time.Time { return f.activity[id] }
+func (f *fakeReclaimer) Forget(id string)                 { f.forgotten = append(f.forgotten, id) }
+func (f *fakeReclaimer) Reclaim(ctx context.Context, c *domain.Container, idleFor time.Duration) error {
+	f.reclaimed = append(f.reclaimed, c.ID)
+	return nil
+}
+
+func newTestIdleWorker(containers ...*domain.Container) (*IdleWorker, *memContainerRepo, *fakeReclaimer) {
+	repo := &memContainerRepo{byID: map[string]*domain.Container{}}
+	for _, c := range containers {
+		_ = repo.Save(c)
+	}
+	reclaimer := &fakeReclaimer{activity: map[string]time.Time{}}
+	cfg := &config.Config{IdleCheckSeconds: 60, IdleMinutes: 30, IdleCPUPercent: 5, IdleNetBytesPerSec: 1024}
+	return NewIdleWorker(repo, &fakeDocker{}, reclaimer, slog.Default(), cfg), repo, reclaimer
+}
+
+// rewind moves every lease's last sample back, as if the previous check ran d ago
+func rewind(w *IdleWorker, d time.Duration) {
+	for _, state := range w.leases {
+		state.sampledAt = state.sampledAt.Add(-d)
+	}
+}
+
+func TestIdleWorkerReclaimsQuietLeaseOnce(t *testing.T) {
+	// fakeDocker reports no CPU or network use
+	w, _, reclaimer := newTestIdleWorker(&domain.Container{
+		ID: "c1", DockerID: "docker-1", TenantID: "tenant-1", Status: "running", CPUMilli: 500, ExpiryAt: time.Now().Add(time.Hour),
+	})
+
+	w.check(context.Background())
+	if len(reclaimer.reclaimed) != 0 {
+		t.Fatal("a lease must not be reclaimed on its first sample")
+	}
+
+	rewind(w, 31*time.Minute)
+	w.check(context.Background())
+	if len(reclaimer.reclaimed) != 1 || reclaimer.reclaimed[0] != "c1" {
+		t.Fatalf("expected c1 reclaimed after 31 idle minutes, got %v", reclaimer.reclaimed)
+	}
+
+	w.check(context.Background())
+	if len(reclaimer.reclaimed) != 1 {
+		t.Fatalf("expected one reclaim per idle period, got %v", reclaimer.reclaimed)
+	}
+}
+
+func TestIdleWorkerHonoursTerminalActivity(t *testing.T) {
+	w, repo, reclaimer := newTestIdleWorker(&domain.Container{
+		ID: "c1", DockerID: "docker-1", TenantID: "tenant-1", Status: "running", CPUMilli: 500, ExpiryAt: time.Now().Add(time.Hour),
+	})
+
+	w.check(context.Background())
+	rewind(w, 31*time.Minute)
+	reclaimer.activity["c1"] = time.Now().Add(-10 * time.Minute)
+	w.check(context.Background())
+	if len(reclaimer.reclaimed) != 0 {
+		t.Fatalf("lease used 10 minutes ago must not be reclaimed, got %v", reclaimer.reclaimed)
+	}
+
+	// Once the lease is gone its state is dropped
+	c, _ := repo.GetByID("c1")
+	c.Status = "terminated"
+	_ = repo.Save(c)
+	w.check(context.Background())
+	if len(w.leases) != 0 || len(reclaimer.forgotten) != 1 {
+		t.Fatalf("expected state for c1 dropped, leases=%d forgotten=%v", len(w.leases), reclaimer.forgotten)
+	}
+}
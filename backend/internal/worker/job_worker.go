@@ -0,0 +1,192 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/observability/metrics"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// jobBlock is how long a worker waits for a job before checking for shutdown
+const jobBlock = 5 * time.Second
+
+// JobFunc runs a job; an error retries it with backoff
+type JobFunc func(ctx context.Context, job *domain.Job) error
+
+// JobFailFunc is called once a job has used up its attempts and been dead-lettered
+type JobFailFunc func(ctx context.Context, job *domain.Job)
+
+type jobHandler struct {
+	run  JobFunc
+	fail JobFailFunc
+}
+
+// JobWorker runs jobs from the durable job queue on a pool of goroutines. A job is only
+// removed from the queue once it has succeeded or been dead-lettered, so jobs running
+// when the server stops are run again after a restart.
+type JobWorker struct {
+	queue        domain.JobQueue
+	handlers     map[string]jobHandler
+	logger       *slog.Logger
+	consumer     string
+	workers      int
+	maxAttempts  int
+	retryBase    time.Duration
+	retryMax     time.Duration
+	claimTimeout time.Duration
+}
+
+// NewJobWorker creates a job worker using the JOB_* settings
+func NewJobWorker(queue domain.JobQueue, logger *slog.Logger, cfg *config.Config) *JobWorker {
+	return &JobWorker{
+		queue:        queue,
+		handlers:     map[string]jobHandler{},
+		logger:       logger,
+		consumer:     cfg.JobConsumer,
+		workers:      cfg.JobWorkers,
+		maxAttempts:  cfg.JobMaxAttempts,
+		retryBase:    time.Duration(cfg.JobRetryBaseSeconds) * time.Second,
+		retryMax:     time.Duration(cfg.JobRetryMaxSeconds) * time.Second,
+		claimTimeout: time.Duration(cfg.JobClaimTimeoutSeconds) * time.Second,
+	}
+}
+
+// Handle registers the functions that run jobs of a kind; fail may be nil
+func (w *JobWorker) Handle(kind string, run JobFunc, fail JobFailFunc) *JobWorker {
+	w.handlers[kind] = jobHandler{run: run, fail: fail}
+	return w
+}
+
+// Start runs jobs until the context is cancelled. Jobs this server had claimed when it
+// last stopped are handed back first; other servers' jobs are handed back once they have
+// been claimed for longer than the claim timeout.
+func (w *JobWorker) Start(ctx context.Context) {
+	w.reclaim(ctx, w.consumer, 0)
+
+	w.logger.Info("job worker started", slog.Int("workers", w.workers), slog.String("consumer", w.consumer))
+	var wg sync.WaitGroup
+	for i := 0; i < w.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.work(ctx)
+		}()
+	}
+
+	ticker := time.NewTicker(w.claimTimeout / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			w.logger.Info("job worker stopped")
+			return
+		case <-ticker.C:
+			w.reclaim(ctx, "", w.claimTimeout)
+		}
+	}
+}
+
+func (w *JobWorker) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := w.queue.Dequeue(ctx, w.consumer, jobBlock)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.logger.Error("failed to dequeue job", slog.String("error", err.Error()))
+				sleepCtx(ctx, time.Second)
+			}
+			continue
+		}
+		if job != nil {
+			w.run(ctx, job)
+		}
+	}
+}
+
+// run runs one job and acknowledges, retries or dead-letters it
+func (w *JobWorker) run(ctx context.Context, job *domain.Job) {
+	logger := w.logger.With(slog.String("job_id", job.ID), slog.String("kind", job.Kind), slog.Int("attempts", job.Attempts))
+
+	handler, ok := w.handlers[job.Kind]
+	if !ok {
+		job.LastError = "no handler for job kind " + job.Kind
+		w.deadLetter(ctx, job, handler, logger, 0)
+		return
+	}
+	// Jobs that keep getting abandoned, e.g. because they crash the server, are not run again
+	if job.Attempts >= w.maxAttempts {
+		w.deadLetter(ctx, job, handler, logger, 0)
+		return
+	}
+
+	start := time.Now()
+	err := handler.run(ctx, job)
+	if err == nil {
+		if err := w.queue.Ack(ctx, job); err != nil {
+			logger.Error("failed to acknowledge job", slog.String("error", err.Error()))
+		}
+		metrics.ObserveJob(job.Kind, "success", time.Since(start))
+		return
+	}
+	if ctx.Err() != nil {
+		// Interrupted by shutdown; the job is still claimed and is reclaimed on restart
+		logger.Warn("job interrupted by shutdown", slog.String("error", err.Error()))
+		return
+	}
+
+	job.Attempts++
+	job.LastError = err.Error()
+	if job.Attempts >= w.maxAttempts {
+		w.deadLetter(ctx, job, handler, logger, time.Since(start))
+		return
+	}
+	delay := w.backoff(job.Attempts)
+	if err := w.queue.Retry(ctx, job, time.Now().Add(delay)); err != nil {
+		logger.Error("failed to schedule job retry", slog.String("error", err.Error()))
+	}
+	metrics.ObserveJob(job.Kind, "retry", time.Since(start))
+	logger.Warn("job failed, will retry", slog.String("error", job.LastError), slog.Duration("retry_in", delay))
+}
+
+func (w *JobWorker) deadLetter(ctx context.Context, job *domain.Job, handler jobHandler, logger *slog.Logger, took time.Duration) {
+	if err := w.queue.DeadLetter(ctx, job); err != nil {
+		logger.Error("failed to dead-letter job", slog.String("error", err.Error()))
+		return
+	}
+	metrics.ObserveJob(job.Kind, "dead", took)
+	logger.Error("job dead-lettered", slog.String("error", job.LastError))
+	if handler.fail != nil {
+		handler.fail(ctx, job)
+	}
+}
+
+// backoff is the delay before the given retry: the base delay doubled for each earlier attempt
+func (w *JobWorker) backoff(attempts int) time.Duration {
+	delay := w.retryBase
+	for i := 1; i < attempts && delay < w.retryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, w.retryMax)
+}
+
+func (w *JobWorker) reclaim(ctx context.Context, consumer string, minIdle time.Duration) {
+	n, err := w.queue.Reclaim(ctx, consumer, minIdle)
+	if err != nil {
+		w.logger.Error("failed to reclaim jobs", slog.String("error", err.Error()))
+		return
+	}
+	if n > 0 {
+		w.logger.Info("unfinished jobs reclaimed", slog.Int("jobs", n), slog.String("consumer", consumer))
+	}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
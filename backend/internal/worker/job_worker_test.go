@@ -0,0 +1,121 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// memJobQueue records what the worker does with each delivery
+type memJobQueue struct {
+	ready   []*domain.Job
+	acked   []string
+	retries map[string]time.Time
+	dead    []*domain.Job
+}
+
+func (q *memJobQueue) Enqueue(ctx context.Context, job *domain.Job) error {
+	q.ready = append(q.ready, job)
+	return nil
+}
+func (q *memJobQueue) Dequeue(ctx context.Context, consumer string, block time.Duration) (*domain.Job, error) {
+	if len(q.ready) == 0 {
+		return nil, nil
+	}
+	job := q.ready[0]
+	q.ready = q.ready[1:]
+	return job, nil
+}
+func (q *memJobQueue) Ack(ctx context.Context, job *domain.Job) error {
+	q.acked = append(q.acked, job.ID)
+	return nil
+}
+func (q *memJobQueue) Retry(ctx context.Context, job *domain.Job, at time.Time) error {
+	q.retries[job.ID] = at
+	return nil
+}
+func (q *memJobQueue) DeadLetter(ctx context.Context, job *domain.Job) error {
+	q.dead = append(q.dead, job)
+	return nil
+}
+func (q *memJobQueue) Reclaim(ctx context.Context, consumer string, minIdle time.Duration) (int, error) {
+	return 0, nil
+}
+func (q *memJobQueue) ListDead(ctx context.Context, limit int) ([]*domain.Job, error) {
+	return q.dead, nil
+}
+
+func newTestJobWorker() (*JobWorker, *memJobQueue) {
+	q := &memJobQueue{retries: map[string]time.Time{}}
+	cfg := &config.Config{JobWorkers: 1, JobMaxAttempts: 3, JobRetryBaseSeconds: 5, JobRetryMaxSeconds: 12, JobClaimTimeoutSeconds: 60, JobConsumer: "test"}
+	return NewJobWorker(q, slog.Default(), cfg), q
+}
+
+func TestJobWorkerRetriesWithBackoffThenDeadLetters(t *testing.T) {
+	w, q := newTestJobWorker()
+	var failed []string
+	w.Handle(domain.JobProvision,
+		func(ctx context.Context, job *domain.Job) error { return errors.New("docker unavailable") },
+		func(ctx context.Context, job *domain.Job) { failed = append(failed, job.ID) },
+	)
+	job := &domain.Job{ID: "provision:c1", Kind: domain.JobProvision}
+
+	before := time.Now()
+	w.run(context.Background(), job)
+	if at, ok := q.retries[job.ID]; !ok || at.Before(before.Add(5*time.Second)) || job.Attempts != 1 || job.LastError != "docker unavailable" {
+		t.Fatalf("expected a retry in 5s after the first failure, got %v attempts=%d", at, job.Attempts)
+	}
+	w.run(context.Background(), job)
+	if at := q.retries[job.ID]; at.Before(before.Add(10 * time.Second)) {
+		t.Fatalf("expected the delay to double, got %v", at.Sub(before))
+	}
+	if len(q.dead) != 0 || len(failed) != 0 {
+		t.Fatal("job must not be dead-lettered before its last attempt")
+	}
+
+	w.run(context.Background(), job)
+	if len(q.dead) != 1 || len(failed) != 1 || failed[0] != job.ID {
+		t.Fatalf("expected the job dead-lettered and its fail hook called, got %d dead, %v failed", len(q.dead), failed)
+	}
+	if w.backoff(5) != 12*time.Second {
+		t.Fatalf("backoff must be capped at the maximum, got %v", w.backoff(5))
+	}
+}
+
+func TestJobWorkerAcksAndDeadLettersUnrunnableJobs(t *testing.T) {
+	w, q := newTestJobWorker()
+	ran := 0
+	w.Handle(domain.JobDelete, func(ctx context.Context, job *domain.Job) error { ran++; return nil }, nil)
+
+	w.run(context.Background(), &domain.Job{ID: "delete:c1", Kind: domain.JobDelete})
+	if ran != 1 || len(q.acked) != 1 {
+		t.Fatalf("expected the job run and acknowledged, ran %d acked %v", ran, q.acked)
+	}
+
+	// Abandoned by crashed workers as often as it may fail
+	w.run(context.Background(), &domain.Job{ID: "delete:c2", Kind: domain.JobDelete, Attempts: 3})
+	w.run(context.Background(), &domain.Job{ID: "mystery:c3", Kind: "mystery"})
+	if ran != 1 || len(q.dead) != 2 {
+		t.Fatalf("expected both jobs dead-lettered without running, ran %d dead %d", ran, len(q.dead))
+	}
+}
+
+func TestJobWorkerLeavesInterruptedJobClaimed(t *testing.T) {
+	w, q := newTestJobWorker()
+	ctx, cancel := context.WithCancel(context.Background())
+	w.Handle(domain.JobSnapshot, func(ctx context.Context, job *domain.Job) error {
+		cancel()
+		return ctx.Err()
+	}, nil)
+
+	job := &domain.Job{ID: "snapshot:s1", Kind: domain.JobSnapshot}
+	w.run(ctx, job)
+	if len(q.acked) != 0 || len(q.retries) != 0 || len(q.dead) != 0 || job.Attempts != 0 {
+		t.Fatal("a job interrupted by shutdown must stay claimed for the restart to reclaim")
+	}
+}
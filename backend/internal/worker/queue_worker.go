@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// QueueProcessor starts queued leases that fit in the free host capacity
+type QueueProcessor interface {
+	Process(ctx context.Context)
+}
+
+// QueueWorker admits queued leases as capacity frees up, e.g. when leases expire or are paused out
+type QueueWorker struct {
+	queue    QueueProcessor
+	logger   *slog.Logger
+	interval time.Duration
+}
+
+// NewQueueWorker creates a worker that checks the provisioning queue every interval
+func NewQueueWorker(queue QueueProcessor, logger *slog.Logger, interval time.Duration) *QueueWorker {
+	return &QueueWorker{
+		queue:    queue,
+		logger:   logger,
+		interval: interval,
+	}
+}
+
+// Start processes the queue until the context is cancelled
+func (w *QueueWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.logger.Info("provisioning queue worker started", slog.Duration("interval", w.interval))
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("provisioning queue worker stopped")
+			return
+		case <-ticker.C:
+			w.queue.Process(ctx)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// ReservationProcessor moves reservations through their states
+type ReservationProcessor interface {
+	Process(ctx context.Context, now time.Time)
+}
+
+// ReservationScheduler provisions reservations shortly before they start and
+// completes them once their window is over
+type ReservationScheduler struct {
+	reservations ReservationProcessor
+	logger       *slog.Logger
+	interval     time.Duration
+}
+
+// NewReservationScheduler creates a scheduler that checks reservations every interval
+func NewReservationScheduler(reservations ReservationProcessor, logger *slog.Logger, interval time.Duration) *ReservationScheduler {
+	return &ReservationScheduler{
+		reservations: reservations,
+		logger:       logger,
+		interval:     interval,
+	}
+}
+
+// Start processes reservations until the context is cancelled. Reservations left
+// provisioning by a restart are picked up on the first run.
+func (s *ReservationScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.logger.Info("reservation scheduler started", slog.Duration("interval", s.interval))
+	s.reservations.Process(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("reservation scheduler stopped")
+			return
+		case <-ticker.C:
+			s.reservations.Process(ctx, time.Now())
+		}
+	}
+}
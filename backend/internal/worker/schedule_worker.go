@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// ScheduleProcessor runs the occurrences of recurring lease schedules that have come
+type ScheduleProcessor interface {
+	Process(ctx context.Context, now time.Time)
+}
+
+// ScheduleWorker creates leases for recurring schedules as their occurrences come
+type ScheduleWorker struct {
+	schedules ScheduleProcessor
+	logger    *slog.Logger
+	interval  time.Duration
+}
+
+// NewScheduleWorker creates a worker that checks schedules every interval
+func NewScheduleWorker(schedules ScheduleProcessor, logger *slog.Logger, interval time.Duration) *ScheduleWorker {
+	return &ScheduleWorker{
+		schedules: schedules,
+		logger:    logger,
+		interval:  interval,
+	}
+}
+
+// Start processes schedules until the context is cancelled. Occurrences that came
+// while the server was down are handled on the first run.
+func (w *ScheduleWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.logger.Info("schedule worker started", slog.Duration("interval", w.interval))
+	w.schedules.Process(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("schedule worker stopped")
+			return
+		case <-ticker.C:
+			w.schedules.Process(ctx, time.Now())
+		}
+	}
+}
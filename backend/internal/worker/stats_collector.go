@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/observability/metrics"
+)
+
+// statsConcurrency bounds parallel stats requests; each takes about a second while Docker measures CPU
+const statsConcurrency = 8
+
+// StatsCollector samples the resource usage of running leases and exports it as
+// Prometheus metrics labeled by tenant and container
+type StatsCollector struct {
+	containerRepository domain.ContainerRepository
+	dockerClient        domain.DockerClient
+	logger              *slog.Logger
+	interval            time.Duration
+	reported            map[string]string // Container ID -> tenant ID of the series currently exported
+}
+
+// NewStatsCollector creates a collector that samples every interval
+func NewStatsCollector(containerRepo domain.ContainerRepository, dockerClient domain.DockerClient, logger *slog.Logger, interval time.Duration) *StatsCollector {
+	return &StatsCollector{
+		containerRepository: containerRepo,
+		dockerClient:        dockerClient,
+		logger:              logger,
+		interval:            interval,
+		reported:            map[string]string{},
+	}
+}
+
+// Start samples running leases until the context is cancelled
+func (c *StatsCollector) Start(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.logger.Info("stats collector started", slog.Duration("interval", c.interval))
+	for {
+		select {
+		case <-ctx.Done():
+			c.logger.Info("stats collector stopped")
+			return
+		case <-ticker.C:
+			c.collect(ctx)
+		}
+	}
+}
+
+// collect samples every running lease and drops the series of leases that stopped
+func (c *StatsCollector) collect(ctx context.Context) {
+	containers, err := c.containerRepository.List()
+	if err != nil {
+		c.logger.Error("failed to list containers for stats", slog.String("error", err.Error()))
+		return
+	}
+
+	now := time.Now()
+	running := map[string]string{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, statsConcurrency)
+	for _, container := range containers {
+		if container.Status != "running" || container.DockerID == "" || !now.Before(container.ExpiryAt) {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(container *domain.Container) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			stats, err := c.dockerClient.Stats(ctx, container.DockerID)
+			if err != nil {
+				c.logger.Debug("failed to sample container stats", slog.String("container_id", container.ID), slog.String("error", err.Error()))
+				return
+			}
+			metrics.ObserveContainerStats(container, *stats)
+			mu.Lock()
+			running[container.ID] = container.TenantID
+			mu.Unlock()
+		}(container)
+	}
+	wg.Wait()
+
+	for id, tenantID := range c.reported {
+		if _, ok := running[id]; !ok {
+			metrics.ForgetContainerStats(tenantID, id)
+		}
+	}
+	c.reported = running
+}
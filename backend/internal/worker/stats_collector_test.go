@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// exportedCPU returns the CPU gauge exported for a container, if any
+func exportedCPU(t *testing.T, containerID string) (float64, bool) {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "containerlease_container_cpu_millicores" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "container" && label.GetValue() == containerID {
+					return m.GetGauge().GetValue(), true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+func TestStatsCollectorExportsRunningLeasesOnly(t *testing.T) {
+	running := &domain.Container{ID: "stats-1", DockerID: "docker-1", TenantID: "tenant-1", Status: "running", CPUMilli: 500, ExpiryAt: time.Now().Add(time.Hour)}
+	stopped := &domain.Container{ID: "stats-2", DockerID: "docker-2", TenantID: "tenant-1", Status: "terminated", ExpiryAt: time.Now().Add(time.Hour)}
+	repo := &memContainerRepo{byID: map[string]*domain.Container{running.ID: running, stopped.ID: stopped}}
+	collector := NewStatsCollector(repo, &fakeDocker{}, slog.Default(), time.Minute)
+
+	collector.collect(context.Background())
+	if _, ok := exportedCPU(t, "stats-1"); !ok {
+		t.Fatal("expected series for the running lease")
+	}
+	if _, ok := exportedCPU(t, "stats-2"); ok {
+		t.Fatal("terminated lease must not be sampled")
+	}
+
+	// Series go away with the lease
+	running.Status = "terminated"
+	collector.collect(context.Background())
+	if _, ok := exportedCPU(t, "stats-1"); ok {
+		t.Fatal("expected series to be removed once the lease stopped")
+	}
+}
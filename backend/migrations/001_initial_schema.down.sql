@@ -0,0 +1,13 @@
+-- Revert Migration 001: drops every table created by the initial schema
+
+DROP TRIGGER IF EXISTS update_tenants_updated_at ON tenants;
+DROP TRIGGER IF EXISTS update_users_updated_at ON users;
+DROP FUNCTION IF EXISTS update_updated_at_column();
+
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS billing_records;
+DROP TABLE IF EXISTS snapshots;
+DROP TABLE IF EXISTS leases;
+DROP TABLE IF EXISTS containers;
+DROP TABLE IF EXISTS tenants;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,14 @@
+-- Revert Migration 002
+-- The docker_id and tenant_id constraints are not restored: rows written while
+-- Postgres was the system of record (pending containers, non-UUID tenants) would violate them
+
+DROP INDEX IF EXISTS idx_containers_tenant_created_at;
+
+ALTER TABLE leases DROP COLUMN IF EXISTS extension_count;
+ALTER TABLE containers DROP COLUMN IF EXISTS log_demo;
+
+ALTER TABLE leases ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE leases ALTER COLUMN expiry_time TYPE TIMESTAMP;
+ALTER TABLE containers ALTER COLUMN last_failure_time TYPE TIMESTAMP;
+ALTER TABLE containers ALTER COLUMN expiry_at TYPE TIMESTAMP;
+ALTER TABLE containers ALTER COLUMN created_at TYPE TIMESTAMP;
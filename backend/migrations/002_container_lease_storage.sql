@@ -0,0 +1,24 @@
+-- Migration 002: Container and lease storage in Postgres
+-- Adapts the containers/leases tables so they can be the system of record
+-- (previously containers and leases only lived in Redis)
+
+-- Pending containers have no Docker ID yet, and self-healing may replace it
+ALTER TABLE containers ALTER COLUMN docker_id DROP NOT NULL;
+ALTER TABLE containers DROP CONSTRAINT IF EXISTS containers_docker_id_key;
+
+-- Tenant IDs come from JWT claims and are not guaranteed to have a tenants row
+ALTER TABLE containers DROP CONSTRAINT IF EXISTS containers_tenant_id_fkey;
+ALTER TABLE containers ALTER COLUMN tenant_id TYPE VARCHAR(255);
+
+-- Store instants with their time zone so expiry comparisons are exact
+ALTER TABLE containers ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE containers ALTER COLUMN expiry_at TYPE TIMESTAMPTZ;
+ALTER TABLE containers ALTER COLUMN last_failure_time TYPE TIMESTAMPTZ;
+ALTER TABLE leases ALTER COLUMN expiry_time TYPE TIMESTAMPTZ;
+ALTER TABLE leases ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+-- Provisioning spec and lease extension tracking
+ALTER TABLE containers ADD COLUMN IF NOT EXISTS log_demo BOOLEAN DEFAULT false;
+ALTER TABLE leases ADD COLUMN IF NOT EXISTS extension_count INTEGER DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_containers_tenant_created_at ON containers(tenant_id, created_at DESC);
@@ -0,0 +1,3 @@
+-- Revert Migration 003
+
+DROP TABLE IF EXISTS tenant_quotas;
@@ -0,0 +1,12 @@
+-- Migration 003: Per-tenant resource quotas
+-- Tenants without a row use the defaults from configuration (-1 = unlimited)
+
+CREATE TABLE tenant_quotas (
+    tenant_id VARCHAR(255) PRIMARY KEY,
+    max_containers INTEGER NOT NULL,
+    max_cpu_milli INTEGER NOT NULL,
+    max_memory_mb INTEGER NOT NULL,
+    max_volume_mb INTEGER NOT NULL,
+    max_snapshots INTEGER NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
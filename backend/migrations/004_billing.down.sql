@@ -0,0 +1,17 @@
+-- Revert Migration 004
+-- Foreign keys are not restored: existing records may reference containers kept only in Redis
+
+DROP INDEX IF EXISTS idx_billing_records_container_id;
+CREATE INDEX idx_billing_records_container_id ON billing_records(container_id);
+
+ALTER TABLE billing_records ALTER COLUMN period_end TYPE TIMESTAMP;
+ALTER TABLE billing_records ALTER COLUMN period_start TYPE TIMESTAMP;
+ALTER TABLE billing_records ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE billing_records DROP COLUMN preset;
+ALTER TABLE billing_records DROP COLUMN volume_mb_hours;
+ALTER TABLE billing_records ALTER COLUMN amount TYPE DECIMAL(10, 2);
+
+ALTER TABLE containers DROP COLUMN preset;
+ALTER TABLE containers DROP COLUMN billed_ms;
+ALTER TABLE containers DROP COLUMN cost_accrued_at;
+ALTER TABLE containers ALTER COLUMN cost TYPE DECIMAL(10, 2);
@@ -0,0 +1,23 @@
+-- Migration 004: Cost accrual and billing records
+-- Containers carry their accrued cost; billing_records receives one row per finished lease
+
+ALTER TABLE containers ALTER COLUMN cost TYPE DECIMAL(14, 6);
+ALTER TABLE containers ADD COLUMN cost_accrued_at TIMESTAMPTZ;
+ALTER TABLE containers ADD COLUMN billed_ms BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE containers ADD COLUMN preset VARCHAR(100);
+
+-- Containers may live only in Redis, and tenant IDs come from JWT claims
+ALTER TABLE billing_records DROP CONSTRAINT IF EXISTS billing_records_container_id_fkey;
+ALTER TABLE billing_records DROP CONSTRAINT IF EXISTS billing_records_tenant_id_fkey;
+ALTER TABLE billing_records ALTER COLUMN tenant_id TYPE VARCHAR(255);
+
+ALTER TABLE billing_records ALTER COLUMN amount TYPE DECIMAL(14, 6);
+ALTER TABLE billing_records ADD COLUMN volume_mb_hours DECIMAL(15, 4) NOT NULL DEFAULT 0;
+ALTER TABLE billing_records ADD COLUMN preset VARCHAR(100);
+ALTER TABLE billing_records ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE billing_records ALTER COLUMN period_start TYPE TIMESTAMPTZ;
+ALTER TABLE billing_records ALTER COLUMN period_end TYPE TIMESTAMPTZ;
+
+-- One record per container makes finalization safe to retry
+DROP INDEX IF EXISTS idx_billing_records_container_id;
+CREATE UNIQUE INDEX idx_billing_records_container_id ON billing_records(container_id);
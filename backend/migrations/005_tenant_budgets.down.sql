@@ -0,0 +1,5 @@
+-- Revert Migration 005
+
+DROP INDEX IF EXISTS idx_billing_records_tenant_period_end;
+DROP TABLE IF EXISTS budget_events;
+DROP TABLE IF EXISTS tenant_budgets;
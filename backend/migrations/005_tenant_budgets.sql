@@ -0,0 +1,27 @@
+-- Migration 005: Monthly tenant spend budgets
+-- Keyed by the JWT tenant ID like tenant_quotas; tenants without a row have no budget
+
+CREATE TABLE tenant_budgets (
+    tenant_id VARCHAR(255) PRIMARY KEY,
+    monthly_limit DECIMAL(14, 2) NOT NULL,
+    alert_threshold DECIMAL(5, 4) NOT NULL,
+    hard_stop BOOLEAN NOT NULL DEFAULT false,
+    shorten_leases BOOLEAN NOT NULL DEFAULT false,
+    grace_minutes INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Alerts and stops are raised at most once per tenant, kind and month
+CREATE TABLE budget_events (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    period TIMESTAMPTZ NOT NULL,
+    spend DECIMAL(14, 6) NOT NULL,
+    monthly_limit DECIMAL(14, 2) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (tenant_id, kind, period)
+);
+
+-- Monthly spend is summed per tenant over recently finished leases
+CREATE INDEX idx_billing_records_tenant_period_end ON billing_records(tenant_id, period_end);
@@ -0,0 +1,3 @@
+-- Revert Migration 006
+
+DROP TABLE IF EXISTS session_recordings;
@@ -0,0 +1,18 @@
+-- Migration 006: Terminal session recordings
+-- No foreign key to containers: recordings are part of the audit trail and outlive the container
+
+CREATE TABLE session_recordings (
+    id VARCHAR(255) PRIMARY KEY,
+    container_id VARCHAR(255) NOT NULL,
+    tenant_id VARCHAR(255) NOT NULL,
+    command TEXT[] NOT NULL DEFAULT '{}',
+    cols INTEGER NOT NULL,
+    rows INTEGER NOT NULL,
+    path TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    started_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_session_recordings_container ON session_recordings(container_id, started_at);
+CREATE INDEX idx_session_recordings_ended_at ON session_recordings(ended_at);
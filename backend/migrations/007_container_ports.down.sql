@@ -0,0 +1,3 @@
+-- Revert Migration 007
+
+ALTER TABLE containers DROP COLUMN ports;
@@ -0,0 +1,3 @@
+-- Migration 007: Container ports reachable through the reverse proxy
+
+ALTER TABLE containers ADD COLUMN ports INTEGER[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,5 @@
+-- Revert Migration 008
+
+ALTER TABLE containers DROP COLUMN image_digest;
+ALTER TABLE containers DROP COLUMN image;
+ALTER TABLE containers ALTER COLUMN image_type TYPE VARCHAR(100);
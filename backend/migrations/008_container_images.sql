@@ -0,0 +1,5 @@
+-- Migration 008: Arbitrary image references and the digest each container ran
+
+ALTER TABLE containers ALTER COLUMN image_type TYPE TEXT;
+ALTER TABLE containers ADD COLUMN image TEXT;
+ALTER TABLE containers ADD COLUMN image_digest TEXT;
@@ -0,0 +1,10 @@
+-- Revert Migration 009
+
+ALTER TABLE containers DROP COLUMN init_output;
+ALTER TABLE containers DROP COLUMN init_exit_code;
+ALTER TABLE containers DROP COLUMN init_status;
+ALTER TABLE containers DROP COLUMN init_script;
+ALTER TABLE containers DROP COLUMN working_dir;
+ALTER TABLE containers DROP COLUMN env;
+ALTER TABLE containers DROP COLUMN command;
+ALTER TABLE containers DROP COLUMN entrypoint;
@@ -0,0 +1,10 @@
+-- Migration 009: Command, environment and init script overrides
+
+ALTER TABLE containers ADD COLUMN entrypoint TEXT[];
+ALTER TABLE containers ADD COLUMN command TEXT[];
+ALTER TABLE containers ADD COLUMN env JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE containers ADD COLUMN working_dir TEXT;
+ALTER TABLE containers ADD COLUMN init_script TEXT;
+ALTER TABLE containers ADD COLUMN init_status TEXT;
+ALTER TABLE containers ADD COLUMN init_exit_code INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE containers ADD COLUMN init_output TEXT;
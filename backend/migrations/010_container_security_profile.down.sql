@@ -0,0 +1,3 @@
+-- Revert Migration 010
+
+ALTER TABLE containers DROP COLUMN security_profile;
@@ -0,0 +1,3 @@
+-- Migration 010: Hardening profile each container runs under
+
+ALTER TABLE containers ADD COLUMN security_profile TEXT;
@@ -0,0 +1,3 @@
+-- Revert Migration 011
+
+ALTER TABLE containers DROP COLUMN egress;
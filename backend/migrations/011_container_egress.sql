@@ -0,0 +1,3 @@
+-- Migration 011: Network egress of the tenant network each container joins
+
+ALTER TABLE containers ADD COLUMN egress TEXT;
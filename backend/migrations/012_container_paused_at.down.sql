@@ -0,0 +1,3 @@
+-- Revert Migration 012
+
+ALTER TABLE containers DROP COLUMN paused_at;
@@ -0,0 +1,3 @@
+-- Migration 012: When a paused container was paused, for pause limits and lease extension
+
+ALTER TABLE containers ADD COLUMN paused_at TIMESTAMPTZ;
@@ -0,0 +1,3 @@
+-- Revert Migration 013
+
+DROP TABLE IF EXISTS reservations;
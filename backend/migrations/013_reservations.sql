@@ -0,0 +1,18 @@
+-- Migration 013: Reservations of leases for a future time window
+-- spec holds the validated provisioning request the leases are created from
+
+CREATE TABLE reservations (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    start_at TIMESTAMPTZ NOT NULL,
+    duration_minutes INTEGER NOT NULL,
+    count INTEGER NOT NULL,
+    spec JSONB NOT NULL,
+    container_ids TEXT[] NOT NULL DEFAULT '{}',
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_reservations_tenant ON reservations(tenant_id, start_at);
+CREATE INDEX idx_reservations_status ON reservations(status, start_at);
@@ -0,0 +1,4 @@
+-- Revert Migration 014
+
+DROP TABLE IF EXISTS lease_schedule_runs;
+DROP TABLE IF EXISTS lease_schedules;
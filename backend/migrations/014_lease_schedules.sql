@@ -0,0 +1,33 @@
+-- Migration 014: Recurring lease schedules and their run history
+-- spec holds the validated provisioning request each occurrence's lease is created from
+
+CREATE TABLE lease_schedules (
+    id VARCHAR(255) PRIMARY KEY,
+    tenant_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    cron VARCHAR(255) NOT NULL,
+    timezone VARCHAR(100) NOT NULL,
+    duration_minutes INTEGER NOT NULL,
+    spec JSONB NOT NULL,
+    restore_snapshot BOOLEAN NOT NULL DEFAULT FALSE,
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    skip_at TIMESTAMPTZ,
+    next_run_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_lease_schedules_tenant ON lease_schedules(tenant_id, created_at);
+CREATE INDEX idx_lease_schedules_due ON lease_schedules(next_run_at) WHERE NOT paused;
+
+CREATE TABLE lease_schedule_runs (
+    id VARCHAR(255) PRIMARY KEY,
+    schedule_id VARCHAR(255) NOT NULL REFERENCES lease_schedules(id) ON DELETE CASCADE,
+    scheduled_at TIMESTAMPTZ NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    container_id VARCHAR(255),
+    snapshot_id VARCHAR(255),
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_lease_schedule_runs_schedule ON lease_schedule_runs(schedule_id, scheduled_at DESC);
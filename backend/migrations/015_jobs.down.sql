@@ -0,0 +1,3 @@
+-- Revert Migration 015
+
+DROP TABLE IF EXISTS jobs;
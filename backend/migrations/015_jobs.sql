@@ -0,0 +1,20 @@
+-- Migration 015: Durable background job queue (JOB_BACKEND=postgres)
+-- status is ready (waiting for run_at), running (claimed by claimed_by) or dead
+
+CREATE TABLE jobs (
+    id VARCHAR(255) PRIMARY KEY,
+    kind VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    run_at TIMESTAMPTZ NOT NULL,
+    claimed_by VARCHAR(255),
+    claimed_at TIMESTAMPTZ,
+    dead_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_jobs_ready ON jobs(run_at) WHERE status = 'ready';
+CREATE INDEX idx_jobs_running ON jobs(claimed_at) WHERE status = 'running';
+CREATE INDEX idx_jobs_dead ON jobs(dead_at) WHERE status = 'dead';
@@ -0,0 +1,3 @@
+-- Revert Migration 016
+
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,13 @@
+-- Migration 016: Idempotency-Key records for deployments without Redis
+-- record holds the request hash and, once the first request has finished, its response
+
+CREATE TABLE idempotency_keys (
+    tenant_id VARCHAR(255) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    record JSONB NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
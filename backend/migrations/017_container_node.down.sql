@@ -0,0 +1,4 @@
+-- Revert Migration 017
+
+ALTER TABLE containers DROP COLUMN node_labels;
+ALTER TABLE containers DROP COLUMN node_id;
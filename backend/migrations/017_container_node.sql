@@ -0,0 +1,4 @@
+-- Migration 017: Docker node each container runs on, and the node labels it asked for
+
+ALTER TABLE containers ADD COLUMN node_id TEXT;
+ALTER TABLE containers ADD COLUMN node_labels JSONB;
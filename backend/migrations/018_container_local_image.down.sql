@@ -0,0 +1,3 @@
+-- Revert Migration 018
+
+ALTER TABLE containers DROP COLUMN local_image;
@@ -0,0 +1,3 @@
+-- Migration 018: Whether a container's image only exists on its node, e.g. a snapshot restored by a schedule
+
+ALTER TABLE containers ADD COLUMN local_image BOOLEAN NOT NULL DEFAULT FALSE;
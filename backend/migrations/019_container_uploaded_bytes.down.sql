@@ -0,0 +1,3 @@
+-- Revert Migration 019
+
+ALTER TABLE containers DROP COLUMN uploaded_bytes;
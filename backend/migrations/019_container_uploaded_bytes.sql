@@ -0,0 +1,3 @@
+-- Migration 019: Bytes uploaded into containers without a volume, counted against the volume quota
+
+ALTER TABLE containers ADD COLUMN uploaded_bytes BIGINT NOT NULL DEFAULT 0;
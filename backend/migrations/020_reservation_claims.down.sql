@@ -0,0 +1,4 @@
+-- Revert Migration 020
+
+ALTER TABLE reservations DROP COLUMN claim_expires_at;
+ALTER TABLE reservations DROP COLUMN claimed_by;
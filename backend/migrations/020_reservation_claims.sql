@@ -0,0 +1,5 @@
+-- Migration 020: The server provisioning a reservation, and until when its claim holds
+-- A provisioning reservation is only resumed by another server once the claim has expired
+
+ALTER TABLE reservations ADD COLUMN claimed_by VARCHAR(255);
+ALTER TABLE reservations ADD COLUMN claim_expires_at TIMESTAMPTZ;
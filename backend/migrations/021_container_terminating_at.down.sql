@@ -0,0 +1,3 @@
+-- Revert Migration 021
+
+ALTER TABLE containers DROP COLUMN terminating_at;
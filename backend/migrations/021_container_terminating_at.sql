@@ -0,0 +1,3 @@
+-- Migration 021: When a container's teardown started, so the exits it causes are not taken for failures
+
+ALTER TABLE containers ADD COLUMN terminating_at TIMESTAMPTZ;
@@ -1,38 +1,177 @@
 package config
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"strconv"
 	"strings"
 )
 
 // Config holds the application configuration
 type Config struct {
-	Environment            string
-	ServerPort             int
-	RedisURL               string
-	DockerHost             string
-	CleanupIntervalMinutes int
-	ContainerMaxDuration   int
-	ContainerMinDuration   int
-	LogLevel               string
-	CORSAllowedOrigins     []string
-	AllowedImages          []string
-	DefaultCPUMilli        int
-	MaxCPUMilli            int
-	DefaultMemoryMB        int
-	MaxMemoryMB            int
-	MaxVolumeMB            int
-	Presets                map[string]Preset
+	Environment             string
+	ServerPort              int
+	RedisURL                string
+	DockerHost              string
+	DockerNodes             []DockerNode // Docker hosts leases are placed on (empty = DockerHost only)
+	PlacementStrategy       string       // How a node is chosen for a lease: binpack or spread
+	NodeHealthSeconds       int          // How often the Docker nodes are health checked
+	CleanupIntervalMinutes  int
+	StatsIntervalSeconds    int               // How often running leases are sampled for Prometheus (0 = off)
+	IdleCheckSeconds        int               // How often running leases are checked for idleness (0 = off)
+	IdleMinutes             int               // How long a lease must stay below the idle thresholds before its policy applies
+	IdleCPUPercent          float64           // CPU use below this percentage of the request counts as idle
+	IdleNetBytesPerSec      float64           // Network traffic below this rate (received + sent) counts as idle
+	IdlePolicy              string            // Default idle policy: off, notify, pause, snapshot-and-terminate or terminate
+	TenantIdlePolicies      map[string]string // Per-tenant overrides of IdlePolicy
+	IdleWebhookURL          string            // Idle notifications are POSTed here as JSON; empty = log only
+	ContainerMaxDuration    int
+	ContainerMinDuration    int
+	LogLevel                string
+	CORSAllowedOrigins      []string
+	AllowedImages           []string                      // Glob patterns of registry/repository names that may be provisioned
+	DeniedImages            []string                      // Glob patterns that are refused even when allowed
+	ImageAliases            map[string]string             // Short names accepted in place of a full image reference
+	RequireImageDigest      bool                          // Only accept references pinned to a digest (name@sha256:...)
+	RegistryCredentials     map[string]RegistryCredential // Registry host -> credentials for pulling private images
+	InitTimeoutSeconds      int                           // How long a container's init script may run
+	SecurityProfiles        map[string]SecurityProfile    // Named container hardening profiles
+	DefaultSecurityProfile  string                        // Profile for leases with no tenant or preset assignment
+	TenantSecurityProfiles  map[string]string             // Tenant -> profile name
+	PresetSecurityProfiles  map[string]string             // Preset -> profile name
+	DefaultCPUMilli         int
+	MaxCPUMilli             int
+	DefaultMemoryMB         int
+	MaxMemoryMB             int
+	MaxVolumeMB             int
+	MaxLeaseExtensions      int            // Default number of times a lease may be extended (-1 = unlimited)
+	TenantLeaseExtensions   map[string]int // Per-tenant overrides of MaxLeaseExtensions
+	PauseLeasePolicy        string         // While a lease is paused its clock keeps running ("run") or is extended ("extend")
+	MaxPauseMinutes         int            // Paused leases are resumed automatically after this long
+	ReservationCheckSeconds int            // How often the scheduler looks for due reservations (0 = off)
+	ReservationLeadMinutes  int            // Reserved leases are provisioned this long before their start time
+	ReservationMaxDays      int            // How far ahead a reservation may start
+	ReservationMaxLeases    int            // Most leases one reservation may book
+	HostCPUMilli            int            // CPU the host can give to leases at once; leases are admitted and reservations booked against it (0 = unlimited)
+	HostMemoryMB            int            // Memory the host can give to leases at once (0 = unlimited)
+	HostCapacityDocker      bool           // Read unset host capacity from Docker's host info at startup
+	QueuePolicy             string         // Order of leases waiting for host capacity: "fifo" or "priority"
+	TenantQueuePriorities   map[string]int // Per-tenant queue priority with the priority policy; higher starts first (default 0)
+	QueueCheckSeconds       int            // How often queued leases are checked against free capacity
+	ScheduleCheckSeconds    int            // How often recurring lease schedules are checked for due occurrences (0 = off)
+	StorageBackend          string         // Where containers and leases are stored: "redis" or "postgres"
+	StorageRedisCache       bool           // With the postgres backend, use Redis as a read-through cache
+	JobBackend              string         // Where background jobs are queued: "redis" (Streams) or "postgres"
+	JobWorkers              int            // Jobs run at once by each server
+	JobMaxAttempts          int            // Deliveries of a failing job before it is dead-lettered
+	JobRetryBaseSeconds     int            // Delay before the first retry; doubled for each further attempt
+	JobRetryMaxSeconds      int            // Longest delay between retries
+	JobClaimTimeoutSeconds  int            // A job claimed this long ago by another server is presumed abandoned and run again
+	JobConsumer             string         // Name this server claims jobs under; must be stable across restarts (default hostname)
+	IdempotencyWindowMins   int            // How long Idempotency-Key responses are kept for replay (0 = keys ignored)
+	TenantMaxContainers     int            // Default per-tenant quotas (-1 = unlimited), overridable per tenant via the admin API
+	TenantMaxCPUMilli       int
+	TenantMaxMemoryMB       int
+	TenantMaxVolumeMB       int
+	TenantMaxSnapshots      int
+	AdminTenantIDs          []string          // Tenants whose users may call /api/admin endpoints
+	PricePerCPUMilliHour    float64           // Dollars per CPU millicore per hour
+	PricePerMemoryMBHour    float64           // Dollars per MB of memory per hour
+	PricePerVolumeMBHour    float64           // Dollars per MB of volume storage per hour
+	BudgetAlertThreshold    float64           // Default fraction of a tenant budget that raises an alert
+	BudgetGraceMinutes      int               // Default time left on running leases after a hard stop
+	BudgetWebhookURL        string            // Budget events are POSTed here as JSON; empty = log only
+	RecordingPolicy         string            // Default terminal recording policy: off, opt-in or mandatory
+	TenantRecordingPolicies map[string]string // Per-tenant overrides of RecordingPolicy
+	RecordingsDir           string            // Where asciicast recordings are written
+	RecordingRetentionDays  int               // Recordings are deleted this long after the session ends
+	FilesRoot               string            // Container directory that file uploads and downloads are confined to
+	ProxyNetwork            string            // Prefix of the per-tenant Docker networks containers join
+	ProxyContainer          string            // The server's own container, joined to tenant networks so the proxy can reach them
+	DefaultEgress           string            // Network egress for tenants without an override: none, internal or full
+	TenantEgress            map[string]string // Per-tenant overrides of DefaultEgress
+	ShareTokenMaxMinutes    int               // Longest lifetime of a proxy share token
+	Presets                 map[string]Preset
+}
+
+// RegistryCredential authenticates image pulls from one registry
+type RegistryCredential struct {
+	Username      string
+	Password      string
+	IdentityToken string
+}
+
+// SecurityProfile is a named set of hardening options applied to leased containers.
+// Zero values leave Docker's defaults in place.
+type SecurityProfile struct {
+	CapDrop         []string       `json:"capDrop"`         // Capabilities to drop; "ALL" drops every capability
+	CapAdd          []string       `json:"capAdd"`          // Capabilities added back after CapDrop
+	NoNewPrivileges bool           `json:"noNewPrivileges"` // Block privilege escalation through setuid binaries
+	PidsLimit       int64          `json:"pidsLimit"`       // Maximum processes in the container (0 = unlimited)
+	ReadOnlyRootfs  bool           `json:"readOnlyRootfs"`  // Mount the image filesystem read-only
+	Tmpfs           map[string]int `json:"tmpfs"`           // Writable tmpfs mounts: path -> size in MB
+	User            string         `json:"user"`            // user[:group] to run as (empty = image default)
+	Seccomp         string         `json:"seccomp"`         // Path to a seccomp profile JSON file, or "unconfined" (empty = Docker default)
+	SeccompJSON     string         `json:"-"`               // Contents of the Seccomp file, loaded at startup
+	Ulimits         []Ulimit       `json:"ulimits"`
+}
+
+// Ulimit is a resource limit set on container processes
+type Ulimit struct {
+	Name string `json:"name"` // e.g. nofile, nproc, core
+	Soft int64  `json:"soft"`
+	Hard int64  `json:"hard"`
+}
+
+// DockerNode is a Docker host of the node pool
+type DockerNode struct {
+	ID       string            `json:"id"`
+	Host     string            `json:"host"`     // Docker endpoint, e.g. tcp://10.0.0.5:2376
+	CPUMilli int               `json:"cpuMilli"` // CPU leases may use on the node at once (0 = unlimited)
+	MemoryMB int               `json:"memoryMB"` // Memory leases may use on the node at once (0 = unlimited)
+	Labels   map[string]string `json:"labels"`   // e.g. {"ssd": "true"}, matched against the nodeLabels of a lease
+}
+
+// Built-in security profiles; a profiles file may override them
+const (
+	SecurityBaseline   = "baseline"
+	SecurityRestricted = "restricted"
+)
+
+// defaultSecurityProfiles returns the built-in profiles
+func defaultSecurityProfiles() map[string]SecurityProfile {
+	return map[string]SecurityProfile{
+		// Keeps what package managers and common tools need as root, drops the rest
+		SecurityBaseline: {
+			CapDrop:         []string{"ALL"},
+			CapAdd:          []string{"CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID", "KILL", "SETGID", "SETUID", "NET_BIND_SERVICE"},
+			NoNewPrivileges: true,
+			PidsLimit:       512,
+			Ulimits:         []Ulimit{{Name: "nofile", Soft: 4096, Hard: 8192}},
+		},
+		// Unprivileged user, no capabilities and a read-only image filesystem
+		SecurityRestricted: {
+			CapDrop:         []string{"ALL"},
+			NoNewPrivileges: true,
+			PidsLimit:       256,
+			ReadOnlyRootfs:  true,
+			Tmpfs:           map[string]int{"/tmp": 64},
+			User:            "65534:65534",
+			Ulimits:         []Ulimit{{Name: "nofile", Soft: 1024, Hard: 4096}, {Name: "core", Soft: 0, Hard: 0}},
+		},
+	}
 }
 
 // Preset defines a provisioning template
 type Preset struct {
-	Name        string
-	CPUMilli    int
-	MemoryMB    int
-	DurationMin int
+	Name         string
+	CPUMilli     int
+	MemoryMB     int
+	DurationMin  int
+	PricePerHour float64 // Flat CPU+memory price; 0 = use the per-resource rates
 }
 
 // Load reads configuration from environment variables
@@ -47,6 +186,46 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid CLEANUP_INTERVAL_MINUTES: %w", err)
 	}
 
+	statsInterval, err := strconv.Atoi(getEnv("STATS_INTERVAL_SECONDS", "30"))
+	if err != nil || statsInterval < 0 {
+		return nil, fmt.Errorf("invalid STATS_INTERVAL_SECONDS: must be a non-negative integer")
+	}
+
+	idleCheckInterval, err := strconv.Atoi(getEnv("IDLE_CHECK_INTERVAL_SECONDS", "60"))
+	if err != nil || idleCheckInterval < 0 {
+		return nil, fmt.Errorf("invalid IDLE_CHECK_INTERVAL_SECONDS: must be a non-negative integer")
+	}
+
+	idleMinutes, err := strconv.Atoi(getEnv("IDLE_MINUTES", "30"))
+	if err != nil || idleMinutes <= 0 {
+		return nil, fmt.Errorf("invalid IDLE_MINUTES: must be a positive integer")
+	}
+
+	idleCPUPercent, err := strconv.ParseFloat(getEnv("IDLE_CPU_PERCENT", "5"), 64)
+	if err != nil || idleCPUPercent < 0 {
+		return nil, fmt.Errorf("invalid IDLE_CPU_PERCENT: must be a non-negative number")
+	}
+
+	idleNetBytesPerSec, err := strconv.ParseFloat(getEnv("IDLE_NETWORK_BYTES_PER_SECOND", "1024"), 64)
+	if err != nil || idleNetBytesPerSec < 0 {
+		return nil, fmt.Errorf("invalid IDLE_NETWORK_BYTES_PER_SECOND: must be a non-negative number")
+	}
+
+	idlePolicy := getEnv("IDLE_POLICY", IdleNotify)
+	if !validIdlePolicy(idlePolicy) {
+		return nil, fmt.Errorf("invalid IDLE_POLICY: %q (expected off, notify, pause, snapshot-and-terminate or terminate)", idlePolicy)
+	}
+
+	tenantIdlePolicies, err := parseStringMapEnv("TENANT_IDLE_POLICIES")
+	if err != nil {
+		return nil, fmt.Errorf("invalid TENANT_IDLE_POLICIES: %w", err)
+	}
+	for tenant, policy := range tenantIdlePolicies {
+		if !validIdlePolicy(policy) {
+			return nil, fmt.Errorf("invalid TENANT_IDLE_POLICIES: %q for tenant %q", policy, tenant)
+		}
+	}
+
 	maxDuration, err := strconv.Atoi(getEnv("CONTAINER_MAX_DURATION_MINUTES", "120"))
 	if err != nil {
 		return nil, fmt.Errorf("invalid CONTAINER_MAX_DURATION_MINUTES: %w", err)
@@ -82,12 +261,262 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid MAX_VOLUME_MB: %w", err)
 	}
 
-	return &Config{
+	maxLeaseExtensions, err := strconv.Atoi(getEnv("MAX_LEASE_EXTENSIONS", "3"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAX_LEASE_EXTENSIONS: %w", err)
+	}
+
+	tenantLeaseExtensions, err := parseIntMapEnv("TENANT_MAX_LEASE_EXTENSIONS")
+	if err != nil {
+		return nil, fmt.Errorf("invalid TENANT_MAX_LEASE_EXTENSIONS: %w", err)
+	}
+
+	pauseLeasePolicy := getEnv("PAUSE_LEASE_POLICY", PauseLeaseRun)
+	if pauseLeasePolicy != PauseLeaseRun && pauseLeasePolicy != PauseLeaseExtend {
+		return nil, fmt.Errorf("invalid PAUSE_LEASE_POLICY: %q (expected run or extend)", pauseLeasePolicy)
+	}
+
+	maxPauseMinutes, err := strconv.Atoi(getEnv("MAX_PAUSE_MINUTES", "60"))
+	if err != nil || maxPauseMinutes <= 0 {
+		return nil, fmt.Errorf("invalid MAX_PAUSE_MINUTES: must be a positive integer")
+	}
+
+	hostCapacityFromDocker, err := strconv.ParseBool(getEnv("HOST_CAPACITY_FROM_DOCKER", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid HOST_CAPACITY_FROM_DOCKER: %w", err)
+	}
+
+	queuePolicy := getEnv("QUEUE_POLICY", QueueFIFO)
+	if queuePolicy != QueueFIFO && queuePolicy != QueuePriority {
+		return nil, fmt.Errorf("invalid QUEUE_POLICY: %q (expected fifo or priority)", queuePolicy)
+	}
+
+	tenantQueuePriorities, err := parseIntMapEnv("TENANT_QUEUE_PRIORITIES")
+	if err != nil {
+		return nil, fmt.Errorf("invalid TENANT_QUEUE_PRIORITIES: %w", err)
+	}
+
+	reservationInts := map[string]int{}
+	for key, def := range map[string]string{
+		"RESERVATION_CHECK_INTERVAL_SECONDS": "30",
+		"RESERVATION_LEAD_MINUTES":           "5",
+		"RESERVATION_MAX_DAYS":               "30",
+		"RESERVATION_MAX_LEASES":             "50",
+		"HOST_CPU_MILLI":                     "0",
+		"HOST_MEMORY_MB":                     "0",
+		"SCHEDULE_CHECK_INTERVAL_SECONDS":    "30",
+		"QUEUE_CHECK_INTERVAL_SECONDS":       "5",
+	} {
+		v, err := strconv.Atoi(getEnv(key, def))
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid %s: must be a non-negative integer", key)
+		}
+		reservationInts[key] = v
+	}
+	if reservationInts["RESERVATION_MAX_LEASES"] == 0 {
+		return nil, fmt.Errorf("invalid RESERVATION_MAX_LEASES: must be a positive integer")
+	}
+	if reservationInts["QUEUE_CHECK_INTERVAL_SECONDS"] == 0 {
+		return nil, fmt.Errorf("invalid QUEUE_CHECK_INTERVAL_SECONDS: must be a positive integer")
+	}
+
+	tenantQuota := map[string]int{}
+	for key, def := range map[string]string{
+		"TENANT_MAX_CONTAINERS": "10",
+		"TENANT_MAX_CPU_MILLI":  "8000",
+		"TENANT_MAX_MEMORY_MB":  "16384",
+		"TENANT_MAX_VOLUME_MB":  "51200",
+		"TENANT_MAX_SNAPSHOTS":  "20",
+	} {
+		n, err := strconv.Atoi(getEnv(key, def))
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("invalid %s: must be a number >= -1", key)
+		}
+		tenantQuota[key] = n
+	}
+
+	pricePerCPUMilliHour, err := strconv.ParseFloat(getEnv("PRICE_PER_CPU_MILLI_HOUR", "0.00003"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PRICE_PER_CPU_MILLI_HOUR: %w", err)
+	}
+
+	pricePerMemoryMBHour, err := strconv.ParseFloat(getEnv("PRICE_PER_MEMORY_MB_HOUR", "0.000005"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PRICE_PER_MEMORY_MB_HOUR: %w", err)
+	}
+
+	pricePerVolumeMBHour, err := strconv.ParseFloat(getEnv("PRICE_PER_VOLUME_MB_HOUR", "0.0000002"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PRICE_PER_VOLUME_MB_HOUR: %w", err)
+	}
+
+	presetPrices, err := parseFloatMapEnv("PRESET_PRICES_PER_HOUR")
+	if err != nil {
+		return nil, fmt.Errorf("invalid PRESET_PRICES_PER_HOUR: %w", err)
+	}
+
+	budgetAlertThreshold, err := strconv.ParseFloat(getEnv("BUDGET_ALERT_THRESHOLD", "0.8"), 64)
+	if err != nil || budgetAlertThreshold <= 0 || budgetAlertThreshold > 1 {
+		return nil, fmt.Errorf("invalid BUDGET_ALERT_THRESHOLD: must be in (0, 1]")
+	}
+
+	budgetGraceMinutes, err := strconv.Atoi(getEnv("BUDGET_GRACE_MINUTES", "10"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid BUDGET_GRACE_MINUTES: %w", err)
+	}
+
+	recordingPolicy := getEnv("RECORDING_POLICY", RecordingOff)
+	if !validRecordingPolicy(recordingPolicy) {
+		return nil, fmt.Errorf("invalid RECORDING_POLICY: %q (expected off, opt-in or mandatory)", recordingPolicy)
+	}
+
+	tenantRecordingPolicies, err := parseStringMapEnv("TENANT_RECORDING_POLICIES")
+	if err != nil {
+		return nil, fmt.Errorf("invalid TENANT_RECORDING_POLICIES: %w", err)
+	}
+	for tenant, policy := range tenantRecordingPolicies {
+		if !validRecordingPolicy(policy) {
+			return nil, fmt.Errorf("invalid TENANT_RECORDING_POLICIES: %q for tenant %q", policy, tenant)
+		}
+	}
+
+	defaultEgress := getEnv("DEFAULT_EGRESS", EgressFull)
+	if !validEgress(defaultEgress) {
+		return nil, fmt.Errorf("invalid DEFAULT_EGRESS: %q (expected none, internal or full)", defaultEgress)
+	}
+
+	tenantEgress, err := parseStringMapEnv("TENANT_EGRESS")
+	if err != nil {
+		return nil, fmt.Errorf("invalid TENANT_EGRESS: %w", err)
+	}
+	for tenant, egress := range tenantEgress {
+		if !validEgress(egress) {
+			return nil, fmt.Errorf("invalid TENANT_EGRESS: %q for tenant %q", egress, tenant)
+		}
+	}
+
+	recordingRetentionDays, err := strconv.Atoi(getEnv("RECORDING_RETENTION_DAYS", "90"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RECORDING_RETENTION_DAYS: %w", err)
+	}
+
+	shareTokenMaxMinutes, err := strconv.Atoi(getEnv("SHARE_TOKEN_MAX_MINUTES", "60"))
+	if err != nil || shareTokenMaxMinutes <= 0 {
+		return nil, fmt.Errorf("invalid SHARE_TOKEN_MAX_MINUTES: must be a positive integer")
+	}
+
+	imageAliases, err := parseStringMapEnv("IMAGE_ALIASES")
+	if err != nil {
+		return nil, fmt.Errorf("invalid IMAGE_ALIASES: %w", err)
+	}
+	if os.Getenv("IMAGE_ALIASES") == "" {
+		imageAliases = map[string]string{"ubuntu": "ubuntu:22.04", "alpine": "alpine:latest"}
+	}
+
+	requireImageDigest, err := strconv.ParseBool(getEnv("REQUIRE_IMAGE_DIGEST", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REQUIRE_IMAGE_DIGEST: %w", err)
+	}
+
+	registryCredentials, err := loadRegistryCredentials(getEnv("REGISTRY_AUTH_FILE", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REGISTRY_AUTH_FILE: %w", err)
+	}
+
+	initScriptTimeout, err := strconv.Atoi(getEnv("INIT_SCRIPT_TIMEOUT_SECONDS", "300"))
+	if err != nil || initScriptTimeout <= 0 {
+		return nil, fmt.Errorf("invalid INIT_SCRIPT_TIMEOUT_SECONDS: must be a positive integer")
+	}
+
+	securityProfiles, err := loadSecurityProfiles(getEnv("SECURITY_PROFILES_FILE", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SECURITY_PROFILES_FILE: %w", err)
+	}
+
+	tenantSecurityProfiles, err := parseStringMapEnv("TENANT_SECURITY_PROFILES")
+	if err != nil {
+		return nil, fmt.Errorf("invalid TENANT_SECURITY_PROFILES: %w", err)
+	}
+
+	presetSecurityProfiles, err := parseStringMapEnv("PRESET_SECURITY_PROFILES")
+	if err != nil {
+		return nil, fmt.Errorf("invalid PRESET_SECURITY_PROFILES: %w", err)
+	}
+
+	storageBackend := getEnv("STORAGE_BACKEND", "redis")
+	if storageBackend != "redis" && storageBackend != "postgres" {
+		return nil, fmt.Errorf("invalid STORAGE_BACKEND: %q (expected redis or postgres)", storageBackend)
+	}
+
+	storageRedisCache, err := strconv.ParseBool(getEnv("STORAGE_REDIS_CACHE", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid STORAGE_REDIS_CACHE: %w", err)
+	}
+
+	jobBackend := getEnv("JOB_BACKEND", "redis")
+	if jobBackend != "redis" && jobBackend != "postgres" {
+		return nil, fmt.Errorf("invalid JOB_BACKEND: %q (expected redis or postgres)", jobBackend)
+	}
+
+	jobInts := map[string]int{}
+	for key, def := range map[string]string{
+		"JOB_WORKERS":               "4",
+		"JOB_MAX_ATTEMPTS":          "5",
+		"JOB_RETRY_BASE_SECONDS":    "5",
+		"JOB_RETRY_MAX_SECONDS":     "300",
+		"JOB_CLAIM_TIMEOUT_SECONDS": "900",
+	} {
+		v, err := strconv.Atoi(getEnv(key, def))
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("invalid %s: must be a positive integer", key)
+		}
+		jobInts[key] = v
+	}
+
+	jobConsumer := getEnv("JOB_CONSUMER_NAME", "")
+	if jobConsumer == "" {
+		if jobConsumer, err = os.Hostname(); err != nil {
+			return nil, fmt.Errorf("invalid JOB_CONSUMER_NAME: not set and no hostname: %w", err)
+		}
+	}
+
+	dockerNodes, err := loadDockerNodes(getEnv("DOCKER_NODES_FILE", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DOCKER_NODES_FILE: %w", err)
+	}
+
+	placementStrategy := getEnv("PLACEMENT_STRATEGY", "spread")
+	if placementStrategy != "binpack" && placementStrategy != "spread" {
+		return nil, fmt.Errorf("invalid PLACEMENT_STRATEGY: %q (expected binpack or spread)", placementStrategy)
+	}
+
+	nodeHealthSeconds, err := strconv.Atoi(getEnv("NODE_HEALTH_INTERVAL_SECONDS", "30"))
+	if err != nil || nodeHealthSeconds <= 0 {
+		return nil, fmt.Errorf("invalid NODE_HEALTH_INTERVAL_SECONDS: must be a positive integer")
+	}
+
+	idempotencyWindow, err := strconv.Atoi(getEnv("IDEMPOTENCY_WINDOW_MINUTES", "1440"))
+	if err != nil || idempotencyWindow < 0 {
+		return nil, fmt.Errorf("invalid IDEMPOTENCY_WINDOW_MINUTES: must be a non-negative integer")
+	}
+
+	cfg := &Config{
 		Environment:            getEnv("ENVIRONMENT", "development"),
 		ServerPort:             port,
 		RedisURL:               getEnv("REDIS_URL", "redis://localhost:6379"),
 		DockerHost:             getEnv("DOCKER_HOST", "unix:///var/run/docker.sock"),
+		DockerNodes:            dockerNodes,
+		PlacementStrategy:      placementStrategy,
+		NodeHealthSeconds:      nodeHealthSeconds,
 		CleanupIntervalMinutes: cleanupInterval,
+		StatsIntervalSeconds:   statsInterval,
+		IdleCheckSeconds:       idleCheckInterval,
+		IdleMinutes:            idleMinutes,
+		IdleCPUPercent:         idleCPUPercent,
+		IdleNetBytesPerSec:     idleNetBytesPerSec,
+		IdlePolicy:             idlePolicy,
+		TenantIdlePolicies:     tenantIdlePolicies,
+		IdleWebhookURL:         getEnv("IDLE_WEBHOOK_URL", ""),
 		ContainerMaxDuration:   maxDuration,
 		ContainerMinDuration:   minDuration,
 		LogLevel:               getEnv("LOG_LEVEL", "info"),
@@ -96,12 +525,68 @@ func Load() (*Config, error) {
 			"http://localhost:3000",
 			"http://frontend:3000",
 		},
-		AllowedImages:   parseCSVEnv("ALLOWED_IMAGES", []string{"ubuntu", "alpine"}),
-		DefaultCPUMilli: defaultCPUMilli,
-		MaxCPUMilli:     maxCPUMilli,
-		DefaultMemoryMB: defaultMemoryMB,
-		MaxMemoryMB:     maxMemoryMB,
-		MaxVolumeMB:     maxVolumeMB,
+		AllowedImages:           parseCSVEnv("ALLOWED_IMAGES", []string{"ubuntu", "alpine"}),
+		DeniedImages:            parseCSVEnv("DENIED_IMAGES", nil),
+		ImageAliases:            imageAliases,
+		RequireImageDigest:      requireImageDigest,
+		RegistryCredentials:     registryCredentials,
+		InitTimeoutSeconds:      initScriptTimeout,
+		SecurityProfiles:        securityProfiles,
+		DefaultSecurityProfile:  getEnv("DEFAULT_SECURITY_PROFILE", SecurityBaseline),
+		TenantSecurityProfiles:  tenantSecurityProfiles,
+		PresetSecurityProfiles:  presetSecurityProfiles,
+		DefaultCPUMilli:         defaultCPUMilli,
+		MaxCPUMilli:             maxCPUMilli,
+		DefaultMemoryMB:         defaultMemoryMB,
+		MaxMemoryMB:             maxMemoryMB,
+		MaxVolumeMB:             maxVolumeMB,
+		MaxLeaseExtensions:      maxLeaseExtensions,
+		TenantLeaseExtensions:   tenantLeaseExtensions,
+		PauseLeasePolicy:        pauseLeasePolicy,
+		MaxPauseMinutes:         maxPauseMinutes,
+		ReservationCheckSeconds: reservationInts["RESERVATION_CHECK_INTERVAL_SECONDS"],
+		ReservationLeadMinutes:  reservationInts["RESERVATION_LEAD_MINUTES"],
+		ReservationMaxDays:      reservationInts["RESERVATION_MAX_DAYS"],
+		ReservationMaxLeases:    reservationInts["RESERVATION_MAX_LEASES"],
+		HostCPUMilli:            reservationInts["HOST_CPU_MILLI"],
+		HostMemoryMB:            reservationInts["HOST_MEMORY_MB"],
+		HostCapacityDocker:      hostCapacityFromDocker,
+		QueuePolicy:             queuePolicy,
+		TenantQueuePriorities:   tenantQueuePriorities,
+		QueueCheckSeconds:       reservationInts["QUEUE_CHECK_INTERVAL_SECONDS"],
+		ScheduleCheckSeconds:    reservationInts["SCHEDULE_CHECK_INTERVAL_SECONDS"],
+		StorageBackend:          storageBackend,
+		JobBackend:              jobBackend,
+		JobWorkers:              jobInts["JOB_WORKERS"],
+		JobMaxAttempts:          jobInts["JOB_MAX_ATTEMPTS"],
+		JobRetryBaseSeconds:     jobInts["JOB_RETRY_BASE_SECONDS"],
+		JobRetryMaxSeconds:      jobInts["JOB_RETRY_MAX_SECONDS"],
+		JobClaimTimeoutSeconds:  jobInts["JOB_CLAIM_TIMEOUT_SECONDS"],
+		JobConsumer:             jobConsumer,
+		IdempotencyWindowMins:   idempotencyWindow,
+		TenantMaxContainers:     tenantQuota["TENANT_MAX_CONTAINERS"],
+		TenantMaxCPUMilli:       tenantQuota["TENANT_MAX_CPU_MILLI"],
+		TenantMaxMemoryMB:       tenantQuota["TENANT_MAX_MEMORY_MB"],
+		TenantMaxVolumeMB:       tenantQuota["TENANT_MAX_VOLUME_MB"],
+		TenantMaxSnapshots:      tenantQuota["TENANT_MAX_SNAPSHOTS"],
+		AdminTenantIDs:          parseCSVEnv("ADMIN_TENANT_IDS", nil),
+		PricePerCPUMilliHour:    pricePerCPUMilliHour,
+		PricePerMemoryMBHour:    pricePerMemoryMBHour,
+		PricePerVolumeMBHour:    pricePerVolumeMBHour,
+		BudgetAlertThreshold:    budgetAlertThreshold,
+		BudgetGraceMinutes:      budgetGraceMinutes,
+		BudgetWebhookURL:        getEnv("BUDGET_WEBHOOK_URL", ""),
+		RecordingPolicy:         recordingPolicy,
+		TenantRecordingPolicies: tenantRecordingPolicies,
+		RecordingsDir:           getEnv("RECORDINGS_DIR", "./data/recordings"),
+		RecordingRetentionDays:  recordingRetentionDays,
+		FilesRoot:               path.Clean(getEnv("FILES_ROOT", "/data")),
+		ProxyNetwork:            getEnv("PROXY_NETWORK", "containerlease"),
+		ProxyContainer:          getEnv("PROXY_CONTAINER", ""),
+		DefaultEgress:           defaultEgress,
+		TenantEgress:            tenantEgress,
+		ShareTokenMaxMinutes:    shareTokenMaxMinutes,
+		StorageRedisCache:       storageRedisCache,
 		Presets: map[string]Preset{
 			"tiny": {
 				Name:        "Tiny (256MB, 250m CPU, 5min)",
@@ -122,7 +607,41 @@ func Load() (*Config, error) {
 				DurationMin: 60,
 			},
 		},
-	}, nil
+	}
+
+	for id, price := range presetPrices {
+		preset, ok := cfg.Presets[id]
+		if !ok {
+			return nil, fmt.Errorf("invalid PRESET_PRICES_PER_HOUR: unknown preset %q", id)
+		}
+		preset.PricePerHour = price
+		cfg.Presets[id] = preset
+	}
+
+	if _, ok := cfg.SecurityProfiles[cfg.DefaultSecurityProfile]; !ok {
+		return nil, fmt.Errorf("invalid DEFAULT_SECURITY_PROFILE: unknown profile %q", cfg.DefaultSecurityProfile)
+	}
+	for key, assignments := range map[string]map[string]string{
+		"TENANT_SECURITY_PROFILES": cfg.TenantSecurityProfiles,
+		"PRESET_SECURITY_PROFILES": cfg.PresetSecurityProfiles,
+	} {
+		for owner, name := range assignments {
+			if _, ok := cfg.SecurityProfiles[name]; !ok {
+				return nil, fmt.Errorf("invalid %s: unknown profile %q for %q", key, name, owner)
+			}
+		}
+	}
+	for preset := range cfg.PresetSecurityProfiles {
+		if _, ok := cfg.Presets[preset]; !ok {
+			return nil, fmt.Errorf("invalid PRESET_SECURITY_PROFILES: unknown preset %q", preset)
+		}
+	}
+
+	if !path.IsAbs(cfg.FilesRoot) || cfg.FilesRoot == "/" {
+		return nil, fmt.Errorf("invalid FILES_ROOT: %q must be an absolute directory other than /", cfg.FilesRoot)
+	}
+
+	return cfg, nil
 }
 
 func getEnv(key, defaultValue string) string {
@@ -148,3 +667,271 @@ func parseCSVEnv(key string, defaultValue []string) []string {
 	}
 	return defaultValue
 }
+
+// parseIntMapEnv parses "key=value,key=value" pairs with integer values
+func parseIntMapEnv(key string) (map[string]int, error) {
+	out := map[string]int{}
+	for _, pair := range parseCSVEnv(key, nil) {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected key=value, got %q", pair)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %q: %w", k, err)
+		}
+		out[strings.TrimSpace(k)] = n
+	}
+	return out, nil
+}
+
+// parseFloatMapEnv parses "key=value,key=value" pairs with decimal values
+func parseFloatMapEnv(key string) (map[string]float64, error) {
+	out := map[string]float64{}
+	for _, pair := range parseCSVEnv(key, nil) {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected key=value, got %q", pair)
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %q: %w", k, err)
+		}
+		out[strings.TrimSpace(k)] = f
+	}
+	return out, nil
+}
+
+// parseStringMapEnv parses "key=value,key=value" pairs
+func parseStringMapEnv(key string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, pair := range parseCSVEnv(key, nil) {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected key=value, got %q", pair)
+		}
+		out[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return out, nil
+}
+
+// loadRegistryCredentials reads the "auths" section of a Docker config.json style file.
+// Entries hold either "auth" (base64 of user:password) or explicit username/password or identitytoken.
+func loadRegistryCredentials(file string) (map[string]RegistryCredential, error) {
+	out := map[string]RegistryCredential{}
+	if file == "" {
+		return out, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Auths map[string]struct {
+			Auth          string `json:"auth"`
+			Username      string `json:"username"`
+			Password      string `json:"password"`
+			IdentityToken string `json:"identitytoken"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	for host, entry := range doc.Auths {
+		cred := RegistryCredential{Username: entry.Username, Password: entry.Password, IdentityToken: entry.IdentityToken}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("invalid auth for %q: %w", host, err)
+			}
+			user, pass, ok := strings.Cut(string(decoded), ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid auth for %q: expected user:password", host)
+			}
+			cred.Username, cred.Password = user, pass
+		}
+		out[RegistryHost(host)] = cred
+	}
+	return out, nil
+}
+
+// RegistryHost normalizes a registry address ("https://index.docker.io/v1/", "ghcr.io")
+// to the host form used in image references
+func RegistryHost(addr string) string {
+	addr = strings.TrimPrefix(strings.TrimPrefix(addr, "https://"), "http://")
+	addr, _, _ = strings.Cut(addr, "/")
+	switch addr {
+	case "index.docker.io", "registry-1.docker.io":
+		return "docker.io"
+	}
+	return addr
+}
+
+// loadSecurityProfiles returns the built-in profiles plus those defined in a JSON file
+// of the form {"name": {"capDrop": ["ALL"], "pidsLimit": 256, ...}}. Seccomp files are read here.
+func loadSecurityProfiles(file string) (map[string]SecurityProfile, error) {
+	profiles := defaultSecurityProfiles()
+	if file == "" {
+		return profiles, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var custom map[string]SecurityProfile
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, err
+	}
+	for name, profile := range custom {
+		if profile.PidsLimit < 0 {
+			return nil, fmt.Errorf("profile %q: pidsLimit must not be negative", name)
+		}
+		for mount, sizeMB := range profile.Tmpfs {
+			if !path.IsAbs(mount) || sizeMB <= 0 {
+				return nil, fmt.Errorf("profile %q: tmpfs %q needs an absolute path and a positive size", name, mount)
+			}
+		}
+		if profile.Seccomp != "" && profile.Seccomp != "unconfined" {
+			seccomp, err := os.ReadFile(profile.Seccomp)
+			if err != nil {
+				return nil, fmt.Errorf("profile %q: %w", name, err)
+			}
+			if !json.Valid(seccomp) {
+				return nil, fmt.Errorf("profile %q: seccomp profile %s is not valid JSON", name, profile.Seccomp)
+			}
+			profile.SeccompJSON = string(seccomp)
+		}
+		profiles[name] = profile
+	}
+	return profiles, nil
+}
+
+// loadDockerNodes reads the node pool from a JSON file holding an array of nodes.
+// The first node is the default one: containers provisioned before the pool was
+// configured are taken to run there.
+func loadDockerNodes(file string) ([]DockerNode, error) {
+	if file == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var nodes []DockerNode
+	if err := json.Unmarshal(data, &nodes); err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, node := range nodes {
+		if node.ID == "" || node.Host == "" {
+			return nil, fmt.Errorf("every node needs an id and a host")
+		}
+		if seen[node.ID] {
+			return nil, fmt.Errorf("node %q is listed twice", node.ID)
+		}
+		if node.CPUMilli < 0 || node.MemoryMB < 0 {
+			return nil, fmt.Errorf("node %q: capacity must not be negative", node.ID)
+		}
+		seen[node.ID] = true
+	}
+	return nodes, nil
+}
+
+// SecurityProfileFor returns the hardening profile name for a lease.
+// A tenant assignment takes precedence over a preset assignment.
+func (c *Config) SecurityProfileFor(tenantID, preset string) string {
+	if name, ok := c.TenantSecurityProfiles[tenantID]; ok {
+		return name
+	}
+	if name, ok := c.PresetSecurityProfiles[preset]; ok && preset != "" {
+		return name
+	}
+	return c.DefaultSecurityProfile
+}
+
+// LeaseExtensionLimit returns how many times a tenant may extend a single lease (-1 = unlimited)
+func (c *Config) LeaseExtensionLimit(tenantID string) int {
+	if limit, ok := c.TenantLeaseExtensions[tenantID]; ok {
+		return limit
+	}
+	return c.MaxLeaseExtensions
+}
+
+// Lease clock policies for paused leases
+const (
+	PauseLeaseRun    = "run"    // The lease expires at its usual time; paused time is lost
+	PauseLeaseExtend = "extend" // The lease is extended by the time spent paused
+)
+
+// Orders of the provisioning queue
+const (
+	QueueFIFO     = "fifo"     // Leases start in the order they were requested
+	QueuePriority = "priority" // Higher tenant priority first, then in the order requested
+)
+
+// QueuePriorityFor returns a tenant's provisioning queue priority; it only applies with the priority policy
+func (c *Config) QueuePriorityFor(tenantID string) int {
+	if c.QueuePolicy != QueuePriority {
+		return 0
+	}
+	return c.TenantQueuePriorities[tenantID]
+}
+
+// Terminal recording policies
+const (
+	RecordingOff       = "off"       // Sessions are never recorded
+	RecordingOptIn     = "opt-in"    // Sessions are recorded when the client asks
+	RecordingMandatory = "mandatory" // Every session is recorded
+)
+
+func validRecordingPolicy(p string) bool {
+	return p == RecordingOff || p == RecordingOptIn || p == RecordingMandatory
+}
+
+// Network egress modes for tenant networks
+const (
+	EgressNone     = "none"     // No network at all
+	EgressInternal = "internal" // Only the tenant's other containers
+	EgressFull     = "full"     // The tenant's containers and the internet
+)
+
+func validEgress(e string) bool {
+	return e == EgressNone || e == EgressInternal || e == EgressFull
+}
+
+// EgressFor returns the network egress mode for a tenant
+func (c *Config) EgressFor(tenantID string) string {
+	if egress, ok := c.TenantEgress[tenantID]; ok {
+		return egress
+	}
+	return c.DefaultEgress
+}
+
+// RecordingPolicyFor returns the terminal recording policy for a tenant
+func (c *Config) RecordingPolicyFor(tenantID string) string {
+	if policy, ok := c.TenantRecordingPolicies[tenantID]; ok {
+		return policy
+	}
+	return c.RecordingPolicy
+}
+
+// Idle lease policies, applied once a lease has stayed below the idle thresholds for IdleMinutes
+const (
+	IdleOff       = "off"                    // Idle leases are left alone
+	IdleNotify    = "notify"                 // An idle event is sent; the lease keeps running
+	IdlePause     = "pause"                  // The container is paused until its terminal or logs are opened
+	IdleSnapshot  = "snapshot-and-terminate" // The container is snapshotted, then terminated
+	IdleTerminate = "terminate"              // The lease ends early
+)
+
+func validIdlePolicy(p string) bool {
+	return p == IdleOff || p == IdleNotify || p == IdlePause || p == IdleSnapshot || p == IdleTerminate
+}
+
+// IdlePolicyFor returns the idle policy for a tenant
+func (c *Config) IdlePolicyFor(tenantID string) string {
+	if policy, ok := c.TenantIdlePolicies[tenantID]; ok {
+		return policy
+	}
+	return c.IdlePolicy
+}
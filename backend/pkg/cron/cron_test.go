@@ -0,0 +1,69 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextWeekdayNight(t *testing.T) {
+	expr, err := Parse("0 1 * * 1-5")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	// Friday 2026-01-23 02:00 -> Monday 01:00
+	from := time.Date(2026, 1, 23, 2, 0, 0, 0, time.UTC)
+	want := time.Date(2026, 1, 26, 1, 0, 0, 0, time.UTC)
+	if got := expr.Next(from); !got.Equal(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	// Strictly after: an occurrence is not returned for its own time
+	if got := expr.Next(want); !got.Equal(want.AddDate(0, 0, 1)) {
+		t.Fatalf("expected Tuesday, got %v", got)
+	}
+}
+
+func TestNextStepsListsAndDays(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 7, 30, 0, time.UTC) // A Thursday
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 1, 1, 0, 15, 0, 0, time.UTC)},
+		{"5,40 9-17/4 * * *", time.Date(2026, 1, 1, 9, 5, 0, 0, time.UTC)},
+		{"0 0 15 * *", time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either matches (the 10th, or Saturday the 3rd)
+		{"0 0 10 * 6", time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		expr, err := Parse(c.expr)
+		if err != nil {
+			t.Fatalf("parse %q: %v", c.expr, err)
+		}
+		if got := expr.Next(from); !got.Equal(c.want) {
+			t.Errorf("%q: expected %v, got %v", c.expr, c.want, got)
+		}
+	}
+}
+
+func TestNextInLocation(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	expr, _ := Parse("0 1 * * *")
+	got := expr.Next(time.Date(2026, 1, 1, 12, 0, 0, 0, loc))
+	if want := time.Date(2026, 1, 2, 1, 0, 0, 0, loc); !got.Equal(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("expected %q to be rejected", expr)
+		}
+	}
+	expr, _ := Parse("0 0 30 2 *")
+	if got := expr.Next(time.Now()); !got.IsZero() {
+		t.Fatalf("February 30th never occurs, got %v", got)
+	}
+}
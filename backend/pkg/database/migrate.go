@@ -0,0 +1,413 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationLockID is the Postgres advisory lock key held while migrations run,
+// so replicas starting at the same time don't apply the same migration twice
+const migrationLockID int64 = 0x636c6d6967 // "clmig"
+
+// Migration is a single versioned schema change loaded from the migrations directory.
+//
+// Files are named NNN_description.sql (or NNN_description.up.sql) with an optional
+// NNN_description.down.sql that reverts it.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // sha256 of the up script
+}
+
+// MigrationStatus describes whether a migration has been applied
+type MigrationStatus struct {
+	Migration
+	Applied          bool
+	AppliedAt        time.Time
+	ChecksumMismatch bool // the file changed after it was applied
+}
+
+// Migrator applies and reverts migrations, recording them in schema_migrations
+type Migrator struct {
+	db     *sql.DB
+	source fs.FS
+	logger *slog.Logger
+
+	baselineTable   string
+	baselineVersion int
+}
+
+// NewMigrator creates a migrator reading .sql files from source
+func NewMigrator(db *sql.DB, source fs.FS, logger *slog.Logger) *Migrator {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Migrator{db: db, source: source, logger: logger}
+}
+
+// WithLegacyBaseline marks migrations up to version as applied when schema_migrations is empty
+// but table already exists, i.e. the database was set up before migrations were tracked
+func (m *Migrator) WithLegacyBaseline(table string, version int) *Migrator {
+	m.baselineTable = table
+	m.baselineVersion = version
+	return m
+}
+
+// LoadMigrations reads and orders the migrations in source
+func LoadMigrations(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		version, name, direction, err := parseMigrationName(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		data, err := fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: name}
+			byVersion[version] = mig
+		} else if mig.Name != name {
+			return nil, fmt.Errorf("migration version %d used by both %q and %q", version, mig.Name, name)
+		}
+
+		switch direction {
+		case "up":
+			if mig.Up != "" {
+				return nil, fmt.Errorf("duplicate up migration for version %d", version)
+			}
+			mig.Up = string(data)
+			sum := sha256.Sum256(data)
+			mig.Checksum = hex.EncodeToString(sum[:])
+		case "down":
+			mig.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has a down script but no up script", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// parseMigrationName splits "001_name.up.sql" into its version, name and direction
+func parseMigrationName(filename string) (int, string, string, error) {
+	base := strings.TrimSuffix(filename, ".sql")
+	direction := "up"
+	switch {
+	case strings.HasSuffix(base, ".down"):
+		direction = "down"
+		base = strings.TrimSuffix(base, ".down")
+	case strings.HasSuffix(base, ".up"):
+		base = strings.TrimSuffix(base, ".up")
+	}
+
+	prefix, name, ok := strings.Cut(base, "_")
+	if !ok || name == "" {
+		return 0, "", "", fmt.Errorf("invalid migration filename %q (expected NNN_name.sql)", filename)
+	}
+	version, err := strconv.Atoi(prefix)
+	if err != nil || version <= 0 {
+		return 0, "", "", fmt.Errorf("invalid migration version in %q", filename)
+	}
+	return version, name, direction, nil
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// Status reports every known migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := LoadMigrations(m.source)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.ensureTable(ctx, m.db); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, mig := range migrations {
+		s := MigrationStatus{Migration: mig}
+		if a, ok := applied[mig.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = a.appliedAt
+			s.ChecksumMismatch = a.checksum != mig.Checksum
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// Up applies all pending migrations in version order, each in its own transaction.
+// It refuses to run if an applied migration's file has changed since.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	migrations, err := LoadMigrations(m.source)
+	if err != nil {
+		return 0, err
+	}
+
+	conn, unlock, err := m.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return 0, err
+	}
+	if err := m.applyBaseline(ctx, conn, migrations); err != nil {
+		return 0, err
+	}
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, mig := range migrations {
+		if a, ok := applied[mig.Version]; ok && a.checksum != mig.Checksum {
+			return 0, fmt.Errorf("migration %d_%s was modified after being applied (checksum mismatch)", mig.Version, mig.Name)
+		}
+	}
+
+	count := 0
+	for _, mig := range migrations {
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+
+		start := time.Now()
+		err := inTx(ctx, conn, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, mig.Up); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+				mig.Version, mig.Name, mig.Checksum,
+			)
+			return err
+		})
+		if err != nil {
+			return count, fmt.Errorf("migration %d_%s failed: %w", mig.Version, mig.Name, err)
+		}
+
+		count++
+		m.logger.Info("migration applied",
+			slog.Int("version", mig.Version),
+			slog.String("name", mig.Name),
+			slog.Duration("duration", time.Since(start)),
+		)
+	}
+	return count, nil
+}
+
+// Down reverts the most recently applied migrations, up to steps of them
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	migrations, err := LoadMigrations(m.source)
+	if err != nil {
+		return 0, err
+	}
+	byVersion := make(map[int]Migration, len(migrations))
+	for _, mig := range migrations {
+		byVersion[mig.Version] = mig
+	}
+
+	conn, unlock, err := m.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return 0, err
+	}
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	versions := make([]int, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+	count := 0
+	for _, version := range versions {
+		if count >= steps {
+			break
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			return count, fmt.Errorf("migration %d is applied but its file is missing", version)
+		}
+		if mig.Down == "" {
+			return count, fmt.Errorf("migration %d_%s has no down migration", mig.Version, mig.Name)
+		}
+
+		err := inTx(ctx, conn, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, mig.Down); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version)
+			return err
+		})
+		if err != nil {
+			return count, fmt.Errorf("reverting migration %d_%s failed: %w", mig.Version, mig.Name, err)
+		}
+
+		count++
+		m.logger.Info("migration reverted", slog.Int("version", mig.Version), slog.String("name", mig.Name))
+	}
+	return count, nil
+}
+
+// execer is satisfied by *sql.DB and *sql.Conn
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, db execer) error {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum VARCHAR(64) NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context, db execer) (map[int]appliedMigration, error) {
+	rows, err := db.QueryContext(ctx, `SELECT version, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int]appliedMigration{}
+	for rows.Next() {
+		var version int
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.checksum, &a.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		applied[version] = a
+	}
+	return applied, rows.Err()
+}
+
+// applyBaseline records legacy migrations as applied for databases created by the old runner
+func (m *Migrator) applyBaseline(ctx context.Context, db execer, migrations []Migration) error {
+	if m.baselineTable == "" {
+		return nil
+	}
+
+	var tracked int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations`).Scan(&tracked); err != nil {
+		return fmt.Errorf("failed to count schema_migrations: %w", err)
+	}
+	if tracked > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := db.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, m.baselineTable).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check for existing schema: %w", err)
+	}
+	if !exists {
+		return nil
+	}
+
+	for _, mig := range migrations {
+		if mig.Version > m.baselineVersion {
+			break
+		}
+		if _, err := db.ExecContext(ctx,
+			`INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)`,
+			mig.Version, mig.Name, mig.Checksum,
+		); err != nil {
+			return fmt.Errorf("failed to record baseline migration %d: %w", mig.Version, err)
+		}
+		m.logger.Info("existing schema detected, marked migration as applied",
+			slog.Int("version", mig.Version),
+			slog.String("name", mig.Name),
+		)
+	}
+	return nil
+}
+
+// lock takes the migration advisory lock on a dedicated connection
+func (m *Migrator) lock(ctx context.Context) (*sql.Conn, func(), error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	m.logger.Debug("waiting for migration lock")
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+
+	unlock := func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+			m.logger.Warn("failed to release migration lock", slog.String("error", err.Error()))
+		}
+		conn.Close()
+	}
+	return conn, unlock, nil
+}
+
+func inTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
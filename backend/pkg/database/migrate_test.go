@@ -0,0 +1,71 @@
+package database
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrationsOrdersAndPairsFiles(t *testing.T) {
+	source := fstest.MapFS{
+		"010_add_index.up.sql":      {Data: []byte("CREATE INDEX a ON t(a);")},
+		"010_add_index.down.sql":    {Data: []byte("DROP INDEX a;")},
+		"002_second.sql":            {Data: []byte("ALTER TABLE t ADD COLUMN b INT;")},
+		"001_initial.sql":           {Data: []byte("CREATE TABLE t (a INT);")},
+		"001_initial.down.sql":      {Data: []byte("DROP TABLE t;")},
+		"README.md":                 {Data: []byte("not a migration")},
+		"subdir/003_ignored.up.sql": {Data: []byte("SELECT 1;")},
+	}
+
+	migrations, err := LoadMigrations(source)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(migrations) != 3 {
+		t.Fatalf("expected 3 migrations, got %d", len(migrations))
+	}
+
+	wantVersions := []int{1, 2, 10}
+	for i, mig := range migrations {
+		if mig.Version != wantVersions[i] {
+			t.Fatalf("expected version %d at position %d, got %d", wantVersions[i], i, mig.Version)
+		}
+		if mig.Checksum == "" {
+			t.Fatalf("expected checksum for migration %d", mig.Version)
+		}
+	}
+	if migrations[0].Name != "initial" || migrations[0].Down != "DROP TABLE t;" {
+		t.Fatalf("expected initial migration with down script, got %+v", migrations[0])
+	}
+	if migrations[1].Down != "" {
+		t.Fatalf("expected no down script for migration 2")
+	}
+}
+
+func TestLoadMigrationsChecksumTracksContent(t *testing.T) {
+	a, err := LoadMigrations(fstest.MapFS{"001_x.sql": {Data: []byte("SELECT 1;")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := LoadMigrations(fstest.MapFS{"001_x.sql": {Data: []byte("SELECT 2;")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a[0].Checksum == b[0].Checksum {
+		t.Fatalf("expected checksum to change with file content")
+	}
+}
+
+func TestLoadMigrationsRejectsInvalidFiles(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"bad name":         {"initial.sql": {Data: []byte("SELECT 1;")}},
+		"bad version":      {"abc_initial.sql": {Data: []byte("SELECT 1;")}},
+		"version conflict": {"001_a.sql": {Data: []byte("SELECT 1;")}, "001_b.sql": {Data: []byte("SELECT 1;")}},
+		"duplicate up":     {"001_a.sql": {Data: []byte("SELECT 1;")}, "001_a.up.sql": {Data: []byte("SELECT 1;")}},
+		"down without up":  {"001_a.down.sql": {Data: []byte("SELECT 1;")}},
+	}
+	for name, source := range cases {
+		if _, err := LoadMigrations(source); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
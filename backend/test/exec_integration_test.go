@@ -0,0 +1,136 @@
+package test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/handler"
+	"github.com/aryan0dhankhar/containerlease/internal/security"
+	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+	"github.com/gorilla/websocket"
+)
+
+// fakeExecSession echoes nothing on its own; the test drives output through the pipe
+type fakeExecSession struct {
+	out     *io.PipeReader
+	outW    *io.PipeWriter
+	mu      sync.Mutex
+	input   []byte
+	resizes [][2]uint
+}
+
+func newFakeExecSession() *fakeExecSession {
+	r, w := io.Pipe()
+	return &fakeExecSession{out: r, outW: w}
+}
+
+func (s *fakeExecSession) Read(p []byte) (int, error) { return s.out.Read(p) }
+func (s *fakeExecSession) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.input = append(s.input, p...)
+	return len(p), nil
+}
+func (s *fakeExecSession) Close() error { return s.outW.Close() }
+func (s *fakeExecSession) Resize(ctx context.Context, cols, rows uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resizes = append(s.resizes, [2]uint{cols, rows})
+	return nil
+}
+
+type execDockerClient struct {
+	mockDockerClient
+	session *fakeExecSession
+	opts    domain.ExecOptions
+}
+
+func (m *execDockerClient) Exec(ctx context.Context, containerID string, opts domain.ExecOptions) (domain.ExecSession, error) {
+	m.opts = opts
+	return m.session, nil
+}
+
+func newExecServer(t *testing.T, tenantID string, container *domain.Container) (*httptest.Server, *execDockerClient) {
+	repo := &mockContainerRepository{containers: map[string]*domain.Container{container.ID: container}}
+	docker := &execDockerClient{session: newFakeExecSession()}
+	execHandler := handler.NewExecHandler(docker, repo, slog.Default(), nil, security.NewAuthorizationService(slog.Default()), nil)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /ws/exec/{id}", execHandler)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r.WithContext(middleware.SetTenantInContext(r.Context(), tenantID)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, docker
+}
+
+func TestExecSessionEndsAtLeaseExpiry(t *testing.T) {
+	srv, docker := newExecServer(t, "tenant-1", &domain.Container{
+		ID: "c1", DockerID: "docker-1", TenantID: "tenant-1", Status: "running",
+		ExpiryAt: time.Now().Add(1500 * time.Millisecond),
+	})
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/exec/c1?cols=100&rows=30"
+	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer ws.Close()
+
+	// Output flows to the client as binary frames
+	go func() { _, _ = docker.session.outW.Write([]byte("$ ")) }()
+	msgType, data, err := ws.ReadMessage()
+	if err != nil || msgType != websocket.BinaryMessage || string(data) != "$ " {
+		t.Fatalf("expected prompt, got type=%d data=%q err=%v", msgType, data, err)
+	}
+
+	if docker.opts.Cols != 100 || docker.opts.Rows != 30 || docker.opts.Cmd[0] != "/bin/sh" {
+		t.Fatalf("unexpected exec options: %+v", docker.opts)
+	}
+
+	// Binary frames and input messages reach stdin; resize messages resize the TTY
+	_ = ws.WriteMessage(websocket.BinaryMessage, []byte("ls\n"))
+	_ = ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"input","data":"pwd\n"}`))
+	_ = ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"resize","cols":120,"rows":40}`))
+
+	// The server closes the socket once the lease runs out
+	_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = ws.ReadMessage()
+	var closeErr *websocket.CloseError
+	if ce, ok := err.(*websocket.CloseError); ok {
+		closeErr = ce
+	}
+	if closeErr == nil || closeErr.Code != websocket.CloseNormalClosure || closeErr.Text != "lease expired" {
+		t.Fatalf("expected close with 'lease expired', got %v", err)
+	}
+
+	docker.session.mu.Lock()
+	defer docker.session.mu.Unlock()
+	if string(docker.session.input) != "ls\npwd\n" {
+		t.Fatalf("unexpected stdin: %q", docker.session.input)
+	}
+	if len(docker.session.resizes) != 1 || docker.session.resizes[0] != [2]uint{120, 40} {
+		t.Fatalf("unexpected resizes: %v", docker.session.resizes)
+	}
+}
+
+func TestExecRejectsOtherTenants(t *testing.T) {
+	srv, _ := newExecServer(t, "tenant-2", &domain.Container{
+		ID: "c1", DockerID: "docker-1", TenantID: "tenant-1", Status: "running",
+		ExpiryAt: time.Now().Add(time.Hour),
+	})
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/exec/c1"
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403, got resp=%v err=%v", resp, err)
+	}
+}
@@ -0,0 +1,152 @@
+package test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/handler"
+	"github.com/aryan0dhankhar/containerlease/internal/observability/metrics"
+	"github.com/aryan0dhankhar/containerlease/internal/security"
+	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+	"github.com/aryan0dhankhar/containerlease/internal/service"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+)
+
+// transferTimeout stands in for the server's read and write timeouts
+const transferTimeout = 200 * time.Millisecond
+
+// slowReader yields one chunk per interval, so a transfer outlasts the server's timeouts
+type slowReader struct {
+	chunks   [][]byte
+	interval time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(r.interval)
+	n := copy(p, r.chunks[0])
+	r.chunks[0] = r.chunks[0][n:]
+	if len(r.chunks[0]) == 0 {
+		r.chunks = r.chunks[1:]
+	}
+	return n, nil
+}
+
+// filesDockerClient keeps the last file copied into a container and serves it back slowly
+type filesDockerClient struct {
+	mockDockerClient
+	uploaded []byte
+}
+
+func (m *filesDockerClient) CopyTo(ctx context.Context, containerID string, dstDir string, archive io.Reader) error {
+	tr := tar.NewReader(archive)
+	if _, err := tr.Next(); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(tr)
+	m.uploaded = data
+	return err
+}
+
+func (m *filesDockerClient) CopyFrom(ctx context.Context, containerID string, srcPath string) (io.ReadCloser, *domain.FileStat, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	_ = tw.WriteHeader(&tar.Header{Name: "notes.txt", Mode: 0o644, Size: int64(len(m.uploaded)), Typeflag: tar.TypeReg})
+	_, _ = tw.Write(m.uploaded)
+	_ = tw.Close()
+
+	// Split the archive so the download spans several write timeouts
+	data := buf.Bytes()
+	var chunks [][]byte
+	for len(data) > 0 {
+		n := min(len(data), 512)
+		chunks = append(chunks, data[:n])
+		data = data[n:]
+	}
+	stat := &domain.FileStat{Name: "notes.txt", Size: int64(len(m.uploaded)), Mode: 0o644, ModTime: time.Now()}
+	return io.NopCloser(&slowReader{chunks: chunks, interval: transferTimeout / 2}), stat, nil
+}
+
+// newFilesServer serves the files endpoints behind the server's middleware chain and short
+// timeouts; the tenant is taken from the X-Tenant header in place of a token
+func newFilesServer(t *testing.T) *httptest.Server {
+	container := &domain.Container{
+		ID: "c1", DockerID: "docker-1", TenantID: "tenant-1", Status: "running",
+		ExpiryAt: time.Now().Add(time.Hour),
+	}
+	repo := &mockContainerRepository{containers: map[string]*domain.Container{container.ID: container}}
+	cfg := &config.Config{FilesRoot: "/data", MaxVolumeMB: 10}
+	files := service.NewFileService(&filesDockerClient{}, repo, slog.Default(), cfg)
+	filesHandler := handler.NewFilesHandler(files, repo, slog.Default(), security.NewAuthorizationService(slog.Default()))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/containers/{id}/files", filesHandler.Download)
+	mux.HandleFunc("PUT /api/containers/{id}/files", filesHandler.Upload)
+	store := &memIdempotencyStore{records: map[string]*domain.IdempotencyRecord{}}
+	idempotent := middleware.IdempotencyMiddleware(store, time.Hour, slog.Default())(mux)
+	withTenant := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idempotent.ServeHTTP(w, r.WithContext(middleware.SetTenantInContext(r.Context(), r.Header.Get("X-Tenant"))))
+	})
+
+	server := httptest.NewUnstartedServer(otelhttp.NewHandler(metrics.HTTPMetricsMiddleware(withTenant), "http.server"))
+	server.Config.ReadTimeout = transferTimeout
+	server.Config.WriteTimeout = transferTimeout
+	server.Start()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFileTransfersOutlastServerTimeouts(t *testing.T) {
+	server := newFilesServer(t)
+	content := strings.Repeat("0123456789abcdef", 128)
+
+	// The body arrives over several read timeouts
+	var chunks [][]byte
+	for i := 0; i < len(content); i += 512 {
+		chunks = append(chunks, []byte(content[i:i+512]))
+	}
+	body := &slowReader{chunks: chunks, interval: transferTimeout / 2}
+	req, _ := http.NewRequest(http.MethodPut, server.URL+"/api/containers/c1/files?path=notes.txt", body)
+	req.ContentLength = int64(len(content))
+	req.Header.Set("X-Tenant", "tenant-1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected the slow upload to complete, got %d", resp.StatusCode)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/api/containers/c1/files?path=notes.txt", nil)
+	req.Header.Set("X-Tenant", "tenant-1")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("expected the slow download to complete: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || string(data) != content {
+		t.Fatalf("expected the uploaded file back, got %d with %d bytes", resp.StatusCode, len(data))
+	}
+	if got := resp.Header.Get("Content-Length"); got != strconv.Itoa(len(content)) {
+		t.Fatalf("expected Content-Length %d, got %s", len(content), got)
+	}
+}
@@ -0,0 +1,174 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+)
+
+// memIdempotencyStore keeps records in memory and ignores expiry
+type memIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*domain.IdempotencyRecord
+}
+
+func (s *memIdempotencyStore) Reserve(ctx context.Context, tenantID, key string, rec *domain.IdempotencyRecord, ttl time.Duration) (*domain.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[tenantID+":"+key]; ok {
+		return existing, nil
+	}
+	s.records[tenantID+":"+key] = rec
+	return nil, nil
+}
+
+func (s *memIdempotencyStore) Save(ctx context.Context, tenantID, key string, rec *domain.IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[tenantID+":"+key] = rec
+	return nil
+}
+
+func (s *memIdempotencyStore) Release(ctx context.Context, tenantID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, tenantID+":"+key)
+	return nil
+}
+
+// newIdempotentServer serves POST /api/provision, counting the containers it creates;
+// the tenant is taken from the X-Tenant header in place of a token
+func newIdempotentServer(t *testing.T, fail *bool) (*httptest.Server, *int) {
+	created := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/provision", func(w http.ResponseWriter, r *http.Request) {
+		if *fail {
+			http.Error(w, "docker unavailable", http.StatusInternalServerError)
+			return
+		}
+		created++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id":"c%d","request":%s}`, created, body)
+	})
+	store := &memIdempotencyStore{records: map[string]*domain.IdempotencyRecord{}}
+	idempotent := middleware.IdempotencyMiddleware(store, time.Hour, slog.Default())(mux)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idempotent.ServeHTTP(w, r.WithContext(middleware.SetTenantInContext(r.Context(), r.Header.Get("X-Tenant"))))
+	}))
+	t.Cleanup(server.Close)
+	return server, &created
+}
+
+func provisionWithKey(t *testing.T, url, tenant, key, body string) (*http.Response, string) {
+	req, _ := http.NewRequest(http.MethodPost, url+"/api/provision", strings.NewReader(body))
+	req.Header.Set("X-Tenant", tenant)
+	req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("provision: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp, string(data)
+}
+
+func TestIdempotencyKeyReplaysProvisioning(t *testing.T) {
+	fail := false
+	server, created := newIdempotentServer(t, &fail)
+	body := `{"imageType":"ubuntu","durationMinutes":30}`
+
+	first, firstBody := provisionWithKey(t, server.URL, "tenant-a", "ci-run-1", body)
+	retry, retryBody := provisionWithKey(t, server.URL, "tenant-a", "ci-run-1", body)
+	if *created != 1 {
+		t.Fatalf("expected one container for a retried request, got %d", *created)
+	}
+	if retry.StatusCode != http.StatusCreated || retryBody != firstBody || retry.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("expected the original response replayed, got %d %q", retry.StatusCode, retryBody)
+	}
+	if first.Header.Get(middleware.IdempotentReplayedHeader) != "" || retry.Header.Get(middleware.IdempotentReplayedHeader) != "true" {
+		t.Fatal("only the replayed response must be marked as replayed")
+	}
+
+	// Keys are per tenant
+	if resp, _ := provisionWithKey(t, server.URL, "tenant-b", "ci-run-1", body); resp.StatusCode != http.StatusCreated || *created != 2 {
+		t.Fatalf("expected another tenant's key to be independent, got %d", resp.StatusCode)
+	}
+
+	if resp, _ := provisionWithKey(t, server.URL, "tenant-a", "ci-run-1", `{"imageType":"alpine"}`); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for the key reused with another body, got %d", resp.StatusCode)
+	}
+}
+
+func TestIdempotencyKeyReleasedWhenHandlerPanics(t *testing.T) {
+	store := &memIdempotencyStore{records: map[string]*domain.IdempotencyRecord{}}
+	handler := middleware.IdempotencyMiddleware(store, time.Hour, slog.Default())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("nil map")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/provision", strings.NewReader(`{}`))
+	req.Header.Set(middleware.IdempotencyKeyHeader, "ci-run-3")
+	req = req.WithContext(middleware.SetTenantInContext(req.Context(), "tenant-a"))
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected the panic passed on to the recovery middleware")
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	if len(store.records) != 0 {
+		t.Fatalf("expected the key released, got %v", store.records)
+	}
+}
+
+func TestIdempotencyKeyRetriesServerErrors(t *testing.T) {
+	fail := true
+	server, created := newIdempotentServer(t, &fail)
+	body := `{"imageType":"ubuntu"}`
+
+	if resp, _ := provisionWithKey(t, server.URL, "tenant-a", "ci-run-2", body); resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected the failure passed through, got %d", resp.StatusCode)
+	}
+	fail = false
+	resp, _ := provisionWithKey(t, server.URL, "tenant-a", "ci-run-2", body)
+	if resp.StatusCode != http.StatusCreated || *created != 1 || resp.Header.Get(middleware.IdempotentReplayedHeader) != "" {
+		t.Fatalf("expected a server error not to be stored, got %d", resp.StatusCode)
+	}
+}
+
+func TestIdempotencyRecorderUnwrapsForResponseController(t *testing.T) {
+	store := &memIdempotencyStore{records: map[string]*domain.IdempotencyRecord{}}
+	var deadlineErr error
+	idempotent := middleware.IdempotencyMiddleware(store, time.Hour, slog.Default())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadlineErr = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		w.WriteHeader(http.StatusCreated)
+	}))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idempotent.ServeHTTP(w, r.WithContext(middleware.SetTenantInContext(r.Context(), "tenant-a")))
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/api/provision", strings.NewReader(`{}`))
+	req.Header.Set(middleware.IdempotencyKeyHeader, "deadline")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("provision: %v", err)
+	}
+	resp.Body.Close()
+	if deadlineErr != nil {
+		t.Fatalf("expected the recorder to reach the connection's writer, got %v", deadlineErr)
+	}
+}
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/handler"
+	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+	"github.com/gorilla/websocket"
+)
+
+type logsDockerClient struct {
+	mockDockerClient
+	streams int
+}
+
+func (m *logsDockerClient) StreamLogs(ctx context.Context, containerID string) (io.ReadCloser, error) {
+	m.streams++
+	return io.NopCloser(strings.NewReader("hello\n")), nil
+}
+
+func TestLogsRejectsOtherTenants(t *testing.T) {
+	container := &domain.Container{
+		ID: "c1", DockerID: "docker-1", TenantID: "tenant-1", Status: "paused",
+		ExpiryAt: time.Now().Add(time.Hour),
+	}
+	repo := &mockContainerRepository{containers: map[string]*domain.Container{container.ID: container}}
+	docker := &logsDockerClient{}
+	logsHandler := handler.NewLogsHandler(docker, slog.Default(), nil, repo)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /ws/logs/{id}", logsHandler)
+	mux.HandleFunc("GET /api/logs", logsHandler.GetLogs)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r.WithContext(middleware.SetTenantInContext(r.Context(), "tenant-2")))
+	}))
+	t.Cleanup(srv.Close)
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/logs/c1"
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403, got resp=%v err=%v", resp, err)
+	}
+
+	rest, err := http.Get(srv.URL + "/api/logs?container=c1")
+	if err != nil {
+		t.Fatalf("get logs: %v", err)
+	}
+	rest.Body.Close()
+	if rest.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 from the REST endpoint, got %d", rest.StatusCode)
+	}
+	if docker.streams != 0 {
+		t.Fatal("another tenant's logs must not be streamed")
+	}
+}
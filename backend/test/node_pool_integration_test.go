@@ -0,0 +1,121 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/infrastructure/docker"
+)
+
+// fakeNode is a Docker host holding containers in memory
+type fakeNode struct {
+	domain.DockerClient
+	name       string
+	mu         sync.Mutex
+	containers map[string]bool // Docker ID -> running
+	down       bool
+}
+
+func newFakeNode(name string) *fakeNode {
+	return &fakeNode{name: name, containers: map[string]bool{}}
+}
+
+func (n *fakeNode) CreateContainer(ctx context.Context, spec domain.ContainerSpec) (string, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	id := fmt.Sprintf("%s-%d", n.name, len(n.containers)+1)
+	n.containers[id] = true
+	return id, nil
+}
+
+func (n *fakeNode) StopContainer(ctx context.Context, containerID string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.containers[containerID]; !ok {
+		return fmt.Errorf("no such container on %s: %s", n.name, containerID)
+	}
+	n.containers[containerID] = false
+	return nil
+}
+
+func (n *fakeNode) InspectContainer(ctx context.Context, containerID string) (*domain.ContainerState, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	running, ok := n.containers[containerID]
+	if !ok {
+		return nil, fmt.Errorf("no such container on %s: %s", n.name, containerID)
+	}
+	return &domain.ContainerState{Running: running}, nil
+}
+
+func (n *fakeNode) HostResources(ctx context.Context) (int, int, error) {
+	if n.down {
+		return 0, 0, errors.New("connection refused")
+	}
+	return 4000, 8192, nil
+}
+
+func TestNodePoolRoutesCallsToTheContainersNode(t *testing.T) {
+	local, remote := newFakeNode("local"), newFakeNode("remote")
+	pool := docker.NewNodePool(slog.Default()).
+		Add(domain.Node{ID: "local"}, local).
+		Add(domain.Node{ID: "remote"}, remote)
+	ctx := context.Background()
+
+	dockerID, err := pool.CreateContainer(domain.WithNode(ctx, "remote"), domain.ContainerSpec{})
+	if err != nil || !remote.containers[dockerID] {
+		t.Fatalf("expected the container created on the placed node, got %q %v", dockerID, err)
+	}
+	// Later calls without a node in the context follow the container
+	if err := pool.StopContainer(ctx, dockerID); err != nil || remote.containers[dockerID] {
+		t.Fatalf("expected the stop sent to the remote node, got %v", err)
+	}
+
+	// A container created before this server started is found by asking every node
+	remote.containers["remote-old"] = true
+	if state, err := pool.InspectContainer(ctx, "remote-old"); err != nil || !state.Running {
+		t.Fatalf("expected the untracked container found on the remote node, got %v", err)
+	}
+	if err := pool.StopContainer(ctx, "remote-old"); err != nil {
+		t.Fatalf("expected the probed node remembered, got %v", err)
+	}
+
+	// Tracked containers go straight to their node; no node means the default one
+	local.containers["local-9"] = true
+	pool.Track(&domain.Container{DockerID: "local-9"})
+	if err := pool.StopContainer(ctx, "local-9"); err != nil || local.containers["local-9"] {
+		t.Fatalf("expected the stop sent to the default node, got %v", err)
+	}
+}
+
+func TestNodePoolHealthChecks(t *testing.T) {
+	local, remote := newFakeNode("local"), newFakeNode("remote")
+	pool := docker.NewNodePool(slog.Default()).
+		Add(domain.Node{ID: "local"}, local).
+		Add(domain.Node{ID: "remote"}, remote)
+	ctx := context.Background()
+
+	remote.down = true
+	pool.CheckHealth(ctx)
+	nodes := pool.Nodes()
+	if !nodes[0].Healthy || nodes[1].Healthy || nodes[1].Error == "" {
+		t.Fatalf("expected only the remote node marked unhealthy, got %+v", nodes)
+	}
+	if cpu, mem, err := pool.HostResources(ctx); err != nil || cpu != 4000 || mem != 8192 {
+		t.Fatalf("expected only healthy nodes counted, got %d %d %v", cpu, mem, err)
+	}
+
+	remote.down = false
+	pool.CheckHealth(ctx)
+	if !pool.Nodes()[1].Healthy {
+		t.Fatal("expected the remote node healthy again")
+	}
+	if cpu, _, _ := pool.HostResources(ctx); cpu != 8000 {
+		t.Fatalf("expected both nodes counted, got %d", cpu)
+	}
+}
@@ -0,0 +1,182 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/handler"
+	"github.com/aryan0dhankhar/containerlease/internal/security"
+	"github.com/aryan0dhankhar/containerlease/internal/security/auth"
+	"github.com/aryan0dhankhar/containerlease/pkg/config"
+	"github.com/gorilla/websocket"
+)
+
+// proxyDockerClient reports every container at the loopback address
+type proxyDockerClient struct {
+	mockDockerClient
+}
+
+func (m *proxyDockerClient) InspectContainer(ctx context.Context, containerID string) (*domain.ContainerState, error) {
+	return &domain.ContainerState{Running: true, Status: "running", IPAddress: "127.0.0.1"}, nil
+}
+
+// newAppServer stands in for a web app inside the container: it echoes what it received
+func newAppServer(t *testing.T) (*httptest.Server, int) {
+	upgrader := websocket.Upgrader{}
+	app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ws" {
+			ws, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer ws.Close()
+			for {
+				msgType, data, err := ws.ReadMessage()
+				if err != nil {
+					return
+				}
+				_ = ws.WriteMessage(msgType, data)
+			}
+		}
+		fmt.Fprintf(w, "path=%s query=%s auth=%q cookie=%q prefix=%s",
+			r.URL.Path, r.URL.RawQuery, r.Header.Get("Authorization"), r.Header.Get("Cookie"), r.Header.Get("X-Forwarded-Prefix"))
+	}))
+	t.Cleanup(app.Close)
+	_, portStr, _ := net.SplitHostPort(app.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	return app, port
+}
+
+func newProxyServer(t *testing.T, container *domain.Container) (*httptest.Server, *auth.TokenManager) {
+	repo := &mockContainerRepository{containers: map[string]*domain.Container{container.ID: container}}
+	tokens := auth.NewTokenManager("test-secret", "containerlease")
+	proxy := handler.NewProxyHandler(&proxyDockerClient{}, repo, tokens, slog.Default(),
+		&config.Config{ShareTokenMaxMinutes: 60}, security.NewAuthorizationService(slog.Default()))
+
+	srv := httptest.NewServer(proxy)
+	t.Cleanup(srv.Close)
+	return srv, tokens
+}
+
+func TestProxyForwardsWithOwnerAndShareTokens(t *testing.T) {
+	_, port := newAppServer(t)
+	container := &domain.Container{
+		ID: "c1", DockerID: "docker-1", TenantID: "tenant-1", Status: "running",
+		ExpiryAt: time.Now().Add(time.Hour), Ports: []int{port},
+	}
+	srv, tokens := newProxyServer(t, container)
+	base := fmt.Sprintf("%s/proxy/c1/%d", srv.URL, port)
+
+	owner, _ := tokens.GenerateToken("tenant-1", "user-1", "a@example.com", time.Hour)
+	resp, err := http.Get(base + "/app/page?x=1&token=" + owner)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+	want := fmt.Sprintf(`path=/app/page query=x=1 auth="" cookie="" prefix=/proxy/c1/%d`, port)
+	if string(body) != want {
+		t.Fatalf("unexpected upstream request:\n got %s\nwant %s", body, want)
+	}
+
+	// The token is kept in a cookie scoped to this route and never forwarded
+	var cookie *http.Cookie
+	for _, c := range resp.Cookies() {
+		if c.Name == "cl_proxy_token" {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.Path != fmt.Sprintf("/proxy/c1/%d/", port) {
+		t.Fatalf("expected a route-scoped token cookie, got %v", resp.Cookies())
+	}
+	req, _ := http.NewRequest(http.MethodGet, base+"/asset.js", nil)
+	req.AddCookie(cookie)
+	req.AddCookie(&http.Cookie{Name: "app", Value: "keep"})
+	resp, _ = http.DefaultClient.Do(req)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `cookie="app=keep"`) {
+		t.Fatalf("cookie request failed: %d %s", resp.StatusCode, body)
+	}
+
+	// Share tokens open exactly one port of one container
+	share, _ := tokens.GenerateShareToken("c1", port, time.Now().Add(time.Minute))
+	if status := proxyStatus(t, base+"/?token="+share); status != http.StatusOK {
+		t.Fatalf("share token: expected 200, got %d", status)
+	}
+	other, _ := tokens.GenerateShareToken("c1", port+1, time.Now().Add(time.Minute))
+	if status := proxyStatus(t, base+"/?token="+other); status != http.StatusForbidden {
+		t.Fatalf("share token for another port: expected 403, got %d", status)
+	}
+	expired, _ := tokens.GenerateShareToken("c1", port, time.Now().Add(-time.Minute))
+	if status := proxyStatus(t, base+"/?token="+expired); status != http.StatusUnauthorized {
+		t.Fatalf("expired share token: expected 401, got %d", status)
+	}
+	stranger, _ := tokens.GenerateToken("tenant-2", "user-2", "b@example.com", time.Hour)
+	if status := proxyStatus(t, base+"/?token="+stranger); status != http.StatusForbidden {
+		t.Fatalf("other tenant: expected 403, got %d", status)
+	}
+	if _, err := tokens.ValidateToken(share); err == nil {
+		t.Fatal("share tokens must not be accepted as login tokens")
+	}
+
+	// Undeclared ports and ended leases have no route
+	if status := proxyStatus(t, fmt.Sprintf("%s/proxy/c1/%d/?token=%s", srv.URL, port+1, owner)); status != http.StatusNotFound {
+		t.Fatalf("undeclared port: expected 404, got %d", status)
+	}
+	container.ExpiryAt = time.Now().Add(-time.Second)
+	if status := proxyStatus(t, base+"/?token="+owner); status != http.StatusNotFound {
+		t.Fatalf("expired lease: expected 404, got %d", status)
+	}
+}
+
+func TestProxyWebSocketClosesAtLeaseExpiry(t *testing.T) {
+	_, port := newAppServer(t)
+	srv, tokens := newProxyServer(t, &domain.Container{
+		ID: "c1", DockerID: "docker-1", TenantID: "tenant-1", Status: "running",
+		ExpiryAt: time.Now().Add(1500 * time.Millisecond), Ports: []int{port},
+	})
+
+	share, _ := tokens.GenerateShareToken("c1", port, time.Now().Add(time.Minute))
+	url := fmt.Sprintf("ws%s/proxy/c1/%d/ws?token=%s", strings.TrimPrefix(srv.URL, "http"), port, share)
+	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer ws.Close()
+
+	_ = ws.WriteMessage(websocket.TextMessage, []byte("hello"))
+	if _, data, err := ws.ReadMessage(); err != nil || string(data) != "hello" {
+		t.Fatalf("expected echo, got %q %v", data, err)
+	}
+
+	_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, _, err := ws.ReadMessage(); err == nil {
+		t.Fatal("expected the connection to close when the lease ended")
+	} else if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		t.Fatal("connection outlived the lease")
+	}
+}
+
+func proxyStatus(t *testing.T, url string) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("get %s: %v", url, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
@@ -114,10 +114,18 @@ type mockDockerClient struct {
 	removedImages       map[string]bool
 }
 
-func (m *mockDockerClient) CreateContainer(ctx context.Context, imageType string, cpuMilli int, memoryMB int, logDemo bool, volumeID string) (string, error) {
+func (m *mockDockerClient) PullImage(ctx context.Context, image string) (string, error) {
+	return "", nil
+}
+
+func (m *mockDockerClient) CreateContainer(ctx context.Context, spec domain.ContainerSpec) (string, error) {
 	return "docker-id-123", nil
 }
 
+func (m *mockDockerClient) RunCommand(ctx context.Context, containerID string, cmd []string, output io.Writer) (int, error) {
+	return 0, nil
+}
+
 func (m *mockDockerClient) StopContainer(ctx context.Context, containerID string) error {
 	return nil
 }
@@ -130,6 +138,14 @@ func (m *mockDockerClient) StartContainer(ctx context.Context, containerID strin
 	return nil
 }
 
+func (m *mockDockerClient) PauseContainer(ctx context.Context, containerID string) error {
+	return nil
+}
+
+func (m *mockDockerClient) UnpauseContainer(ctx context.Context, containerID string) error {
+	return nil
+}
+
 func (m *mockDockerClient) StreamLogs(ctx context.Context, containerID string) (io.ReadCloser, error) {
 	return io.NopCloser(bytes.NewReader([]byte("mock logs"))), nil
 }
@@ -160,6 +176,40 @@ func (m *mockDockerClient) RemoveImage(ctx context.Context, imageName string) er
 	return nil
 }
 
+func (m *mockDockerClient) WatchEvents(ctx context.Context, since time.Time) (<-chan domain.ContainerEvent, <-chan error) {
+	return make(chan domain.ContainerEvent), make(chan error)
+}
+
+func (m *mockDockerClient) InspectContainer(ctx context.Context, containerID string) (*domain.ContainerState, error) {
+	return &domain.ContainerState{Running: true, Status: "running"}, nil
+}
+
+func (m *mockDockerClient) Exec(ctx context.Context, containerID string, opts domain.ExecOptions) (domain.ExecSession, error) {
+	return nil, fmt.Errorf("exec not supported")
+}
+
+func (m *mockDockerClient) CopyTo(ctx context.Context, containerID string, dstDir string, archive io.Reader) error {
+	return nil
+}
+
+func (m *mockDockerClient) CopyFrom(ctx context.Context, containerID string, srcPath string) (io.ReadCloser, *domain.FileStat, error) {
+	return nil, nil, domain.ErrPathNotFound
+}
+
+func (m *mockDockerClient) Stats(ctx context.Context, containerID string) (*domain.ContainerStats, error) {
+	return &domain.ContainerStats{Time: time.Now()}, nil
+}
+
+func (m *mockDockerClient) StreamStats(ctx context.Context, containerID string) (<-chan domain.ContainerStats, <-chan error) {
+	out := make(chan domain.ContainerStats)
+	close(out)
+	return out, make(chan error)
+}
+
+func (m *mockDockerClient) HostResources(ctx context.Context) (int, int, error) {
+	return 4000, 8192, nil
+}
+
 // TestCreateSnapshot tests creating a snapshot of a running container
 func TestCreateSnapshot(t *testing.T) {
 	logger := slog.Default()
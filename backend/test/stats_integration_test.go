@@ -0,0 +1,145 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aryan0dhankhar/containerlease/internal/domain"
+	"github.com/aryan0dhankhar/containerlease/internal/handler"
+	"github.com/aryan0dhankhar/containerlease/internal/security"
+	"github.com/aryan0dhankhar/containerlease/internal/security/middleware"
+	"github.com/gorilla/websocket"
+)
+
+// statsDockerClient reports a fixed sample, streamed every 50ms
+type statsDockerClient struct {
+	mockDockerClient
+	sample domain.ContainerStats
+}
+
+func (m *statsDockerClient) Stats(ctx context.Context, containerID string) (*domain.ContainerStats, error) {
+	sample := m.sample
+	return &sample, nil
+}
+
+func (m *statsDockerClient) StreamStats(ctx context.Context, containerID string) (<-chan domain.ContainerStats, <-chan error) {
+	out := make(chan domain.ContainerStats)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case out <- m.sample:
+			case <-ctx.Done():
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}()
+	return out, make(chan error)
+}
+
+func newStatsServer(t *testing.T, tenantID string, container *domain.Container) *httptest.Server {
+	repo := &mockContainerRepository{containers: map[string]*domain.Container{container.ID: container}}
+	docker := &statsDockerClient{sample: domain.ContainerStats{
+		Time: time.Now(), CPUMilli: 250, MemoryBytes: 128 << 20, MemoryLimitBytes: 512 << 20,
+		NetRxBytes: 1000, NetTxBytes: 2000, BlockReadBytes: 4096, BlockWriteBytes: 8192, PIDs: 7,
+	}}
+	statsHandler := handler.NewStatsHandler(docker, repo, slog.Default(), nil, security.NewAuthorizationService(slog.Default()))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/containers/{id}/stats", statsHandler.Get)
+	mux.Handle("GET /ws/stats/{id}", statsHandler)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r.WithContext(middleware.SetTenantInContext(r.Context(), tenantID)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestStatsReportsUsageAgainstRequest(t *testing.T) {
+	srv := newStatsServer(t, "tenant-1", &domain.Container{
+		ID: "c1", DockerID: "docker-1", TenantID: "tenant-1", Status: "running",
+		CPUMilli: 500, MemoryMB: 512, ExpiryAt: time.Now().Add(time.Hour),
+	})
+
+	resp, err := http.Get(srv.URL + "/api/containers/c1/stats")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	var sample handler.StatsSample
+	if err := json.NewDecoder(resp.Body).Decode(&sample); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if sample.CPU.Percent != 50 || sample.CPU.RequestedMilli != 500 {
+		t.Fatalf("expected 250m of 500m = 50%%, got %+v", sample.CPU)
+	}
+	if sample.Memory.Percent != 25 || sample.Memory.UsedBytes != 128<<20 {
+		t.Fatalf("expected 128MB of 512MB = 25%%, got %+v", sample.Memory)
+	}
+	if sample.Network.ReadBytes != 1000 || sample.BlockIO.WriteBytes != 8192 || sample.PIDs != 7 {
+		t.Fatalf("unexpected I/O or PIDs: %+v", sample)
+	}
+}
+
+func TestStatsRejectsOtherTenantsAndStoppedLeases(t *testing.T) {
+	container := &domain.Container{
+		ID: "c1", DockerID: "docker-1", TenantID: "tenant-1", Status: "running",
+		ExpiryAt: time.Now().Add(time.Hour),
+	}
+	if status := statsStatus(t, newStatsServer(t, "tenant-2", container).URL+"/api/containers/c1/stats"); status != http.StatusForbidden {
+		t.Fatalf("other tenant: expected 403, got %d", status)
+	}
+
+	container.Status = "terminated"
+	if status := statsStatus(t, newStatsServer(t, "tenant-1", container).URL+"/api/containers/c1/stats"); status != http.StatusConflict {
+		t.Fatalf("terminated lease: expected 409, got %d", status)
+	}
+}
+
+func TestStatsStreamClosesAtLeaseExpiry(t *testing.T) {
+	srv := newStatsServer(t, "tenant-1", &domain.Container{
+		ID: "c1", DockerID: "docker-1", TenantID: "tenant-1", Status: "running",
+		CPUMilli: 1000, MemoryMB: 256, ExpiryAt: time.Now().Add(time.Second),
+	})
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws/stats/c1", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer ws.Close()
+
+	var sample handler.StatsSample
+	if err := ws.ReadJSON(&sample); err != nil || sample.CPU.Percent != 25 {
+		t.Fatalf("expected a sample at 25%% CPU, got %+v %v", sample.CPU, err)
+	}
+
+	_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err = ws.ReadMessage(); err != nil {
+			break
+		}
+	}
+	if ce, ok := err.(*websocket.CloseError); !ok || ce.Text != "lease expired" {
+		t.Fatalf("expected close with 'lease expired', got %v", err)
+	}
+}
+
+func statsStatus(t *testing.T, url string) int {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("get %s: %v", url, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}